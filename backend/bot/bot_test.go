@@ -0,0 +1,145 @@
+package bot
+
+import (
+	"accountbook/models"
+	"accountbook/repository"
+	"accountbook/services"
+	"accountbook/services/telegramtest"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// testChatID 測試用的聊天室（在允許清單中，記到預設使用者的個人帳本）
+const testChatID int64 = 1001
+
+// newTestBot 以 MemoryStore 與假的 Telegram Bot API 建立可離線執行的 Bot
+// 原因：webhook 模式下 SendText、PressButton 回傳時 Bot 已處理完畢，測試不需等待
+func newTestBot(t *testing.T) *telegramtest.Server {
+	t.Helper()
+
+	store := repository.NewMemoryStore()
+	repository.Default = store
+	services.Ledger = services.NewLedgerService(store)
+	services.Exchange = services.NewExchangeService(store)
+	services.Users = services.NewUserService(store)
+	services.Books = services.NewBookService(store)
+	services.Suggestions = services.NewSuggestionService(store)
+	services.Aliases = services.NewAliasService(store)
+	services.Budgets = services.NewBudgetService(store)
+	services.Recurring = services.NewRecurringService(store, Timezone)
+	services.Charts = services.NewChartService(store)
+	services.TelegramAuth = services.NewTelegramAuthService(store, []int64{testChatID})
+	if err := services.Users.CreateUser(&models.User{Username: "admin"}); err != nil {
+		t.Fatalf("建立使用者失敗: %v", err)
+	}
+
+	// 清除前一個測試留下的會話與復原紀錄
+	sessionMu.Lock()
+	sessionStore = make(map[int64]*Session)
+	sessionMu.Unlock()
+	undoMu.Lock()
+	undoStore = make(map[int64]*undoAction)
+	undoMu.Unlock()
+
+	fake := telegramtest.NewServer("test")
+	apiURL, stopAPI := fake.Start()
+	t.Cleanup(stopAPI)
+	services.Telegram = services.NewTelegramClient(services.TelegramConfig{Token: "test", BaseURL: apiURL, Timeout: 5 * time.Second})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/telegram/webhook", HandleWebhook)
+	webhook := httptest.NewServer(router)
+	t.Cleanup(webhook.Close)
+	services.SetupWebhook(webhook.URL+"/telegram/webhook", "test-secret")
+	return fake
+}
+
+// sendText 模擬使用者輸入文字
+func sendText(t *testing.T, fake *telegramtest.Server, text string) {
+	t.Helper()
+	if _, err := fake.SendText(testChatID, text); err != nil {
+		t.Fatalf("發送「%s」失敗: %v", text, err)
+	}
+}
+
+// lastMessage Bot 最後發送的訊息
+func lastMessage(t *testing.T, fake *telegramtest.Server) telegramtest.Message {
+	t.Helper()
+	m, ok := fake.LastMessage(testChatID)
+	if !ok {
+		t.Fatal("Bot 沒有發送任何訊息")
+	}
+	return m
+}
+
+// findMessage 依 ID 取得 Bot 發送的訊息（含之後的編輯）
+func findMessage(t *testing.T, fake *telegramtest.Server, messageID int) telegramtest.Message {
+	t.Helper()
+	for _, m := range fake.Messages(testChatID) {
+		if m.MessageID == messageID {
+			return m
+		}
+	}
+	t.Fatalf("找不到訊息 #%d", messageID)
+	return telegramtest.Message{}
+}
+
+// pressButton 點擊訊息目前的按鈕（依按鈕文字）
+func pressButton(t *testing.T, fake *telegramtest.Server, messageID int, label string) {
+	t.Helper()
+	data, ok := findMessage(t, fake, messageID).Button(label)
+	if !ok {
+		t.Fatalf("訊息 #%d 沒有「%s」按鈕", messageID, label)
+	}
+	if err := fake.PressButton(testChatID, messageID, data); err != nil {
+		t.Fatalf("點擊「%s」失敗: %v", label, err)
+	}
+}
+
+func TestNewRecordFlow(t *testing.T) {
+	fake := newTestBot(t)
+
+	sendText(t, fake, "/new")
+	preview := lastMessage(t, fake).MessageID
+
+	pressButton(t, fake, preview, "金額")
+	if prompt := lastMessage(t, fake); !strings.Contains(prompt.Text, "金額") {
+		t.Fatalf("點擊金額後的提示 = %q", prompt.Text)
+	}
+	sendText(t, fake, "150")
+	pressButton(t, fake, preview, "項目")
+	sendText(t, fake, "午餐")
+	if text := findMessage(t, fake, preview).Text; !strings.Contains(text, "150") || !strings.Contains(text, "午餐") {
+		t.Fatalf("預覽未更新：%q", text)
+	}
+
+	pressButton(t, fake, preview, "確認送出")
+	if text := findMessage(t, fake, preview).Text; !strings.Contains(text, "新增成功") {
+		t.Fatalf("確認後的訊息 = %q", text)
+	}
+	// 提示與使用者輸入的訊息都已刪除，只剩預覽（成功）訊息
+	if msgs := fake.Messages(testChatID); len(msgs) != 1 {
+		t.Errorf("聊天室剩下 %d 則訊息，預期 1 則", len(msgs))
+	}
+	if GetSession(testChatID) != nil {
+		t.Error("送出後會話應已清除")
+	}
+
+	store := repository.Default.ForBook(models.DefaultBookID, models.DefaultUserID)
+	records, err := store.Records().ListByDate(time.Now().Format("2006-01-02"))
+	if err != nil || len(records) != 1 {
+		t.Fatalf("今天的紀錄 = %d 筆（%v），預期 1 筆", len(records), err)
+	}
+	if r := records[0]; r.Item != "午餐" || r.Amount != models.MoneyFromFloat(150) || r.Type != "支出" {
+		t.Errorf("新增的紀錄 = %+v", r)
+	}
+	account, err := store.Accounts().Get(records[0].AccountID)
+	if err != nil || account.Balance != models.MoneyFromFloat(-150) {
+		t.Errorf("帳戶餘額 = %v（%v），預期 -150", account, err)
+	}
+}
@@ -0,0 +1,87 @@
+package bot
+
+import (
+	"accountbook/models"
+	"accountbook/repository"
+	"accountbook/services"
+	"errors"
+	"log"
+	"strings"
+	"time"
+)
+
+// chartKindNames /chart 指令可使用的圖表種類名稱
+var chartKindNames = map[string]string{
+	"pie":  models.ChartPie,
+	"圓餅":   models.ChartPie,
+	"分類":   models.ChartPie,
+	"bar":  models.ChartBar,
+	"長條":   models.ChartBar,
+	"每日":   models.ChartBar,
+	"line": models.ChartLine,
+	"折線":   models.ChartLine,
+	"資產":   models.ChartLine,
+}
+
+// handleChart 處理 /chart [種類] [月份或年份]：預設為本月支出的分類圓餅圖
+// 原因：參數順序不限，無法辨識為種類的參數視為期間（格式同 /stats，另可指定年份）
+func handleChart(chatID int64, store repository.Store, args []string) {
+	kind := models.ChartPie
+	var rest []string
+	for _, arg := range args {
+		if k, ok := chartKindNames[strings.ToLower(arg)]; ok {
+			kind = k
+			continue
+		}
+		rest = append(rest, arg)
+	}
+
+	arg := strings.Join(rest, "")
+	period, ok := parseStatsMonth(arg)
+	if !ok {
+		if _, err := time.Parse("2006", arg); err != nil {
+			services.SendMessage(chatID, "❌ 無法辨識「"+arg+"」\n\n"+FormatChartUsage())
+			return
+		}
+		period = arg
+	}
+	sendChart(chatID, store, kind, period, "支出")
+}
+
+// handleChartAction 處理圖表訊息上的按鈕：ch_<種類>_<期間>_<e|i>
+// 原因：圖片訊息無法改為其他圖片的文字，每次點擊發送一張新圖
+func handleChartAction(chatID int64, data string) {
+	cb, ok := chatBook(chatID)
+	if !ok {
+		return
+	}
+	parts := strings.Split(strings.TrimPrefix(data, "ch_"), "_")
+	if len(parts) != 3 || !validPeriod(parts[1]) || statTypeCodes[parts[2]] == "" {
+		return
+	}
+	sendChart(chatID, repository.Default.ForBook(cb.BookID, cb.UserID), parts[0], parts[1], statTypeCodes[parts[2]])
+}
+
+// sendChart 產生並發送圖表，說明文字列出圖例編號對應的分類
+func sendChart(chatID int64, store repository.Store, kind, period, recordType string) {
+	chart, err := services.Charts.ForBook(store.BookID(), store.UserID()).Render(models.ChartRequest{
+		Kind:     kind,
+		Period:   period,
+		Type:     recordType,
+		Currency: models.DefaultCurrency,
+	})
+	if errors.Is(err, services.ErrInvalidChart) || errors.Is(err, services.ErrInvalidPeriod) {
+		services.SendMessage(chatID, "❌ "+err.Error())
+		return
+	}
+	if err != nil {
+		respondStatsError(chatID, err)
+		return
+	}
+
+	current := currentPeriod(len(period) == len("2006-01"))
+	if _, err := services.SendPhotoWithKeyboard(chatID, chart.PNG, FormatChartCaption(chart), BuildChartKeyboard(chart.Request, current)); err != nil {
+		log.Printf("發送圖表失敗: %v", err)
+		services.SendMessage(chatID, "系統忙碌中，請稍後再試")
+	}
+}
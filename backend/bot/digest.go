@@ -0,0 +1,307 @@
+package bot
+
+import (
+	"accountbook/models"
+	"accountbook/repository"
+	"accountbook/services"
+	"errors"
+	"log"
+	"strings"
+	"time"
+)
+
+// Timezone 紀錄的日期（今天、昨天、週五）、定期摘要的發送時間與「昨天」「上週」「上個月」以此時區計算（BOT_TIMEZONE，預設為系統時區）
+var Timezone = time.Local
+
+// digestCheckInterval 排程檢查到期摘要的間隔
+const digestCheckInterval = time.Minute
+
+// defaultDigestTime 訂閱時未指定時間的預設發送時間
+const defaultDigestTime = "08:00"
+
+// digestKindNames 訂閱指令可使用的摘要種類名稱
+var digestKindNames = map[string]string{
+	"daily":   models.DigestDaily,
+	"每日":      models.DigestDaily,
+	"每天":      models.DigestDaily,
+	"weekly":  models.DigestWeekly,
+	"每週":      models.DigestWeekly,
+	"每周":      models.DigestWeekly,
+	"monthly": models.DigestMonthly,
+	"每月":      models.DigestMonthly,
+}
+
+// RunDigestScheduler 定期發送到期的摘要，啟動時立即檢查一次以補發停機期間錯過的摘要（每種只補最近一期）
+func RunDigestScheduler() {
+	for {
+		sendDueDigests(time.Now().In(Timezone))
+		time.Sleep(digestCheckInterval)
+	}
+}
+
+// digestPeriod 摘要在 now 所屬的期間，以期間的第一天表示
+// 原因：daily 為當天、weekly 為該週週一、monthly 為該月 1 號，每個期間發送一次
+func digestPeriod(kind string, now time.Time) time.Time {
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch kind {
+	case models.DigestWeekly:
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case models.DigestMonthly:
+		return day.AddDate(0, 0, 1-day.Day())
+	}
+	return day
+}
+
+// digestSendAt 期間第一天的發送時間
+// 原因：以日曆時間計算而非由午夜加上時數，日光節約時間切換當天才會在設定的時刻發送
+func digestSendAt(period time.Time, sendTime string) time.Time {
+	t, err := time.Parse("15:04", sendTime)
+	if err != nil {
+		return period
+	}
+	return time.Date(period.Year(), period.Month(), period.Day(), t.Hour(), t.Minute(), 0, 0, period.Location())
+}
+
+// sendDueDigests 發送所有本期已到發送時間且尚未發送的摘要
+func sendDueDigests(now time.Time) {
+	subs, err := repository.Default.Digests().List()
+	if err != nil {
+		log.Printf("查詢摘要訂閱失敗: %v", err)
+		return
+	}
+	for _, d := range subs {
+		period := digestPeriod(d.Kind, now)
+		key := period.Format("2006-01-02")
+		if d.LastSent >= key || now.Before(digestSendAt(period, d.SendTime)) {
+			continue
+		}
+		sendDigest(d, period, key)
+	}
+}
+
+// sendDigest 產生並發送一則摘要，成功後記錄本期已發送
+// 原因：聊天室已失去授權、封鎖 Bot 或不存在時取消訂閱；其他錯誤（例如網路、其他 400）留待下次檢查重試
+func sendDigest(d models.DigestSubscription, period time.Time, key string) {
+	ok, err := services.TelegramAuth.Authorized(d.ChatID)
+	if err != nil {
+		log.Printf("查詢聊天室 %d 的授權失敗: %v", d.ChatID, err)
+		return
+	}
+	if !ok {
+		cancelDigest(d, "聊天室已失去授權")
+		return
+	}
+
+	cb, err := services.Books.ChatBook(d.ChatID)
+	if err != nil {
+		log.Printf("查詢聊天室 %d 的帳本失敗: %v", d.ChatID, err)
+		return
+	}
+	text, err := buildDigest(repository.Default.ForBook(cb.BookID, cb.UserID), d.Kind, period)
+	if errors.Is(err, services.ErrRateNotFound) {
+		// 缺少匯率時重試也不會成功，改為通知使用者
+		text = "⚠️ 無法產生" + digestTitles[d.Kind] + "：" + err.Error()
+	} else if err != nil {
+		log.Printf("產生聊天室 %d 的%s失敗: %v", d.ChatID, digestTitles[d.Kind], err)
+		return
+	}
+
+	if err := services.SendMessage(d.ChatID, text); err != nil {
+		if chatUnreachable(err) {
+			cancelDigest(d, err.Error())
+			return
+		}
+		log.Printf("發送聊天室 %d 的%s失敗: %v", d.ChatID, digestTitles[d.Kind], err)
+		return
+	}
+	if err := repository.Default.Digests().MarkSent(d.ChatID, d.Kind, key); err != nil && err != repository.ErrNotFound {
+		log.Printf("記錄聊天室 %d 的%s失敗: %v", d.ChatID, digestTitles[d.Kind], err)
+	}
+}
+
+// chatUnreachable 判斷發送失敗是否因為 Bot 已被封鎖、踢出或聊天室不存在
+// 原因：其他 400（例如訊息過長、格式錯誤）可能是暫時性或 Bot 本身的問題，不應因此取消使用者的訂閱
+func chatUnreachable(err error) bool {
+	var tgErr *services.TelegramError
+	if !errors.As(err, &tgErr) {
+		return false
+	}
+	return tgErr.ErrorCode == 403 || (tgErr.ErrorCode == 400 && strings.Contains(tgErr.Description, "chat not found"))
+}
+
+// cancelDigest 取消無法再發送的訂閱
+func cancelDigest(d models.DigestSubscription, reason string) {
+	log.Printf("取消聊天室 %d 的%s訂閱: %s", d.ChatID, digestTitles[d.Kind], reason)
+	if err := repository.Default.Digests().Delete(d.ChatID, d.Kind); err != nil && err != repository.ErrNotFound {
+		log.Printf("取消摘要訂閱失敗: %v", err)
+	}
+}
+
+// buildDigest 產生 period 期間發送的摘要內容（統計前一天、前一週或前一個月）
+// 原因：與統計頁相同的查詢（見 statTotals），金額換算為預設幣別，不計入轉帳
+func buildDigest(store repository.Store, kind string, period time.Time) (string, error) {
+	base := models.DefaultCurrency
+	switch kind {
+	case models.DigestWeekly:
+		from := period.AddDate(0, 0, -7).Format("2006-01-02")
+		to := period.AddDate(0, 0, -1).Format("2006-01-02")
+		filter := models.StatisticFilter{From: from, To: to}
+		income, expense, err := statTotals(store, filter, base, to)
+		if err != nil {
+			return "", err
+		}
+		totals, err := statCategoryTotals(store, filter, base, to)
+		if err != nil {
+			return "", err
+		}
+		return FormatWeeklyDigest(from, to, base, income, expense, totals), nil
+
+	case models.DigestMonthly:
+		first := period.AddDate(0, -1, 0)
+		month := first.Format("2006-01")
+		end := period.AddDate(0, 0, -1).Format("2006-01-02")
+		filter := models.StatisticFilter{Month: month}
+		income, expense, err := statTotals(store, filter, base, end)
+		if err != nil {
+			return "", err
+		}
+		totals, err := statCategoryTotals(store, filter, base, end)
+		if err != nil {
+			return "", err
+		}
+		budgets, err := services.Budgets.ForBook(store.BookID(), store.UserID()).Status(month)
+		if err != nil {
+			return "", err
+		}
+		return FormatMonthlyDigest(month, base, income, expense, totals, budgets), nil
+	}
+
+	date := period.AddDate(0, 0, -1).Format("2006-01-02")
+	records, err := store.Records().ListByDate(date)
+	if err != nil {
+		return "", err
+	}
+	income, expense, err := statTotals(store, models.StatisticFilter{From: date, To: date}, base, date)
+	if err != nil {
+		return "", err
+	}
+	return FormatDailyDigest(date, base, income, expense, records), nil
+}
+
+// === 訂閱指令 ===
+
+// handleSubscribe 處理 /subscribe [種類] [時間]
+// 原因：不帶參數時列出目前的訂閱與用法；種類為 all 時一次訂閱全部，再次訂閱同一種類只更新發送時間
+func handleSubscribe(chatID int64, args []string) {
+	if len(args) == 0 {
+		handleSubscriptions(chatID, "", true)
+		return
+	}
+
+	var kinds []string
+	sendTime := defaultDigestTime
+	for _, arg := range args {
+		if kind, ok := digestKindNames[strings.ToLower(arg)]; ok {
+			kinds = append(kinds, kind)
+			continue
+		}
+		if arg == "all" || arg == "全部" {
+			kinds = append(kinds, models.DigestKinds...)
+			continue
+		}
+		t, err := time.Parse("15:04", arg)
+		if err != nil {
+			services.SendMessage(chatID, "❌ 無法辨識「"+arg+"」\n\n"+FormatDigestUsage())
+			return
+		}
+		sendTime = t.Format("15:04")
+	}
+	if len(kinds) == 0 {
+		services.SendMessage(chatID, "❌ 請指定摘要種類\n\n"+FormatDigestUsage())
+		return
+	}
+
+	existing, err := repository.Default.Digests().ListByChat(chatID)
+	if err != nil {
+		log.Printf("查詢摘要訂閱失敗: %v", err)
+		services.SendMessage(chatID, "系統忙碌中，請稍後再試")
+		return
+	}
+	now := time.Now().In(Timezone)
+	for _, kind := range kinds {
+		d := models.DigestSubscription{ChatID: chatID, Kind: kind, SendTime: sendTime}
+		for _, e := range existing {
+			if e.Kind == kind {
+				d.LastSent = e.LastSent
+			}
+		}
+		// 本期的發送時間已過時視為已發送，從下一期開始，避免訂閱後立即收到摘要
+		period := digestPeriod(kind, now)
+		if key := period.Format("2006-01-02"); d.LastSent < key && !now.Before(digestSendAt(period, sendTime)) {
+			d.LastSent = key
+		}
+		if err := repository.Default.Digests().Save(&d); err != nil {
+			log.Printf("儲存摘要訂閱失敗: %v", err)
+			services.SendMessage(chatID, "系統忙碌中，請稍後再試")
+			return
+		}
+	}
+	handleSubscriptions(chatID, "✅ 已訂閱", false)
+}
+
+// handleUnsubscribe 處理 /unsubscribe [種類]，不帶參數時取消所有訂閱
+func handleUnsubscribe(chatID int64, args []string) {
+	kinds := models.DigestKinds
+	if len(args) > 0 {
+		kinds = nil
+		for _, arg := range args {
+			kind, ok := digestKindNames[strings.ToLower(arg)]
+			if !ok && arg != "all" && arg != "全部" {
+				services.SendMessage(chatID, "❌ 無法辨識「"+arg+"」\n\n"+FormatDigestUsage())
+				return
+			}
+			if !ok {
+				kinds = append(kinds, models.DigestKinds...)
+				continue
+			}
+			kinds = append(kinds, kind)
+		}
+	}
+
+	removed := 0
+	for _, kind := range kinds {
+		err := repository.Default.Digests().Delete(chatID, kind)
+		if err == repository.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			log.Printf("取消摘要訂閱失敗: %v", err)
+			services.SendMessage(chatID, "系統忙碌中，請稍後再試")
+			return
+		}
+		removed++
+	}
+	if removed == 0 {
+		handleSubscriptions(chatID, "目前沒有要取消的訂閱", false)
+		return
+	}
+	handleSubscriptions(chatID, "✅ 已取消訂閱", false)
+}
+
+// handleSubscriptions 列出聊天室目前的訂閱，notice 不為空時顯示於開頭，withUsage 時附上指令用法
+func handleSubscriptions(chatID int64, notice string, withUsage bool) {
+	subs, err := repository.Default.Digests().ListByChat(chatID)
+	if err != nil {
+		log.Printf("查詢摘要訂閱失敗: %v", err)
+		services.SendMessage(chatID, "系統忙碌中，請稍後再試")
+		return
+	}
+	text := FormatDigestSubscriptions(subs, Timezone.String())
+	if withUsage || len(subs) == 0 {
+		text += "\n\n" + FormatDigestUsage()
+	}
+	if notice != "" {
+		text = notice + "\n\n" + text
+	}
+	services.SendMessage(chatID, text)
+}
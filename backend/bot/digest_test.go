@@ -0,0 +1,87 @@
+package bot
+
+import (
+	"accountbook/models"
+	"accountbook/repository"
+	"testing"
+	"time"
+	_ "time/tzdata" // 原因：測試環境可能沒有時區資料
+)
+
+func TestDigestSendAtAcrossDST(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("載入時區失敗: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		day      time.Time
+		sendTime string
+		want     string
+	}{
+		{"一般日子", time.Date(2026, 3, 7, 12, 0, 0, 0, newYork), "08:00", "2026-03-07 08:00 EST"},
+		{"開始日光節約時間（當天只有 23 小時）", time.Date(2026, 3, 8, 12, 0, 0, 0, newYork), "08:00", "2026-03-08 08:00 EDT"},
+		{"結束日光節約時間（當天有 25 小時）", time.Date(2026, 11, 1, 12, 0, 0, 0, newYork), "21:30", "2026-11-01 21:30 EST"},
+		{"時間格式錯誤時為期間開始", time.Date(2026, 11, 1, 12, 0, 0, 0, newYork), "25:00", "2026-11-01 00:00 EDT"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			period := digestPeriod(models.DigestDaily, tt.day)
+			if got := digestSendAt(period, tt.sendTime).Format("2006-01-02 15:04 MST"); got != tt.want {
+				t.Errorf("digestSendAt = %s，預期 %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDigestCancelsOnlyWhenChatUnreachable(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		description string
+		cancelled   bool
+	}{
+		{"封鎖 Bot", 403, "Forbidden: bot was blocked by the user", true},
+		{"聊天室不存在", 400, "Bad Request: chat not found", true},
+		{"訊息過長", 400, "Bad Request: message is too long", false},
+		{"伺服器錯誤", 500, "Internal Server Error", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newTestBot(t)
+			digests := repository.Default.Digests()
+			if err := digests.Save(&models.DigestSubscription{ChatID: testChatID, Kind: models.DigestDaily, SendTime: "00:00"}); err != nil {
+				t.Fatalf("訂閱失敗: %v", err)
+			}
+			now := time.Now().In(Timezone)
+			key := digestPeriod(models.DigestDaily, now).Format("2006-01-02")
+
+			fake.FailChat(testChatID, tt.status, tt.description)
+			sendDueDigests(now)
+			subs, err := digests.ListByChat(testChatID)
+			if err != nil {
+				t.Fatalf("查詢訂閱失敗: %v", err)
+			}
+			if tt.cancelled {
+				if len(subs) != 0 {
+					t.Errorf("訂閱 = %+v，預期已取消", subs)
+				}
+				return
+			}
+			if len(subs) != 1 || subs[0].LastSent == key {
+				t.Fatalf("訂閱 = %+v，預期保留且本期尚未發送", subs)
+			}
+
+			// 錯誤排除後，下次檢查重試發送
+			fake.FailChat(testChatID, 0, "")
+			sendDueDigests(now)
+			if subs, _ := digests.ListByChat(testChatID); len(subs) != 1 || subs[0].LastSent != key {
+				t.Errorf("重試後的訂閱 = %+v，預期本期已發送", subs)
+			}
+			if len(fake.Messages(testChatID)) != 1 {
+				t.Errorf("重試後的訊息 = %+v，預期 1 則摘要", fake.Messages(testChatID))
+			}
+		})
+	}
+}
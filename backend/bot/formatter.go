@@ -1,34 +1,78 @@
 package bot
 
 import (
-	"accountbook/initializers"
+	"accountbook/charts"
 	"accountbook/models"
+	"accountbook/repository"
 	"accountbook/services"
 	"fmt"
 	"strings"
+	"time"
 )
 
-// FormatSuccess 格式化新增成功的回覆訊息
-func FormatSuccess(date, accountName, recordType string, amount float64, item, categoryName, note string) string {
-	return fmt.Sprintf(`✅ 新增成功！
+// formatAmount 格式化金額，非預設幣別時附上幣別代碼
+// 原因：外幣帳戶的金額若不標示幣別，容易與台幣混淆
+func formatAmount(amount models.Money, currency string) string {
+	if currency == "" || currency == models.DefaultCurrency {
+		return amount.String()
+	}
+	return amount.String() + " " + currency
+}
+
+// dateOnly 只取日期部分
+// 原因：資料庫讀出的 DATE 可能帶有時間部分（如 2026-01-15T00:00:00Z）
+func dateOnly(date string) string {
+	if len(date) > 10 {
+		return date[:10]
+	}
+	return date
+}
+
+// FormatSuccess 格式化新增（或修改）成功的回覆訊息，title 為第一行的標題
+func FormatSuccess(title, date, accountName, recordType, amount, item, categoryName, note string) string {
+	return fmt.Sprintf(`%s
 
 📅 %s
-💰 %s %.0f
+💰 %s %s
 📝 %s
 🏷 %s
 🏦 %s
-📌 %s`, date, recordType, amount, item, categoryName, accountName, note)
+📌 %s`, title, date, recordType, amount, item, categoryName, accountName, note)
+}
+
+// FormatBudgetAlerts 格式化紀錄使預算跨過 80% 或 100% 的提醒，附加於成功訊息後
+func FormatBudgetAlerts(alerts []models.BudgetAlert) string {
+	var lines []string
+	for _, a := range alerts {
+		name := a.Status.CategoryName + "預算"
+		if a.Status.CategoryID == 0 {
+			name = "本月總預算"
+		}
+		budgeted := formatAmount(a.Status.Budgeted, a.Status.Currency)
+		switch {
+		case a.Threshold >= 100 && a.Status.Remaining >= 0:
+			lines = append(lines, fmt.Sprintf("🚨 %s已用完（預算 %s）", name, budgeted))
+		case a.Threshold >= 100:
+			lines = append(lines, fmt.Sprintf("🚨 %s已超支：已用 %.0f%%，超出 %s（預算 %s）",
+				name, a.Status.Percent, formatAmount(-a.Status.Remaining, a.Status.Currency), budgeted))
+		default:
+			lines = append(lines, fmt.Sprintf("⚠️ %s已用 %.0f%%，剩餘 %s（預算 %s）",
+				name, a.Status.Percent, formatAmount(a.Status.Remaining, a.Status.Currency), budgeted))
+		}
+	}
+	return strings.Join(lines, "\n")
 }
 
 // FormatPreview 格式化新增紀錄的預覽訊息
 // 原因：顯示目前所有欄位值，讓使用者一目了然，點擊按鈕即可修改
 func FormatPreview(s *Session) string {
-	accountName := resolveAccountName(s.AccountID)
-	categoryName := resolveCategoryName(s.CategoryID)
+	store := s.store()
+	accountName := resolveAccountName(store, s.AccountID)
+	categoryName := resolveCategoryName(store, s.CategoryID)
 
 	amountStr := "（未填）"
 	if s.Amount > 0 {
-		amountStr = fmt.Sprintf("%.0f", s.Amount)
+		amountStr = formatAmount(s.Amount, resolveAccountCurrency(store, s.AccountID))
 	}
 
 	itemStr := s.Item
@@ -41,7 +85,12 @@ func FormatPreview(s *Session) string {
 		noteStr = "（無）"
 	}
 
-	return fmt.Sprintf(`📋 新增紀錄
+	title := "📋 新增紀錄"
+	if s.RecordID > 0 {
+		title = "✏️ 修改紀錄"
+	}
+
+	return fmt.Sprintf(`%s
 
 📅 日期：%s
 🏦 帳戶：%s
@@ -51,7 +100,7 @@ func FormatPreview(s *Session) string {
 🏷 分類：%s
 📌 備註：%s
 
-點擊下方按鈕修改欄位，或按「✅ 確認送出」`, s.Date, accountName, s.Type, amountStr, itemStr, categoryName, noteStr)
+點擊下方按鈕修改欄位，或按「✅ 確認送出」`, title, s.Date, accountName, s.Type, amountStr, itemStr, categoryName, noteStr)
 }
 
 // BuildPreviewKeyboard 建立預覽訊息的 Inline Keyboard
@@ -91,22 +140,42 @@ func BuildPreviewKeyboard(s *Session) services.InlineKeyboardMarkup {
 	}
 }
 
+// FormatAmbiguity 格式化快捷輸入中符合多個帳戶或分類的提示
+func FormatAmbiguity(a Ambiguity) string {
+	return fmt.Sprintf("❓「%s」符合多個帳戶或分類，請選擇：", a.Token)
+}
+
+// BuildAmbiguityKeyboard 建立快捷輸入的候選按鈕（每排 1 個），previewMsgID 為對應的預覽訊息
+func BuildAmbiguityKeyboard(a Ambiguity, previewMsgID int) services.InlineKeyboardMarkup {
+	var buttons [][]services.InlineKeyboardButton
+	for _, acc := range a.Accounts {
+		buttons = append(buttons, []services.InlineKeyboardButton{{
+			Text:         "🏦 " + acc.Name,
+			CallbackData: fmt.Sprintf("pick_account_%d_%d", acc.ID, previewMsgID),
+		}})
+	}
+	for _, c := range a.Categories {
+		buttons = append(buttons, []services.InlineKeyboardButton{{
+			Text:         "🏷 " + c.Name,
+			CallbackData: fmt.Sprintf("pick_category_%d_%d", c.ID, previewMsgID),
+		}})
+	}
+	buttons = append(buttons, []services.InlineKeyboardButton{{Text: "略過（保留目前的值）", CallbackData: "pick_skip"}})
+	return services.InlineKeyboardMarkup{InlineKeyboard: buttons}
+}
+
 // BuildAccountKeyboard 建立帳戶選擇的 Inline Keyboard
 // 原因：列出所有帳戶讓使用者直接點擊選擇，不需要手動輸入
-func BuildAccountKeyboard() services.InlineKeyboardMarkup {
-	rows, _ := initializers.DB.Query("SELECT id, name FROM accounts ORDER BY sort_order")
-	defer rows.Close()
+func BuildAccountKeyboard(store repository.Store) services.InlineKeyboardMarkup {
+	accounts, _ := store.Accounts().List()
 
 	var buttons [][]services.InlineKeyboardButton
 	var row []services.InlineKeyboardButton
 
-	for rows.Next() {
-		var id int
-		var name string
-		rows.Scan(&id, &name)
+	for _, a := range accounts {
 		row = append(row, services.InlineKeyboardButton{
-			Text:         name,
-			CallbackData: fmt.Sprintf("set_account_%d", id),
+			Text:         a.Name,
+			CallbackData: fmt.Sprintf("set_account_%d", a.ID),
 		})
 		// 每排 2 個按鈕
 		if len(row) == 2 {
@@ -135,20 +204,16 @@ func BuildTypeKeyboard() services.InlineKeyboardMarkup {
 
 // BuildCategoryKeyboard 建立分類選擇的 Inline Keyboard
 // 原因：列出所有分類讓使用者直接點擊選擇
-func BuildCategoryKeyboard() services.InlineKeyboardMarkup {
-	rows, _ := initializers.DB.Query("SELECT id, name FROM categories ORDER BY sort_order")
-	defer rows.Close()
+func BuildCategoryKeyboard(store repository.Store) services.InlineKeyboardMarkup {
+	categories, _ := store.Categories().List()
 
 	var buttons [][]services.InlineKeyboardButton
 	var row []services.InlineKeyboardButton
 
-	for rows.Next() {
-		var id int
-		var name string
-		rows.Scan(&id, &name)
+	for _, c := range categories {
 		row = append(row, services.InlineKeyboardButton{
-			Text:         name,
-			CallbackData: fmt.Sprintf("set_category_%d", id),
+			Text:         c.Name,
+			CallbackData: fmt.Sprintf("set_category_%d", c.ID),
 		})
 		// 每排 3 個按鈕
 		if len(row) == 3 {
@@ -178,35 +243,43 @@ func BuildDateKeyboard() services.InlineKeyboardMarkup {
 }
 
 // resolveAccountName 取得帳戶名稱
-func resolveAccountName(id int) string {
-	var name string
-	err := initializers.DB.QueryRow("SELECT name FROM accounts WHERE id = ?", id).Scan(&name)
+func resolveAccountName(store repository.Store, id int) string {
+	a, err := store.Accounts().Get(id)
 	if err != nil {
 		return "未知"
 	}
-	return name
+	return a.Name
+}
+
+// resolveAccountCurrency 取得帳戶幣別
+func resolveAccountCurrency(store repository.Store, id int) string {
+	a, err := store.Accounts().Get(id)
+	if err != nil {
+		return ""
+	}
+	return a.Currency
 }
 
 // resolveCategoryName 取得分類名稱
-func resolveCategoryName(id int) string {
-	var name string
-	err := initializers.DB.QueryRow("SELECT name FROM categories WHERE id = ?", id).Scan(&name)
+func resolveCategoryName(store repository.Store, id int) string {
+	c, err := store.Categories().Get(id)
 	if err != nil {
 		return "未知"
 	}
-	return name
+	return c.Name
 }
 
 // === 轉帳格式化 ===
 
 // FormatTransferPreview 格式化轉帳預覽訊息
 func FormatTransferPreview(s *Session) string {
-	fromName := resolveAccountName(s.AccountID)
-	toName := resolveAccountName(s.ToAccountID)
+	store := s.store()
+	fromName := resolveAccountName(store, s.AccountID)
+	toName := resolveAccountName(store, s.ToAccountID)
 
 	amountStr := "（未填）"
 	if s.Amount > 0 {
-		amountStr = fmt.Sprintf("%.0f", s.Amount)
+		amountStr = formatAmount(s.Amount, resolveAccountCurrency(store, s.AccountID))
 	}
 
 	noteStr := s.Note
@@ -249,20 +322,16 @@ func BuildTransferKeyboard(s *Session) services.InlineKeyboardMarkup {
 }
 
 // BuildTransferAccountKeyboard 建立轉帳用帳戶選擇鍵盤
-func BuildTransferAccountKeyboard(prefix string) services.InlineKeyboardMarkup {
-	rows, _ := initializers.DB.Query("SELECT id, name FROM accounts ORDER BY sort_order")
-	defer rows.Close()
+func BuildTransferAccountKeyboard(store repository.Store, prefix string) services.InlineKeyboardMarkup {
+	accounts, _ := store.Accounts().List()
 
 	var buttons [][]services.InlineKeyboardButton
 	var row []services.InlineKeyboardButton
 
-	for rows.Next() {
-		var id int
-		var name string
-		rows.Scan(&id, &name)
+	for _, a := range accounts {
 		row = append(row, services.InlineKeyboardButton{
-			Text:         name,
-			CallbackData: fmt.Sprintf("%s%d", prefix, id),
+			Text:         a.Name,
+			CallbackData: fmt.Sprintf("%s%d", prefix, a.ID),
 		})
 		if len(row) == 2 {
 			buttons = append(buttons, row)
@@ -277,51 +346,48 @@ func BuildTransferAccountKeyboard(prefix string) services.InlineKeyboardMarkup {
 }
 
 // FormatTransferSuccess 格式化轉帳成功訊息
-func FormatTransferSuccess(fromName, toName string, amount float64, note string) string {
-	noteStr := note
+// 跨幣別轉帳時同時顯示轉出與轉入金額
+func FormatTransferSuccess(store repository.Store, fromName, toName string, t *models.Transfer) string {
+	noteStr := t.Note
 	if noteStr == "" {
 		noteStr = "（無）"
 	}
+
+	fromCurrency := resolveAccountCurrency(store, t.FromAccountID)
+	toCurrency := resolveAccountCurrency(store, t.ToAccountID)
+	amountStr := formatAmount(t.Amount, fromCurrency)
+	if fromCurrency != toCurrency {
+		amountStr += " ➡️ " + formatAmount(t.ToAmount, toCurrency)
+	}
+
 	return fmt.Sprintf(`✅ 轉帳成功！
 
 🏦 %s ➡️ %s
-💰 %.0f
-📌 %s`, fromName, toName, amount, noteStr)
+💰 %s
+📌 %s`, fromName, toName, amountStr, noteStr)
 }
 
-// FormatRecentRecords 格式化最近紀錄的查詢結果（分頁顯示）
-func FormatRecentRecords(offset, pageSize int) (string, int) {
-	// 先查詢總筆數
-	var total int
-	initializers.DB.QueryRow("SELECT COUNT(*) FROM records").Scan(&total)
-
-	if total == 0 {
-		return "📭 目前沒有任何紀錄", 0
+// FormatRecentRecords 格式化最近紀錄的查詢結果（分頁顯示），並回傳本頁紀錄供建立按鈕
+// 原因：每筆紀錄標上頁內編號，對應鍵盤上的修改、刪除按鈕
+func FormatRecentRecords(store repository.Store, offset, pageSize int) (string, []models.RecordWithNames, int) {
+	records, total, err := store.Records().Recent(offset, pageSize)
+	if err != nil {
+		return "查詢紀錄失敗", nil, 0
 	}
 
-	rows, err := initializers.DB.Query(`
-		SELECT r.date, a.name, r.type, r.amount, r.item, c.name, r.note
-		FROM records r
-		LEFT JOIN accounts a ON r.account_id = a.id
-		LEFT JOIN categories c ON r.category_id = c.id
-		ORDER BY r.date DESC, r.id DESC
-		LIMIT ? OFFSET ?
-	`, pageSize, offset)
-	if err != nil {
-		return "查詢紀錄失敗", 0
+	if total == 0 {
+		return "📭 目前沒有任何紀錄", nil, 0
 	}
-	defer rows.Close()
 
 	var lines []string
-	for rows.Next() {
-		var date, accountName, recordType, item, categoryName, note string
-		var amount float64
-		rows.Scan(&date, &accountName, &recordType, &amount, &item, &categoryName, &note)
-
-		line := fmt.Sprintf("📅 %s｜%s %.0f\n📝 %s｜🏷 %s｜🏦 %s",
-			date, recordType, amount, item, categoryName, accountName)
-		if note != "" {
-			line += fmt.Sprintf("\n📌 %s", note)
+	for i, r := range records {
+		line := fmt.Sprintf("%d. 📅 %s｜%s %s\n📝 %s｜🏷 %s｜🏦 %s",
+			i+1, dateOnly(r.Date), r.Type, formatAmount(r.Amount, r.Currency), r.Item, r.CategoryName, r.AccountName)
+		if r.Note != "" {
+			line += fmt.Sprintf("\n📌 %s", r.Note)
+		}
+		if r.TransferID != nil {
+			line += "\n🔁 轉帳紀錄（請至網頁修改）"
 		}
 		lines = append(lines, line)
 	}
@@ -330,7 +396,45 @@ func FormatRecentRecords(offset, pageSize int) (string, int) {
 	totalPages := (total + pageSize - 1) / pageSize
 	header := fmt.Sprintf("📋 最近紀錄（第 %d/%d 頁）\n", page, totalPages)
 
-	return header + "\n" + strings.Join(lines, "\n\n"), total
+	return header + "\n" + strings.Join(lines, "\n\n"), records, total
+}
+
+// BuildRecentKeyboard 建立最近紀錄的按鈕：每筆紀錄一排修改、刪除按鈕，最後一排為翻頁
+// 原因：轉帳產生的紀錄需透過轉帳一起修改，不提供按鈕
+func BuildRecentKeyboard(records []models.RecordWithNames, offset, pageSize, total int) services.InlineKeyboardMarkup {
+	var buttons [][]services.InlineKeyboardButton
+	for i, r := range records {
+		if r.TransferID != nil {
+			continue
+		}
+		buttons = append(buttons, []services.InlineKeyboardButton{
+			{Text: fmt.Sprintf("✏️ 修改 %d", i+1), CallbackData: fmt.Sprintf("rec_edit_%d", r.ID)},
+			{Text: fmt.Sprintf("🗑 刪除 %d", i+1), CallbackData: fmt.Sprintf("rec_del_%d_%d", r.ID, offset)},
+		})
+	}
+
+	buttons = append(buttons, BuildPaginationKeyboard(offset, pageSize, total).InlineKeyboard...)
+	return services.InlineKeyboardMarkup{InlineKeyboard: buttons}
+}
+
+// FormatDeleteConfirm 格式化刪除紀錄的確認訊息
+func FormatDeleteConfirm(r *models.RecordWithNames) string {
+	text := fmt.Sprintf("🗑 確定要刪除這筆紀錄嗎？\n\n📅 %s｜%s %s\n📝 %s｜🏷 %s｜🏦 %s",
+		dateOnly(r.Date), r.Type, formatAmount(r.Amount, r.Currency), r.Item, r.CategoryName, r.AccountName)
+	if r.Note != "" {
+		text += fmt.Sprintf("\n📌 %s", r.Note)
+	}
+	return text
+}
+
+// BuildDeleteConfirmKeyboard 建立刪除確認按鈕，offset 為返回時的最近紀錄頁
+func BuildDeleteConfirmKeyboard(recordID, offset int) services.InlineKeyboardMarkup {
+	return services.InlineKeyboardMarkup{
+		InlineKeyboard: [][]services.InlineKeyboardButton{{
+			{Text: "✅ 確認刪除", CallbackData: fmt.Sprintf("rec_delok_%d_%d", recordID, offset)},
+			{Text: "↩️ 返回", CallbackData: fmt.Sprintf("recent_page_%d", offset)},
+		}},
+	}
 }
 
 // BuildPaginationKeyboard 建立翻頁按鈕
@@ -359,22 +463,511 @@ func BuildPaginationKeyboard(offset, pageSize, total int) services.InlineKeyboar
 	return services.InlineKeyboardMarkup{InlineKeyboard: buttons}
 }
 
+// roleLabels 帳本角色的顯示名稱
+var roleLabels = map[string]string{
+	models.RoleOwner:  "擁有者",
+	models.RoleEditor: "編輯者",
+	models.RoleViewer: "檢視者",
+}
+
+// FormatBooks 格式化帳本列表（標示目前使用的帳本）
+func FormatBooks(books []models.BookWithRole, currentID int) string {
+	lines := []string{"📚 你的帳本（點擊按鈕切換）", ""}
+	for _, b := range books {
+		mark := "　"
+		if b.ID == currentID {
+			mark = "✅"
+		}
+		lines = append(lines, fmt.Sprintf("%s %s（%s）", mark, b.Name, roleLabels[b.Role]))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// BuildBookKeyboard 建立帳本切換按鈕（每排 1 個）
+func BuildBookKeyboard(books []models.BookWithRole, currentID int) services.InlineKeyboardMarkup {
+	var buttons [][]services.InlineKeyboardButton
+	for _, b := range books {
+		text := b.Name
+		if b.ID == currentID {
+			text = "✅ " + text
+		}
+		buttons = append(buttons, []services.InlineKeyboardButton{{
+			Text:         text,
+			CallbackData: fmt.Sprintf("book_%d", b.ID),
+		}})
+	}
+	return services.InlineKeyboardMarkup{InlineKeyboard: buttons}
+}
+
+// frequencyUnits 定期紀錄頻率的顯示單位
+var frequencyUnits = map[string]string{
+	models.FrequencyDaily:   "天",
+	models.FrequencyWeekly:  "週",
+	models.FrequencyMonthly: "個月",
+	models.FrequencyYearly:  "年",
+}
+
+// formatFrequency 格式化定期紀錄的頻率，例如「每個月」「每 2 週」
+func formatFrequency(r *models.RecurringRule) string {
+	if r.Interval > 1 {
+		return fmt.Sprintf("每 %d %s", r.Interval, frequencyUnits[r.Frequency])
+	}
+	return "每" + frequencyUnits[r.Frequency]
+}
+
+// FormatRecurringRules 格式化定期紀錄列表（依下次日期排列並編號，對應下方按鈕）
+func FormatRecurringRules(rules []models.RecurringRule) string {
+	if len(rules) == 0 {
+		return "目前沒有定期紀錄\n可於網頁新增房租、訂閱、薪資等每期固定的紀錄"
+	}
+
+	lines := []string{"🔁 定期紀錄", ""}
+	for i, r := range rules {
+		next := "下次 " + r.NextDate
+		switch {
+		case r.Finished():
+			next = "已結束"
+		case r.Paused:
+			next = "⏸ 已暫停"
+		}
+		lines = append(lines, fmt.Sprintf("%d. %s｜%s %s｜%s\n    %s｜🏷 %s｜🏦 %s",
+			i+1, r.Item, r.Type, formatAmount(r.Amount, r.Currency), formatFrequency(&r), next, r.CategoryName, r.AccountName))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// BuildRecurringKeyboard 建立每筆定期紀錄的暫停（或恢復）與略過下次按鈕，已結束的規則不顯示
+func BuildRecurringKeyboard(rules []models.RecurringRule) services.InlineKeyboardMarkup {
+	var buttons [][]services.InlineKeyboardButton
+	for i, r := range rules {
+		if r.Finished() {
+			continue
+		}
+		if r.Paused {
+			buttons = append(buttons, []services.InlineKeyboardButton{
+				{Text: fmt.Sprintf("▶️ 恢復 %d", i+1), CallbackData: fmt.Sprintf("rr_resume_%d", r.ID)},
+			})
+			continue
+		}
+		buttons = append(buttons, []services.InlineKeyboardButton{
+			{Text: fmt.Sprintf("⏸ 暫停 %d", i+1), CallbackData: fmt.Sprintf("rr_pause_%d", r.ID)},
+			{Text: fmt.Sprintf("⏭ 略過下次 %d", i+1), CallbackData: fmt.Sprintf("rr_skip_%d", r.ID)},
+		})
+	}
+	return services.InlineKeyboardMarkup{InlineKeyboard: buttons}
+}
+
+// === 定期摘要 ===
+
+// digestTitles 各種摘要的名稱
+var digestTitles = map[string]string{
+	models.DigestDaily:   "每日摘要",
+	models.DigestWeekly:  "每週摘要",
+	models.DigestMonthly: "每月報告",
+}
+
+// digestSchedules 各種摘要的發送日
+var digestSchedules = map[string]string{
+	models.DigestDaily:   "每天",
+	models.DigestWeekly:  "每週一",
+	models.DigestMonthly: "每月 1 號",
+}
+
+// digestRecordLimit 每日摘要最多列出的紀錄筆數
+// 原因：Telegram 單則訊息上限 4096 字，其餘只顯示筆數
+const digestRecordLimit = 30
+
+// digestCategoryLimit 每週摘要與月報最多列出的分類數
+const digestCategoryLimit = 10
+
+// formatPeriodTotals 格式化收入、支出與結餘
+func formatPeriodTotals(currency string, income, expense models.Money) string {
+	return fmt.Sprintf("💰 收入 %s｜支出 %s｜結餘 %s",
+		formatAmount(income, currency), formatAmount(expense, currency), formatAmount(income-expense, currency))
+}
+
+// FormatDailyDigest 格式化每日摘要：昨天的每一筆紀錄與收支總額
+func FormatDailyDigest(date, currency string, income, expense models.Money, records []models.RecordWithNames) string {
+	lines := []string{"🗓 " + digestTitles[models.DigestDaily] + "｜" + date, ""}
+	if len(records) == 0 {
+		return strings.Join(append(lines, "這天沒有任何紀錄，記得補記喔"), "\n")
+	}
+	for i, r := range records {
+		if i == digestRecordLimit {
+			lines = append(lines, fmt.Sprintf("…另有 %d 筆", len(records)-digestRecordLimit))
+			break
+		}
+		lines = append(lines, fmt.Sprintf("• %s %s %s｜🏷 %s｜🏦 %s",
+			r.Item, r.Type, formatAmount(r.Amount, r.Currency), r.CategoryName, r.AccountName))
+	}
+	lines = append(lines, "", formatPeriodTotals(currency, income, expense))
+	return strings.Join(lines, "\n")
+}
+
+// FormatWeeklyDigest 格式化每週摘要：上週的收支總額與各支出分類
+func FormatWeeklyDigest(from, to, currency string, income, expense models.Money, totals []models.CategoryTotal) string {
+	lines := []string{"📊 " + digestTitles[models.DigestWeekly] + "｜" + from + " ～ " + to, ""}
+	lines = append(lines, formatPeriodTotals(currency, income, expense))
+	if categories := formatCategoryShares(currency, "支出", expense, totals, digestCategoryLimit); len(categories) > 0 {
+		lines = append(lines, "", "支出分類：")
+		lines = append(lines, categories...)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// FormatMonthlyDigest 格式化每月報告：上個月的收支總額、各支出分類與預算使用情況
+func FormatMonthlyDigest(month, currency string, income, expense models.Money, totals []models.CategoryTotal, budgets []models.BudgetStatus) string {
+	lines := []string{"📅 " + digestTitles[models.DigestMonthly] + "｜" + month, ""}
+	lines = append(lines, formatPeriodTotals(currency, income, expense))
+	if categories := formatCategoryShares(currency, "支出", expense, totals, digestCategoryLimit); len(categories) > 0 {
+		lines = append(lines, "", "支出分類：")
+		lines = append(lines, categories...)
+	}
+	if len(budgets) > 0 {
+		lines = append(lines, "", "預算：")
+	}
+	for _, b := range budgets {
+		name := b.CategoryName
+		if b.CategoryID == 0 {
+			name = "總預算"
+		}
+		mark := "✅"
+		switch {
+		case b.Remaining < 0:
+			mark = "🚨"
+		case b.Percent >= 80:
+			mark = "⚠️"
+		}
+		lines = append(lines, fmt.Sprintf("  %s %s 已用 %s / %s（%.0f%%）",
+			mark, name, formatAmount(b.Spent, b.Currency), formatAmount(b.Budgeted, b.Currency), b.Percent))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// FormatDigestSubscriptions 格式化聊天室目前的訂閱
+func FormatDigestSubscriptions(subs []models.DigestSubscription, timezone string) string {
+	if len(subs) == 0 {
+		return "目前沒有訂閱任何定期摘要"
+	}
+	lines := []string{"🔔 已訂閱的定期摘要（時區 " + timezone + "）", ""}
+	for _, d := range subs {
+		lines = append(lines, fmt.Sprintf("• %s：%s %s", digestTitles[d.Kind], digestSchedules[d.Kind], d.SendTime))
+	}
+	lines = append(lines, "", "摘要內容為目前的帳本（/book 可切換）")
+	return strings.Join(lines, "\n")
+}
+
+// FormatDigestUsage 格式化訂閱指令的用法
+func FormatDigestUsage() string {
+	return `用法：
+/subscribe 種類 [時間] - 訂閱，時間省略為 ` + defaultDigestTime + `
+/unsubscribe [種類] - 取消訂閱，省略種類時取消全部
+
+種類：
+daily（每日）- 每天發送昨天的紀錄
+weekly（每週）- 每週一發送上週各分類的收支
+monthly（每月）- 每月 1 號發送上個月的報告與預算
+all（全部）
+
+例如：/subscribe daily 21:30`
+}
+
+// === 統計 ===
+
+// statsCategoryLimit 統計訊息最多列出的分類數（附查看紀錄按鈕）
+const statsCategoryLimit = 12
+
+// statTypeCode 類型在統計按鈕上的代碼（見 statTypeCodes）
+func statTypeCode(recordType string) string {
+	if recordType == "收入" {
+		return "i"
+	}
+	return "e"
+}
+
+// formatPeriod 格式化統計期間，例如「2026 年 10 月」「2026 年」
+func formatPeriod(period string) string {
+	if t, err := time.Parse("2006-01", period); err == nil {
+		return fmt.Sprintf("%d 年 %d 月", t.Year(), t.Month())
+	}
+	return period + " 年"
+}
+
+// formatCategoryShares 格式化某類型的各分類（金額由大到小，附上佔該類型總額的百分比），最多 limit 個
+func formatCategoryShares(currency, recordType string, total models.Money, totals []models.CategoryTotal, limit int) []string {
+	var lines []string
+	for _, t := range totals {
+		if t.Type != recordType {
+			continue
+		}
+		if len(lines) == limit {
+			lines = append(lines, "  …")
+			break
+		}
+		percent := 0.0
+		if total > 0 {
+			percent = t.Total.Float64() / total.Float64() * 100
+		}
+		lines = append(lines, fmt.Sprintf("  🏷 %s %s（%.0f%%）", t.CategoryName, formatAmount(t.Total, currency), percent))
+	}
+	return lines
+}
+
+// FormatStats 格式化分類統計：收支總額、指定類型的各分類佔比，年度統計另列每個月的收支
+func FormatStats(r *StatsReport) string {
+	lines := []string{"📊 " + formatPeriod(r.Period) + " " + r.Type + "統計", ""}
+	lines = append(lines, formatPeriodTotals(r.Currency, r.Income, r.Expense))
+
+	if len(r.Months) > 0 {
+		lines = append(lines, "", "每月收支：")
+		for _, m := range r.Months {
+			lines = append(lines, fmt.Sprintf("  %s 收入 %s｜支出 %s",
+				m.Month[5:]+" 月", formatAmount(m.Income, r.Currency), formatAmount(m.Expense, r.Currency)))
+		}
+	}
+
+	total := r.Expense
+	if r.Type == "收入" {
+		total = r.Income
+	}
+	lines = append(lines, "", r.Type+"分類：")
+	if categories := formatCategoryShares(r.Currency, r.Type, total, r.Categories, statsCategoryLimit); len(categories) > 0 {
+		lines = append(lines, categories...)
+	} else {
+		lines = append(lines, "  沒有"+r.Type+"紀錄")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// BuildStatsKeyboard 建立分類統計的按鈕：前後期間與切換收入/支出、各分類的紀錄、總覽或年度統計
+// 原因：不超過目前的期間（current）時才顯示下一期
+func BuildStatsKeyboard(r *StatsReport, current string) services.InlineKeyboardMarkup {
+	code := statTypeCode(r.Type)
+	toggle := services.InlineKeyboardButton{Text: "💵 看收入", CallbackData: fmt.Sprintf("st_%s_i", r.Period)}
+	if r.Type == "收入" {
+		toggle = services.InlineKeyboardButton{Text: "💸 看支出", CallbackData: fmt.Sprintf("st_%s_e", r.Period)}
+	}
+	nav := []services.InlineKeyboardButton{
+		{Text: "⬅️ " + formatPeriod(shiftPeriod(r.Period, -1)), CallbackData: fmt.Sprintf("st_%s_%s", shiftPeriod(r.Period, -1), code)},
+		toggle,
+	}
+	if next := shiftPeriod(r.Period, 1); next <= current {
+		nav = append(nav, services.InlineKeyboardButton{Text: formatPeriod(next) + " ➡️", CallbackData: fmt.Sprintf("st_%s_%s", next, code)})
+	}
+	buttons := [][]services.InlineKeyboardButton{nav}
+
+	var row []services.InlineKeyboardButton
+	for i, c := range r.Categories {
+		if i == statsCategoryLimit {
+			break
+		}
+		row = append(row, services.InlineKeyboardButton{
+			Text:         "🏷 " + c.CategoryName,
+			CallbackData: fmt.Sprintf("st_c_%s_%s_%d_0", r.Period, code, c.CategoryID),
+		})
+		if len(row) == 3 {
+			buttons = append(buttons, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		buttons = append(buttons, row)
+	}
+
+	if len(r.Period) == len("2006-01") {
+		buttons = append(buttons, []services.InlineKeyboardButton{
+			{Text: "📋 月總覽", CallbackData: "st_s_" + r.Period},
+			{Text: "📆 " + r.Period[:4] + " 年度", CallbackData: fmt.Sprintf("st_%s_%s", r.Period[:4], code)},
+		})
+	}
+	buttons = append(buttons, []services.InlineKeyboardButton{
+		{Text: "🖼 圖表", CallbackData: fmt.Sprintf("ch_%s_%s_%s", models.ChartPie, r.Period, code)},
+	})
+	return services.InlineKeyboardMarkup{InlineKeyboard: buttons}
+}
+
+// FormatSummary 格式化月總覽：收支總額、結餘、日均支出與上個月支出的比較
+func FormatSummary(r *SummaryReport) string {
+	lines := []string{"📋 " + formatPeriod(r.Month) + " 總覽", ""}
+	lines = append(lines,
+		"💵 收入 "+formatAmount(r.Income, r.Currency),
+		"💸 支出 "+formatAmount(r.Expense, r.Currency),
+		"💰 結餘 "+formatAmount(r.Income-r.Expense, r.Currency),
+	)
+	if r.Days > 0 {
+		lines = append(lines, "📅 日均支出 "+formatAmount((r.Expense/models.Money(r.Days)).Round(models.CurrencyDecimals(r.Currency)), r.Currency))
+	}
+	if r.PrevExpense > 0 {
+		change := (r.Expense.Float64() - r.PrevExpense.Float64()) / r.PrevExpense.Float64() * 100
+		lines = append(lines, fmt.Sprintf("📈 支出較上月 %+.0f%%（上月 %s）", change, formatAmount(r.PrevExpense, r.Currency)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// BuildSummaryKeyboard 建立月總覽的按鈕：前後月份（不超過本月）與分類統計
+func BuildSummaryKeyboard(month, current string) services.InlineKeyboardMarkup {
+	prev := shiftPeriod(month, -1)
+	nav := []services.InlineKeyboardButton{{Text: "⬅️ " + formatPeriod(prev), CallbackData: "st_s_" + prev}}
+	if next := shiftPeriod(month, 1); next <= current {
+		nav = append(nav, services.InlineKeyboardButton{Text: formatPeriod(next) + " ➡️", CallbackData: "st_s_" + next})
+	}
+	return services.InlineKeyboardMarkup{InlineKeyboard: [][]services.InlineKeyboardButton{
+		nav,
+		{
+			{Text: "💸 支出分類", CallbackData: fmt.Sprintf("st_%s_e", month)},
+			{Text: "💵 收入分類", CallbackData: fmt.Sprintf("st_%s_i", month)},
+		},
+	}}
+}
+
+// FormatCategoryRecords 格式化分類在期間內的紀錄（分頁顯示）
+func FormatCategoryRecords(period, categoryName, recordType string, records []models.RecordWithNames, offset, total int) string {
+	title := fmt.Sprintf("🏷 %s｜%s %s", categoryName, formatPeriod(period), recordType)
+	if total == 0 {
+		return title + "\n\n📭 沒有紀錄"
+	}
+
+	lines := []string{fmt.Sprintf("%s（共 %d 筆，第 %d/%d 頁）", title, total, offset/statsPageSize+1, (total+statsPageSize-1)/statsPageSize), ""}
+	for _, r := range records {
+		line := fmt.Sprintf("📅 %s｜%s %s｜🏦 %s", dateOnly(r.Date), r.Item, formatAmount(r.Amount, r.Currency), r.AccountName)
+		if r.Note != "" {
+			line += "\n    📌 " + r.Note
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// BuildCategoryRecordsKeyboard 建立分類紀錄的翻頁與返回統計按鈕
+func BuildCategoryRecordsKeyboard(period, recordType string, categoryID, offset, pageSize, total int) services.InlineKeyboardMarkup {
+	code := statTypeCode(recordType)
+	var row []services.InlineKeyboardButton
+	if offset > 0 {
+		row = append(row, services.InlineKeyboardButton{
+			Text:         "⬅️ 上一頁",
+			CallbackData: fmt.Sprintf("st_c_%s_%s_%d_%d", period, code, categoryID, offset-pageSize),
+		})
+	}
+	if offset+pageSize < total {
+		row = append(row, services.InlineKeyboardButton{
+			Text:         "➡️ 下一頁",
+			CallbackData: fmt.Sprintf("st_c_%s_%s_%d_%d", period, code, categoryID, offset+pageSize),
+		})
+	}
+
+	var buttons [][]services.InlineKeyboardButton
+	if len(row) > 0 {
+		buttons = append(buttons, row)
+	}
+	buttons = append(buttons, []services.InlineKeyboardButton{
+		{Text: "↩️ 返回統計", CallbackData: fmt.Sprintf("st_%s_%s", period, code)},
+	})
+	return services.InlineKeyboardMarkup{InlineKeyboard: buttons}
+}
+
+// === 統計圖表 ===
+
+// chartTitles 各種圖表在說明文字中的名稱
+var chartTitles = map[string]string{
+	models.ChartPie:  "分類佔比",
+	models.ChartBar:  "收支長條圖",
+	models.ChartLine: "資產變化",
+}
+
+// chartIcons 各種圖表按鈕的圖示
+var chartIcons = map[string]string{
+	models.ChartPie:  "🥧",
+	models.ChartBar:  "📊",
+	models.ChartLine: "📈",
+}
+
+// FormatChartCaption 格式化圖表的說明文字，圓餅圖列出圖例編號對應的分類
+// 原因：圖片上只能顯示英數字，分類名稱由說明文字對照
+func FormatChartCaption(chart *models.Chart) string {
+	req := chart.Request
+	title := chartIcons[req.Kind] + " " + formatPeriod(req.Period) + " "
+	switch req.Kind {
+	case models.ChartLine:
+		return title + chartTitles[req.Kind] + "\n\n💰 期末資產 " + formatAmount(chart.Total, req.Currency)
+	case models.ChartBar:
+		every := "每日"
+		if len(req.Period) == len("2006") {
+			every = "每月"
+		}
+		return title + every + req.Type + "\n\n" + req.Type + "合計 " + formatAmount(chart.Total, req.Currency)
+	}
+
+	lines := []string{title + req.Type + chartTitles[req.Kind], "", req.Type + "合計 " + formatAmount(chart.Total, req.Currency)}
+	if len(chart.Categories) == 0 {
+		return strings.Join(append(lines, "沒有"+req.Type+"紀錄"), "\n")
+	}
+	for i, c := range chart.Categories {
+		if i == len(charts.Palette) {
+			lines = append(lines, fmt.Sprintf("+ 其他 %d 個分類", len(chart.Categories)-i))
+			break
+		}
+		percent := 0.0
+		if chart.Total > 0 {
+			percent = c.Total.Float64() / chart.Total.Float64() * 100
+		}
+		lines = append(lines, fmt.Sprintf("%d. %s %s（%.0f%%）", i+1, c.CategoryName, formatAmount(c.Total, req.Currency), percent))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// BuildChartKeyboard 建立圖表的按鈕：切換圖表種類、前後期間（不超過 current）、收入/支出與返回統計
+func BuildChartKeyboard(req models.ChartRequest, current string) services.InlineKeyboardMarkup {
+	code := statTypeCode(req.Type)
+	var kinds []services.InlineKeyboardButton
+	for _, kind := range models.ChartKinds {
+		if kind != req.Kind {
+			kinds = append(kinds, services.InlineKeyboardButton{
+				Text:         chartIcons[kind] + " " + chartTitles[kind],
+				CallbackData: fmt.Sprintf("ch_%s_%s_%s", kind, req.Period, code),
+			})
+		}
+	}
+
+	prev := shiftPeriod(req.Period, -1)
+	nav := []services.InlineKeyboardButton{
+		{Text: "⬅️ " + formatPeriod(prev), CallbackData: fmt.Sprintf("ch_%s_%s_%s", req.Kind, prev, code)},
+	}
+	if next := shiftPeriod(req.Period, 1); next <= current {
+		nav = append(nav, services.InlineKeyboardButton{Text: formatPeriod(next) + " ➡️", CallbackData: fmt.Sprintf("ch_%s_%s_%s", req.Kind, next, code)})
+	}
+
+	// 資產變化不分收入或支出
+	var other []services.InlineKeyboardButton
+	if req.Kind != models.ChartLine {
+		toggle := services.InlineKeyboardButton{Text: "💵 看收入", CallbackData: fmt.Sprintf("ch_%s_%s_i", req.Kind, req.Period)}
+		if req.Type == "收入" {
+			toggle = services.InlineKeyboardButton{Text: "💸 看支出", CallbackData: fmt.Sprintf("ch_%s_%s_e", req.Kind, req.Period)}
+		}
+		other = append(other, toggle)
+	}
+	other = append(other, services.InlineKeyboardButton{Text: "📊 統計", CallbackData: fmt.Sprintf("st_%s_%s", req.Period, code)})
+
+	return services.InlineKeyboardMarkup{InlineKeyboard: [][]services.InlineKeyboardButton{kinds, nav, other}}
+}
+
+// FormatChartUsage 格式化 /chart 指令的用法
+func FormatChartUsage() string {
+	return `用法：/chart [種類] [月份或年份]
+種類：pie（圓餅，預設）、bar（長條）、line（折線）
+例如：/chart、/chart bar 上月、/chart line 2026`
+}
+
 // === 以下保留原有的查詢格式化功能 ===
 
 // FormatCategories 格式化分類列表
-func FormatCategories() string {
-	rows, err := initializers.DB.Query("SELECT id, name FROM categories ORDER BY sort_order")
+func FormatCategories(store repository.Store) string {
+	categories, err := store.Categories().List()
 	if err != nil {
 		return "查詢分類失敗"
 	}
-	defer rows.Close()
 
 	var lines []string
-	for rows.Next() {
-		var cat models.Category
-		if err := rows.Scan(&cat.ID, &cat.Name); err != nil {
-			continue
-		}
+	for _, cat := range categories {
 		lines = append(lines, fmt.Sprintf("%d: %s", cat.ID, cat.Name))
 	}
 
@@ -382,21 +975,15 @@ func FormatCategories() string {
 }
 
 // FormatAccounts 格式化帳戶列表
-func FormatAccounts() string {
-	rows, err := initializers.DB.Query("SELECT name, balance FROM accounts ORDER BY sort_order")
+func FormatAccounts(store repository.Store) string {
+	accounts, err := store.Accounts().List()
 	if err != nil {
 		return "查詢帳戶失敗"
 	}
-	defer rows.Close()
 
 	var lines []string
-	for rows.Next() {
-		var name string
-		var balance float64
-		if err := rows.Scan(&name, &balance); err != nil {
-			continue
-		}
-		lines = append(lines, fmt.Sprintf("%s: %.0f", name, balance))
+	for _, a := range accounts {
+		lines = append(lines, fmt.Sprintf("%s: %s", a.Name, formatAmount(a.Balance, a.Currency)))
 	}
 
 	return strings.Join(lines, "\n")
@@ -408,18 +995,111 @@ func FormatUsage() string {
 
 輸入 /new 即可開始新增紀錄
 所有欄位都已預設好，只需修改需要的項目
+也可以直接輸入一行，例如「昨天 信用卡 午餐 150 #飲食 跟同事」（順序不限，// 之後為備註）
+也可以一次輸入多行（時間、帳戶、收入/支出、金額、項目、分類、備註，帳戶、類型與備註可省略）
 
 指令列表：
 /new - 開始記帳
 /transfer - 帳戶轉帳
-/recent - 查看最近紀錄
+/recent - 查看最近紀錄（可修改、刪除）
+/undo - 復原最近一筆新增的紀錄或轉帳
+/recurring - 查看定期紀錄（可暫停、略過下次）
+/stats - 本月各分類的收支（/stats 2026-09 查看其他月份）
+/summary - 本月收支總覽
+/year - 今年每月的收支與各分類統計
+/chart - 統計圖表（圓餅、長條、折線）
+/subscribe - 訂閱每日、每週、每月摘要
+/unsubscribe - 取消訂閱摘要
+/book - 切換帳本
 /start - 顯示此說明
 /查詢分類 - 查看所有分類
 /查詢帳戶 - 查看所有帳戶餘額
 /cancel - 取消目前操作`
 }
 
-// FormatError 格式化錯誤訊息（保留給多行格式解析失敗時使用）
-func FormatError() string {
-	return "輸入格式有誤，請重新輸入"
+// FormatSessionExpired 會話閒置逾時後，預覽訊息改為的內容
+func FormatSessionExpired(s *Session) string {
+	action := "新增紀錄"
+	if s.Mode == ModeTransfer {
+		action = "轉帳"
+	} else if s.RecordID > 0 {
+		action = "修改紀錄"
+	}
+	return fmt.Sprintf("⌛ 已取消%s（超過 %s未操作）\n可輸入 /new 或 /transfer 重新開始", action, formatDuration(SessionTTL))
+}
+
+// BuildUndoKeyboard 建立成功訊息上的復原按鈕
+func BuildUndoKeyboard() services.InlineKeyboardMarkup {
+	return services.InlineKeyboardMarkup{
+		InlineKeyboard: [][]services.InlineKeyboardButton{{
+			{Text: "↩️ 復原", CallbackData: "undo"},
+		}},
+	}
+}
+
+// FormatUndone 格式化已復原的成功訊息（保留原本內容供對照）
+func FormatUndone(a *undoAction) string {
+	what := "紀錄"
+	if a.Kind == undoTransfer {
+		what = "轉帳"
+	}
+	return fmt.Sprintf("%s\n\n↩️ 已復原：%s已刪除，帳戶餘額已還原", a.Text, what)
+}
+
+// FormatUndoUnavailable 沒有可復原操作時的說明
+func FormatUndoUnavailable() string {
+	if UndoWindow <= 0 {
+		return "未開啟復原功能"
+	}
+	return fmt.Sprintf("⚠️ 沒有可復原的操作\n只能復原最近一筆新增的紀錄或轉帳，且需在送出後 %s內", formatDuration(UndoWindow))
+}
+
+// formatDuration 將時間長度格式化為「2 小時」「30 分鐘」「45 秒」
+func formatDuration(d time.Duration) string {
+	switch {
+	case d >= time.Hour && d%time.Hour == 0:
+		return fmt.Sprintf("%d 小時", int(d.Hours()))
+	case d >= time.Minute:
+		return fmt.Sprintf("%d 分鐘", int(d.Minutes()))
+	default:
+		return fmt.Sprintf("%d 秒", int(d.Seconds()))
+	}
+}
+
+// FormatUnpaired 未授權聊天室的說明
+// 原因：使用者需要知道如何綁定，chat ID 供管理員加入允許清單或以 API 綁定
+func FormatUnpaired(chatID int64) string {
+	return fmt.Sprintf(`🔒 此聊天室尚未綁定使用者
+
+請登入網頁，於設定頁產生配對碼後輸入：
+/pair 配對碼
+
+此聊天室的 ID：%d`, chatID)
+}
+
+// FormatError 格式化多行格式解析失敗的訊息，附上錯誤原因與完整格式
+// 原因：列出目前帳本的帳戶與分類，使用者可直接對照修正
+func FormatError(store repository.Store, err error) string {
+	var accounts, categories []string
+	if list, e := store.Accounts().List(); e == nil {
+		for _, a := range list {
+			accounts = append(accounts, fmt.Sprintf("%d: %s", a.ID, a.Name))
+		}
+	}
+	if list, e := store.Categories().List(); e == nil {
+		for _, c := range list {
+			categories = append(categories, fmt.Sprintf("%d: %s", c.ID, c.Name))
+		}
+	}
+
+	return fmt.Sprintf(`❌ 新增失敗！%s
+
+請確認格式是否正確（每行一個欄位）：
+時間（昨天、今天、明天，或 2026/01/01、01/01、1/1）
+帳戶名稱（可省略，預設為現金：%s）
+收入/支出（可省略，預設為支出）
+金額
+項目名稱
+分類（%s）
+備註（可省略）`, err.Error(), strings.Join(accounts, "、"), strings.Join(categories, "、"))
 }
@@ -1,24 +1,26 @@
 package bot
 
 import (
-	"accountbook/initializers"
+	"accountbook/models"
+	"accountbook/repository"
 	"accountbook/services"
+	"crypto/subtle"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 // === Telegram 資料結構 ===
 
-// TelegramUpdate Telegram Webhook 推播的完整結構
+// TelegramUpdate Telegram Webhook 推播（或 getUpdates 取得）的完整結構
 // 原因：需同時處理一般訊息 (message) 和按鈕回調 (callback_query)
 type TelegramUpdate struct {
+	UpdateID      int                    `json:"update_id"`
 	Message       *TelegramMessage       `json:"message"`
 	CallbackQuery *TelegramCallbackQuery `json:"callback_query"`
 }
@@ -42,26 +44,45 @@ type TelegramCallbackQuery struct {
 // === Webhook 入口 ===
 
 // HandleWebhook 處理 Telegram Webhook 推播
+// 原因：此路由不需登入，以註冊 webhook 時的密鑰確認請求來自 Telegram
 func HandleWebhook(c *gin.Context) {
+	secret := c.GetHeader("X-Telegram-Bot-Api-Secret-Token")
+	if services.WebhookSecret == "" || subtle.ConstantTimeCompare([]byte(secret), []byte(services.WebhookSecret)) != 1 {
+		c.JSON(http.StatusForbidden, gin.H{"error": "無效的 webhook 密鑰"})
+		return
+	}
+
 	var update TelegramUpdate
 	if err := json.NewDecoder(c.Request.Body).Decode(&update); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "無法解析請求"})
 		return
 	}
 
+	handleUpdate(&update)
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// handleUpdate 分派 update 到訊息或按鈕處理（webhook 與 long polling 共用）
+// 同一聊天室的 update 依序處理，處理完後寫入該聊天室的會話，重啟後可以接續
+func handleUpdate(update *TelegramUpdate) {
 	// 處理按鈕回調（Callback Query）
 	if update.CallbackQuery != nil {
-		handleCallbackQuery(update.CallbackQuery)
-		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+		if update.CallbackQuery.Message != nil {
+			chatID := update.CallbackQuery.Message.Chat.ID
+			defer lockChat(chatID)()
+			handleCallbackQuery(update.CallbackQuery)
+			SaveSession(chatID)
+		}
 		return
 	}
 
 	// 處理一般訊息
 	if update.Message != nil && update.Message.Text != "" {
+		chatID := update.Message.Chat.ID
+		defer lockChat(chatID)()
 		handleMessage(update.Message)
+		SaveSession(chatID)
 	}
-
-	c.JSON(http.StatusOK, gin.H{"status": "ok"})
 }
 
 // === 一般訊息處理 ===
@@ -70,6 +91,24 @@ func handleMessage(msg *TelegramMessage) {
 	chatID := msg.Chat.ID
 	text := strings.TrimSpace(msg.Text)
 
+	// 未授權的聊天室只能以 /pair 綁定，其餘訊息一律回覆綁定說明
+	if !authorizedChat(chatID) {
+		switch {
+		case strings.HasPrefix(text, "/pair"):
+			handlePair(chatID, msg.MessageID, strings.TrimSpace(strings.TrimPrefix(text, "/pair")))
+		default:
+			services.SendMessage(chatID, FormatUnpaired(chatID))
+		}
+		return
+	}
+
+	// 依聊天室找出使用者與目前帳本，之後的查詢與寫入都限定在此帳本
+	cb, ok := chatBook(chatID)
+	if !ok {
+		return
+	}
+	store := repository.Default.ForBook(cb.BookID, cb.UserID)
+
 	// 指令處理
 	switch {
 	case text == "/start":
@@ -77,23 +116,70 @@ func handleMessage(msg *TelegramMessage) {
 		return
 
 	case text == "/查詢分類" || text == "/categories" || text == "/分類":
-		services.SendMessage(chatID, FormatCategories())
+		services.SendMessage(chatID, FormatCategories(store))
 		return
 
 	case text == "/查詢帳戶" || text == "/accounts" || text == "/帳戶":
-		services.SendMessage(chatID, FormatAccounts())
+		services.SendMessage(chatID, FormatAccounts(store))
 		return
 
 	case text == "/new" || text == "/記帳":
-		startNewRecord(chatID)
+		if requireEditor(chatID, cb) {
+			startNewRecord(chatID, cb)
+		}
 		return
 
 	case text == "/recent" || text == "/最近":
-		handleRecentRecords(chatID, 0)
+		handleRecentRecords(chatID, store, 0)
 		return
 
 	case text == "/transfer" || text == "/轉帳":
-		startTransfer(chatID)
+		if requireEditor(chatID, cb) {
+			startTransfer(chatID, cb)
+		}
+		return
+
+	case text == "/undo" || text == "/復原":
+		handleUndo(chatID, nil)
+		return
+
+	case text == "/recurring" || text == "/定期":
+		handleRecurringRules(chatID, cb)
+		return
+
+	case commandIs(text, "/stats", "/統計"):
+		handleStats(chatID, store, strings.Fields(text)[1:])
+		return
+
+	case commandIs(text, "/summary", "/總覽"):
+		handleSummary(chatID, store, strings.Fields(text)[1:])
+		return
+
+	case commandIs(text, "/year", "/年度"):
+		handleYear(chatID, store, strings.Fields(text)[1:])
+		return
+
+	case commandIs(text, "/chart", "/圖表"):
+		handleChart(chatID, store, strings.Fields(text)[1:])
+		return
+
+	case commandIs(text, "/subscribe", "/訂閱"):
+		handleSubscribe(chatID, strings.Fields(text)[1:])
+		return
+
+	case commandIs(text, "/unsubscribe", "/取消訂閱"):
+		handleUnsubscribe(chatID, strings.Fields(text)[1:])
+		return
+
+	case text == "/book" || text == "/帳本":
+		handleBooks(chatID, cb)
+		return
+
+	case strings.HasPrefix(text, "/pair"):
+		// 已綁定的聊天室可改綁到其他使用者，原本的會話與可復原的操作屬於舊使用者
+		DeleteSession(chatID)
+		forgetUndo(chatID)
+		handlePair(chatID, msg.MessageID, strings.TrimSpace(strings.TrimPrefix(text, "/pair")))
 		return
 
 	case text == "/cancel" || text == "/取消":
@@ -102,7 +188,7 @@ func handleMessage(msg *TelegramMessage) {
 		return
 
 	case strings.HasPrefix(text, "/"):
-		services.SendMessage(chatID, "未知指令，可用指令：/start、/new、/transfer、/recent、/查詢帳戶、/查詢分類")
+		services.SendMessage(chatID, "未知指令，可用指令：/start、/new、/transfer、/recent、/undo、/recurring、/stats、/summary、/year、/chart、/subscribe、/book、/查詢帳戶、/查詢分類")
 		return
 	}
 
@@ -113,14 +199,103 @@ func handleMessage(msg *TelegramMessage) {
 		return
 	}
 
+	if !requireEditor(chatID, cb) {
+		return
+	}
+
+	// 多行訊息 → 以完整格式解析，成功後開啟預覽確認
+	if len(splitLines(text)) > 1 {
+		parsed, err := ParseRecord(store, text)
+		if err != nil {
+			services.SendMessage(chatID, FormatError(store, err))
+			return
+		}
+		startNewRecordFromParsed(chatID, cb, parsed)
+		return
+	}
+
 	// 非指令、無會話 → 嘗試解析快捷輸入後開始新增紀錄流程
-	startNewRecordWithQuickInput(chatID, text)
+	startNewRecordWithQuickInput(chatID, cb, text)
+}
+
+// commandIs 訊息的第一個詞是否為指定的指令之一（之後可帶參數）
+func commandIs(text string, commands ...string) bool {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return false
+	}
+	for _, c := range commands {
+		if fields[0] == c {
+			return true
+		}
+	}
+	return false
+}
+
+// authorizedChat 聊天室是否已綁定使用者或在允許清單中
+func authorizedChat(chatID int64) bool {
+	ok, err := services.TelegramAuth.Authorized(chatID)
+	if err != nil {
+		log.Printf("查詢聊天室 %d 的授權失敗: %v", chatID, err)
+		return false
+	}
+	return ok
+}
+
+// handlePair 以網頁取得的配對碼將聊天室綁定到使用者
+// 原因：配對碼等同密碼，綁定後刪除使用者輸入的訊息
+func handlePair(chatID int64, userMsgID int, code string) {
+	if code == "" {
+		services.SendMessage(chatID, "請輸入 /pair 配對碼（配對碼於網頁設定頁產生）")
+		return
+	}
+
+	user, err := services.TelegramAuth.Pair(chatID, code)
+	if err != nil {
+		if err == services.ErrInvalidPairCode {
+			services.SendMessage(chatID, "❌ "+err.Error()+"，請至網頁設定頁重新產生")
+			return
+		}
+		if err == services.ErrChatLinked {
+			services.SendMessage(chatID, "❌ "+err.Error())
+			return
+		}
+		log.Printf("聊天室 %d 配對失敗: %v", chatID, err)
+		services.SendMessage(chatID, "系統忙碌中，請稍後再試")
+		return
+	}
+
+	services.DeleteMessage(chatID, userMsgID)
+	services.SendMessage(chatID, "✅ 已綁定到使用者「"+user.DisplayName+"」\n\n"+FormatUsage())
+}
+
+// chatBook 取得聊天室對應的使用者與目前帳本，查詢失敗時回覆錯誤訊息
+func chatBook(chatID int64) (*services.ChatBook, bool) {
+	cb, err := services.Books.ChatBook(chatID)
+	if err != nil {
+		log.Printf("查詢聊天室 %d 的帳本失敗: %v", chatID, err)
+		services.SendMessage(chatID, "系統忙碌中，請稍後再試")
+		return nil, false
+	}
+	return cb, true
+}
+
+// readOnlyMessage 檢視者嘗試記帳時的回覆
+const readOnlyMessage = "👀 你在目前帳本的角色為檢視者，無法新增或修改紀錄\n可輸入 /book 切換帳本"
+
+// requireEditor 確認使用者可修改目前帳本，檢視者回覆提示並回傳 false
+func requireEditor(chatID int64, cb *services.ChatBook) bool {
+	if cb.CanEdit() {
+		return true
+	}
+	services.SendMessage(chatID, readOnlyMessage)
+	return false
 }
 
 // startNewRecord 啟動互動式新增紀錄流程
 // 原因：建立帶有預設值的會話，發送預覽訊息搭配 Inline Keyboard
-func startNewRecord(chatID int64) {
-	session := NewSession(chatID)
+func startNewRecord(chatID int64, cb *services.ChatBook) {
+	session := NewSession(chatID, cb.BookID, cb.UserID)
 
 	text := FormatPreview(session)
 	keyboard := BuildPreviewKeyboard(session)
@@ -149,7 +324,7 @@ func handleFieldInput(chatID int64, userMsgID int, session *Session, text string
 	case StateEditAmt:
 		amount, err := parseAmount(text)
 		if err != nil {
-			services.SendMessage(chatID, "❌ 請輸入正確的金額（數字）")
+			services.SendMessage(chatID, "❌ "+err.Error())
 			return
 		}
 		session.Amount = amount
@@ -172,7 +347,7 @@ func handleFieldInput(chatID int64, userMsgID int, session *Session, text string
 	case StateTransferAmt:
 		amount, err := parseAmount(text)
 		if err != nil {
-			services.SendMessage(chatID, "❌ 請輸入正確的金額（數字）")
+			services.SendMessage(chatID, "❌ "+err.Error())
 			return
 		}
 		session.Amount = amount
@@ -219,14 +394,56 @@ func handleCallbackQuery(cq *TelegramCallbackQuery) {
 	// 先回應 callback（消除按鈕 loading）
 	services.AnswerCallbackQuery(cq.ID, "")
 
+	// 未授權的聊天室不處理任何按鈕
+	if !authorizedChat(chatID) {
+		return
+	}
+
+	// 處理帳本切換按鈕（不需要會話）
+	if strings.HasPrefix(data, "book_") {
+		handleBookSwitch(chatID, cq.Message.MessageID, strings.TrimPrefix(data, "book_"))
+		return
+	}
+
 	// 處理翻頁按鈕（不需要會話）
 	if strings.HasPrefix(data, "recent_page_") {
+		cb, ok := chatBook(chatID)
+		if !ok {
+			return
+		}
 		offsetStr := strings.TrimPrefix(data, "recent_page_")
 		offset, _ := strconv.Atoi(offsetStr)
-		const pageSize = 5
-		text, total := FormatRecentRecords(offset, pageSize)
-		keyboard := BuildPaginationKeyboard(offset, pageSize, total)
-		services.EditMessageWithKeyboard(chatID, cq.Message.MessageID, text, keyboard)
+		showRecentPage(chatID, cq.Message.MessageID, repository.Default.ForBook(cb.BookID, cb.UserID), offset, "")
+		return
+	}
+
+	// 處理復原按鈕（不需要會話）
+	if data == "undo" {
+		handleUndo(chatID, cq.Message)
+		return
+	}
+
+	// 處理最近紀錄上的修改、刪除按鈕（不需要會話）
+	if strings.HasPrefix(data, "rec_") {
+		handleRecordAction(chatID, cq.Message.MessageID, data)
+		return
+	}
+
+	// 處理定期紀錄上的暫停、恢復、略過按鈕（不需要會話）
+	if strings.HasPrefix(data, "rr_") {
+		handleRecurringAction(chatID, cq.Message.MessageID, data)
+		return
+	}
+
+	// 處理統計訊息上的切換期間、類型與分類紀錄按鈕（不需要會話）
+	if strings.HasPrefix(data, "st_") {
+		handleStatsAction(chatID, cq.Message.MessageID, data)
+		return
+	}
+
+	// 處理圖表訊息上的切換種類、期間與類型按鈕（不需要會話）
+	if strings.HasPrefix(data, "ch_") {
+		handleChartAction(chatID, data)
 		return
 	}
 
@@ -235,7 +452,9 @@ func handleCallbackQuery(cq *TelegramCallbackQuery) {
 	// 若無會話但收到 callback，可能是過期的按鈕
 	if session == nil {
 		if data == "new_record" {
-			startNewRecord(chatID)
+			if cb, ok := chatBook(chatID); ok && requireEditor(chatID, cb) {
+				startNewRecord(chatID, cb)
+			}
 			return
 		}
 		services.AnswerCallbackQuery(cq.ID, "此操作已過期，請重新開始")
@@ -260,13 +479,13 @@ func handleCallbackQuery(cq *TelegramCallbackQuery) {
 	case strings.HasPrefix(data, "set_date_"):
 		offsetStr := strings.TrimPrefix(data, "set_date_")
 		offset, _ := strconv.Atoi(offsetStr)
-		session.Date = time.Now().AddDate(0, 0, offset).Format("2006-01-02")
+		session.Date = localNow().AddDate(0, 0, offset).Format("2006-01-02")
 		session.State = StatePreview
 		updatePreview(chatID, session)
 
 	// 編輯帳戶：顯示帳戶選擇鍵盤
 	case data == "edit_account":
-		keyboard := BuildAccountKeyboard()
+		keyboard := BuildAccountKeyboard(session.store())
 		services.EditMessageWithKeyboard(chatID, session.MessageID,
 			"🏦 選擇帳戶：", keyboard)
 
@@ -304,7 +523,7 @@ func handleCallbackQuery(cq *TelegramCallbackQuery) {
 
 	// 編輯分類：顯示分類選擇鍵盤
 	case data == "edit_category":
-		keyboard := BuildCategoryKeyboard()
+		keyboard := BuildCategoryKeyboard(session.store())
 		services.EditMessageWithKeyboard(chatID, session.MessageID,
 			"🏷 選擇分類：", keyboard)
 
@@ -322,6 +541,10 @@ func handleCallbackQuery(cq *TelegramCallbackQuery) {
 		promptID, _ := services.SendMessageReturningID(chatID, "📌 請輸入備註（輸入「無」可清除）：")
 		session.PromptMsgID = promptID
 
+	// 快捷輸入的詞符合多個帳戶或分類時，由使用者選擇（按鈕帶有預覽訊息 ID，避免套用到之後的會話）
+	case strings.HasPrefix(data, "pick_"):
+		handleAmbiguityPick(chatID, cq.Message.MessageID, session, data)
+
 	// 確認送出
 	case data == "confirm":
 		handleConfirm(chatID, session)
@@ -329,31 +552,101 @@ func handleCallbackQuery(cq *TelegramCallbackQuery) {
 	// 取消
 	case data == "cancel":
 		DeleteSession(chatID)
-		services.EditMessageText(chatID, session.MessageID, "❌ 已取消新增紀錄")
+		if session.RecordID > 0 {
+			services.EditMessageText(chatID, session.MessageID, "❌ 已取消修改紀錄")
+		} else {
+			services.EditMessageText(chatID, session.MessageID, "❌ 已取消新增紀錄")
+		}
 	}
 }
 
-// startNewRecordWithQuickInput 解析快捷輸入並帶入欄位後開始新增紀錄
-// 支援格式：
-//   - 純數字（如 "150"）→ 帶入金額
-//   - "文字 數字"（如 "午餐 150"）→ 帶入項目名稱 + 金額
-//   - "數字 文字"（如 "150 午餐"）→ 帶入金額 + 項目名稱
-func startNewRecordWithQuickInput(chatID int64, text string) {
-	session := NewSession(chatID)
+// startNewRecordWithQuickInput 解析單行快捷輸入並帶入欄位後開始新增紀錄
+// 支援的寫法見 ParseQuickEntry，例如「150」「午餐 150」「昨天 信用卡 午餐 150 #飲食 跟同事」
+// 未指定的分類與帳戶依過去同名紀錄建議；符合多個帳戶或分類的詞，另外發送選擇按鈕；找不到的 #分類 提示使用者修改
+func startNewRecordWithQuickInput(chatID int64, cb *services.ChatBook, text string) {
+	session := NewSession(chatID, cb.BookID, cb.UserID)
 
-	// 嘗試解析快捷格式
-	item, amount := parseQuickInput(text)
-	if amount > 0 {
-		session.Amount = amount
+	entry := ParseQuickEntry(session.store(), text)
+	entry.apply(session)
+	applySuggestion(session, entry)
+
+	msgID, err := services.SendMessageWithKeyboard(chatID, FormatPreview(session), BuildPreviewKeyboard(session))
+	if err != nil {
+		log.Printf("發送預覽訊息失敗: %v", err)
+		return
 	}
-	if item != "" {
-		session.Item = item
+	session.MessageID = msgID
+
+	for _, a := range entry.Ambiguous {
+		services.SendMessageWithKeyboard(chatID, FormatAmbiguity(a), BuildAmbiguityKeyboard(a, msgID))
 	}
+	for _, tag := range entry.Unknown {
+		services.SendMessage(chatID, "⚠️ 找不到分類「"+tag+"」，請點擊「🏷 分類」修改")
+	}
+}
 
-	text2 := FormatPreview(session)
-	keyboard := BuildPreviewKeyboard(session)
+// applySuggestion 快捷輸入未指定分類或帳戶時，依過去同名紀錄帶入
+func applySuggestion(session *Session, entry *QuickEntry) {
+	if session.Item == "" || (entry.CategoryID != 0 && entry.AccountID != 0) {
+		return
+	}
+
+	suggestion, err := services.Suggestions.ForBook(session.BookID, session.UserID).Suggest(session.Item)
+	if err != nil {
+		log.Printf("查詢項目建議失敗: %v", err)
+		return
+	}
+	if entry.CategoryID == 0 && suggestion.CategoryID != 0 {
+		session.CategoryID = suggestion.CategoryID
+	}
+	if entry.AccountID == 0 && suggestion.AccountID != 0 {
+		session.AccountID = suggestion.AccountID
+	}
+}
+
+// handleAmbiguityPick 處理快捷輸入的選擇按鈕
+//   - pick_account_<帳戶ID>_<預覽訊息ID>、pick_category_<分類ID>_<預覽訊息ID>：帶入選擇的欄位
+//   - pick_skip：保留目前的值
+//
+// 選擇後刪除選擇訊息並更新預覽
+func handleAmbiguityPick(chatID int64, msgID int, session *Session, data string) {
+	services.DeleteMessage(chatID, msgID)
+	if data == "pick_skip" {
+		return
+	}
 
-	msgID, err := services.SendMessageWithKeyboard(chatID, text2, keyboard)
+	field, rest, _ := strings.Cut(strings.TrimPrefix(data, "pick_"), "_")
+	idStr, previewStr, _ := strings.Cut(rest, "_")
+	id, _ := strconv.Atoi(idStr)
+	previewID, _ := strconv.Atoi(previewStr)
+	if previewID != session.MessageID {
+		services.SendMessage(chatID, "此操作已過期，請重新開始")
+		return
+	}
+
+	switch field {
+	case "account":
+		session.AccountID = id
+	case "category":
+		session.CategoryID = id
+	}
+	session.State = StatePreview
+	updatePreview(chatID, session)
+}
+
+// startNewRecordFromParsed 以多行格式解析的結果帶入所有欄位後開始新增紀錄
+// 原因：仍先顯示預覽，使用者確認（或修改）後才寫入
+func startNewRecordFromParsed(chatID int64, cb *services.ChatBook, parsed *ParsedRecord) {
+	session := NewSession(chatID, cb.BookID, cb.UserID)
+	session.Date = parsed.Date
+	session.AccountID = parsed.AccountID
+	session.Type = parsed.Type
+	session.Amount = parsed.Amount
+	session.Item = parsed.Item
+	session.CategoryID = parsed.CategoryID
+	session.Note = parsed.Note
+
+	msgID, err := services.SendMessageWithKeyboard(chatID, FormatPreview(session), BuildPreviewKeyboard(session))
 	if err != nil {
 		log.Printf("發送預覽訊息失敗: %v", err)
 		return
@@ -362,47 +655,216 @@ func startNewRecordWithQuickInput(chatID int64, text string) {
 	session.MessageID = msgID
 }
 
-// parseQuickInput 解析快捷輸入文字，回傳項目名稱與金額
-func parseQuickInput(text string) (item string, amount float64) {
-	// 純數字 → 金額
-	if a, err := strconv.ParseFloat(text, 64); err == nil && a > 0 {
-		return "", a
+// recentPageSize 最近紀錄每頁筆數
+const recentPageSize = 5
+
+// handleRecentRecords 查詢最近紀錄並發送帶修改、刪除與翻頁按鈕的訊息
+func handleRecentRecords(chatID int64, store repository.Store, offset int) {
+	text, records, total := FormatRecentRecords(store, offset, recentPageSize)
+	keyboard := BuildRecentKeyboard(records, offset, recentPageSize, total)
+
+	if len(keyboard.InlineKeyboard) > 0 {
+		services.SendMessageWithKeyboard(chatID, text, keyboard)
+	} else {
+		services.SendMessage(chatID, text)
+	}
+}
+
+// showRecentPage 將訊息更新為指定頁的最近紀錄，notice 不為空時顯示在最上方
+// 原因：刪除紀錄後該頁可能已無資料，改為顯示最後一頁
+func showRecentPage(chatID int64, msgID int, store repository.Store, offset int, notice string) {
+	text, records, total := FormatRecentRecords(store, offset, recentPageSize)
+	if len(records) == 0 && offset > 0 && total > 0 {
+		offset = (total - 1) / recentPageSize * recentPageSize
+		text, records, total = FormatRecentRecords(store, offset, recentPageSize)
+	}
+	if notice != "" {
+		text = notice + "\n\n" + text
+	}
+	services.EditMessageWithKeyboard(chatID, msgID, text, BuildRecentKeyboard(records, offset, recentPageSize, total))
+}
+
+// handleRecordAction 處理最近紀錄上的按鈕
+//   - rec_edit_<ID>：以紀錄目前的值開啟預覽，送出後更新紀錄
+//   - rec_del_<ID>_<頁>：將訊息改為刪除確認
+//   - rec_delok_<ID>_<頁>：刪除紀錄並回到原本的頁面
+func handleRecordAction(chatID int64, msgID int, data string) {
+	cb, ok := chatBook(chatID)
+	if !ok || !requireEditor(chatID, cb) {
+		return
 	}
+	store := repository.Default.ForBook(cb.BookID, cb.UserID)
 
-	// 以空白分割，嘗試「文字 數字」或「數字 文字」
-	parts := strings.Fields(text)
-	if len(parts) == 2 {
-		// 「文字 數字」
-		if a, err := strconv.ParseFloat(parts[1], 64); err == nil && a > 0 {
-			return parts[0], a
+	switch {
+	case strings.HasPrefix(data, "rec_edit_"):
+		id, _ := strconv.Atoi(strings.TrimPrefix(data, "rec_edit_"))
+		r, err := store.Records().Get(id)
+		if err != nil {
+			services.SendMessage(chatID, "⚠️ 找不到該紀錄，可能已被刪除")
+			return
+		}
+		if r.TransferID != nil {
+			services.SendMessage(chatID, "⚠️ "+services.ErrTransferLeg.Error())
+			return
+		}
+		startEditRecord(chatID, cb, r)
+
+	case strings.HasPrefix(data, "rec_delok_"):
+		id, offset := parseRecordAction(strings.TrimPrefix(data, "rec_delok_"))
+		var notice string
+		switch err := services.Ledger.ForBook(cb.BookID, cb.UserID).VoidRecord(id); {
+		case err == nil:
+			notice = "🗑 已刪除紀錄"
+		case errors.Is(err, services.ErrRecordNotFound), errors.Is(err, services.ErrTransferLeg):
+			notice = "⚠️ " + err.Error()
+		default:
+			log.Printf("刪除紀錄失敗: %v", err)
+			notice = "⚠️ 刪除紀錄失敗，請稍後再試"
 		}
-		// 「數字 文字」
-		if a, err := strconv.ParseFloat(parts[0], 64); err == nil && a > 0 {
-			return parts[1], a
+		showRecentPage(chatID, msgID, store, offset, notice)
+
+	case strings.HasPrefix(data, "rec_del_"):
+		id, offset := parseRecordAction(strings.TrimPrefix(data, "rec_del_"))
+		r, err := store.Records().Get(id)
+		if err != nil {
+			showRecentPage(chatID, msgID, store, offset, "⚠️ 找不到該紀錄，可能已被刪除")
+			return
 		}
+		services.EditMessageWithKeyboard(chatID, msgID, FormatDeleteConfirm(r), BuildDeleteConfirmKeyboard(id, offset))
 	}
+}
 
-	return "", 0
+// parseRecordAction 解析「<ID>_<頁的 offset>」格式的按鈕資料
+func parseRecordAction(s string) (id, offset int) {
+	idStr, offsetStr, _ := strings.Cut(s, "_")
+	id, _ = strconv.Atoi(idStr)
+	offset, _ = strconv.Atoi(offsetStr)
+	return id, offset
 }
 
-// handleRecentRecords 查詢最近紀錄並發送帶翻頁按鈕的訊息
-func handleRecentRecords(chatID int64, offset int) {
-	const pageSize = 5
-	text, total := FormatRecentRecords(offset, pageSize)
-	keyboard := BuildPaginationKeyboard(offset, pageSize, total)
+// startEditRecord 以既有紀錄開啟預覽訊息，沿用新增紀錄的欄位按鈕
+func startEditRecord(chatID int64, cb *services.ChatBook, r *models.RecordWithNames) {
+	session := NewEditSession(chatID, cb.BookID, cb.UserID, r)
 
+	msgID, err := services.SendMessageWithKeyboard(chatID, FormatPreview(session), BuildPreviewKeyboard(session))
+	if err != nil {
+		log.Printf("發送預覽訊息失敗: %v", err)
+		return
+	}
+
+	session.MessageID = msgID
+}
+
+// === 定期紀錄 ===
+
+// handleRecurringRules 列出目前帳本的定期紀錄與暫停、略過按鈕
+func handleRecurringRules(chatID int64, cb *services.ChatBook) {
+	rules, err := services.Recurring.ForBook(cb.BookID, cb.UserID).List()
+	if err != nil {
+		log.Printf("查詢定期紀錄失敗: %v", err)
+		services.SendMessage(chatID, "系統忙碌中，請稍後再試")
+		return
+	}
+
+	keyboard := BuildRecurringKeyboard(rules)
 	if len(keyboard.InlineKeyboard) > 0 {
-		services.SendMessageWithKeyboard(chatID, text, keyboard)
+		services.SendMessageWithKeyboard(chatID, FormatRecurringRules(rules), keyboard)
 	} else {
-		services.SendMessage(chatID, text)
+		services.SendMessage(chatID, FormatRecurringRules(rules))
+	}
+}
+
+// handleRecurringAction 處理定期紀錄上的按鈕，完成後將訊息更新為最新的列表
+//   - rr_pause_<ID>：暫停
+//   - rr_resume_<ID>：恢復（暫停期間的期數不補上）
+//   - rr_skip_<ID>：略過下一次
+func handleRecurringAction(chatID int64, msgID int, data string) {
+	cb, ok := chatBook(chatID)
+	if !ok || !requireEditor(chatID, cb) {
+		return
+	}
+	recurring := services.Recurring.ForBook(cb.BookID, cb.UserID)
+
+	action, idStr, _ := strings.Cut(strings.TrimPrefix(data, "rr_"), "_")
+	id, _ := strconv.Atoi(idStr)
+	var rule *models.RecurringRule
+	var err error
+	var notice string
+	switch action {
+	case "pause":
+		rule, err = recurring.SetPaused(id, true)
+		notice = "⏸ 已暫停"
+	case "resume":
+		rule, err = recurring.SetPaused(id, false)
+		notice = "▶️ 已恢復"
+	case "skip":
+		rule, err = recurring.Skip(id)
+		notice = "⏭ 已略過"
+	default:
+		return
+	}
+	switch {
+	case err == nil && rule.Paused:
+		notice += "「" + rule.Item + "」"
+	case err == nil:
+		notice += "「" + rule.Item + "」，下次 " + rule.NextDate
+	case errors.Is(err, services.ErrRuleNotFound), errors.Is(err, services.ErrRuleFinished):
+		notice = "⚠️ " + err.Error()
+	default:
+		log.Printf("更新定期紀錄失敗: %v", err)
+		notice = "⚠️ 更新定期紀錄失敗，請稍後再試"
+	}
+
+	rules, err := recurring.List()
+	if err != nil {
+		log.Printf("查詢定期紀錄失敗: %v", err)
+		services.SendMessage(chatID, notice)
+		return
+	}
+	services.EditMessageWithKeyboard(chatID, msgID, notice+"\n\n"+FormatRecurringRules(rules), BuildRecurringKeyboard(rules))
+}
+
+// === 帳本切換 ===
+
+// handleBooks 列出使用者的帳本與切換按鈕
+func handleBooks(chatID int64, cb *services.ChatBook) {
+	books, err := repository.Default.Books().ListByUser(cb.UserID)
+	if err != nil {
+		log.Printf("查詢帳本失敗: %v", err)
+		services.SendMessage(chatID, "查詢帳本失敗")
+		return
+	}
+	services.SendMessageWithKeyboard(chatID, FormatBooks(books, cb.BookID), BuildBookKeyboard(books, cb.BookID))
+}
+
+// handleBookSwitch 切換聊天室目前的帳本並更新帳本列表
+// 原因：進行中的會話屬於原本的帳本，切換後一併清除
+func handleBookSwitch(chatID int64, messageID int, idStr string) {
+	cb, ok := chatBook(chatID)
+	if !ok {
+		return
+	}
+	bookID, _ := strconv.Atoi(idStr)
+	if err := services.Books.SwitchChatBook(chatID, cb.UserID, bookID); err != nil {
+		log.Printf("切換帳本失敗: %v", err)
+		services.SendMessage(chatID, "切換帳本失敗："+err.Error())
+		return
 	}
+	DeleteSession(chatID)
+
+	books, err := repository.Default.Books().ListByUser(cb.UserID)
+	if err != nil {
+		log.Printf("查詢帳本失敗: %v", err)
+		return
+	}
+	services.EditMessageWithKeyboard(chatID, messageID, FormatBooks(books, bookID), BuildBookKeyboard(books, bookID))
 }
 
 // === 轉帳功能 ===
 
 // startTransfer 啟動轉帳流程
-func startTransfer(chatID int64) {
-	session := NewTransferSession(chatID)
+func startTransfer(chatID int64, cb *services.ChatBook) {
+	session := NewTransferSession(chatID, cb.BookID, cb.UserID)
 
 	text := FormatTransferPreview(session)
 	keyboard := BuildTransferKeyboard(session)
@@ -438,12 +900,12 @@ func handleTransferCallback(chatID int64, cq *TelegramCallbackQuery, session *Se
 	case strings.HasPrefix(data, "set_date_") && session.Mode == ModeTransfer:
 		offsetStr := strings.TrimPrefix(data, "set_date_")
 		offset, _ := strconv.Atoi(offsetStr)
-		session.Date = time.Now().AddDate(0, 0, offset).Format("2006-01-02")
+		session.Date = localNow().AddDate(0, 0, offset).Format("2006-01-02")
 		session.State = StateTransferPreview
 		updateTransferPreview(chatID, session)
 
 	case data == "transfer_edit_from":
-		keyboard := BuildTransferAccountKeyboard("transfer_from_")
+		keyboard := BuildTransferAccountKeyboard(session.store(), "transfer_from_")
 		services.EditMessageWithKeyboard(chatID, session.MessageID,
 			"🏦 選擇轉出帳戶：", keyboard)
 
@@ -455,7 +917,7 @@ func handleTransferCallback(chatID int64, cq *TelegramCallbackQuery, session *Se
 		updateTransferPreview(chatID, session)
 
 	case data == "transfer_edit_to":
-		keyboard := BuildTransferAccountKeyboard("transfer_to_")
+		keyboard := BuildTransferAccountKeyboard(session.store(), "transfer_to_")
 		services.EditMessageWithKeyboard(chatID, session.MessageID,
 			"🏦 選擇轉入帳戶：", keyboard)
 
@@ -487,6 +949,12 @@ func handleTransferCallback(chatID int64, cq *TelegramCallbackQuery, session *Se
 
 // handleTransferConfirm 確認轉帳
 func handleTransferConfirm(chatID int64, session *Session) {
+	if !session.canEdit() {
+		DeleteSession(chatID)
+		services.EditMessageText(chatID, session.MessageID, readOnlyMessage)
+		return
+	}
+
 	if session.Amount <= 0 {
 		updateTransferPreview(chatID, session)
 		services.SendMessage(chatID, "⚠️ 請先填寫轉帳金額")
@@ -503,89 +971,46 @@ func handleTransferConfirm(chatID int64, session *Session) {
 		session.Note = ""
 	}
 
-	fromName := resolveAccountName(session.AccountID)
-	toName := resolveAccountName(session.ToAccountID)
-	categoryID := getTransferCategoryID()
-
-	tx, err := initializers.DB.Begin()
-	if err != nil {
-		services.SendMessage(chatID, "系統錯誤，請稍後再試")
-		return
-	}
-
-	now := time.Now().Format("2006-01-02 15:04:05")
+	store := session.store()
+	fromName := resolveAccountName(store, session.AccountID)
+	toName := resolveAccountName(store, session.ToAccountID)
 
-	// 扣除轉出帳戶餘額
-	_, err = tx.Exec("UPDATE accounts SET balance = balance - ?, updated_at = ? WHERE id = ?",
-		session.Amount, now, session.AccountID)
-	if err != nil {
-		tx.Rollback()
-		services.SendMessage(chatID, "轉帳失敗")
-		return
+	transfer := &models.Transfer{
+		Date:          session.Date,
+		FromAccountID: session.AccountID,
+		ToAccountID:   session.ToAccountID,
+		Amount:        session.Amount,
+		Note:          session.Note,
 	}
-
-	// 增加轉入帳戶餘額
-	_, err = tx.Exec("UPDATE accounts SET balance = balance + ?, updated_at = ? WHERE id = ?",
-		session.Amount, now, session.ToAccountID)
-	if err != nil {
-		tx.Rollback()
-		services.SendMessage(chatID, "轉帳失敗")
+	if err := session.ledger().PostTransfer(transfer); err != nil {
+		services.SendMessage(chatID, "轉帳失敗："+err.Error())
+		log.Printf("轉帳失敗: %v", err)
 		return
 	}
 
-	// 建立轉出紀錄（支出）
-	outItem := fmt.Sprintf("轉帳至 %s", toName)
-	_, err = tx.Exec(
-		"INSERT INTO records (date, account_id, type, amount, item, category_id, note, created_at, updated_at) VALUES (?, ?, '支出', ?, ?, ?, ?, ?, ?)",
-		session.Date, session.AccountID, session.Amount, outItem, categoryID, session.Note, now, now,
-	)
-	if err != nil {
-		tx.Rollback()
-		services.SendMessage(chatID, "轉帳失敗")
-		return
-	}
-
-	// 建立轉入紀錄（收入）
-	inItem := fmt.Sprintf("從 %s 轉入", fromName)
-	_, err = tx.Exec(
-		"INSERT INTO records (date, account_id, type, amount, item, category_id, note, created_at, updated_at) VALUES (?, ?, '收入', ?, ?, ?, ?, ?, ?)",
-		session.Date, session.ToAccountID, session.Amount, inItem, categoryID, session.Note, now, now,
-	)
-	if err != nil {
-		tx.Rollback()
-		services.SendMessage(chatID, "轉帳失敗")
-		return
-	}
-
-	if err = tx.Commit(); err != nil {
-		services.SendMessage(chatID, "系統錯誤，請稍後再試")
-		return
-	}
-
-	successMsg := FormatTransferSuccess(fromName, toName, session.Amount, session.Note)
-	services.EditMessageText(chatID, session.MessageID, successMsg)
+	successMsg := FormatTransferSuccess(store, fromName, toName, transfer)
+	showUndoable(chatID, &undoAction{
+		Kind:      undoTransfer,
+		ID:        transfer.ID,
+		BookID:    session.BookID,
+		UserID:    session.UserID,
+		MessageID: session.MessageID,
+		Text:      successMsg,
+	})
 
 	DeleteSession(chatID)
 }
 
-// getTransferCategoryID 取得或建立「轉帳」分類的 ID
-func getTransferCategoryID() int {
-	var id int
-	err := initializers.DB.QueryRow("SELECT id FROM categories WHERE name = '轉帳'").Scan(&id)
-	if err == nil {
-		return id
-	}
-	result, err := initializers.DB.Exec("INSERT INTO categories (name, sort_order) VALUES ('轉帳', 999)")
-	if err != nil {
-		return 1
-	}
-	newID, _ := result.LastInsertId()
-	return int(newID)
-}
-
 // handleConfirm 確認送出紀錄
 // 原因：驗證必填欄位後，寫入資料庫並更新帳戶餘額
 func handleConfirm(chatID int64, session *Session) {
+	// 檢視者不可送出（角色可能在預覽期間被修改）
+	if !session.canEdit() {
+		DeleteSession(chatID)
+		services.EditMessageText(chatID, session.MessageID, readOnlyMessage)
+		return
+	}
+
 	// 驗證必填欄位
 	if session.Amount <= 0 {
 		services.AnswerCallbackQuery("", "請先填寫金額")
@@ -604,45 +1029,68 @@ func handleConfirm(chatID int64, session *Session) {
 		session.Note = ""
 	}
 
-	// 使用 Transaction 新增紀錄並更新帳戶餘額
-	tx, err := initializers.DB.Begin()
-	if err != nil {
-		services.SendMessage(chatID, "系統錯誤，請稍後再試")
-		return
+	// 新增（或更新）紀錄並同步更新帳戶餘額
+	record := &models.Record{
+		ID:         session.RecordID,
+		Date:       session.Date,
+		AccountID:  session.AccountID,
+		Type:       session.Type,
+		Amount:     session.Amount,
+		Item:       session.Item,
+		CategoryID: session.CategoryID,
+		Note:       session.Note,
 	}
-
-	now := time.Now().Format("2006-01-02 15:04:05")
-
-	_, err = tx.Exec(
-		"INSERT INTO records (date, account_id, type, amount, item, category_id, note, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
-		session.Date, session.AccountID, session.Type, session.Amount, session.Item, session.CategoryID, session.Note, now, now,
-	)
-	if err != nil {
-		tx.Rollback()
-		services.SendMessage(chatID, "新增紀錄失敗")
-		log.Printf("新增紀錄失敗: %v", err)
-		return
-	}
-
-	// 更新帳戶餘額
-	if session.Type == "支出" {
-		tx.Exec("UPDATE accounts SET balance = balance - ?, updated_at = ? WHERE id = ?", session.Amount, now, session.AccountID)
+	title, action := "✅ 新增成功！", "新增紀錄"
+	var previous *models.RecordWithNames // 修改前的紀錄，用於判斷預算提醒
+	var err error
+	if session.RecordID > 0 {
+		title, action = "✅ 修改成功！", "修改紀錄"
+		if previous, err = session.store().Records().Get(session.RecordID); err == nil {
+			err = session.ledger().AmendRecord(record)
+		} else if errors.Is(err, repository.ErrNotFound) {
+			err = services.ErrRecordNotFound
+		}
 	} else {
-		tx.Exec("UPDATE accounts SET balance = balance + ?, updated_at = ? WHERE id = ?", session.Amount, now, session.AccountID)
+		err = session.ledger().PostRecord(record)
 	}
-
-	if err = tx.Commit(); err != nil {
-		services.SendMessage(chatID, "系統錯誤，請稍後再試")
+	if errors.Is(err, services.ErrRecordNotFound) {
+		// 紀錄在修改期間被刪除，無法再送出
+		DeleteSession(chatID)
+		services.EditMessageText(chatID, session.MessageID, "⚠️ "+action+"失敗："+err.Error())
+		return
+	}
+	if err != nil {
+		services.SendMessage(chatID, action+"失敗："+err.Error())
+		log.Printf("%s失敗: %v", action, err)
 		return
 	}
 
 	// 取得名稱用於回覆
-	accountName := resolveAccountName(session.AccountID)
-	categoryName := resolveCategoryName(session.CategoryID)
+	store := session.store()
+	accountName := resolveAccountName(store, session.AccountID)
+	categoryName := resolveCategoryName(store, session.CategoryID)
 
 	// 更新預覽訊息為成功訊息（移除鍵盤）
-	successMsg := FormatSuccess(session.Date, accountName, session.Type, session.Amount, session.Item, categoryName, session.Note)
-	services.EditMessageText(chatID, session.MessageID, successMsg)
+	amount := formatAmount(session.Amount, resolveAccountCurrency(store, session.AccountID))
+	successMsg := FormatSuccess(title, session.Date, accountName, session.Type, amount, session.Item, categoryName, session.Note)
+	if alerts, err := services.Budgets.ForBook(session.BookID, session.UserID).Alerts(record, previous); err != nil {
+		log.Printf("檢查預算失敗: %v", err)
+	} else if len(alerts) > 0 {
+		successMsg += "\n\n" + FormatBudgetAlerts(alerts)
+	}
+	if session.RecordID > 0 {
+		// 修改既有紀錄無法以刪除復原
+		services.EditMessageText(chatID, session.MessageID, successMsg)
+	} else {
+		showUndoable(chatID, &undoAction{
+			Kind:      undoRecord,
+			ID:        record.ID,
+			BookID:    session.BookID,
+			UserID:    session.UserID,
+			MessageID: session.MessageID,
+			Text:      successMsg,
+		})
+	}
 
 	// 清除會話
 	DeleteSession(chatID)
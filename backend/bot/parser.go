@@ -1,7 +1,8 @@
 package bot
 
 import (
-	"accountbook/initializers"
+	"accountbook/models"
+	"accountbook/repository"
 	"fmt"
 	"strconv"
 	"strings"
@@ -13,7 +14,7 @@ type ParsedRecord struct {
 	Date       string
 	AccountID  int
 	Type       string
-	Amount     float64
+	Amount     models.Money
 	Item       string
 	CategoryID int
 	Note       string
@@ -24,7 +25,7 @@ type ParsedRecord struct {
 // 格式（完整版）：
 //
 //	時間 / 帳戶名稱(可省略) / 收入或支出(可省略) / 金額 / 項目名稱 / 分類 / 備註(可省略)
-func ParseRecord(message string) (*ParsedRecord, error) {
+func ParseRecord(store repository.Store, message string) (*ParsedRecord, error) {
 	lines := splitLines(message)
 	if len(lines) < 3 {
 		return nil, fmt.Errorf("格式錯誤：至少需要 3 行（時間、金額、項目名稱+分類）")
@@ -33,14 +34,14 @@ func ParseRecord(message string) (*ParsedRecord, error) {
 	// 第 1 行固定為時間
 	date, err := parseDate(lines[0])
 	if err != nil {
-		return nil, fmt.Errorf("時間格式錯誤：%s", lines[0])
+		return nil, lineErr(1, fmt.Errorf("時間格式錯誤：%s", lines[0]))
 	}
 
 	remaining := lines[1:]
 	record := &ParsedRecord{
 		Date:      date,
-		AccountID: getDefaultAccountID(), // 預設帳戶：現金
-		Type:      "支出",                  // 預設類型：支出
+		AccountID: getDefaultAccountID(store), // 預設帳戶：現金
+		Type:      "支出",                       // 預設類型：支出
 	}
 
 	// 依據剩餘行數判斷各欄位位置
@@ -54,11 +55,11 @@ func ParseRecord(message string) (*ParsedRecord, error) {
 		// 省略帳戶、類型、備註：金額 / 項目名稱 / 分類
 		amount, err := parseAmount(remaining[0])
 		if err != nil {
-			return nil, err
+			return nil, lineErr(2, err)
 		}
-		categoryID, err := parseCategoryID(remaining[2])
+		categoryID, err := parseCategoryID(store, remaining[2])
 		if err != nil {
-			return nil, err
+			return nil, lineErr(4, err)
 		}
 		record.Amount = amount
 		record.Item = remaining[1]
@@ -68,16 +69,16 @@ func ParseRecord(message string) (*ParsedRecord, error) {
 		// 可能是以下兩種格式之一：
 		// A: 帳戶 / 金額 / 項目 / 分類
 		// B: 收入或支出 / 金額 / 項目 / 分類
-		if isAccountName(remaining[0]) {
+		if isAccountName(store, remaining[0]) {
 			// 格式 A
-			accountID, _ := resolveAccountID(remaining[0])
+			accountID, _ := resolveAccountID(store, remaining[0])
 			amount, err := parseAmount(remaining[1])
 			if err != nil {
-				return nil, err
+				return nil, lineErr(3, err)
 			}
-			categoryID, err := parseCategoryID(remaining[3])
+			categoryID, err := parseCategoryID(store, remaining[3])
 			if err != nil {
-				return nil, err
+				return nil, lineErr(5, err)
 			}
 			record.AccountID = accountID
 			record.Amount = amount
@@ -87,11 +88,11 @@ func ParseRecord(message string) (*ParsedRecord, error) {
 			// 格式 B
 			amount, err := parseAmount(remaining[1])
 			if err != nil {
-				return nil, err
+				return nil, lineErr(3, err)
 			}
-			categoryID, err := parseCategoryID(remaining[3])
+			categoryID, err := parseCategoryID(store, remaining[3])
 			if err != nil {
-				return nil, err
+				return nil, lineErr(5, err)
 			}
 			record.Type = normalizeType(remaining[0])
 			record.Amount = amount
@@ -101,11 +102,11 @@ func ParseRecord(message string) (*ParsedRecord, error) {
 			// 嘗試當作 金額 / 項目 / 分類 / 備註
 			amount, err := parseAmount(remaining[0])
 			if err != nil {
-				return nil, fmt.Errorf("無法識別格式，第二行應為帳戶名稱、收入/支出、或金額")
+				return nil, lineErr(2, fmt.Errorf("無法識別格式，應為帳戶名稱、收入/支出、或金額"))
 			}
-			categoryID, err := parseCategoryID(remaining[2])
+			categoryID, err := parseCategoryID(store, remaining[2])
 			if err != nil {
-				return nil, err
+				return nil, lineErr(4, err)
 			}
 			record.Amount = amount
 			record.Item = remaining[1]
@@ -117,17 +118,17 @@ func ParseRecord(message string) (*ParsedRecord, error) {
 		// 帳戶 / 類型 / 金額 / 項目 / 分類
 		// 或 帳戶 / 金額 / 項目 / 分類 / 備註
 		if isType(remaining[1]) {
-			accountID, err := resolveAccountID(remaining[0])
+			accountID, err := resolveAccountID(store, remaining[0])
 			if err != nil {
-				return nil, err
+				return nil, lineErr(2, err)
 			}
 			amount, err := parseAmount(remaining[2])
 			if err != nil {
-				return nil, err
+				return nil, lineErr(4, err)
 			}
-			categoryID, err := parseCategoryID(remaining[4])
+			categoryID, err := parseCategoryID(store, remaining[4])
 			if err != nil {
-				return nil, err
+				return nil, lineErr(6, err)
 			}
 			record.AccountID = accountID
 			record.Type = normalizeType(remaining[1])
@@ -135,17 +136,17 @@ func ParseRecord(message string) (*ParsedRecord, error) {
 			record.Item = remaining[3]
 			record.CategoryID = categoryID
 		} else {
-			accountID, err := resolveAccountID(remaining[0])
+			accountID, err := resolveAccountID(store, remaining[0])
 			if err != nil {
-				return nil, err
+				return nil, lineErr(2, err)
 			}
 			amount, err := parseAmount(remaining[1])
 			if err != nil {
-				return nil, err
+				return nil, lineErr(3, err)
 			}
-			categoryID, err := parseCategoryID(remaining[3])
+			categoryID, err := parseCategoryID(store, remaining[3])
 			if err != nil {
-				return nil, err
+				return nil, lineErr(5, err)
 			}
 			record.AccountID = accountID
 			record.Amount = amount
@@ -156,17 +157,20 @@ func ParseRecord(message string) (*ParsedRecord, error) {
 
 	case 6:
 		// 完整格式：帳戶 / 類型 / 金額 / 項目 / 分類 / 備註
-		accountID, err := resolveAccountID(remaining[0])
+		accountID, err := resolveAccountID(store, remaining[0])
 		if err != nil {
-			return nil, err
+			return nil, lineErr(2, err)
+		}
+		if !isType(remaining[1]) {
+			return nil, lineErr(3, fmt.Errorf("類型必須為收入或支出：%s", remaining[1]))
 		}
 		amount, err := parseAmount(remaining[2])
 		if err != nil {
-			return nil, err
+			return nil, lineErr(4, err)
 		}
-		categoryID, err := parseCategoryID(remaining[4])
+		categoryID, err := parseCategoryID(store, remaining[4])
 		if err != nil {
-			return nil, err
+			return nil, lineErr(6, err)
 		}
 		record.AccountID = accountID
 		record.Type = normalizeType(remaining[1])
@@ -182,6 +186,12 @@ func ParseRecord(message string) (*ParsedRecord, error) {
 	return record, nil
 }
 
+// lineErr 在錯誤前標示訊息的第幾行（由 1 起算）
+// 原因：多行格式欄位多，回覆時需指出是哪一行有誤
+func lineErr(line int, err error) error {
+	return fmt.Errorf("第 %d 行：%w", line, err)
+}
+
 // splitLines 分割訊息為行並去除空白
 func splitLines(message string) []string {
 	raw := strings.Split(message, "\n")
@@ -195,18 +205,36 @@ func splitLines(message string) []string {
 	return lines
 }
 
-// parseDate 解析日期字串
-// 原因：支援多種日期格式（昨天/今天/明天/完整日期/短日期）
+// relativeDays 相對日期的天數位移
+var relativeDays = map[string]int{
+	"大前天": -3,
+	"前天":  -2,
+	"昨天":  -1,
+	"今天":  0,
+	"明天":  1,
+	"後天":  2,
+}
+
+// localNow Bot 使用者所在時區（Timezone）的現在時間
+// 原因：伺服器時區與使用者不同時，午夜前後的「今天」「昨天」會差一天
+func localNow() time.Time {
+	return time.Now().In(Timezone)
+}
+
+// parseDate 解析日期字串，相對日期以 Timezone 的今天計算
 func parseDate(input string) (string, error) {
-	now := time.Now()
-
-	switch input {
-	case "昨天":
-		return now.AddDate(0, 0, -1).Format("2006-01-02"), nil
-	case "今天":
-		return now.Format("2006-01-02"), nil
-	case "明天":
-		return now.AddDate(0, 0, 1).Format("2006-01-02"), nil
+	return parseDateAt(input, localNow())
+}
+
+// parseDateAt 以 now 為今天解析日期字串
+// 原因：支援多種日期格式（前天/昨天/今天/明天/週五/上週五/完整日期/短日期）
+func parseDateAt(input string, now time.Time) (string, error) {
+
+	if days, ok := relativeDays[input]; ok {
+		return now.AddDate(0, 0, days).Format("2006-01-02"), nil
+	}
+	if t, ok := parseWeekday(input, now); ok {
+		return t.Format("2006-01-02"), nil
 	}
 
 	// 嘗試完整日期格式：2026/01/01
@@ -229,12 +257,49 @@ func parseDate(input string) (string, error) {
 	return "", fmt.Errorf("無法解析日期: %s", input)
 }
 
+// weekPrefixes 星期的寫法與週的位移（0 為本週，-1 為上週，1 為下週）
+var weekPrefixes = []struct {
+	prefix string
+	weeks  int
+}{
+	{"上週", -1}, {"上周", -1}, {"上星期", -1}, {"上禮拜", -1},
+	{"下週", 1}, {"下周", 1}, {"下星期", 1}, {"下禮拜", 1},
+	{"這週", 0}, {"這周", 0}, {"本週", 0}, {"本周", 0}, {"這星期", 0}, {"這禮拜", 0},
+	{"週", 0}, {"周", 0}, {"星期", 0}, {"禮拜", 0},
+}
+
+// weekdayNames 星期幾的寫法（週一為一週的第一天）
+var weekdayNames = map[string]int{"一": 0, "二": 1, "三": 2, "四": 3, "五": 4, "六": 5, "日": 6, "天": 6}
+
+// parseWeekday 解析「週五」「上週五」「這週五」等星期寫法
+// 原因：一週從週一開始；只寫「週五」時代表最近一次（不晚於今天）的週五，記帳多半是補記過去的花費
+func parseWeekday(input string, now time.Time) (time.Time, bool) {
+	for _, p := range weekPrefixes {
+		day, ok := weekdayNames[strings.TrimPrefix(input, p.prefix)]
+		if !strings.HasPrefix(input, p.prefix) || !ok {
+			continue
+		}
+
+		today := (int(now.Weekday()) + 6) % 7 // 週一為 0
+		if p.prefix == "週" || p.prefix == "周" || p.prefix == "星期" || p.prefix == "禮拜" {
+			back := (today - day + 7) % 7
+			return now.AddDate(0, 0, -back), true
+		}
+		return now.AddDate(0, 0, day-today+7*p.weeks), true
+	}
+	return time.Time{}, false
+}
+
 // parseAmount 解析金額
-func parseAmount(input string) (float64, error) {
-	amount, err := strconv.ParseFloat(input, 64)
-	if err != nil {
+// 原因：小數位數依預設幣別限制（例如不接受 0.001）
+func parseAmount(input string) (models.Money, error) {
+	amount, err := models.ParseMoney(input, models.CurrencyDecimals(models.DefaultCurrency))
+	if err == models.ErrMoneyFormat {
 		return 0, fmt.Errorf("金額格式錯誤：%s", input)
 	}
+	if err != nil {
+		return 0, err
+	}
 	if amount <= 0 {
 		return 0, fmt.Errorf("金額必須大於 0")
 	}
@@ -243,59 +308,40 @@ func parseAmount(input string) (float64, error) {
 
 // parseCategoryID 解析分類（支援編號或名稱）
 // 原因：使用者可以輸入分類編號（快速）或分類名稱（直覺）
-func parseCategoryID(input string) (int, error) {
+func parseCategoryID(store repository.Store, input string) (int, error) {
 	// 嘗試作為編號
 	if id, err := strconv.Atoi(input); err == nil {
-		var exists int
-		err := initializers.DB.QueryRow("SELECT COUNT(*) FROM categories WHERE id = ?", id).Scan(&exists)
-		if err == nil && exists > 0 {
+		if _, err := store.Categories().Get(id); err == nil {
 			return id, nil
 		}
 	}
 
 	// 嘗試作為名稱
-	var id int
-	err := initializers.DB.QueryRow("SELECT id FROM categories WHERE name = ?", input).Scan(&id)
-	if err == nil {
-		return id, nil
+	if c, err := store.Categories().FindByName(input); err == nil {
+		return c.ID, nil
 	}
 
 	return 0, fmt.Errorf("找不到分類：%s", input)
 }
 
 // isAccountName 判斷文字是否為帳戶名稱或編號
-func isAccountName(input string) bool {
-	// 嘗試作為編號
-	if id, err := strconv.Atoi(input); err == nil {
-		var exists int
-		initializers.DB.QueryRow("SELECT COUNT(*) FROM accounts WHERE id = ?", id).Scan(&exists)
-		if exists > 0 {
-			return true
-		}
-	}
-
-	// 嘗試作為名稱
-	var exists int
-	initializers.DB.QueryRow("SELECT COUNT(*) FROM accounts WHERE name = ?", input).Scan(&exists)
-	return exists > 0
+func isAccountName(store repository.Store, input string) bool {
+	_, err := resolveAccountID(store, input)
+	return err == nil
 }
 
 // resolveAccountID 取得帳戶 ID（支援名稱或編號）
-func resolveAccountID(input string) (int, error) {
+func resolveAccountID(store repository.Store, input string) (int, error) {
 	// 嘗試作為編號
 	if id, err := strconv.Atoi(input); err == nil {
-		var exists int
-		initializers.DB.QueryRow("SELECT COUNT(*) FROM accounts WHERE id = ?", id).Scan(&exists)
-		if exists > 0 {
+		if _, err := store.Accounts().Get(id); err == nil {
 			return id, nil
 		}
 	}
 
 	// 嘗試作為名稱
-	var id int
-	err := initializers.DB.QueryRow("SELECT id FROM accounts WHERE name = ?", input).Scan(&id)
-	if err == nil {
-		return id, nil
+	if a, err := store.Accounts().FindByName(input); err == nil {
+		return a.ID, nil
 	}
 
 	return 0, fmt.Errorf("找不到帳戶：%s", input)
@@ -316,11 +362,15 @@ func normalizeType(input string) string {
 
 // getDefaultAccountID 取得預設帳戶（現金）的 ID
 // 原因：省略帳戶欄位時使用預設值
-func getDefaultAccountID() int {
-	var id int
-	err := initializers.DB.QueryRow("SELECT id FROM accounts WHERE name = '現金' LIMIT 1").Scan(&id)
-	if err != nil {
-		return 1 // 若找不到現金帳戶，預設使用 ID 1
+func getDefaultAccountID(store repository.Store) int {
+	a, err := store.Accounts().FindByName("現金")
+	if err == nil {
+		return a.ID
+	}
+	// 若找不到現金帳戶，使用該帳本的第一個帳戶
+	accounts, err := store.Accounts().List()
+	if err != nil || len(accounts) == 0 {
+		return 0
 	}
-	return id
+	return accounts[0].ID
 }
@@ -0,0 +1,143 @@
+package bot
+
+import (
+	"accountbook/models"
+	"accountbook/repository"
+	"accountbook/services"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newParserStore 預設使用者的個人帳本（帳戶：現金 1、信用卡 2、銀行帳戶 3；分類：飲食 1 … 其他 6）
+func newParserStore(t *testing.T) repository.Store {
+	t.Helper()
+	store := repository.NewMemoryStore()
+	if err := services.NewUserService(store).CreateUser(&models.User{Username: "admin"}); err != nil {
+		t.Fatalf("建立使用者失敗: %v", err)
+	}
+	return store.ForBook(models.DefaultBookID, models.DefaultUserID)
+}
+
+func TestParseRecord(t *testing.T) {
+	store := newParserStore(t)
+	yesterday := time.Now().AddDate(0, 0, -1).Format("2006-01-02")
+	money := models.MoneyFromFloat
+
+	tests := []struct {
+		name    string
+		message string
+		want    *ParsedRecord
+	}{
+		{
+			name:    "4 行：金額 / 項目 / 分類",
+			message: "2026/10/15\n150\n午餐\n飲食",
+			want:    &ParsedRecord{Date: "2026-10-15", AccountID: 1, Type: "支出", Amount: money(150), Item: "午餐", CategoryID: 1},
+		},
+		{
+			name:    "4 行：分類以編號表示、相對日期",
+			message: "昨天\n60\n捷運\n2",
+			want:    &ParsedRecord{Date: yesterday, AccountID: 1, Type: "支出", Amount: money(60), Item: "捷運", CategoryID: 2},
+		},
+		{
+			name:    "5 行：帳戶 / 金額 / 項目 / 分類",
+			message: "2026/10/15\n信用卡\n1500\n外套\n服飾",
+			want:    &ParsedRecord{Date: "2026-10-15", AccountID: 2, Type: "支出", Amount: money(1500), Item: "外套", CategoryID: 3},
+		},
+		{
+			name:    "5 行：類型 / 金額 / 項目 / 分類",
+			message: "2026/10/15\n收入\n42000\n薪水\n其他",
+			want:    &ParsedRecord{Date: "2026-10-15", AccountID: 1, Type: "收入", Amount: money(42000), Item: "薪水", CategoryID: 6},
+		},
+		{
+			name:    "5 行：金額 / 項目 / 分類 / 備註",
+			message: "2026/10/15\n150\n午餐\n飲食\n跟同事",
+			want:    &ParsedRecord{Date: "2026-10-15", AccountID: 1, Type: "支出", Amount: money(150), Item: "午餐", CategoryID: 1, Note: "跟同事"},
+		},
+		{
+			name:    "6 行：帳戶 / 類型 / 金額 / 項目 / 分類",
+			message: "2026/10/15\n銀行帳戶\n收入\n42000\n薪水\n其他",
+			want:    &ParsedRecord{Date: "2026-10-15", AccountID: 3, Type: "收入", Amount: money(42000), Item: "薪水", CategoryID: 6},
+		},
+		{
+			name:    "6 行：帳戶 / 金額 / 項目 / 分類 / 備註",
+			message: "2026/10/15\n信用卡\n12000\n耳機\n3C\n生日禮物",
+			want:    &ParsedRecord{Date: "2026-10-15", AccountID: 2, Type: "支出", Amount: money(12000), Item: "耳機", CategoryID: 4, Note: "生日禮物"},
+		},
+		{
+			name:    "7 行：完整格式",
+			message: "2026/10/15\n現金\n支出\n800\n電影\n娛樂\n首映",
+			want:    &ParsedRecord{Date: "2026-10-15", AccountID: 1, Type: "支出", Amount: money(800), Item: "電影", CategoryID: 5, Note: "首映"},
+		},
+		{
+			name:    "空白行與前後空白會被忽略",
+			message: "  2026/10/15 \n\n 150\n午餐  \n\n飲食\n",
+			want:    &ParsedRecord{Date: "2026-10-15", AccountID: 1, Type: "支出", Amount: money(150), Item: "午餐", CategoryID: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRecord(store, tt.message)
+			if err != nil {
+				t.Fatalf("ParseRecord 錯誤: %v", err)
+			}
+			if *got != *tt.want {
+				t.Errorf("ParseRecord = %+v，預期 %+v", *got, *tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRecordErrors(t *testing.T) {
+	store := newParserStore(t)
+
+	tests := []struct {
+		name    string
+		message string
+		want    []string // 錯誤訊息應包含的文字
+	}{
+		{"少於 3 行", "2026/10/15\n150", []string{"至少需要 3 行"}},
+		{"3 行：缺少分類", "2026/10/15\n150\n午餐", []string{"至少需要提供分類"}},
+		{"時間格式錯誤", "某天\n150\n午餐\n飲食", []string{"第 1 行", "時間格式錯誤"}},
+		{"4 行：金額錯誤", "2026/10/15\n一百五\n午餐\n飲食", []string{"第 2 行", "金額格式錯誤"}},
+		{"4 行：金額為 0", "2026/10/15\n0\n午餐\n飲食", []string{"第 2 行", "金額必須大於 0"}},
+		{"4 行：分類不存在", "2026/10/15\n150\n午餐\n宵夜", []string{"第 4 行", "找不到分類"}},
+		{"5 行：帳戶不存在", "2026/10/15\n悠遊卡\n150\n午餐\n飲食", []string{"第 2 行", "無法識別格式"}},
+		{"5 行：帳戶後的金額錯誤", "2026/10/15\n信用卡\n很多\n外套\n服飾", []string{"第 3 行", "金額格式錯誤"}},
+		{"6 行：帳戶不存在", "2026/10/15\n悠遊卡\n支出\n60\n捷運\n交通", []string{"第 2 行", "找不到帳戶：悠遊卡"}},
+		{"6 行：帳戶不存在（含備註）", "2026/10/15\n悠遊卡\n60\n捷運\n交通\n上班", []string{"第 2 行", "找不到帳戶：悠遊卡"}},
+		{"7 行：類型錯誤", "2026/10/15\n現金\n借款\n800\n電影\n娛樂\n首映", []string{"第 3 行", "類型必須為收入或支出"}},
+		{"7 行：帳戶不存在", "2026/10/15\n悠遊卡\n支出\n800\n電影\n娛樂\n首映", []string{"第 2 行", "找不到帳戶"}},
+		{"8 行：行數過多", "2026/10/15\n現金\n支出\n800\n電影\n娛樂\n首映\n多一行", []string{"行數過多"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRecord(store, tt.message)
+			if err == nil {
+				t.Fatalf("ParseRecord = %+v，預期錯誤", *got)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("錯誤訊息 %q 應包含 %q", err.Error(), want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseDateUsesTimezone(t *testing.T) {
+	defer func(loc *time.Location) { Timezone = loc }(Timezone)
+
+	// 兩個時區相差 25 小時，任何時刻的「今天」都不同
+	for _, loc := range []*time.Location{time.FixedZone("UTC+14", 14*60*60), time.FixedZone("UTC-11", -11*60*60)} {
+		Timezone = loc
+		today := time.Now().In(loc)
+		for input, want := range map[string]time.Time{"今天": today, "昨天": today.AddDate(0, 0, -1)} {
+			if got, err := parseDate(input); err != nil || got != want.Format("2006-01-02") {
+				t.Errorf("%s：parseDate(%q) = %s（%v），預期 %s", loc, input, got, err, want.Format("2006-01-02"))
+			}
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package bot
+
+import (
+	"accountbook/repository"
+	"accountbook/services"
+	"encoding/json"
+	"log"
+	"strconv"
+	"time"
+)
+
+// updateOffsetKey settings 中記錄下一個要取得的 update_id 的鍵
+const updateOffsetKey = "telegram_update_offset"
+
+// pollTimeout getUpdates 每次等待新訊息的秒數
+const pollTimeout = 50
+
+// pollRetryDelay 取得訊息失敗後，重試前等待的時間
+const pollRetryDelay = 5 * time.Second
+
+// RunPolling 以 getUpdates 持續接收訊息，與 webhook 使用相同的處理流程
+// 原因：不需公開的 HTTPS 網址，Bot 可在筆電或 NAT 後方執行；
+// 每處理完一筆即記錄 offset，重啟後從下一筆繼續，不會重複記帳
+func RunPolling() {
+	if err := services.DeleteWebhook(); err != nil {
+		log.Printf("%v，仍嘗試以 long polling 接收訊息", err)
+	}
+
+	offset := loadUpdateOffset()
+	log.Printf("Telegram Bot 以 long polling 接收訊息（offset %d）", offset)
+
+	for {
+		updates, err := services.GetUpdates(offset, pollTimeout)
+		if err != nil {
+			log.Printf("%v，%v 後重試", err, pollRetryDelay)
+			time.Sleep(pollRetryDelay)
+			continue
+		}
+
+		for _, raw := range updates {
+			var update TelegramUpdate
+			if err := json.Unmarshal(raw, &update); err != nil {
+				// 無法解析的 update 仍需跳過，否則會一直重複取得（型別不符時 update_id 仍會被填入）
+				log.Printf("無法解析 Telegram update: %v", err)
+			} else {
+				handleUpdate(&update)
+			}
+
+			if update.UpdateID >= offset {
+				offset = update.UpdateID + 1
+				saveUpdateOffset(offset)
+			}
+		}
+	}
+}
+
+// loadUpdateOffset 讀取上次記錄的 offset，尚未記錄時為 0（從 Telegram 保留的最早訊息開始）
+func loadUpdateOffset() int {
+	value, err := repository.Default.Settings().Get(updateOffsetKey)
+	if err != nil {
+		if err != repository.ErrNotFound {
+			log.Printf("讀取 Telegram update offset 失敗: %v", err)
+		}
+		return 0
+	}
+	offset, _ := strconv.Atoi(value)
+	return offset
+}
+
+func saveUpdateOffset(offset int) {
+	if err := repository.Default.Settings().Set(updateOffsetKey, strconv.Itoa(offset)); err != nil {
+		log.Printf("記錄 Telegram update offset 失敗: %v", err)
+	}
+}
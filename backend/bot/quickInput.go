@@ -0,0 +1,251 @@
+package bot
+
+import (
+	"accountbook/models"
+	"accountbook/repository"
+	"strings"
+	"unicode/utf8"
+)
+
+// noteSeparators 分隔備註的符號，之後的文字全部視為備註
+var noteSeparators = []string{"//", "｜", "|"}
+
+// QuickEntry 單行快捷輸入的解析結果，未出現的欄位為零值（沿用會話的預設值）
+type QuickEntry struct {
+	Date       string
+	AccountID  int
+	Type       string
+	Amount     models.Money
+	Item       string
+	CategoryID int
+	Note       string
+	Ambiguous  []Ambiguity // 符合多個帳戶或分類的詞，需請使用者選擇
+	Unknown    []string    // 找不到的 #分類
+}
+
+// Ambiguity 符合多個帳戶或分類的詞
+type Ambiguity struct {
+	Token      string
+	Accounts   []models.Account
+	Categories []models.Category
+}
+
+// ParseQuickEntry 解析單行快捷輸入，例如「昨天 信用卡 午餐 150 #飲食 跟同事」
+// 原因：詞的順序不限，依內容判斷欄位：
+//   - 日期：前天、昨天、今天、週五、上週五、3/5、2026/3/5
+//   - 收入、支出
+//   - 金額：第一個數字（可加 $ 或「元」）
+//   - #分類：以名稱、別名或部分名稱指定分類
+//   - 帳戶或分類的名稱、別名（或部分名稱，至少兩個字）
+//   - 其餘的詞：第一個為項目，之後的為備註；「//」或「｜」之後的文字全部為備註
+func ParseQuickEntry(store repository.Store, text string) *QuickEntry {
+	entry := &QuickEntry{}
+
+	for _, sep := range noteSeparators {
+		if before, after, ok := strings.Cut(text, sep); ok {
+			text, entry.Note = before, strings.TrimSpace(after)
+			break
+		}
+	}
+
+	names := loadEntities(store)
+
+	var words []string
+	var categoryWord string // 以名稱（非 #）指定分類的詞，沒有項目時當作項目
+	for _, token := range strings.Fields(text) {
+		if tag, ok := strings.CutPrefix(token, "#"); ok && tag != "" {
+			if entry.CategoryID != 0 {
+				words = append(words, tag)
+				continue
+			}
+			switch matched, _ := names.matchCategories(tag); len(matched) {
+			case 0:
+				entry.Unknown = append(entry.Unknown, tag)
+			case 1:
+				entry.CategoryID = matched[0].ID
+			default:
+				entry.Ambiguous = append(entry.Ambiguous, Ambiguity{Token: tag, Categories: matched})
+			}
+			continue
+		}
+
+		if entry.Date == "" {
+			if date, err := parseDate(token); err == nil {
+				entry.Date = date
+				continue
+			}
+		}
+		if entry.Type == "" && isType(token) {
+			entry.Type = token
+			continue
+		}
+		if entry.Amount == 0 {
+			if amount, err := parseAmount(trimCurrencySymbol(token)); err == nil {
+				entry.Amount = amount
+				continue
+			}
+		}
+
+		categoryBefore := entry.CategoryID
+		if amb, ok := names.match(entry, token); ok {
+			if amb != nil {
+				entry.Ambiguous = append(entry.Ambiguous, *amb)
+			} else if categoryBefore == 0 && entry.CategoryID != 0 {
+				categoryWord = token
+			}
+			continue
+		}
+
+		words = append(words, token)
+	}
+
+	if len(words) > 0 {
+		entry.Item = words[0]
+		if rest := strings.Join(words[1:], " "); rest != "" {
+			entry.Note = strings.TrimSpace(rest + " " + entry.Note)
+		}
+	} else if categoryWord != "" {
+		// 例如「娛樂 300」：分類名稱同時當作項目
+		entry.Item = categoryWord
+	}
+	return entry
+}
+
+// entities 帳本中可在快捷輸入指定的帳戶、分類與其別名
+type entities struct {
+	accounts   []models.Account
+	categories []models.Category
+	aliases    []models.Alias
+}
+
+// loadEntities 讀取帳本的帳戶、分類與別名（查詢失敗時視為沒有，該詞當作項目或備註）
+func loadEntities(store repository.Store) *entities {
+	e := &entities{}
+	e.accounts, _ = store.Accounts().List()
+	e.categories, _ = store.Categories().List()
+	e.aliases, _ = store.Aliases().List()
+	return e
+}
+
+// match 以名稱或別名比對帳戶與分類（已指定的欄位不再比對），都不符合時回傳 false，該詞當作項目或備註
+// 唯一符合時直接寫入 entry；符合多個時回傳候選，由使用者選擇
+func (e *entities) match(entry *QuickEntry, token string) (*Ambiguity, bool) {
+	var accs []models.Account
+	var cats []models.Category
+	var exactAcc, exactCat bool
+	if entry.AccountID == 0 {
+		accs, exactAcc = e.matchAccounts(token)
+	}
+	if entry.CategoryID == 0 {
+		cats, exactCat = e.matchCategories(token)
+	}
+
+	// 完全符合名稱或別名時優先，不視為模稜兩可
+	if exactAcc != exactCat {
+		if exactAcc {
+			cats = nil
+		} else {
+			accs = nil
+		}
+	}
+
+	switch {
+	case len(accs)+len(cats) == 0:
+		return nil, false
+	case len(accs) == 1 && len(cats) == 0:
+		entry.AccountID = accs[0].ID
+		return nil, true
+	case len(cats) == 1 && len(accs) == 0:
+		entry.CategoryID = cats[0].ID
+		return nil, true
+	}
+	return &Ambiguity{Token: token, Accounts: accs, Categories: cats}, true
+}
+
+// matchAccounts 名稱或別名完全符合（不分大小寫）的帳戶，exact 為 true；
+// 沒有時改以部分名稱比對（至少兩個字，避免單字誤判）
+// 原因：別名是使用者刻意設定的寫法，只需完全符合，單字的別名（例如「卡」）也可使用
+func (e *entities) matchAccounts(token string) (matched []models.Account, exact bool) {
+	for _, a := range e.accounts {
+		if strings.EqualFold(a.Name, token) {
+			return []models.Account{a}, true
+		}
+	}
+	if id := e.aliasOf(token, func(a models.Alias) int { return a.AccountID }); id != 0 {
+		for _, a := range e.accounts {
+			if a.ID == id {
+				return []models.Account{a}, true
+			}
+		}
+	}
+	for _, a := range e.accounts {
+		if utf8.RuneCountInString(token) >= 2 && strings.Contains(strings.ToLower(a.Name), strings.ToLower(token)) {
+			matched = append(matched, a)
+		}
+	}
+	return matched, false
+}
+
+// matchCategories 名稱或別名完全符合（不分大小寫）的分類，exact 為 true；
+// 沒有時改以部分名稱比對（至少兩個字，避免單字誤判）
+func (e *entities) matchCategories(token string) (matched []models.Category, exact bool) {
+	for _, c := range e.categories {
+		if strings.EqualFold(c.Name, token) {
+			return []models.Category{c}, true
+		}
+	}
+	if id := e.aliasOf(token, func(a models.Alias) int { return a.CategoryID }); id != 0 {
+		for _, c := range e.categories {
+			if c.ID == id {
+				return []models.Category{c}, true
+			}
+		}
+	}
+	for _, c := range e.categories {
+		if utf8.RuneCountInString(token) >= 2 && strings.Contains(strings.ToLower(c.Name), strings.ToLower(token)) {
+			matched = append(matched, c)
+		}
+	}
+	return matched, false
+}
+
+// aliasOf 名稱完全符合 token 的別名所對應的 ID（由 target 取出帳戶或分類 ID），沒有時為 0
+func (e *entities) aliasOf(token string, target func(models.Alias) int) int {
+	for _, a := range e.aliases {
+		if strings.EqualFold(a.Name, token) {
+			return target(a)
+		}
+	}
+	return 0
+}
+
+// trimCurrencySymbol 去除金額前後的 $ 與「元」
+func trimCurrencySymbol(token string) string {
+	token = strings.TrimPrefix(token, "$")
+	return strings.TrimSuffix(token, "元")
+}
+
+// apply 將解析出的欄位帶入會話
+func (e *QuickEntry) apply(s *Session) {
+	if e.Date != "" {
+		s.Date = e.Date
+	}
+	if e.AccountID != 0 {
+		s.AccountID = e.AccountID
+	}
+	if e.Type != "" {
+		s.Type = e.Type
+	}
+	if e.Amount > 0 {
+		s.Amount = e.Amount
+	}
+	if e.Item != "" {
+		s.Item = e.Item
+	}
+	if e.CategoryID != 0 {
+		s.CategoryID = e.CategoryID
+	}
+	if e.Note != "" {
+		s.Note = e.Note
+	}
+}
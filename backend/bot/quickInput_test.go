@@ -0,0 +1,264 @@
+package bot
+
+import (
+	"accountbook/models"
+	"accountbook/repository"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 快捷輸入測試帳本中額外建立的帳戶與分類
+const (
+	quickEsunCard   = 4 // 玉山信用卡
+	quickCardFee    = 7 // 信用卡費
+	quickInstalment = 8 // 信用卡費分期
+)
+
+// newQuickStore 預設帳本（帳戶：現金 1、信用卡 2、銀行帳戶 3；分類：飲食 1、交通 2、服飾 3、3C 4、娛樂 5、其他 6）
+// 加上名稱相近的帳戶與分類，以及別名「刷」→ 信用卡、「早餐」→ 飲食
+func newQuickStore(t *testing.T) repository.Store {
+	t.Helper()
+	store := newParserStore(t)
+	if err := store.Accounts().Create(&models.Account{Name: "玉山信用卡", Currency: models.DefaultCurrency}); err != nil {
+		t.Fatalf("建立帳戶失敗: %v", err)
+	}
+	for _, name := range []string{"信用卡費", "信用卡費分期"} {
+		if err := store.Categories().Create(&models.Category{Name: name}); err != nil {
+			t.Fatalf("建立分類失敗: %v", err)
+		}
+	}
+	for _, a := range []*models.Alias{{Name: "刷", AccountID: 2}, {Name: "早餐", CategoryID: 1}} {
+		if err := store.Aliases().Create(a); err != nil {
+			t.Fatalf("建立別名失敗: %v", err)
+		}
+	}
+	return store
+}
+
+func TestParseDateAt(t *testing.T) {
+	saturday := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC) // 2026-10-17 為週六
+
+	tests := []struct {
+		input string
+		now   time.Time
+		want  string
+	}{
+		{"今天", saturday, "2026-10-17"},
+		{"昨天", saturday, "2026-10-16"},
+		{"前天", saturday, "2026-10-15"},
+		{"大前天", saturday, "2026-10-14"},
+		{"明天", saturday, "2026-10-18"},
+		{"週五", saturday, "2026-10-16"},
+		{"周日", saturday, "2026-10-11"}, // 只寫星期幾時為最近一次（不晚於今天）
+		{"星期六", saturday, "2026-10-17"},
+		{"上週五", saturday, "2026-10-09"},
+		{"上禮拜日", saturday, "2026-10-11"}, // 一週從週一開始
+		{"這週一", saturday, "2026-10-12"},
+		{"下週一", saturday, "2026-10-19"},
+		{"3/5", saturday, "2026-03-05"},
+		{"03/05", saturday, "2026-03-05"},
+		{"2026/3/5", saturday, "2026-03-05"},
+		{"2025/12/31", saturday, "2025-12-31"},
+		// 跨月、跨年
+		{"前天", time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC), "2026-02-27"},
+		{"上週五", time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC), "2026-02-27"},
+		{"週六", time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC), "2026-02-28"},
+		{"昨天", time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC), "2025-12-31"},
+		{"下週一", time.Date(2026, 12, 31, 9, 0, 0, 0, time.UTC), "2027-01-04"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input+"@"+tt.now.Format("2006-01-02"), func(t *testing.T) {
+			got, err := parseDateAt(tt.input, tt.now)
+			if err != nil || got != tt.want {
+				t.Errorf("parseDateAt(%q) = %q（%v），預期 %q", tt.input, got, err, tt.want)
+			}
+		})
+	}
+
+	for _, input := range []string{"2/30", "13/1", "某天", "週八", "上週"} {
+		if got, err := parseDateAt(input, saturday); err == nil {
+			t.Errorf("parseDateAt(%q) = %q，預期錯誤", input, got)
+		}
+	}
+}
+
+func TestParseQuickEntry(t *testing.T) {
+	store := newQuickStore(t)
+	now := localNow()
+	date := func(days int) string { return now.AddDate(0, 0, days).Format("2006-01-02") }
+	lastFriday, _ := parseDateAt("上週五", now)
+	year := now.Format("2006")
+	money := models.MoneyFromFloat
+
+	tests := []struct {
+		name string
+		text string
+		want QuickEntry // 不含 Ambiguous 與 Unknown
+	}{
+		{"完整範例", "昨天 信用卡 午餐 150 #飲食 跟同事",
+			QuickEntry{Date: date(-1), AccountID: 2, Amount: money(150), Item: "午餐", CategoryID: 1, Note: "跟同事"}},
+		{"詞的順序不限", "跟同事 #飲食 150 午餐 信用卡 昨天",
+			QuickEntry{Date: date(-1), AccountID: 2, Amount: money(150), Item: "跟同事", CategoryID: 1, Note: "午餐"}},
+		{"只有金額", "150", QuickEntry{Amount: money(150)}},
+		{"金額加 $", "$150 午餐", QuickEntry{Amount: money(150), Item: "午餐"}},
+		{"金額加元", "午餐 150元", QuickEntry{Amount: money(150), Item: "午餐"}},
+		{"第二個數字當作備註", "午餐 150 2", QuickEntry{Amount: money(150), Item: "午餐", Note: "2"}},
+
+		// 日期
+		{"前天", "前天 午餐 150", QuickEntry{Date: date(-2), Amount: money(150), Item: "午餐"}},
+		{"上週五", "電影 上週五 300", QuickEntry{Date: lastFriday, Amount: money(300), Item: "電影"}},
+		{"短日期", "3/5 午餐 150", QuickEntry{Date: year + "-03-05", Amount: money(150), Item: "午餐"}},
+		{"完整日期", "午餐 2026/3/5 150", QuickEntry{Date: "2026-03-05", Amount: money(150), Item: "午餐"}},
+		{"只取第一個日期", "今天 午餐 150 昨天", QuickEntry{Date: date(0), Amount: money(150), Item: "午餐", Note: "昨天"}},
+
+		// 收入、支出
+		{"收入", "收入 薪水 42000 銀行帳戶", QuickEntry{AccountID: 3, Type: "收入", Amount: money(42000), Item: "薪水"}},
+		{"支出", "午餐 支出 150", QuickEntry{Type: "支出", Amount: money(150), Item: "午餐"}},
+
+		// 帳戶與分類的名稱可出現在任何位置
+		{"帳戶在最後", "午餐 150 現金", QuickEntry{AccountID: 1, Amount: money(150), Item: "午餐"}},
+		{"分類名稱不加 #", "捷運 30 交通", QuickEntry{Amount: money(30), Item: "捷運", CategoryID: 2}},
+		{"分類名稱不分大小寫", "3c 耳機 1200", QuickEntry{Amount: money(1200), Item: "耳機", CategoryID: 4}},
+		{"只有分類時當作項目", "娛樂 300", QuickEntry{Amount: money(300), Item: "娛樂", CategoryID: 5}},
+		{"部分名稱（兩個字）", "銀行 轉帳手續費 15", QuickEntry{AccountID: 3, Amount: money(15), Item: "轉帳手續費"}},
+		{"部分名稱只有一個字時不比對", "現 午餐 150", QuickEntry{Amount: money(150), Item: "現", Note: "午餐"}},
+		{"完整名稱優先於其他的部分名稱", "信用卡 繳費 1000", QuickEntry{AccountID: 2, Amount: money(1000), Item: "繳費"}},
+
+		// 別名：完全符合即可，單字的別名也可使用
+		{"帳戶別名", "刷 午餐 150", QuickEntry{AccountID: 2, Amount: money(150), Item: "午餐"}},
+		{"分類別名", "蛋餅 早餐 45", QuickEntry{Amount: money(45), Item: "蛋餅", CategoryID: 1}},
+		{"#別名", "蛋餅 45 #早餐", QuickEntry{Amount: money(45), Item: "蛋餅", CategoryID: 1}},
+		{"只有分類別名時當作項目", "早餐 45", QuickEntry{Amount: money(45), Item: "早餐", CategoryID: 1}},
+
+		// #分類
+		{"#分類", "#交通 計程車 250", QuickEntry{Amount: money(250), Item: "計程車", CategoryID: 2}},
+		{"#部分名稱", "手續費 #分期 30", QuickEntry{Amount: money(30), Item: "手續費", CategoryID: quickInstalment}},
+		{"已指定分類時 # 當作一般的詞", "#交通 #飲食 150", QuickEntry{Amount: money(150), Item: "飲食", CategoryID: 2}},
+		{"單獨的 #", "午餐 # 150", QuickEntry{Amount: money(150), Item: "午餐", Note: "#"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseQuickEntry(store, tt.text)
+			if len(got.Ambiguous) != 0 || len(got.Unknown) != 0 {
+				t.Errorf("ParseQuickEntry(%q) 有模稜兩可或找不到的詞：%+v、%v", tt.text, got.Ambiguous, got.Unknown)
+			}
+			got.Ambiguous, got.Unknown = nil, nil
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("ParseQuickEntry(%q)\n得到 %+v\n預期 %+v", tt.text, *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseQuickEntryNoteSeparators(t *testing.T) {
+	store := newQuickStore(t)
+
+	for _, sep := range noteSeparators {
+		t.Run(sep, func(t *testing.T) {
+			// 分隔符號之後的文字全部為備註，不再解析帳戶、分類與金額
+			got := ParseQuickEntry(store, "午餐 150 跟同事 "+sep+" 現金 #交通 200")
+			want := QuickEntry{Amount: models.MoneyFromFloat(150), Item: "午餐", Note: "跟同事 現金 #交通 200"}
+			if !reflect.DeepEqual(*got, want) {
+				t.Errorf("得到 %+v，預期 %+v", *got, want)
+			}
+
+			// 分隔符號前後不需要空白
+			got = ParseQuickEntry(store, "午餐 150"+sep+"跟同事")
+			if got.Item != "午餐" || got.Note != "跟同事" {
+				t.Errorf("沒有空白時：%+v", *got)
+			}
+		})
+	}
+}
+
+// ambiguityIDs 候選的帳戶與分類 ID，例如「a2 a4 c7」
+func ambiguityIDs(a Ambiguity) string {
+	var ids []string
+	for _, acc := range a.Accounts {
+		ids = append(ids, fmt.Sprintf("a%d", acc.ID))
+	}
+	for _, c := range a.Categories {
+		ids = append(ids, fmt.Sprintf("c%d", c.ID))
+	}
+	return strings.Join(ids, " ")
+}
+
+func TestParseQuickEntryAmbiguity(t *testing.T) {
+	store := newQuickStore(t)
+
+	tests := []struct {
+		name    string
+		text    string
+		want    []string // 每個模稜兩可的詞與其候選，例如「信用：a2 a4」
+		unknown []string
+		item    string
+	}{
+		{"部分名稱符合多個帳戶與分類", "信用 午餐 150", []string{"信用：a2 a4 c7 c8"}, nil, "午餐"},
+		{"部分名稱符合多個分類", "卡費 年繳 1800", []string{"卡費：c7 c8"}, nil, "年繳"},
+		{"已指定帳戶時只比對分類", "現金 信用 500", []string{"信用：c7 c8"}, nil, ""},
+		{"#部分名稱符合多個分類", "#信用 1800", []string{"信用：c7 c8"}, nil, ""},
+		{"#找不到的分類", "宵夜 80 #宵夜", nil, []string{"宵夜"}, "宵夜"},
+		{"#部分名稱只有一個字", "宵夜 80 #飲", nil, []string{"飲"}, "宵夜"},
+		{"多個模稜兩可的詞", "信用 卡費 300", []string{"信用：a2 a4 c7 c8", "卡費：c7 c8"}, nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseQuickEntry(store, tt.text)
+			var ambiguous []string
+			for _, a := range got.Ambiguous {
+				ambiguous = append(ambiguous, a.Token+"："+ambiguityIDs(a))
+			}
+			if strings.Join(ambiguous, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("模稜兩可的詞 = %v，預期 %v", ambiguous, tt.want)
+			}
+			if strings.Join(got.Unknown, "|") != strings.Join(tt.unknown, "|") {
+				t.Errorf("找不到的分類 = %v，預期 %v", got.Unknown, tt.unknown)
+			}
+			if got.Item != tt.item {
+				t.Errorf("項目 = %q，預期 %q", got.Item, tt.item)
+			}
+			// 模稜兩可的詞不帶入欄位，由使用者選擇
+			if got.AccountID == 2 || got.AccountID == quickEsunCard || got.CategoryID == quickCardFee || got.CategoryID == quickInstalment {
+				t.Errorf("模稜兩可時不應直接帶入：%+v", *got)
+			}
+		})
+	}
+}
+
+func TestQuickEntryAmbiguityPrompt(t *testing.T) {
+	fake := newTestBot(t)
+	store := repository.Default.ForBook(models.DefaultBookID, models.DefaultUserID)
+	if err := store.Accounts().Create(&models.Account{Name: "玉山信用卡", Currency: models.DefaultCurrency}); err != nil {
+		t.Fatalf("建立帳戶失敗: %v", err)
+	}
+
+	sendText(t, fake, "信用 午餐 150")
+	msgs := fake.Messages(testChatID)
+	if len(msgs) != 2 {
+		t.Fatalf("Bot 發送了 %d 則訊息，預期 2 則（預覽、選擇）", len(msgs))
+	}
+	preview, prompt := msgs[0], msgs[1]
+	if !strings.Contains(prompt.Text, "「信用」符合多個帳戶或分類") {
+		t.Fatalf("選擇提示 = %q", prompt.Text)
+	}
+	for _, label := range []string{"🏦 信用卡", "🏦 玉山信用卡", "略過（保留目前的值）"} {
+		if _, ok := prompt.Button(label); !ok {
+			t.Errorf("選擇提示沒有「%s」按鈕", label)
+		}
+	}
+
+	// 選擇後刪除提示並帶入預覽
+	pressButton(t, fake, prompt.MessageID, "🏦 玉山信用卡")
+	if s := GetSession(testChatID); s == nil || s.AccountID != quickEsunCard || s.Item != "午餐" {
+		t.Fatalf("選擇後的會話 = %+v", s)
+	}
+	if text := findMessage(t, fake, preview.MessageID).Text; !strings.Contains(text, "玉山信用卡") {
+		t.Errorf("預覽未更新：%q", text)
+	}
+	if msgs := fake.Messages(testChatID); len(msgs) != 1 || msgs[0].MessageID != preview.MessageID {
+		t.Errorf("選擇後提示訊息應已刪除，只剩預覽：%+v", msgs)
+	}
+}
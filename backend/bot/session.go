@@ -1,7 +1,10 @@
 package bot
 
 import (
-	"accountbook/initializers"
+	"accountbook/models"
+	"accountbook/repository"
+	"accountbook/services"
+	"log"
 	"sync"
 	"time"
 )
@@ -35,51 +38,235 @@ const (
 
 // Session 單一使用者的會話狀態
 type Session struct {
+	UserID      int // 聊天室對應的使用者（送出的紀錄記錄為此使用者建立）
+	BookID      int // 開始操作時的帳本（原因：帳戶與分類的選項、送出的紀錄都限定在此帳本）
 	Mode        SessionMode
 	State       SessionState
-	Date        string  // 日期
-	AccountID   int     // 帳戶 ID（記帳用，或轉帳來源）
-	Type        string  // 收入/支出
-	Amount      float64 // 金額
-	Item        string  // 項目名稱
-	CategoryID  int     // 分類 ID
-	Note        string  // 備註
-	MessageID   int     // 上一則預覽訊息的 ID（用於編輯訊息）
-	PromptMsgID int     // 「請輸入XXX：」提示訊息的 ID（原因：使用者輸入後需一併刪除）
-	ToAccountID int     // 轉帳目標帳戶 ID
+	Date        string       // 日期
+	AccountID   int          // 帳戶 ID（記帳用，或轉帳來源）
+	Type        string       // 收入/支出
+	Amount      models.Money // 金額
+	Item        string       // 項目名稱
+	CategoryID  int          // 分類 ID
+	Note        string       // 備註
+	MessageID   int          // 上一則預覽訊息的 ID（用於編輯訊息）
+	PromptMsgID int          // 「請輸入XXX：」提示訊息的 ID（原因：使用者輸入後需一併刪除）
+	ToAccountID int          // 轉帳目標帳戶 ID
+	RecordID    int          // 修改中的紀錄 ID（由 /recent 開啟），0 代表新增紀錄
 	UpdatedAt   time.Time
 }
 
 // sessionStore 全域會話儲存（以 chatID 為 key）
-// 原因：Telegram Bot 是無狀態的 Webhook，需自行管理使用者的操作狀態
+// 原因：Telegram Bot 是無狀態的 Webhook，需自行管理使用者的操作狀態；
+// 記憶體中的會話為工作副本，每次處理完 update 後由 SaveSession 寫入 bot_sessions，重啟時以 RestoreSessions 還原
 var (
 	sessionStore = make(map[int64]*Session)
 	sessionMu    sync.RWMutex
 )
 
-// GetSession 取得使用者的會話，若不存在則建立新的
+// SessionTTL 會話閒置多久後自動取消（BOT_SESSION_TTL，預設 1 小時）
+// 原因：Telegram 超過 48 小時的訊息無法編輯，需在此之前將過期的預覽訊息標示為已取消
+var SessionTTL = time.Hour
+
+// chatLock 單一聊天室的鎖，refs 為持有或等待此鎖的數量
+type chatLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// chatLocks 每個聊天室一把鎖（以 chatID 為 key）
+// 原因：webhook 可能同時送來同一聊天室的多個 update（例如連點按鈕），處理器會直接修改 *Session 的欄位，
+// 同一聊天室的 update 與逾時檢查需依序處理；不同聊天室之間仍可並行
+var (
+	chatLocks   = make(map[int64]*chatLock)
+	chatLocksMu sync.Mutex
+)
+
+// lockChat 鎖定聊天室，回傳解鎖函式
+// 原因：沒有人持有或等待時移除該聊天室的鎖，聊天室數量增加時不會一直累積；
+// 仍有人等待時不可移除，否則之後的 update 會拿到另一把鎖而與等待中的 update 同時執行
+func lockChat(chatID int64) func() {
+	chatLocksMu.Lock()
+	l, ok := chatLocks[chatID]
+	if !ok {
+		l = &chatLock{}
+		chatLocks[chatID] = l
+	}
+	l.refs++
+	chatLocksMu.Unlock()
+
+	l.mu.Lock()
+	return func() {
+		l.mu.Unlock()
+		chatLocksMu.Lock()
+		if l.refs--; l.refs == 0 {
+			delete(chatLocks, chatID)
+		}
+		chatLocksMu.Unlock()
+	}
+}
+
+// sessionJanitorInterval 檢查閒置會話的間隔
+const sessionJanitorInterval = 5 * time.Minute
+
+// GetSession 取得使用者的會話，不存在或已閒置逾時時回傳 nil
+// 呼叫前需以 lockChat 鎖定聊天室（handleUpdate 已鎖定）
 func GetSession(chatID int64) *Session {
 	sessionMu.RLock()
 	s, ok := sessionStore[chatID]
 	sessionMu.RUnlock()
-	if ok {
-		return s
+	if !ok {
+		return nil
+	}
+	if time.Since(s.UpdatedAt) > SessionTTL {
+		expireSession(chatID, s)
+		return nil
+	}
+	return s
+}
+
+// SaveSession 將聊天室目前的會話寫入資料庫，並更新最後操作時間
+// 原因：處理器直接修改 *Session 的欄位，由 handleUpdate 在每次處理完 update 後統一寫入
+func SaveSession(chatID int64) {
+	sessionMu.RLock()
+	s, ok := sessionStore[chatID]
+	sessionMu.RUnlock()
+	if !ok {
+		return
+	}
+
+	s.UpdatedAt = time.Now()
+	if err := repository.Default.BotSessions().Save(s.toModel(chatID)); err != nil {
+		log.Printf("儲存聊天室 %d 的會話失敗: %v", chatID, err)
+	}
+}
+
+// RestoreSessions 啟動時從資料庫還原會話
+// 原因：重啟前進行中的新增紀錄或轉帳可以接續，預覽訊息上的按鈕仍然有效
+func RestoreSessions() {
+	sessions, err := repository.Default.BotSessions().List()
+	if err != nil {
+		log.Printf("還原 Bot 會話失敗: %v", err)
+		return
+	}
+
+	sessionMu.Lock()
+	for _, b := range sessions {
+		sessionStore[b.ChatID] = sessionFromModel(b)
+	}
+	sessionMu.Unlock()
+	if len(sessions) > 0 {
+		log.Printf("已還原 %d 個 Bot 會話", len(sessions))
+	}
+}
+
+// RunSessionJanitor 定期取消閒置逾時的會話（含停機期間已逾時的會話），並移除過期的復原按鈕
+// 原因：逾時設定較短時提高檢查頻率，預覽訊息才會及時改為已取消
+func RunSessionJanitor() {
+	interval := sessionJanitorInterval
+	if SessionTTL/2 < interval {
+		interval = SessionTTL / 2
+	}
+	for {
+		expireIdleSessions()
+		expireUndoActions()
+		time.Sleep(interval)
+	}
+}
+
+// expireIdleSessions 取消所有閒置超過 SessionTTL 的會話
+// 原因：逐一鎖定聊天室後再以 GetSession 檢查，避免與正在處理的 update 同時修改同一個會話
+func expireIdleSessions() {
+	sessionMu.RLock()
+	chatIDs := make([]int64, 0, len(sessionStore))
+	for chatID := range sessionStore {
+		chatIDs = append(chatIDs, chatID)
+	}
+	sessionMu.RUnlock()
+
+	for _, chatID := range chatIDs {
+		unlock := lockChat(chatID)
+		GetSession(chatID)
+		unlock()
+	}
+}
+
+// expireSession 刪除逾時的會話，並將預覽訊息改為已逾時（移除按鈕）
+// 原因：避免使用者點擊早已失效的按鈕
+func expireSession(chatID int64, s *Session) {
+	DeleteSession(chatID)
+	if s.PromptMsgID > 0 {
+		services.DeleteMessage(chatID, s.PromptMsgID)
+	}
+	if s.MessageID > 0 {
+		services.EditMessageText(chatID, s.MessageID, FormatSessionExpired(s))
+	}
+}
+
+// toModel 轉為 bot_sessions 的資料列
+func (s *Session) toModel(chatID int64) *models.BotSession {
+	return &models.BotSession{
+		ChatID:      chatID,
+		UserID:      s.UserID,
+		BookID:      s.BookID,
+		Mode:        string(s.Mode),
+		State:       string(s.State),
+		Date:        s.Date,
+		AccountID:   s.AccountID,
+		ToAccountID: s.ToAccountID,
+		Type:        s.Type,
+		Amount:      s.Amount,
+		Item:        s.Item,
+		CategoryID:  s.CategoryID,
+		Note:        s.Note,
+		MessageID:   s.MessageID,
+		PromptMsgID: s.PromptMsgID,
+		RecordID:    s.RecordID,
+		UpdatedAt:   s.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+// sessionFromModel 由 bot_sessions 的資料列還原會話，最後操作時間無法解析時視為已逾時
+func sessionFromModel(b models.BotSession) *Session {
+	updatedAt, err := time.Parse(time.RFC3339, b.UpdatedAt)
+	if err != nil {
+		updatedAt = time.Time{}
+	}
+	return &Session{
+		UserID:      b.UserID,
+		BookID:      b.BookID,
+		Mode:        SessionMode(b.Mode),
+		State:       SessionState(b.State),
+		Date:        b.Date,
+		AccountID:   b.AccountID,
+		Type:        b.Type,
+		Amount:      b.Amount,
+		Item:        b.Item,
+		CategoryID:  b.CategoryID,
+		Note:        b.Note,
+		MessageID:   b.MessageID,
+		PromptMsgID: b.PromptMsgID,
+		ToAccountID: b.ToAccountID,
+		RecordID:    b.RecordID,
+		UpdatedAt:   updatedAt,
 	}
-	return nil
 }
 
 // NewSession 建立新會話並帶入預設值
 // 原因：開始新增紀錄時，預先填入今天日期、預設帳戶、支出等預設值
-func NewSession(chatID int64) *Session {
+func NewSession(chatID int64, bookID, userID int) *Session {
+	store := repository.Default.ForBook(bookID, userID)
 	s := &Session{
+		UserID:     userID,
+		BookID:     bookID,
 		Mode:       ModeRecord,
 		State:      StatePreview,
-		Date:       time.Now().Format("2006-01-02"),
-		AccountID:  getDefaultAccountID(),
+		Date:       localNow().Format("2006-01-02"),
+		AccountID:  getDefaultAccountID(store),
 		Type:       "支出",
 		Amount:     0,
 		Item:       "",
-		CategoryID: getDefaultCategoryID(),
+		CategoryID: getDefaultCategoryID(store),
 		Note:       "",
 		UpdatedAt:  time.Now(),
 	}
@@ -89,19 +276,46 @@ func NewSession(chatID int64) *Session {
 	return s
 }
 
+// NewEditSession 建立修改既有紀錄的會話，欄位帶入紀錄目前的值
+// 原因：修改與新增共用同一個預覽鍵盤，送出時依 RecordID 改為更新紀錄
+func NewEditSession(chatID int64, bookID, userID int, r *models.RecordWithNames) *Session {
+	s := &Session{
+		UserID:     userID,
+		BookID:     bookID,
+		Mode:       ModeRecord,
+		State:      StatePreview,
+		Date:       dateOnly(r.Date),
+		AccountID:  r.AccountID,
+		Type:       r.Type,
+		Amount:     r.Amount,
+		Item:       r.Item,
+		CategoryID: r.CategoryID,
+		Note:       r.Note,
+		RecordID:   r.ID,
+		UpdatedAt:  time.Now(),
+	}
+	sessionMu.Lock()
+	sessionStore[chatID] = s
+	sessionMu.Unlock()
+	return s
+}
+
 // NewTransferSession 建立轉帳會話
-func NewTransferSession(chatID int64) *Session {
+func NewTransferSession(chatID int64, bookID, userID int) *Session {
+	store := repository.Default.ForBook(bookID, userID)
 	s := &Session{
+		UserID:    userID,
+		BookID:    bookID,
 		Mode:      ModeTransfer,
 		State:     StateTransferPreview,
-		Date:      time.Now().Format("2006-01-02"),
-		AccountID: getDefaultAccountID(),
+		Date:      localNow().Format("2006-01-02"),
+		AccountID: getDefaultAccountID(store),
 		Amount:    0,
 		Note:      "",
 		UpdatedAt: time.Now(),
 	}
 	// 取得第二個帳戶作為預設轉入帳戶
-	s.ToAccountID = getSecondAccountID(s.AccountID)
+	s.ToAccountID = getSecondAccountID(store, s.AccountID)
 	sessionMu.Lock()
 	sessionStore[chatID] = s
 	sessionMu.Unlock()
@@ -109,28 +323,55 @@ func NewTransferSession(chatID int64) *Session {
 }
 
 // getSecondAccountID 取得非指定帳戶的第一個帳戶 ID
-func getSecondAccountID(excludeID int) int {
-	var id int
-	err := initializers.DB.QueryRow("SELECT id FROM accounts WHERE id != ? ORDER BY sort_order LIMIT 1", excludeID).Scan(&id)
+func getSecondAccountID(store repository.Store, excludeID int) int {
+	accounts, err := store.Accounts().List()
 	if err != nil {
 		return excludeID
 	}
-	return id
+	for _, a := range accounts {
+		if a.ID != excludeID {
+			return a.ID
+		}
+	}
+	return excludeID
+}
+
+// store 會話所屬帳本的資料存取
+func (s *Session) store() repository.Store {
+	return repository.Default.ForBook(s.BookID, s.UserID)
+}
+
+// ledger 會話所屬帳本的記帳服務
+func (s *Session) ledger() *services.LedgerService {
+	return services.Ledger.ForBook(s.BookID, s.UserID)
+}
+
+// canEdit 使用者目前是否仍可修改會話所屬的帳本
+// 原因：預覽期間角色可能被擁有者改為檢視者，送出前需重新確認
+func (s *Session) canEdit() bool {
+	role, err := services.Books.Role(s.BookID, s.UserID)
+	return err == nil && models.CanEdit(role)
 }
 
 // DeleteSession 清除使用者的會話
 func DeleteSession(chatID int64) {
 	sessionMu.Lock()
+	_, ok := sessionStore[chatID]
 	delete(sessionStore, chatID)
 	sessionMu.Unlock()
+
+	if ok {
+		if err := repository.Default.BotSessions().Delete(chatID); err != nil {
+			log.Printf("刪除聊天室 %d 的會話失敗: %v", chatID, err)
+		}
+	}
 }
 
 // getDefaultCategoryID 取得第一個分類的 ID 作為預設值
-func getDefaultCategoryID() int {
-	var id int
-	err := initializers.DB.QueryRow("SELECT id FROM categories ORDER BY sort_order LIMIT 1").Scan(&id)
-	if err != nil {
-		return 1
+func getDefaultCategoryID(store repository.Store) int {
+	categories, err := store.Categories().List()
+	if err != nil || len(categories) == 0 {
+		return 0
 	}
-	return id
+	return categories[0].ID
 }
@@ -0,0 +1,201 @@
+package bot
+
+import (
+	"accountbook/models"
+	"accountbook/repository"
+	"accountbook/services"
+	"accountbook/services/telegramtest"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// callbackUpdate 使用者點擊 messageID 訊息上的按鈕所產生的 update
+func callbackUpdate(chatID int64, messageID int, data string, n int) *TelegramUpdate {
+	return &TelegramUpdate{CallbackQuery: &TelegramCallbackQuery{
+		ID:      fmt.Sprintf("cq-%d-%d", chatID, n),
+		Message: &TelegramMessage{MessageID: messageID, Chat: &TelegramChat{ID: chatID}},
+		Data:    data,
+	}}
+}
+
+// runParallel 同時處理多個 update（模擬 webhook 同時送達），全部處理完才回傳
+func runParallel(updates []*TelegramUpdate) {
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for _, u := range updates {
+		wg.Add(1)
+		go func(u *TelegramUpdate) {
+			defer wg.Done()
+			<-start
+			handleUpdate(u)
+		}(u)
+	}
+	close(start)
+	wg.Wait()
+}
+
+// startFilledPreview 以 /new 開始新增紀錄並填好金額與項目，回傳預覽訊息的 ID
+func startFilledPreview(t *testing.T, fake *telegramtest.Server) int {
+	t.Helper()
+	sendText(t, fake, "/new")
+	preview := lastMessage(t, fake).MessageID
+	pressButton(t, fake, preview, "金額")
+	sendText(t, fake, "150")
+	pressButton(t, fake, preview, "項目")
+	sendText(t, fake, "午餐")
+	return preview
+}
+
+// savedSession 資料庫中聊天室的會話
+func savedSession(t *testing.T, chatID int64) (models.BotSession, bool) {
+	t.Helper()
+	sessions, err := repository.Default.BotSessions().List()
+	if err != nil {
+		t.Fatalf("查詢會話失敗: %v", err)
+	}
+	for _, s := range sessions {
+		if s.ChatID == chatID {
+			return s, true
+		}
+	}
+	return models.BotSession{}, false
+}
+
+// expectNoChatLocks 所有 update 處理完後不應留下聊天室的鎖
+func expectNoChatLocks(t *testing.T) {
+	t.Helper()
+	chatLocksMu.Lock()
+	defer chatLocksMu.Unlock()
+	if len(chatLocks) != 0 {
+		t.Errorf("處理完後仍有 %d 個聊天室的鎖", len(chatLocks))
+	}
+}
+
+func TestConcurrentConfirmPostsOnce(t *testing.T) {
+	fake := newTestBot(t)
+	preview := startFilledPreview(t, fake)
+
+	// 連點確認：只有第一個取得會話的 update 會送出，其餘視為過期的按鈕
+	updates := make([]*TelegramUpdate, 20)
+	for i := range updates {
+		updates[i] = callbackUpdate(testChatID, preview, "confirm", i)
+	}
+	runParallel(updates)
+
+	store := repository.Default.ForBook(models.DefaultBookID, models.DefaultUserID)
+	records, err := store.Records().ListByDate(time.Now().Format("2006-01-02"))
+	if err != nil || len(records) != 1 {
+		t.Fatalf("今天的紀錄 = %d 筆（%v），預期 1 筆", len(records), err)
+	}
+	account, err := store.Accounts().Get(records[0].AccountID)
+	if err != nil || account.Balance != models.MoneyFromFloat(-150) {
+		t.Errorf("帳戶餘額 = %v（%v），預期 -150（只扣款一次）", account, err)
+	}
+	if GetSession(testChatID) != nil {
+		t.Error("送出後會話應已清除")
+	}
+	if _, ok := savedSession(t, testChatID); ok {
+		t.Error("送出後資料庫中的會話應已刪除")
+	}
+	expectNoChatLocks(t)
+}
+
+func TestConcurrentCallbacksKeepSession(t *testing.T) {
+	fake := newTestBot(t)
+	preview := startFilledPreview(t, fake)
+
+	// 同一聊天室同時修改欄位：每個 update 依序處理，會話不可遺失或被覆寫為空
+	data := []string{"set_type_收入", "set_type_支出", "set_date_-1", "set_date_0", "edit_type", "set_type_收入"}
+	var updates []*TelegramUpdate
+	for i := 0; i < 30; i++ {
+		updates = append(updates, callbackUpdate(testChatID, preview, data[i%len(data)], i))
+	}
+	runParallel(updates)
+
+	s := GetSession(testChatID)
+	if s == nil {
+		t.Fatal("會話遺失")
+	}
+	if s.Amount != models.MoneyFromFloat(150) || s.Item != "午餐" || s.MessageID != preview {
+		t.Errorf("會話內容被覆寫：%+v", s)
+	}
+	saved, ok := savedSession(t, testChatID)
+	if !ok {
+		t.Fatal("資料庫中的會話遺失")
+	}
+	if saved.Item != s.Item || saved.Type != s.Type || saved.Date != s.Date {
+		t.Errorf("資料庫中的會話 %+v 與記憶體中的會話 %+v 不一致", saved, s)
+	}
+	expectNoChatLocks(t)
+}
+
+func TestConcurrentSessionsAcrossChats(t *testing.T) {
+	newTestBot(t)
+
+	// 其他聊天室綁定到預設使用者，同時開始、修改與取消各自的會話
+	chats := []int64{2001, 2002, 2003, 2004, 2005}
+	for _, chatID := range chats {
+		if err := services.Users.LinkChat(chatID, models.DefaultUserID); err != nil {
+			t.Fatalf("綁定聊天室 %d 失敗: %v", chatID, err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, chatID := range chats {
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(chatID int64, i int) {
+				defer wg.Done()
+				unlock := lockChat(chatID)
+				defer unlock()
+				switch i % 3 {
+				case 0:
+					if GetSession(chatID) == nil {
+						NewSession(chatID, models.DefaultBookID, models.DefaultUserID)
+					}
+				case 1:
+					if s := GetSession(chatID); s != nil {
+						s.Item = fmt.Sprintf("項目 %d", i)
+					}
+				case 2:
+					DeleteSession(chatID)
+					NewSession(chatID, models.DefaultBookID, models.DefaultUserID)
+				}
+				SaveSession(chatID)
+			}(chatID, i)
+		}
+	}
+	wg.Wait()
+
+	// 每個聊天室最後都有會話（最後一個動作一定會建立或保留會話），且已寫入資料庫
+	for _, chatID := range chats {
+		if GetSession(chatID) == nil {
+			t.Errorf("聊天室 %d 的會話遺失", chatID)
+		}
+		if _, ok := savedSession(t, chatID); !ok {
+			t.Errorf("聊天室 %d 資料庫中的會話遺失", chatID)
+		}
+	}
+	expectNoChatLocks(t)
+}
+
+func TestIdleSessionExpires(t *testing.T) {
+	fake := newTestBot(t)
+	preview := startFilledPreview(t, fake)
+
+	// 模擬閒置超過 SessionTTL：janitor 取消會話並將預覽訊息改為已取消
+	unlock := lockChat(testChatID)
+	GetSession(testChatID).UpdatedAt = time.Now().Add(-SessionTTL - time.Minute)
+	unlock()
+	expireIdleSessions()
+
+	if GetSession(testChatID) != nil {
+		t.Error("閒置逾時的會話應已清除")
+	}
+	if m := findMessage(t, fake, preview); len(m.Buttons) != 0 {
+		t.Errorf("逾時後預覽訊息應移除按鈕：%+v", m)
+	}
+	expectNoChatLocks(t)
+}
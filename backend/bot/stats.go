@@ -0,0 +1,299 @@
+package bot
+
+import (
+	"accountbook/models"
+	"accountbook/repository"
+	"accountbook/services"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// statsPageSize 分類紀錄每頁顯示的筆數
+const statsPageSize = 10
+
+// statTypeCodes 統計按鈕上的類型代碼（callback data 長度有限，不直接放中文）
+var statTypeCodes = map[string]string{"e": "支出", "i": "收入"}
+
+// StatsReport 分類統計訊息的內容
+type StatsReport struct {
+	Period     string // 2006-01 為月統計，2006 為年度統計
+	Type       string // 分類列表只列出此類型（支出或收入）
+	Currency   string
+	Income     models.Money
+	Expense    models.Money
+	Categories []models.CategoryTotal // 此類型的各分類（金額由大到小）
+	Months     []MonthTotal           // 年度統計時每個月的收支
+}
+
+// MonthTotal 單月的收入與支出
+type MonthTotal struct {
+	Month   string
+	Income  models.Money
+	Expense models.Money
+}
+
+// SummaryReport 月總覽訊息的內容
+type SummaryReport struct {
+	Month       string
+	Currency    string
+	Income      models.Money
+	Expense     models.Money
+	PrevExpense models.Money // 上個月的支出，用於比較
+	Days        int          // 計算日均支出的天數（本月為已過的天數）
+}
+
+// statTotals 期間的收入與支出總額（換算為 base 幣別）
+// 原因：與統計頁（GetSummary）相同的查詢與換算，Bot 的數字才會與網頁一致
+func statTotals(store repository.Store, filter models.StatisticFilter, base, date string) (income, expense models.Money, err error) {
+	totals, err := store.Records().TypeTotals(filter)
+	if err != nil {
+		return 0, 0, err
+	}
+	return services.Exchange.ConvertTypeTotals(totals, base, date)
+}
+
+// statCategoryTotals 期間各分類的加總（換算為 base 幣別，金額由大到小），同 GetStatistics
+func statCategoryTotals(store repository.Store, filter models.StatisticFilter, base, date string) ([]models.CategoryTotal, error) {
+	totals, err := store.Records().CategoryTotals(filter)
+	if err != nil {
+		return nil, err
+	}
+	return services.Exchange.ConvertCategoryTotals(totals, base, date)
+}
+
+// statFilter 依期間（2006-01 或 2006）建立統計條件
+func statFilter(period string) models.StatisticFilter {
+	if len(period) == len("2006-01") {
+		return models.StatisticFilter{Month: period}
+	}
+	return models.StatisticFilter{Year: period}
+}
+
+// statPeriodEnd 期間的最後一天，作為換算匯率的基準日（同統計頁）
+func statPeriodEnd(period string) string {
+	if t, err := time.Parse("2006-01", period); err == nil {
+		return t.AddDate(0, 1, -1).Format("2006-01-02")
+	}
+	return period + "-12-31"
+}
+
+// validPeriod 期間格式是否為 2006-01 或 2006
+func validPeriod(period string) bool {
+	if _, err := time.Parse("2006-01", period); err == nil {
+		return true
+	}
+	_, err := time.Parse("2006", period)
+	return err == nil
+}
+
+// shiftPeriod 將期間往前或往後移動 delta 個月（月統計）或年（年度統計）
+func shiftPeriod(period string, delta int) string {
+	if t, err := time.Parse("2006-01", period); err == nil {
+		return t.AddDate(0, delta, 0).Format("2006-01")
+	}
+	t, _ := time.Parse("2006", period)
+	return t.AddDate(delta, 0, 0).Format("2006")
+}
+
+// currentPeriod 目前所在的月份（monthly 為 true）或年份
+func currentPeriod(monthly bool) string {
+	if monthly {
+		return time.Now().Format("2006-01")
+	}
+	return time.Now().Format("2006")
+}
+
+// parseStatsMonth 解析 /stats、/summary 的月份參數：2026-10、2026/10、10（今年）、上月
+func parseStatsMonth(arg string) (string, bool) {
+	now := time.Now()
+	switch arg {
+	case "":
+		return now.Format("2006-01"), true
+	case "上月", "上個月":
+		return now.AddDate(0, -1, 1-now.Day()).Format("2006-01"), true
+	}
+	for _, layout := range []string{"2006-1", "2006/1"} {
+		if t, err := time.Parse(layout, arg); err == nil {
+			return t.Format("2006-01"), true
+		}
+	}
+	if m, err := strconv.Atoi(strings.TrimSuffix(arg, "月")); err == nil && m >= 1 && m <= 12 {
+		return fmt.Sprintf("%d-%02d", now.Year(), m), true
+	}
+	return "", false
+}
+
+// buildStatsReport 查詢期間的收支總額與指定類型的各分類統計（不含轉帳，換算為預設幣別）
+func buildStatsReport(store repository.Store, period, recordType string) (*StatsReport, error) {
+	base := models.DefaultCurrency
+	filter := statFilter(period)
+	end := statPeriodEnd(period)
+
+	r := &StatsReport{Period: period, Type: recordType, Currency: base}
+	var err error
+	if r.Income, r.Expense, err = statTotals(store, filter, base, end); err != nil {
+		return nil, err
+	}
+	totals, err := statCategoryTotals(store, filter, base, end)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range totals {
+		if t.Type == recordType {
+			r.Categories = append(r.Categories, t)
+		}
+	}
+
+	// 年度統計另外列出每個月的收支（今年只到本月）
+	if filter.Year != "" {
+		for m := 1; m <= 12; m++ {
+			month := fmt.Sprintf("%s-%02d", period, m)
+			if month > currentPeriod(true) {
+				break
+			}
+			mt := MonthTotal{Month: month}
+			if mt.Income, mt.Expense, err = statTotals(store, models.StatisticFilter{Month: month}, base, statPeriodEnd(month)); err != nil {
+				return nil, err
+			}
+			r.Months = append(r.Months, mt)
+		}
+	}
+	return r, nil
+}
+
+// buildSummaryReport 查詢月份的收支總額，並與上個月的支出比較
+func buildSummaryReport(store repository.Store, month string) (*SummaryReport, error) {
+	base := models.DefaultCurrency
+	r := &SummaryReport{Month: month, Currency: base}
+	var err error
+	if r.Income, r.Expense, err = statTotals(store, models.StatisticFilter{Month: month}, base, statPeriodEnd(month)); err != nil {
+		return nil, err
+	}
+	prev := shiftPeriod(month, -1)
+	if _, r.PrevExpense, err = statTotals(store, models.StatisticFilter{Month: prev}, base, statPeriodEnd(prev)); err != nil {
+		return nil, err
+	}
+
+	end, _ := time.Parse("2006-01-02", statPeriodEnd(month))
+	r.Days = end.Day()
+	if month == currentPeriod(true) {
+		r.Days = time.Now().Day()
+	}
+	return r, nil
+}
+
+// === 指令與按鈕 ===
+
+// handleStats 處理 /stats [月份]：本月（或指定月份）各支出分類的統計
+func handleStats(chatID int64, store repository.Store, args []string) {
+	month, ok := parseStatsMonth(strings.Join(args, ""))
+	if !ok {
+		services.SendMessage(chatID, "❌ 月份格式錯誤，例如：/stats 2026-10、/stats 10、/stats 上月")
+		return
+	}
+	showStats(chatID, 0, store, month, "支出")
+}
+
+// handleYear 處理 /year [年份]：今年（或指定年份）的每月收支與各支出分類
+func handleYear(chatID int64, store repository.Store, args []string) {
+	year := currentPeriod(false)
+	if len(args) > 0 {
+		year = args[0]
+	}
+	if _, err := time.Parse("2006", year); err != nil {
+		services.SendMessage(chatID, "❌ 年份格式錯誤，例如：/year 2026")
+		return
+	}
+	showStats(chatID, 0, store, year, "支出")
+}
+
+// handleSummary 處理 /summary [月份]：本月（或指定月份）的收支總覽
+func handleSummary(chatID int64, store repository.Store, args []string) {
+	month, ok := parseStatsMonth(strings.Join(args, ""))
+	if !ok {
+		services.SendMessage(chatID, "❌ 月份格式錯誤，例如：/summary 2026-10、/summary 10、/summary 上月")
+		return
+	}
+	showSummary(chatID, 0, store, month)
+}
+
+// showStats 發送（msgID 為 0）或更新分類統計訊息
+func showStats(chatID int64, msgID int, store repository.Store, period, recordType string) {
+	r, err := buildStatsReport(store, period, recordType)
+	if err != nil {
+		respondStatsError(chatID, err)
+		return
+	}
+	sendOrEdit(chatID, msgID, FormatStats(r), BuildStatsKeyboard(r, currentPeriod(len(period) == len("2006-01"))))
+}
+
+// showSummary 發送（msgID 為 0）或更新月總覽訊息
+func showSummary(chatID int64, msgID int, store repository.Store, month string) {
+	r, err := buildSummaryReport(store, month)
+	if err != nil {
+		respondStatsError(chatID, err)
+		return
+	}
+	sendOrEdit(chatID, msgID, FormatSummary(r), BuildSummaryKeyboard(month, currentPeriod(true)))
+}
+
+// showCategoryRecords 更新為分類在期間內的紀錄（分頁）
+func showCategoryRecords(chatID int64, msgID int, store repository.Store, period, recordType string, categoryID, offset int) {
+	filter := statFilter(period)
+	filter.CategoryID = categoryID
+	filter.Type = recordType
+	records, total, err := store.Records().ListByStatistic(filter, offset, statsPageSize)
+	if err != nil {
+		respondStatsError(chatID, err)
+		return
+	}
+	text := FormatCategoryRecords(period, resolveCategoryName(store, categoryID), recordType, records, offset, total)
+	sendOrEdit(chatID, msgID, text, BuildCategoryRecordsKeyboard(period, recordType, categoryID, offset, statsPageSize, total))
+}
+
+// handleStatsAction 處理統計訊息上的按鈕，更新同一則訊息
+//   - st_<期間>_<e|i>：期間的支出（e）或收入（i）分類統計，期間為 2006-01 或 2006
+//   - st_c_<期間>_<e|i>_<分類ID>_<offset>：分類在期間內的紀錄
+//   - st_s_<月份>：月總覽
+func handleStatsAction(chatID int64, msgID int, data string) {
+	cb, ok := chatBook(chatID)
+	if !ok {
+		return
+	}
+	store := repository.Default.ForBook(cb.BookID, cb.UserID)
+
+	parts := strings.Split(strings.TrimPrefix(data, "st_"), "_")
+	switch {
+	case len(parts) == 2 && parts[0] == "s" && validPeriod(parts[1]) && len(parts[1]) == len("2006-01"):
+		showSummary(chatID, msgID, store, parts[1])
+	case len(parts) == 5 && parts[0] == "c" && validPeriod(parts[1]) && statTypeCodes[parts[2]] != "":
+		categoryID, _ := strconv.Atoi(parts[3])
+		offset, _ := strconv.Atoi(parts[4])
+		showCategoryRecords(chatID, msgID, store, parts[1], statTypeCodes[parts[2]], categoryID, offset)
+	case len(parts) == 2 && validPeriod(parts[0]) && statTypeCodes[parts[1]] != "":
+		showStats(chatID, msgID, store, parts[0], statTypeCodes[parts[1]])
+	}
+}
+
+// sendOrEdit msgID 為 0 時發送新訊息，否則更新該訊息
+func sendOrEdit(chatID int64, msgID int, text string, keyboard services.InlineKeyboardMarkup) {
+	if msgID == 0 {
+		services.SendMessageWithKeyboard(chatID, text, keyboard)
+		return
+	}
+	services.EditMessageWithKeyboard(chatID, msgID, text, keyboard)
+}
+
+// respondStatsError 回覆統計查詢失敗（缺少匯率時說明缺少哪組匯率）
+func respondStatsError(chatID int64, err error) {
+	if errors.Is(err, services.ErrRateNotFound) {
+		services.SendMessage(chatID, "⚠️ "+err.Error())
+		return
+	}
+	log.Printf("查詢統計失敗: %v", err)
+	services.SendMessage(chatID, "系統忙碌中，請稍後再試")
+}
@@ -0,0 +1,152 @@
+package bot
+
+import (
+	"accountbook/models"
+	"accountbook/services"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// UndoWindow 送出後多久內可以復原（BOT_UNDO_WINDOW，預設 10 分鐘，0 代表關閉復原）
+var UndoWindow = 10 * time.Minute
+
+// 可復原的操作種類
+const (
+	undoRecord   = "record"
+	undoTransfer = "transfer"
+)
+
+// undoAction 聊天室最近一次新增的紀錄或轉帳
+type undoAction struct {
+	Kind      string // undoRecord 或 undoTransfer
+	ID        int    // 紀錄或轉帳 ID
+	BookID    int
+	UserID    int
+	MessageID int    // 成功訊息的 ID
+	Text      string // 成功訊息的內容（原因：復原或逾時後需改寫同一則訊息並移除按鈕）
+	CreatedAt time.Time
+}
+
+// undoStore 每個聊天室最近一次可復原的操作（以 chatID 為 key）
+// 原因：只保留最近一筆，復原期限很短，不另存資料庫；重啟後無法再復原
+var (
+	undoStore = make(map[int64]*undoAction)
+	undoMu    sync.Mutex
+)
+
+// showUndoable 將預覽訊息改為成功訊息並附上復原按鈕，記錄為聊天室最近一次的操作
+// 原因：只能復原最近一筆，前一則成功訊息的復原按鈕一併移除
+func showUndoable(chatID int64, a *undoAction) {
+	if UndoWindow <= 0 {
+		services.EditMessageText(chatID, a.MessageID, a.Text)
+		return
+	}
+
+	a.CreatedAt = time.Now()
+	undoMu.Lock()
+	prev := undoStore[chatID]
+	undoStore[chatID] = a
+	undoMu.Unlock()
+
+	if prev != nil && prev.MessageID != a.MessageID {
+		services.EditMessageText(chatID, prev.MessageID, prev.Text)
+	}
+	services.EditMessageWithKeyboard(chatID, a.MessageID, a.Text, BuildUndoKeyboard())
+}
+
+// forgetUndo 清除聊天室可復原的操作，並移除成功訊息上的復原按鈕
+func forgetUndo(chatID int64) {
+	undoMu.Lock()
+	a := undoStore[chatID]
+	delete(undoStore, chatID)
+	undoMu.Unlock()
+
+	if a != nil {
+		services.EditMessageText(chatID, a.MessageID, a.Text)
+	}
+}
+
+// handleUndo 復原聊天室最近一次新增的紀錄或轉帳
+// msg 為按下復原按鈕的訊息，以 /undo 指令復原時為 nil
+func handleUndo(chatID int64, msg *TelegramMessage) {
+	undoMu.Lock()
+	a := undoStore[chatID]
+	undoMu.Unlock()
+
+	// 按鈕所在的訊息不是最近一次的操作（例如重啟後），移除按鈕（無法取得內容的舊訊息則保留）
+	if msg != nil && (a == nil || a.MessageID != msg.MessageID) {
+		if msg.Text != "" {
+			services.EditMessageText(chatID, msg.MessageID, msg.Text)
+		}
+		services.SendMessage(chatID, FormatUndoUnavailable())
+		return
+	}
+	if a == nil {
+		services.SendMessage(chatID, FormatUndoUnavailable())
+		return
+	}
+	if time.Since(a.CreatedAt) > UndoWindow {
+		forgetUndo(chatID)
+		services.SendMessage(chatID, FormatUndoUnavailable())
+		return
+	}
+
+	// 角色可能在送出後被改為檢視者
+	role, err := services.Books.Role(a.BookID, a.UserID)
+	if err != nil || !models.CanEdit(role) {
+		services.SendMessage(chatID, readOnlyMessage)
+		return
+	}
+
+	ledger := services.Ledger.ForBook(a.BookID, a.UserID)
+	if a.Kind == undoTransfer {
+		err = ledger.VoidTransfer(a.ID)
+	} else {
+		err = ledger.VoidRecord(a.ID)
+	}
+	switch {
+	case errors.Is(err, services.ErrRecordNotFound), errors.Is(err, services.ErrTransferNotFound):
+		forgetUndo(chatID)
+		services.SendMessage(chatID, "⚠️ 已被刪除，無需復原")
+		return
+	case err != nil:
+		log.Printf("復原失敗: %v", err)
+		services.SendMessage(chatID, "⚠️ 復原失敗，請稍後再試")
+		return
+	}
+
+	undoMu.Lock()
+	delete(undoStore, chatID)
+	undoMu.Unlock()
+	services.EditMessageText(chatID, a.MessageID, FormatUndone(a))
+	if msg == nil {
+		services.SendMessage(chatID, "↩️ 已復原")
+	}
+}
+
+// expireUndoActions 移除超過復原期限的復原按鈕
+// 原因：由 RunSessionJanitor 定期呼叫，避免使用者點擊已失效的按鈕
+func expireUndoActions() {
+	undoMu.Lock()
+	var chatIDs []int64
+	for chatID, a := range undoStore {
+		if time.Since(a.CreatedAt) > UndoWindow {
+			chatIDs = append(chatIDs, chatID)
+		}
+	}
+	undoMu.Unlock()
+
+	for _, chatID := range chatIDs {
+		unlock := lockChat(chatID)
+		undoMu.Lock()
+		a := undoStore[chatID]
+		undoMu.Unlock()
+		// 鎖定期間可能已有新的操作
+		if a != nil && time.Since(a.CreatedAt) > UndoWindow {
+			forgetUndo(chatID)
+		}
+		unlock()
+	}
+}
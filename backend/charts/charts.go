@@ -0,0 +1,321 @@
+// Package charts 以標準函式庫繪製統計圖表（圓餅圖、長條圖、折線圖）並輸出 PNG
+// 原因：Bot 與網頁共用同一張圖片，不依賴瀏覽器或外部繪圖套件
+// 圖上的文字只支援數字、英文與少數符號，分類名稱以圖例編號對照
+package charts
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"strings"
+)
+
+// 圖片尺寸與邊界
+const (
+	Width  = 800
+	Height = 480
+
+	marginLeft   = 90
+	marginRight  = 30
+	marginTop    = 60
+	marginBottom = 50
+)
+
+// 常用顏色
+var (
+	background = color.RGBA{255, 255, 255, 255}
+	foreground = color.RGBA{51, 51, 51, 255}
+	gridColor  = color.RGBA{230, 230, 230, 255}
+	axisColor  = color.RGBA{160, 160, 160, 255}
+	otherColor = color.RGBA{189, 189, 189, 255}
+	lineColor  = color.RGBA{54, 162, 235, 255}
+	barColor   = color.RGBA{255, 99, 132, 255}
+)
+
+// Palette 圓餅圖各區塊的顏色（依序使用）
+var Palette = []color.RGBA{
+	{255, 99, 132, 255},
+	{54, 162, 235, 255},
+	{255, 206, 86, 255},
+	{75, 192, 192, 255},
+	{153, 102, 255, 255},
+	{255, 159, 64, 255},
+	{46, 204, 113, 255},
+	{231, 76, 60, 255},
+	{52, 73, 94, 255},
+}
+
+// Point 長條圖或折線圖的一個資料點，Label 為 X 軸標籤（例如日期）
+type Point struct {
+	Label string
+	Value float64
+}
+
+// Pie 繪製圓餅圖，右側圖例為「編號 百分比」，編號由 1 開始對應 values 的順序
+// 原因：超過 Palette 數量的區塊合併為灰色的最後一塊，圖例標示為「+」
+func Pie(title string, values []float64) ([]byte, error) {
+	img := newCanvas(title)
+
+	total := 0.0
+	for _, v := range values {
+		if v > 0 {
+			total += v
+		}
+	}
+	if total == 0 {
+		drawEmpty(img)
+		return encode(img)
+	}
+
+	// 依序計算每塊的結束角度與顏色，超過的部分合併
+	type slice struct {
+		end   float64
+		color color.RGBA
+		label string
+		share float64
+	}
+	var slices []slice
+	acc := 0.0
+	for i, v := range values {
+		if v <= 0 {
+			continue
+		}
+		if len(slices) == len(Palette) {
+			rest := total - acc
+			slices = append(slices, slice{end: 2 * math.Pi, color: otherColor, label: "+", share: rest / total})
+			break
+		}
+		acc += v
+		slices = append(slices, slice{end: acc / total * 2 * math.Pi, color: Palette[len(slices)], label: fmt.Sprint(i + 1), share: v / total})
+	}
+
+	cx, cy := float64(marginLeft+150), float64(marginTop+(Height-marginTop-marginBottom)/2)
+	radius := 170.0
+	colorAt := func(x, y float64) (color.RGBA, bool) {
+		dx, dy := x-cx, y-cy
+		if dx*dx+dy*dy > radius*radius {
+			return background, false
+		}
+		// 由 12 點鐘方向順時針
+		angle := math.Atan2(dx, -dy)
+		if angle < 0 {
+			angle += 2 * math.Pi
+		}
+		for _, s := range slices {
+			if angle <= s.end {
+				return s.color, true
+			}
+		}
+		return slices[len(slices)-1].color, true
+	}
+
+	// 每個像素取 3×3 個取樣點平均，邊緣較平滑
+	const samples = 3
+	for y := int(cy - radius - 1); y <= int(cy+radius+1); y++ {
+		for x := int(cx - radius - 1); x <= int(cx+radius+1); x++ {
+			var r, g, b, n int
+			for sy := 0; sy < samples; sy++ {
+				for sx := 0; sx < samples; sx++ {
+					c, _ := colorAt(float64(x)+(float64(sx)+0.5)/samples, float64(y)+(float64(sy)+0.5)/samples)
+					r, g, b, n = r+int(c.R), g+int(c.G), b+int(c.B), n+1
+				}
+			}
+			img.SetRGBA(x, y, color.RGBA{uint8(r / n), uint8(g / n), uint8(b / n), 255})
+		}
+	}
+
+	// 圖例
+	x, y := int(cx+radius)+70, marginTop+10
+	for _, s := range slices {
+		fillRect(img, x, y, 20, 20, s.color)
+		drawText(img, x+30, y+3, fmt.Sprintf("%-3s%5.1f%%", s.label, s.share*100), 2, foreground)
+		y += 34
+	}
+	return encode(img)
+}
+
+// Bar 繪製長條圖（例如每日支出），X 軸標籤過多時間隔顯示
+func Bar(title string, points []Point) ([]byte, error) {
+	img := newCanvas(title)
+	if len(points) == 0 {
+		drawEmpty(img)
+		return encode(img)
+	}
+
+	lo, hi := valueRange(points, true)
+	plot := drawAxes(img, points, lo, hi)
+	step := float64(plot.Dx()) / float64(len(points))
+	barWidth := int(step * 0.7)
+	if barWidth < 1 {
+		barWidth = 1
+	}
+	zero := plot.Max.Y - int(float64(plot.Dy())*(0-lo)/(hi-lo))
+	for i, p := range points {
+		x := plot.Min.X + int(step*float64(i)+(step-float64(barWidth))/2)
+		top := plot.Max.Y - int(float64(plot.Dy())*(p.Value-lo)/(hi-lo))
+		if top <= zero {
+			fillRect(img, x, top, barWidth, zero-top, barColor)
+		} else {
+			fillRect(img, x, zero, barWidth, top-zero, barColor)
+		}
+	}
+	return encode(img)
+}
+
+// Line 繪製折線圖（例如資產變化），資料點以圓點標示
+func Line(title string, points []Point) ([]byte, error) {
+	img := newCanvas(title)
+	if len(points) == 0 {
+		drawEmpty(img)
+		return encode(img)
+	}
+
+	lo, hi := valueRange(points, false)
+	plot := drawAxes(img, points, lo, hi)
+	step := float64(plot.Dx()) / float64(len(points))
+	pos := func(i int) (float64, float64) {
+		return float64(plot.Min.X) + step*(float64(i)+0.5), float64(plot.Max.Y) - float64(plot.Dy())*(points[i].Value-lo)/(hi-lo)
+	}
+	for i := 1; i < len(points); i++ {
+		x0, y0 := pos(i - 1)
+		x1, y1 := pos(i)
+		drawLine(img, x0, y0, x1, y1, 3, lineColor)
+	}
+	for i := range points {
+		x, y := pos(i)
+		fillRect(img, int(x)-3, int(y)-3, 7, 7, lineColor)
+	}
+	return encode(img)
+}
+
+// newCanvas 建立白底畫布並繪製標題
+func newCanvas(title string) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, Width, Height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{background}, image.Point{}, draw.Src)
+	drawText(img, (Width-textWidth(title, 3))/2, 18, title, 3, foreground)
+	return img
+}
+
+// drawEmpty 沒有資料時在中央顯示 0
+func drawEmpty(img *image.RGBA) {
+	drawText(img, (Width-textWidth("0", 6))/2, (Height-glyphHeight*6)/2, "0", 6, axisColor)
+}
+
+// valueRange 資料的上下限（取整到刻度），includeZero 時一定包含 0
+func valueRange(points []Point, includeZero bool) (float64, float64) {
+	lo, hi := points[0].Value, points[0].Value
+	for _, p := range points {
+		lo, hi = math.Min(lo, p.Value), math.Max(hi, p.Value)
+	}
+	if includeZero {
+		lo, hi = math.Min(lo, 0), math.Max(hi, 0)
+	}
+	if hi == lo {
+		if hi == 0 {
+			return 0, 1
+		}
+		lo, hi = lo-math.Abs(lo)*0.1, hi+math.Abs(hi)*0.1
+	}
+	tick := niceStep((hi - lo) / 4)
+	return math.Floor(lo/tick) * tick, math.Ceil(hi/tick) * tick
+}
+
+// niceStep 不小於 raw 的「好看」刻度間隔（1、2、5 乘以 10 的次方）
+func niceStep(raw float64) float64 {
+	exp := math.Pow(10, math.Floor(math.Log10(raw)))
+	for _, m := range []float64{1, 2, 5, 10} {
+		if m*exp >= raw {
+			return m * exp
+		}
+	}
+	return 10 * exp
+}
+
+// drawAxes 繪製格線、Y 軸刻度與 X 軸標籤，回傳繪圖區域
+func drawAxes(img *image.RGBA, points []Point, lo, hi float64) image.Rectangle {
+	plot := image.Rect(marginLeft, marginTop, Width-marginRight, Height-marginBottom)
+
+	tick := niceStep((hi - lo) / 4)
+	for v := lo; v <= hi+tick/2; v += tick {
+		y := plot.Max.Y - int(float64(plot.Dy())*(v-lo)/(hi-lo))
+		fillRect(img, plot.Min.X, y, plot.Dx(), 1, gridColor)
+		label := compactNumber(v)
+		drawText(img, plot.Min.X-10-textWidth(label, 2), y-glyphHeight, label, 2, foreground)
+	}
+	fillRect(img, plot.Min.X, plot.Min.Y, 1, plot.Dy(), axisColor)
+	fillRect(img, plot.Min.X, plot.Max.Y, plot.Dx(), 1, axisColor)
+
+	// X 軸標籤間隔顯示，避免重疊
+	step := float64(plot.Dx()) / float64(len(points))
+	labelWidth := 0
+	for _, p := range points {
+		if w := textWidth(p.Label, 2); w > labelWidth {
+			labelWidth = w
+		}
+	}
+	every := 1
+	for step*float64(every) < float64(labelWidth+10) {
+		every++
+	}
+	for i, p := range points {
+		if i%every != 0 {
+			continue
+		}
+		x := plot.Min.X + int(step*(float64(i)+0.5)) - textWidth(p.Label, 2)/2
+		drawText(img, x, plot.Max.Y+12, p.Label, 2, foreground)
+	}
+	return plot
+}
+
+// compactNumber 以 K、M 縮寫座標軸上的數字，例如 1500 → 1.5K
+func compactNumber(v float64) string {
+	abs := math.Abs(v)
+	switch {
+	case abs >= 1e6:
+		return trimZero(fmt.Sprintf("%.1f", v/1e6)) + "M"
+	case abs >= 1e3:
+		return trimZero(fmt.Sprintf("%.1f", v/1e3)) + "K"
+	case abs == math.Trunc(abs):
+		return fmt.Sprintf("%.0f", v)
+	}
+	return trimZero(fmt.Sprintf("%.2f", v))
+}
+
+// trimZero 移除小數點後多餘的 0
+func trimZero(s string) string {
+	if !strings.Contains(s, ".") {
+		return s
+	}
+	return strings.TrimSuffix(strings.TrimRight(s, "0"), ".")
+}
+
+// fillRect 以 (x, y) 為左上角填滿 w×h 的矩形（超出畫布的部分忽略）
+func fillRect(img *image.RGBA, x, y, w, h int, c color.Color) {
+	draw.Draw(img, image.Rect(x, y, x+w, y+h).Intersect(img.Bounds()), &image.Uniform{c}, image.Point{}, draw.Src)
+}
+
+// drawLine 以寬度 width 的方形筆刷繪製直線
+func drawLine(img *image.RGBA, x0, y0, x1, y1 float64, width int, c color.Color) {
+	steps := int(math.Max(math.Abs(x1-x0), math.Abs(y1-y0)))
+	if steps == 0 {
+		steps = 1
+	}
+	for i := 0; i <= steps; i++ {
+		t := float64(i) / float64(steps)
+		x, y := x0+(x1-x0)*t, y0+(y1-y0)*t
+		fillRect(img, int(x)-width/2, int(y)-width/2, width, width, c)
+	}
+}
+
+// encode 輸出 PNG
+func encode(img *image.RGBA) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,104 @@
+// faketelegram 離線開發用的假 Telegram Bot API
+//
+// 用法：
+//
+//	go run ./cmd/faketelegram -addr :8081 -token dev
+//	TELEGRAM_BOT_TOKEN=dev TELEGRAM_API_URL=http://localhost:8081 TELEGRAM_MODE=polling ./accountbook-server
+//
+// 之後在標準輸入輸入「chat_id 文字」模擬使用者訊息，例如 `1001 /new`；
+// 輸入「chat_id [按鈕文字]」點擊該聊天室最後一則含有此按鈕的訊息，例如 `1001 [確認]`
+// 文字中的 \n 代表換行，用來模擬多行訊息，例如 `1001 今天\n150\n午餐\n飲食`
+package main
+
+import (
+	"accountbook/services/telegramtest"
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// photoDir 儲存收到的圖片的目錄
+var photoDir *string
+
+func main() {
+	addr := flag.String("addr", ":8081", "監聽位址")
+	token := flag.String("token", "dev", "Bot token（需與 TELEGRAM_BOT_TOKEN 相同）")
+	photoDir = flag.String("photo-dir", "", "儲存 sendPhoto 收到的圖片的目錄（空字串不儲存）")
+	flag.Parse()
+
+	server := telegramtest.NewServer(*token)
+	server.OnMessage = printMessage
+
+	go func() {
+		log.Fatal(http.ListenAndServe(*addr, server))
+	}()
+	log.Printf("假 Telegram Bot API 啟動於 %s", *addr)
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		if err := handleInput(server, strings.TrimSpace(scanner.Text())); err != nil {
+			fmt.Println("❗", err)
+		}
+	}
+}
+
+// handleInput 解析一行輸入：「chat_id 文字」或「chat_id [按鈕文字]」
+func handleInput(server *telegramtest.Server, line string) error {
+	if line == "" {
+		return nil
+	}
+	fields := strings.SplitN(line, " ", 2)
+	chatID, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil || len(fields) < 2 {
+		return fmt.Errorf("格式：chat_id 文字，或 chat_id [按鈕文字]")
+	}
+	text := strings.TrimSpace(fields[1])
+
+	if strings.HasPrefix(text, "[") && strings.HasSuffix(text, "]") {
+		label := strings.TrimSuffix(strings.TrimPrefix(text, "["), "]")
+		msgs := server.Messages(chatID)
+		for i := len(msgs) - 1; i >= 0; i-- {
+			if data, ok := msgs[i].Button(label); ok {
+				return server.PressButton(chatID, msgs[i].MessageID, data)
+			}
+		}
+		return fmt.Errorf("找不到按鈕「%s」", label)
+	}
+
+	_, err = server.SendText(chatID, strings.ReplaceAll(text, `\n`, "\n"))
+	return err
+}
+
+func printMessage(m telegramtest.Message) {
+	action := "發送"
+	if m.Edits > 0 {
+		action = "編輯"
+	}
+	fmt.Printf("── [%d] #%d %s\n", m.ChatID, m.MessageID, action)
+	if m.Photo != nil {
+		fmt.Printf("🖼 圖片 %d bytes", len(m.Photo))
+		if *photoDir != "" {
+			path := filepath.Join(*photoDir, fmt.Sprintf("%d_%d.png", m.ChatID, m.MessageID))
+			if err := os.WriteFile(path, m.Photo, 0o644); err != nil {
+				fmt.Printf("（儲存失敗：%v）", err)
+			} else {
+				fmt.Printf("（已儲存至 %s）", path)
+			}
+		}
+		fmt.Println()
+	}
+	fmt.Println(m.Text)
+	for _, row := range m.Buttons {
+		var labels []string
+		for _, b := range row {
+			labels = append(labels, "["+b.Text+"]")
+		}
+		fmt.Println(strings.Join(labels, " "))
+	}
+}
@@ -1,10 +1,11 @@
 package controllers
 
 import (
-	"accountbook/initializers"
 	"accountbook/models"
+	"accountbook/repository"
+	"accountbook/services"
 	"net/http"
-	"time"
+	"sort"
 
 	"github.com/gin-gonic/gin"
 )
@@ -12,33 +13,27 @@ import (
 // GetAccounts 取得所有帳戶列表
 // 原因：前端帳戶頁與下拉選單需要完整帳戶資料
 func GetAccounts(c *gin.Context) {
-	rows, err := initializers.DB.Query("SELECT id, name, balance, sort_order, created_at, updated_at FROM accounts ORDER BY name")
+	accounts, err := bookStore(c).Accounts().List()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "查詢帳戶失敗"})
 		return
 	}
-	defer rows.Close()
 
-	var accounts []models.Account
-	for rows.Next() {
-		var a models.Account
-		if err := rows.Scan(&a.ID, &a.Name, &a.Balance, &a.SortOrder, &a.CreatedAt, &a.UpdatedAt); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "讀取帳戶資料失敗"})
-			return
-		}
-		accounts = append(accounts, a)
-	}
+	// 帳戶頁依名稱排列
+	sort.SliceStable(accounts, func(i, j int) bool { return accounts[i].Name < accounts[j].Name })
 
 	c.JSON(http.StatusOK, accounts)
 }
 
 // GetAccount 取得單一帳戶
 func GetAccount(c *gin.Context) {
-	id := c.Param("id")
+	id, ok := paramID(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "找不到該帳戶"})
+		return
+	}
 
-	var a models.Account
-	err := initializers.DB.QueryRow("SELECT id, name, balance, sort_order, created_at, updated_at FROM accounts WHERE id = ?", id).
-		Scan(&a.ID, &a.Name, &a.Balance, &a.SortOrder, &a.CreatedAt, &a.UpdatedAt)
+	a, err := bookStore(c).Accounts().Get(id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "找不到該帳戶"})
 		return
@@ -48,11 +43,12 @@ func GetAccount(c *gin.Context) {
 }
 
 // CreateAccount 新增帳戶
-// 原因：使用者可自訂帳戶（如新增電子錢包等）
+// 原因：使用者可自訂帳戶（如新增電子錢包、外幣帳戶等），未指定幣別時使用預設幣別
 func CreateAccount(c *gin.Context) {
 	var input struct {
-		Name    string `json:"name" binding:"required"`
-		Balance float64 `json:"balance"`
+		Name     string       `json:"name" binding:"required"`
+		Currency string       `json:"currency"`
+		Balance  models.Money `json:"balance"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -60,35 +56,45 @@ func CreateAccount(c *gin.Context) {
 		return
 	}
 
-	// 取得目前最大排序值，新帳戶排在最後
-	var maxOrder int
-	initializers.DB.QueryRow("SELECT COALESCE(MAX(sort_order), -1) FROM accounts").Scan(&maxOrder)
+	currency := models.DefaultCurrency
+	if input.Currency != "" {
+		var ok bool
+		if currency, ok = models.NormalizeCurrency(input.Currency); !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrInvalidCurrency.Error()})
+			return
+		}
+	}
 
-	now := time.Now().Format("2006-01-02 15:04:05")
-	result, err := initializers.DB.Exec(
-		"INSERT INTO accounts (name, balance, sort_order, created_at, updated_at) VALUES (?, ?, ?, ?, ?)",
-		input.Name, input.Balance, maxOrder+1, now, now,
-	)
-	if err != nil {
+	// 新帳戶尚無紀錄，初始餘額即為期初餘額
+	account := &models.Account{Name: input.Name, Currency: currency, Balance: input.Balance, OpeningBalance: input.Balance}
+	if err := bookStore(c).Accounts().Create(account); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "帳戶名稱已存在"})
 		return
 	}
 
-	id, _ := result.LastInsertId()
 	c.JSON(http.StatusCreated, gin.H{
-		"id":      id,
-		"name":    input.Name,
-		"balance": input.Balance,
+		"id":       account.ID,
+		"name":     account.Name,
+		"currency": account.Currency,
+		"balance":  account.Balance,
 	})
 }
 
-// UpdateAccount 更新帳戶（名稱與餘額）
+// UpdateAccount 更新帳戶（名稱、幣別、餘額或期初餘額）
+// 原因：修改餘額時改為調整期初餘額，維持「期初餘額 + 紀錄」與餘額一致；
+// 已有紀錄的帳戶不可變更幣別，否則既有金額的意義會改變
 func UpdateAccount(c *gin.Context) {
-	id := c.Param("id")
+	id, ok := paramID(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "找不到該帳戶"})
+		return
+	}
 
 	var input struct {
-		Name    *string  `json:"name"`
-		Balance *float64 `json:"balance"`
+		Name           *string       `json:"name"`
+		Currency       *string       `json:"currency"`
+		Balance        *models.Money `json:"balance"`
+		OpeningBalance *models.Money `json:"opening_balance"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -96,43 +102,154 @@ func UpdateAccount(c *gin.Context) {
 		return
 	}
 
-	now := time.Now().Format("2006-01-02 15:04:05")
-
 	// 依據有提供的欄位進行更新
 	if input.Name != nil {
-		initializers.DB.Exec("UPDATE accounts SET name = ?, updated_at = ? WHERE id = ?", *input.Name, now, id)
+		err := bookStore(c).Accounts().Rename(id, *input.Name)
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "找不到該帳戶"})
+			return
+		}
+		if err == repository.ErrDuplicate {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "帳戶名稱已存在"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "更新失敗"})
+			return
+		}
+	}
+	if input.Currency != nil {
+		if !updateAccountCurrency(c, id, *input.Currency) {
+			return
+		}
+	}
+	if input.OpeningBalance != nil {
+		if err := bookLedger(c).SetOpeningBalance(id, *input.OpeningBalance); err != nil {
+			respondAccountError(c, err)
+			return
+		}
 	}
 	if input.Balance != nil {
-		initializers.DB.Exec("UPDATE accounts SET balance = ?, updated_at = ? WHERE id = ?", *input.Balance, now, id)
+		if err := bookLedger(c).SetAccountBalance(id, *input.Balance); err != nil {
+			respondAccountError(c, err)
+			return
+		}
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "更新成功"})
 }
 
+// updateAccountCurrency 變更帳戶幣別，失敗時直接回覆錯誤並回傳 false
+func updateAccountCurrency(c *gin.Context, id int, code string) bool {
+	currency, ok := models.NormalizeCurrency(code)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrInvalidCurrency.Error()})
+		return false
+	}
+
+	account, err := bookStore(c).Accounts().Get(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "找不到該帳戶"})
+		return false
+	}
+	if account.Currency == currency {
+		return true
+	}
+
+	count, err := bookStore(c).Records().CountByAccount(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新失敗"})
+		return false
+	}
+	if count > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "此帳戶已有紀錄，無法變更幣別"})
+		return false
+	}
+
+	if err := bookStore(c).Accounts().SetCurrency(id, currency); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新失敗"})
+		return false
+	}
+	return true
+}
+
+// ReconcileAccount 依紀錄重新計算單一帳戶餘額並回報差異（不修正）
+// 原因：餘額為快取值，可能因舊版程式或手動修改而與紀錄不符
+func ReconcileAccount(c *gin.Context) {
+	id, ok := paramID(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "找不到該帳戶"})
+		return
+	}
+
+	report, err := bookLedger(c).Reconcile(id)
+	if err != nil {
+		respondAccountError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// ReconcileAccounts 對所有帳戶對帳，並將不一致的餘額修正為重新計算的值
+func ReconcileAccounts(c *gin.Context) {
+	reports, err := bookLedger(c).ReconcileAll(true)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "對帳失敗"})
+		return
+	}
+
+	repaired := 0
+	for _, r := range reports {
+		if r.Repaired {
+			repaired++
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"accounts": reports,
+		"repaired": repaired,
+	})
+}
+
+// respondAccountError 回覆帳戶相關操作的錯誤
+func respondAccountError(c *gin.Context, err error) {
+	if err == services.ErrAccountNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "找不到該帳戶"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "更新失敗"})
+}
+
 // DeleteAccount 刪除帳戶
 // 原因：需檢查是否有關聯紀錄，有則不允許刪除
 func DeleteAccount(c *gin.Context) {
-	id := c.Param("id")
-
-	// 檢查是否有關聯紀錄
-	var count int
-	initializers.DB.QueryRow("SELECT COUNT(*) FROM records WHERE account_id = ?", id).Scan(&count)
-	if count > 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "此帳戶尚有紀錄，無法刪除"})
+	id, ok := paramID(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "找不到該帳戶"})
 		return
 	}
 
-	result, err := initializers.DB.Exec("DELETE FROM accounts WHERE id = ?", id)
+	// 檢查是否有關聯紀錄
+	count, err := bookStore(c).Records().CountByAccount(id)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "刪除失敗"})
 		return
 	}
+	if count > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "此帳戶尚有紀錄，無法刪除"})
+		return
+	}
 
-	affected, _ := result.RowsAffected()
-	if affected == 0 {
+	err = bookStore(c).Accounts().Delete(id)
+	if err == repository.ErrNotFound {
 		c.JSON(http.StatusNotFound, gin.H{"error": "找不到該帳戶"})
 		return
 	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "刪除失敗"})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "刪除成功"})
 }
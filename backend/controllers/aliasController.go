@@ -0,0 +1,68 @@
+package controllers
+
+import (
+	"accountbook/models"
+	"accountbook/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetAliases 取得所有帳戶與分類的別名
+func GetAliases(c *gin.Context) {
+	aliases, err := bookAliases(c).List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查詢別名失敗"})
+		return
+	}
+	if aliases == nil {
+		aliases = []models.Alias{}
+	}
+	c.JSON(http.StatusOK, aliases)
+}
+
+// CreateAlias 新增帳戶或分類的別名
+// 原因：Bot 快捷輸入可用別名指定帳戶或分類，account_id 與 category_id 擇一
+func CreateAlias(c *gin.Context) {
+	var input models.AliasInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "請提供別名"})
+		return
+	}
+
+	alias, err := bookAliases(c).Create(input)
+	if err != nil {
+		respondAliasError(c, err, "新增別名失敗")
+		return
+	}
+	c.JSON(http.StatusCreated, alias)
+}
+
+// DeleteAlias 刪除別名
+func DeleteAlias(c *gin.Context) {
+	id, ok := paramID(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": services.ErrAliasNotFound.Error()})
+		return
+	}
+
+	if err := bookAliases(c).Delete(id); err != nil {
+		respondAliasError(c, err, "刪除別名失敗")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "刪除成功"})
+}
+
+// respondAliasError 依別名服務的錯誤種類回覆對應的 HTTP 狀態
+func respondAliasError(c *gin.Context, err error, fallback string) {
+	switch err {
+	case services.ErrAliasNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case services.ErrAliasExists:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case services.ErrInvalidAlias, services.ErrAliasTarget, services.ErrAccountNotFound, services.ErrCategoryNotFound:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -0,0 +1,218 @@
+package controllers
+
+import (
+	"accountbook/models"
+	"accountbook/repository"
+	"accountbook/services"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// gin.Context 中存放認證資訊的鍵
+const (
+	tokenIDKey = "tokenID"
+	isAdminKey = "isAdmin"
+)
+
+// Authenticate 驗證 Authorization: Bearer <token>，並以 token 的使用者作為目前使用者
+// 原因：啟用認證後不再信任 X-User-ID 標頭；關閉認證時沿用 CurrentUser（僅限內網使用）
+func Authenticate() gin.HandlerFunc {
+	fallback := CurrentUser()
+	return func(c *gin.Context) {
+		if !services.Auth.Enabled() {
+			c.Set(isAdminKey, true)
+			fallback(c)
+			return
+		}
+
+		token := bearerToken(c)
+		if token == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "請先登入"})
+			return
+		}
+		t, err := services.Auth.Authenticate(token)
+		if err != nil {
+			respondAuthError(c, err, "驗證失敗")
+			c.Abort()
+			return
+		}
+		user, err := repository.Default.Users().Get(t.UserID)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": services.ErrUnauthorized.Error()})
+			return
+		}
+
+		c.Set(userIDKey, user.ID)
+		c.Set(tokenIDKey, t.ID)
+		c.Set(isAdminKey, user.IsAdmin)
+		c.Next()
+	}
+}
+
+// RequireAdmin 限定管理員才能呼叫的路由（建立使用者、修改匯率）
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !c.GetBool(isAdminKey) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "需要管理員權限"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// requireSelf 確認目前使用者為 userID 本人或管理員，否則回覆 403
+func requireSelf(c *gin.Context, userID int) bool {
+	if currentUserID(c) == userID || c.GetBool(isAdminKey) {
+		return true
+	}
+	c.JSON(http.StatusForbidden, gin.H{"error": "只能修改自己的資料"})
+	return false
+}
+
+// bearerToken 取得 Authorization 標頭中的 token
+func bearerToken(c *gin.Context) string {
+	header := c.GetHeader("Authorization")
+	if len(header) > 7 && strings.EqualFold(header[:7], "Bearer ") {
+		return strings.TrimSpace(header[7:])
+	}
+	return ""
+}
+
+// GetAuthConfig 回傳是否需要登入（不需認證）
+// 原因：前端依此決定顯示登入頁或使用者切換選單
+func GetAuthConfig(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"enabled": services.Auth.Enabled()})
+}
+
+// Login 以帳號密碼登入，回傳 JWT
+func Login(c *gin.Context) {
+	var input struct {
+		Username string `json:"username" binding:"required"`
+		Password string `json:"password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "請輸入帳號與密碼"})
+		return
+	}
+
+	token, t, err := services.Auth.Login(input.Username, input.Password)
+	if err != nil {
+		respondAuthError(c, err, "登入失敗")
+		return
+	}
+	user, _ := repository.Default.Users().Get(t.UserID)
+	c.JSON(http.StatusOK, gin.H{"token": token, "expires_at": t.ExpiresAt, "user": user})
+}
+
+// Logout 撤銷目前使用的 token
+func Logout(c *gin.Context) {
+	id, ok := c.Get(tokenIDKey)
+	if !ok {
+		c.JSON(http.StatusOK, gin.H{"message": "已登出"})
+		return
+	}
+	if err := repository.Default.APITokens().Revoke(id.(int)); err != nil && err != repository.ErrNotFound {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "登出失敗"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "已登出"})
+}
+
+// GetTokens 列出目前使用者尚未撤銷的 token（含登入與個人 token）
+func GetTokens(c *gin.Context) {
+	tokens, err := repository.Default.APITokens().ListByUser(currentUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查詢 token 失敗"})
+		return
+	}
+	if tokens == nil {
+		tokens = []models.APIToken{}
+	}
+	c.JSON(http.StatusOK, tokens)
+}
+
+// CreateToken 建立個人 API token（給腳本使用），token 只會在此回傳一次
+func CreateToken(c *gin.Context) {
+	var input struct {
+		Name          string `json:"name" binding:"required"`
+		ExpiresInDays int    `json:"expires_in_days"` // 0 代表永不過期
+	}
+	if err := c.ShouldBindJSON(&input); err != nil || input.ExpiresInDays < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrTokenName.Error()})
+		return
+	}
+
+	ttl := time.Duration(input.ExpiresInDays) * 24 * time.Hour
+	token, t, err := services.Auth.CreatePersonalToken(currentUserID(c), input.Name, ttl)
+	if err != nil {
+		respondAuthError(c, err, "建立 token 失敗")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"token": token, "id": t.ID, "name": t.Name, "prefix": t.Prefix, "expires_at": t.ExpiresAt})
+}
+
+// RevokeToken 撤銷 token（自己的，或管理員撤銷任何人的）
+func RevokeToken(c *gin.Context) {
+	id, ok := paramID(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": services.ErrTokenNotFound.Error()})
+		return
+	}
+
+	actor := &models.User{ID: currentUserID(c), IsAdmin: c.GetBool(isAdminKey)}
+	if err := services.Auth.Revoke(actor, id); err != nil {
+		respondAuthError(c, err, "撤銷 token 失敗")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "已撤銷"})
+}
+
+// ChangePassword 修改密碼：本人需提供目前的密碼，管理員可直接重設他人的密碼
+func ChangePassword(c *gin.Context) {
+	id, ok := paramID(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": services.ErrUserNotFound.Error()})
+		return
+	}
+	if !requireSelf(c, id) {
+		return
+	}
+
+	var input struct {
+		CurrentPassword string `json:"current_password"`
+		Password        string `json:"password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "請提供新密碼"})
+		return
+	}
+
+	var err error
+	if id == currentUserID(c) {
+		err = services.Auth.ChangePassword(id, input.CurrentPassword, input.Password)
+	} else {
+		err = services.Auth.SetPassword(id, input.Password)
+	}
+	if err != nil {
+		respondAuthError(c, err, "修改密碼失敗")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "密碼已更新"})
+}
+
+// respondAuthError 依認證服務的錯誤回覆對應的 HTTP 狀態
+func respondAuthError(c *gin.Context, err error, fallback string) {
+	switch err {
+	case services.ErrInvalidCredentials, services.ErrUnauthorized:
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case services.ErrTokenNotFound, services.ErrUserNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case services.ErrWeakPassword, services.ErrWrongPassword, services.ErrTokenName:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -0,0 +1,253 @@
+package controllers
+
+import (
+	"accountbook/models"
+	"accountbook/repository"
+	"accountbook/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// gin.Context 中存放目前帳本與角色的鍵
+const (
+	bookIDKey   = "bookID"
+	bookRoleKey = "bookRole"
+)
+
+// CurrentBook 依 X-Book-ID 標頭（或 book_id 查詢參數）決定目前請求的帳本
+// 原因：未指定時使用目前使用者的個人帳本，升級前的前端不需修改即可使用；
+// 指定的帳本必須是目前使用者所屬的帳本
+func CurrentBook() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := currentUserID(c)
+		value := c.GetHeader("X-Book-ID")
+		if value == "" {
+			value = c.Query("book_id")
+		}
+
+		var bookID int
+		if value != "" {
+			id, err := strconv.Atoi(value)
+			if err != nil || id <= 0 {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "X-Book-ID 格式錯誤"})
+				return
+			}
+			bookID = id
+		} else {
+			id, err := services.Books.DefaultBook(userID)
+			if err != nil {
+				respondBookError(c, err, "查詢帳本失敗")
+				c.Abort()
+				return
+			}
+			bookID = id
+		}
+
+		role, err := services.Books.Role(bookID, userID)
+		if err != nil {
+			respondBookError(c, err, "查詢帳本失敗")
+			c.Abort()
+			return
+		}
+		c.Set(bookIDKey, bookID)
+		c.Set(bookRoleKey, role)
+		c.Next()
+	}
+}
+
+// RequireEditor 限定擁有者與編輯者才能呼叫的路由（新增、修改、刪除）
+func RequireEditor() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !models.CanEdit(c.GetString(bookRoleKey)) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": services.ErrReadOnly.Error()})
+			return
+		}
+		c.Next()
+	}
+}
+
+// currentBookID 取得目前請求的帳本 ID（未經過 CurrentBook 時為預設帳本）
+func currentBookID(c *gin.Context) int {
+	if id, ok := c.Get(bookIDKey); ok {
+		return id.(int)
+	}
+	return models.DefaultBookID
+}
+
+// GetBooks 取得目前使用者所屬的帳本與角色
+func GetBooks(c *gin.Context) {
+	books, err := repository.Default.Books().ListByUser(currentUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查詢帳本失敗"})
+		return
+	}
+	if books == nil {
+		books = []models.BookWithRole{}
+	}
+	c.JSON(http.StatusOK, books)
+}
+
+// CreateBook 新增帳本，目前使用者為擁有者
+// 原因：新帳本會一併建立預設帳戶與分類
+func CreateBook(c *gin.Context) {
+	var input struct {
+		Name string `json:"name" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrBookName.Error()})
+		return
+	}
+
+	book, err := services.Books.CreateBook(currentUserID(c), input.Name)
+	if err != nil {
+		respondBookError(c, err, "新增帳本失敗")
+		return
+	}
+	c.JSON(http.StatusCreated, models.BookWithRole{Book: *book, Role: models.RoleOwner})
+}
+
+// UpdateBook 修改帳本名稱（限擁有者）
+func UpdateBook(c *gin.Context) {
+	id, ok := paramID(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": services.ErrBookNotFound.Error()})
+		return
+	}
+
+	var input struct {
+		Name string `json:"name" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrBookName.Error()})
+		return
+	}
+
+	if err := services.Books.Rename(id, currentUserID(c), input.Name); err != nil {
+		respondBookError(c, err, "更新帳本失敗")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "更新成功"})
+}
+
+// GetBookMembers 取得帳本成員（限帳本成員）
+func GetBookMembers(c *gin.Context) {
+	id, ok := paramID(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": services.ErrBookNotFound.Error()})
+		return
+	}
+	if _, err := services.Books.Role(id, currentUserID(c)); err != nil {
+		respondBookError(c, err, "查詢成員失敗")
+		return
+	}
+
+	members, err := repository.Default.Books().Members(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查詢成員失敗"})
+		return
+	}
+	if members == nil {
+		members = []models.BookMember{}
+	}
+	c.JSON(http.StatusOK, members)
+}
+
+// AddBookMember 依使用者名稱將使用者加入帳本（限擁有者），已是成員時改為新的角色
+func AddBookMember(c *gin.Context) {
+	id, ok := paramID(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": services.ErrBookNotFound.Error()})
+		return
+	}
+
+	var input struct {
+		Username string `json:"username" binding:"required"`
+		Role     string `json:"role"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "請提供使用者名稱"})
+		return
+	}
+	// 預設為編輯者
+	if input.Role == "" {
+		input.Role = models.RoleEditor
+	}
+
+	user, err := repository.Default.Users().FindByUsername(input.Username)
+	if err != nil {
+		respondBookError(c, services.ErrUserNotFound, "新增成員失敗")
+		return
+	}
+	if err := services.Books.SetMember(id, currentUserID(c), user.ID, input.Role); err != nil {
+		respondBookError(c, err, "新增成員失敗")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "新增成功", "user_id": user.ID, "role": input.Role})
+}
+
+// UpdateBookMember 修改成員角色（限擁有者），尚未加入的使用者會以此角色加入
+func UpdateBookMember(c *gin.Context) {
+	id, userID, ok := bookMemberParams(c)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Role string `json:"role" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrInvalidRole.Error()})
+		return
+	}
+
+	if err := services.Books.SetMember(id, currentUserID(c), userID, input.Role); err != nil {
+		respondBookError(c, err, "更新成員失敗")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "更新成功"})
+}
+
+// RemoveBookMember 移除成員（限擁有者，或成員自行退出）
+func RemoveBookMember(c *gin.Context) {
+	id, userID, ok := bookMemberParams(c)
+	if !ok {
+		return
+	}
+
+	if err := services.Books.RemoveMember(id, currentUserID(c), userID); err != nil {
+		respondBookError(c, err, "移除成員失敗")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "已移除成員"})
+}
+
+// bookMemberParams 解析路由參數 :id 與 :user_id，失敗時已回覆 404
+func bookMemberParams(c *gin.Context) (int, int, bool) {
+	id, ok := paramID(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": services.ErrBookNotFound.Error()})
+		return 0, 0, false
+	}
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil || userID <= 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": services.ErrUserNotFound.Error()})
+		return 0, 0, false
+	}
+	return id, userID, true
+}
+
+// respondBookError 依帳本服務的錯誤回覆對應的 HTTP 狀態
+func respondBookError(c *gin.Context, err error, fallback string) {
+	switch err {
+	case services.ErrBookNotFound, services.ErrUserNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case services.ErrReadOnly, services.ErrOwnerRequired:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case services.ErrLastOwner, services.ErrInvalidRole, services.ErrBookName:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
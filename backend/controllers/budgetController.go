@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"accountbook/models"
+	"accountbook/services"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetBudgets 取得所有預算
+func GetBudgets(c *gin.Context) {
+	budgets, err := bookBudgets(c).List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查詢預算失敗"})
+		return
+	}
+	if budgets == nil {
+		budgets = []models.Budget{}
+	}
+	c.JSON(http.StatusOK, budgets)
+}
+
+// CreateBudget 新增預算
+// 原因：category_id 省略或為 0 時為整本帳本的總預算，每個分類至多一筆
+func CreateBudget(c *gin.Context) {
+	var input models.BudgetInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "請提供預算金額"})
+		return
+	}
+
+	budget, err := bookBudgets(c).Create(input)
+	if err != nil {
+		respondBudgetError(c, err, "新增預算失敗")
+		return
+	}
+	c.JSON(http.StatusCreated, budget)
+}
+
+// UpdateBudget 更新預算的金額、滾存與開始月份
+func UpdateBudget(c *gin.Context) {
+	id, ok := paramID(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": services.ErrBudgetNotFound.Error()})
+		return
+	}
+
+	var input models.BudgetInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "請提供預算金額"})
+		return
+	}
+
+	budget, err := bookBudgets(c).Update(id, input)
+	if err != nil {
+		respondBudgetError(c, err, "更新預算失敗")
+		return
+	}
+	c.JSON(http.StatusOK, budget)
+}
+
+// DeleteBudget 刪除預算
+func DeleteBudget(c *gin.Context) {
+	id, ok := paramID(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": services.ErrBudgetNotFound.Error()})
+		return
+	}
+
+	if err := bookBudgets(c).Delete(id); err != nil {
+		respondBudgetError(c, err, "刪除預算失敗")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "刪除成功"})
+}
+
+// GetBudgetStatus 取得各預算在指定月份的支出、剩餘與百分比
+// 原因：month 省略時為本月；金額以預設幣別計價，其他幣別的支出依月底匯率換算
+func GetBudgetStatus(c *gin.Context) {
+	month := c.DefaultQuery("month", time.Now().Format("2006-01"))
+
+	statuses, err := bookBudgets(c).Status(month)
+	if err != nil {
+		respondBudgetError(c, err, "查詢預算失敗")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"month":    month,
+		"currency": models.DefaultCurrency,
+		"budgets":  statuses,
+	})
+}
+
+// respondBudgetError 依預算服務的錯誤種類回覆對應的 HTTP 狀態
+func respondBudgetError(c *gin.Context, err error, fallback string) {
+	if errors.Is(err, services.ErrRateNotFound) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	switch err {
+	case services.ErrBudgetNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case services.ErrBudgetExists:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case services.ErrCategoryNotFound, services.ErrInvalidAmount, services.ErrAmountPrecision, services.ErrInvalidMonth:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -1,10 +1,10 @@
 package controllers
 
 import (
-	"accountbook/initializers"
 	"accountbook/models"
+	"accountbook/repository"
 	"net/http"
-	"time"
+	"sort"
 
 	"github.com/gin-gonic/gin"
 )
@@ -12,22 +12,14 @@ import (
 // GetCategories 取得所有分類列表
 // 原因：前端設定頁、下拉選單、Telegram Bot 都需要分類資料
 func GetCategories(c *gin.Context) {
-	rows, err := initializers.DB.Query("SELECT id, name, sort_order, created_at, updated_at FROM categories ORDER BY name")
+	categories, err := bookStore(c).Categories().List()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "查詢分類失敗"})
 		return
 	}
-	defer rows.Close()
-
-	var categories []models.Category
-	for rows.Next() {
-		var cat models.Category
-		if err := rows.Scan(&cat.ID, &cat.Name, &cat.SortOrder, &cat.CreatedAt, &cat.UpdatedAt); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "讀取分類資料失敗"})
-			return
-		}
-		categories = append(categories, cat)
-	}
+
+	// 設定頁依名稱排列
+	sort.SliceStable(categories, func(i, j int) bool { return categories[i].Name < categories[j].Name })
 
 	c.JSON(http.StatusOK, categories)
 }
@@ -44,30 +36,25 @@ func CreateCategory(c *gin.Context) {
 		return
 	}
 
-	// 新分類排在最後
-	var maxOrder int
-	initializers.DB.QueryRow("SELECT COALESCE(MAX(sort_order), -1) FROM categories").Scan(&maxOrder)
-
-	now := time.Now().Format("2006-01-02 15:04:05")
-	result, err := initializers.DB.Exec(
-		"INSERT INTO categories (name, sort_order, created_at, updated_at) VALUES (?, ?, ?, ?)",
-		input.Name, maxOrder+1, now, now,
-	)
-	if err != nil {
+	category := &models.Category{Name: input.Name}
+	if err := bookStore(c).Categories().Create(category); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "分類名稱已存在"})
 		return
 	}
 
-	id, _ := result.LastInsertId()
 	c.JSON(http.StatusCreated, gin.H{
-		"id":   id,
-		"name": input.Name,
+		"id":   category.ID,
+		"name": category.Name,
 	})
 }
 
 // UpdateCategory 更新分類名稱
 func UpdateCategory(c *gin.Context) {
-	id := c.Param("id")
+	id, ok := paramID(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "找不到該分類"})
+		return
+	}
 
 	var input struct {
 		Name string `json:"name" binding:"required"`
@@ -78,16 +65,13 @@ func UpdateCategory(c *gin.Context) {
 		return
 	}
 
-	now := time.Now().Format("2006-01-02 15:04:05")
-	result, err := initializers.DB.Exec("UPDATE categories SET name = ?, updated_at = ? WHERE id = ?", input.Name, now, id)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "分類名稱已存在"})
+	err := bookStore(c).Categories().Rename(id, input.Name)
+	if err == repository.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "找不到該分類"})
 		return
 	}
-
-	affected, _ := result.RowsAffected()
-	if affected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "找不到該分類"})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "分類名稱已存在"})
 		return
 	}
 
@@ -97,27 +81,32 @@ func UpdateCategory(c *gin.Context) {
 // DeleteCategory 刪除分類
 // 原因：需檢查是否有關聯紀錄，有則不允許刪除
 func DeleteCategory(c *gin.Context) {
-	id := c.Param("id")
-
-	// 檢查是否有關聯紀錄
-	var count int
-	initializers.DB.QueryRow("SELECT COUNT(*) FROM records WHERE category_id = ?", id).Scan(&count)
-	if count > 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "此分類尚有紀錄，無法刪除"})
+	id, ok := paramID(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "找不到該分類"})
 		return
 	}
 
-	result, err := initializers.DB.Exec("DELETE FROM categories WHERE id = ?", id)
+	// 檢查是否有關聯紀錄
+	count, err := bookStore(c).Records().CountByCategory(id)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "刪除失敗"})
 		return
 	}
+	if count > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "此分類尚有紀錄，無法刪除"})
+		return
+	}
 
-	affected, _ := result.RowsAffected()
-	if affected == 0 {
+	err = bookStore(c).Categories().Delete(id)
+	if err == repository.ErrNotFound {
 		c.JSON(http.StatusNotFound, gin.H{"error": "找不到該分類"})
 		return
 	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "刪除失敗"})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "刪除成功"})
 }
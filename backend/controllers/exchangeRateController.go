@@ -0,0 +1,113 @@
+package controllers
+
+import (
+	"accountbook/models"
+	"accountbook/repository"
+	"accountbook/services"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetExchangeRates 取得匯率列表（可依 from、to 幣別篩選）
+func GetExchangeRates(c *gin.Context) {
+	from := strings.ToUpper(c.Query("from"))
+	to := strings.ToUpper(c.Query("to"))
+
+	rates, err := repository.Default.ExchangeRates().List(from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查詢匯率失敗"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rates)
+}
+
+// CreateExchangeRate 新增單筆匯率，同一天同幣別已存在時覆寫
+func CreateExchangeRate(c *gin.Context) {
+	var input models.ExchangeRate
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "輸入格式錯誤"})
+		return
+	}
+
+	rate := &models.ExchangeRate{
+		Date:         input.Date,
+		FromCurrency: input.FromCurrency,
+		ToCurrency:   input.ToCurrency,
+		Rate:         input.Rate,
+	}
+	if err := services.Exchange.SaveRate(rate); err != nil {
+		respondRateError(c, err, "新增匯率失敗")
+		return
+	}
+
+	c.JSON(http.StatusCreated, rate)
+}
+
+// ImportExchangeRates 匯入 CSV 匯率
+// 原因：旅遊期間的每日匯率可由銀行網站匯出後一次匯入
+// 接受上傳檔案（欄位名稱 file）或直接以 CSV 作為請求內容
+func ImportExchangeRates(c *gin.Context) {
+	var body io.Reader = c.Request.Body
+	if c.ContentType() == "multipart/form-data" {
+		file, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "請上傳 CSV 檔案"})
+			return
+		}
+		f, err := file.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "無法讀取上傳檔案"})
+			return
+		}
+		defer f.Close()
+		body = f
+	}
+
+	count, err := services.Exchange.ImportCSV(body)
+	if err != nil {
+		respondRateError(c, err, "匯入匯率失敗")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "匯入成功", "imported": count})
+}
+
+// DeleteExchangeRate 刪除匯率
+func DeleteExchangeRate(c *gin.Context) {
+	id, ok := paramID(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "找不到該匯率"})
+		return
+	}
+
+	err := repository.Default.ExchangeRates().Delete(id)
+	if err == repository.ErrNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "找不到該匯率"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "刪除失敗"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "刪除成功"})
+}
+
+// respondRateError 回覆匯率操作的錯誤
+// 原因：CSV 匯入錯誤帶有行號，需原樣回傳給使用者
+func respondRateError(c *gin.Context, err error, fallback string) {
+	var importErr *services.ImportError
+	switch {
+	case errors.As(err, &importErr),
+		err == services.ErrInvalidDate, err == services.ErrInvalidCurrency,
+		err == services.ErrSameCurrency, err == services.ErrInvalidRate:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
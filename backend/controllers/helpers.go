@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	"accountbook/repository"
+	"accountbook/services"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// paramID 解析路由參數 :id
+// 原因：非數字的 ID 一律視為找不到資料
+func paramID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		return 0, false
+	}
+	return id, true
+}
+
+// bookStore 目前請求帳本的資料存取
+// 原因：所有帳戶、分類與紀錄的查詢都必須限定在目前帳本，新增的紀錄記錄為目前使用者建立
+func bookStore(c *gin.Context) repository.Store {
+	return repository.Default.ForBook(currentBookID(c), currentUserID(c))
+}
+
+// bookLedger 目前請求帳本的記帳服務
+func bookLedger(c *gin.Context) *services.LedgerService {
+	return services.Ledger.ForBook(currentBookID(c), currentUserID(c))
+}
+
+// bookSuggestions 目前請求帳本的分類與帳戶建議
+func bookSuggestions(c *gin.Context) *services.SuggestionService {
+	return services.Suggestions.ForBook(currentBookID(c), currentUserID(c))
+}
+
+// bookAliases 目前請求帳本的別名服務
+func bookAliases(c *gin.Context) *services.AliasService {
+	return services.Aliases.ForBook(currentBookID(c), currentUserID(c))
+}
+
+// bookBudgets 目前請求帳本的預算服務
+func bookBudgets(c *gin.Context) *services.BudgetService {
+	return services.Budgets.ForBook(currentBookID(c), currentUserID(c))
+}
+
+// bookRecurring 目前請求帳本的定期紀錄服務
+func bookRecurring(c *gin.Context) *services.RecurringService {
+	return services.Recurring.ForBook(currentBookID(c), currentUserID(c))
+}
+
+// bookCharts 目前請求帳本的圖表服務
+func bookCharts(c *gin.Context) *services.ChartService {
+	return services.Charts.ForBook(currentBookID(c), currentUserID(c))
+}
+
+// respondLedgerError 依記帳服務的錯誤種類回覆對應的 HTTP 狀態
+// 原因：輸入錯誤（帳戶、分類不存在等）回 400，找不到紀錄回 404，
+// 單獨修改轉帳的其中一筆回 409，其餘視為系統錯誤
+func respondLedgerError(c *gin.Context, err error, fallback string) {
+	// 跨幣別轉帳缺少匯率（錯誤訊息帶有幣別與日期）
+	if errors.Is(err, services.ErrRateNotFound) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	switch err {
+	case services.ErrRecordNotFound, services.ErrTransferNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case services.ErrTransferLeg:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case services.ErrAccountNotFound, services.ErrCategoryNotFound,
+		services.ErrInvalidAmount, services.ErrAmountPrecision, services.ErrInvalidType, services.ErrSameAccount:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -1,10 +1,9 @@
 package controllers
 
 import (
-	"accountbook/initializers"
 	"accountbook/models"
+	"accountbook/services"
 	"net/http"
-	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -27,35 +26,19 @@ func GetRecords(c *gin.Context) {
 // getRecordsByDate 查詢指定日期的紀錄列表
 // 原因：首頁點擊行事曆日期時，顯示當日所有紀錄
 func getRecordsByDate(c *gin.Context, date string) {
-	rows, err := initializers.DB.Query(`
-		SELECT r.id, r.date, r.account_id, a.name, r.type, r.amount, r.item, r.category_id, c.name, r.note
-		FROM records r
-		JOIN accounts a ON r.account_id = a.id
-		JOIN categories c ON r.category_id = c.id
-		WHERE r.date = ?
-		ORDER BY r.created_at DESC
-	`, date)
+	records, err := bookStore(c).Records().ListByDate(date)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "查詢紀錄失敗"})
 		return
 	}
-	defer rows.Close()
 
-	var records []models.RecordWithNames
-	var totalIncome, totalExpense float64
-
-	for rows.Next() {
-		var r models.RecordWithNames
-		if err := rows.Scan(&r.ID, &r.Date, &r.AccountID, &r.AccountName, &r.Type, &r.Amount, &r.Item, &r.CategoryID, &r.CategoryName, &r.Note); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "讀取紀錄資料失敗"})
-			return
-		}
+	var totalIncome, totalExpense models.Money
+	for _, r := range records {
 		if r.Type == "收入" {
 			totalIncome += r.Amount
 		} else {
 			totalExpense += r.Amount
 		}
-		records = append(records, r)
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -68,45 +51,45 @@ func getRecordsByDate(c *gin.Context, date string) {
 
 // getRecordsByMonth 查詢指定月份的每日摘要
 // 原因：行事曆需要知道哪些日期有紀錄，以及每日收支金額
+// 金額換算為 currency 參數指定的幣別，與 GetSummary 同樣以月底匯率換算，各日加總才會等於當月總額
 func getRecordsByMonth(c *gin.Context, month string) {
-	rows, err := initializers.DB.Query(`
-		SELECT date, type, SUM(amount) as total
-		FROM records
-		WHERE strftime('%Y-%m', date) = ?
-		GROUP BY date, type
-		ORDER BY date
-	`, month)
+	base, ok := baseCurrency(c)
+	if !ok {
+		return
+	}
+
+	totals, err := bookStore(c).Records().DailyTotals(month)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "查詢月份資料失敗"})
 		return
 	}
-	defer rows.Close()
+	totals, err = services.Exchange.ConvertDailyTotals(totals, base, periodEnd(month, ""))
+	if err != nil {
+		respondConvertError(c, err)
+		return
+	}
 
 	// 每日摘要：日期 -> {income, expense}
 	type DailySummary struct {
-		Income  float64 `json:"income"`
-		Expense float64 `json:"expense"`
+		Income  models.Money `json:"income"`
+		Expense models.Money `json:"expense"`
 	}
 	dailySummary := make(map[string]*DailySummary)
 
-	for rows.Next() {
-		var date, recordType string
-		var total float64
-		if err := rows.Scan(&date, &recordType, &total); err != nil {
-			continue
-		}
-		if _, ok := dailySummary[date]; !ok {
-			dailySummary[date] = &DailySummary{}
+	for _, t := range totals {
+		if _, ok := dailySummary[t.Date]; !ok {
+			dailySummary[t.Date] = &DailySummary{}
 		}
-		if recordType == "收入" {
-			dailySummary[date].Income = total
+		if t.Type == "收入" {
+			dailySummary[t.Date].Income = t.Total
 		} else {
-			dailySummary[date].Expense = total
+			dailySummary[t.Date].Expense = t.Total
 		}
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"month":         month,
+		"currency":      base,
 		"daily_summary": dailySummary,
 	})
 }
@@ -114,17 +97,13 @@ func getRecordsByMonth(c *gin.Context, month string) {
 // GetRecord 取得單筆紀錄詳情
 // 原因：進入編輯頁時需要取得完整紀錄資料
 func GetRecord(c *gin.Context) {
-	id := c.Param("id")
-
-	var r models.RecordWithNames
-	err := initializers.DB.QueryRow(`
-		SELECT r.id, r.date, r.account_id, a.name, r.type, r.amount, r.item, r.category_id, c.name, r.note
-		FROM records r
-		JOIN accounts a ON r.account_id = a.id
-		JOIN categories c ON r.category_id = c.id
-		WHERE r.id = ?
-	`, id).Scan(&r.ID, &r.Date, &r.AccountID, &r.AccountName, &r.Type, &r.Amount, &r.Item, &r.CategoryID, &r.CategoryName, &r.Note)
+	id, ok := paramID(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "找不到該紀錄"})
+		return
+	}
 
+	r, err := bookStore(c).Records().Get(id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "找不到該紀錄"})
 		return
@@ -134,7 +113,7 @@ func GetRecord(c *gin.Context) {
 }
 
 // CreateRecord 新增紀錄
-// 原因：新增紀錄時需同步更新帳戶餘額，使用 Transaction 確保資料一致性
+// 原因：新增紀錄時需同步更新帳戶餘額，由記帳服務以 Transaction 確保資料一致性
 func CreateRecord(c *gin.Context) {
 	var input models.RecordInput
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -147,123 +126,81 @@ func CreateRecord(c *gin.Context) {
 		input.Type = "支出"
 	}
 
-	// 使用 Transaction 確保紀錄新增與帳戶餘額同步
-	tx, err := initializers.DB.Begin()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "交易開始失敗"})
-		return
+	// 未指定分類時依過去同名紀錄建議，沒有紀錄時使用第一個分類
+	if input.CategoryID == 0 {
+		categoryID, err := suggestCategory(c, input.Item)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "查詢分類失敗"})
+			return
+		}
+		input.CategoryID = categoryID
 	}
 
-	now := time.Now().Format("2006-01-02 15:04:05")
-
-	// 新增紀錄
-	result, err := tx.Exec(
-		"INSERT INTO records (date, account_id, type, amount, item, category_id, note, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
-		input.Date, input.AccountID, input.Type, input.Amount, input.Item, input.CategoryID, input.Note, now, now,
-	)
-	if err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "新增紀錄失敗"})
+	record := input.ToRecord()
+	if err := bookLedger(c).PostRecord(record); err != nil {
+		respondLedgerError(c, err, "新增紀錄失敗")
 		return
 	}
 
-	// 更新帳戶餘額：支出扣款、收入加款
-	if input.Type == "支出" {
-		_, err = tx.Exec("UPDATE accounts SET balance = balance - ?, updated_at = ? WHERE id = ?", input.Amount, now, input.AccountID)
-	} else {
-		_, err = tx.Exec("UPDATE accounts SET balance = balance + ?, updated_at = ? WHERE id = ?", input.Amount, now, input.AccountID)
-	}
+	// 查詢帳戶與分類名稱回傳
+	created, err := bookStore(c).Records().Get(record.ID)
 	if err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新帳戶餘額失敗"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "讀取紀錄失敗"})
 		return
 	}
 
-	if err = tx.Commit(); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "交易提交失敗"})
-		return
-	}
-
-	recordID, _ := result.LastInsertId()
-
-	// 查詢帳戶與分類名稱回傳
-	var accountName, categoryName string
-	initializers.DB.QueryRow("SELECT name FROM accounts WHERE id = ?", input.AccountID).Scan(&accountName)
-	initializers.DB.QueryRow("SELECT name FROM categories WHERE id = ?", input.CategoryID).Scan(&categoryName)
-
 	c.JSON(http.StatusCreated, gin.H{
-		"id":            recordID,
-		"date":          input.Date,
-		"account_name":  accountName,
-		"type":          input.Type,
-		"amount":        input.Amount,
-		"item":          input.Item,
-		"category_name": categoryName,
-		"note":          input.Note,
+		"id":            created.ID,
+		"date":          created.Date,
+		"account_name":  created.AccountName,
+		"type":          created.Type,
+		"amount":        created.Amount,
+		"item":          created.Item,
+		"category_name": created.CategoryName,
+		"note":          created.Note,
 	})
 }
 
-// UpdateRecord 更新紀錄
-// 原因：需回滾舊紀錄對帳戶餘額的影響，再套用新值
-func UpdateRecord(c *gin.Context) {
-	id := c.Param("id")
-
-	var input models.RecordInput
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "輸入格式錯誤"})
-		return
+// suggestCategory 依項目名稱建議分類，沒有同名紀錄時回傳第一個分類（依排序）
+func suggestCategory(c *gin.Context, item string) (int, error) {
+	suggestion, err := bookSuggestions(c).Suggest(item)
+	if err != nil {
+		return 0, err
 	}
-
-	if input.Type == "" {
-		input.Type = "支出"
+	if suggestion.CategoryID != 0 {
+		return suggestion.CategoryID, nil
 	}
 
-	// 先查詢舊紀錄，用於回滾帳戶餘額
-	var oldAccountID int
-	var oldType string
-	var oldAmount float64
-	err := initializers.DB.QueryRow("SELECT account_id, type, amount FROM records WHERE id = ?", id).
-		Scan(&oldAccountID, &oldType, &oldAmount)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "找不到該紀錄"})
-		return
+	categories, err := bookStore(c).Categories().List()
+	if err != nil || len(categories) == 0 {
+		return 0, err
 	}
+	return categories[0].ID, nil
+}
 
-	tx, err := initializers.DB.Begin()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "交易開始失敗"})
+// UpdateRecord 更新紀錄
+// 原因：需回滾舊紀錄對帳戶餘額的影響，再套用新值
+func UpdateRecord(c *gin.Context) {
+	id, ok := paramID(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "找不到該紀錄"})
 		return
 	}
 
-	now := time.Now().Format("2006-01-02 15:04:05")
-
-	// 回滾舊帳戶餘額
-	if oldType == "支出" {
-		tx.Exec("UPDATE accounts SET balance = balance + ?, updated_at = ? WHERE id = ?", oldAmount, now, oldAccountID)
-	} else {
-		tx.Exec("UPDATE accounts SET balance = balance - ?, updated_at = ? WHERE id = ?", oldAmount, now, oldAccountID)
-	}
-
-	// 更新紀錄
-	_, err = tx.Exec(
-		"UPDATE records SET date=?, account_id=?, type=?, amount=?, item=?, category_id=?, note=?, updated_at=? WHERE id=?",
-		input.Date, input.AccountID, input.Type, input.Amount, input.Item, input.CategoryID, input.Note, now, id,
-	)
-	if err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新紀錄失敗"})
+	var input models.RecordInput
+	if err := c.ShouldBindJSON(&input); err != nil || input.CategoryID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "輸入格式錯誤"})
 		return
 	}
 
-	// 套用新帳戶餘額
-	if input.Type == "支出" {
-		tx.Exec("UPDATE accounts SET balance = balance - ?, updated_at = ? WHERE id = ?", input.Amount, now, input.AccountID)
-	} else {
-		tx.Exec("UPDATE accounts SET balance = balance + ?, updated_at = ? WHERE id = ?", input.Amount, now, input.AccountID)
+	if input.Type == "" {
+		input.Type = "支出"
 	}
 
-	if err = tx.Commit(); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "交易提交失敗"})
+	record := input.ToRecord()
+	record.ID = id
+	if err := bookLedger(c).AmendRecord(record); err != nil {
+		respondLedgerError(c, err, "更新紀錄失敗")
 		return
 	}
 
@@ -273,44 +210,14 @@ func UpdateRecord(c *gin.Context) {
 // DeleteRecord 刪除紀錄
 // 原因：刪除紀錄時需回滾對帳戶餘額的影響
 func DeleteRecord(c *gin.Context) {
-	id := c.Param("id")
-
-	// 先查詢紀錄以回滾餘額
-	var accountID int
-	var recordType string
-	var amount float64
-	err := initializers.DB.QueryRow("SELECT account_id, type, amount FROM records WHERE id = ?", id).
-		Scan(&accountID, &recordType, &amount)
-	if err != nil {
+	id, ok := paramID(c)
+	if !ok {
 		c.JSON(http.StatusNotFound, gin.H{"error": "找不到該紀錄"})
 		return
 	}
 
-	tx, err := initializers.DB.Begin()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "交易開始失敗"})
-		return
-	}
-
-	now := time.Now().Format("2006-01-02 15:04:05")
-
-	// 刪除紀錄
-	_, err = tx.Exec("DELETE FROM records WHERE id = ?", id)
-	if err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "刪除紀錄失敗"})
-		return
-	}
-
-	// 回滾帳戶餘額
-	if recordType == "支出" {
-		tx.Exec("UPDATE accounts SET balance = balance + ?, updated_at = ? WHERE id = ?", amount, now, accountID)
-	} else {
-		tx.Exec("UPDATE accounts SET balance = balance - ?, updated_at = ? WHERE id = ?", amount, now, accountID)
-	}
-
-	if err = tx.Commit(); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "交易提交失敗"})
+	if err := bookLedger(c).VoidRecord(id); err != nil {
+		respondLedgerError(c, err, "刪除紀錄失敗")
 		return
 	}
 
@@ -0,0 +1,150 @@
+package controllers
+
+import (
+	"accountbook/models"
+	"accountbook/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetRecurringRules 取得所有定期紀錄規則（依下次日期排列）
+func GetRecurringRules(c *gin.Context) {
+	rules, err := bookRecurring(c).List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查詢定期紀錄失敗"})
+		return
+	}
+	if rules == nil {
+		rules = []models.RecurringRule{}
+	}
+	c.JSON(http.StatusOK, rules)
+}
+
+// GetRecurringRule 取得單一定期紀錄規則
+func GetRecurringRule(c *gin.Context) {
+	id, ok := paramID(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": services.ErrRuleNotFound.Error()})
+		return
+	}
+
+	rule, err := bookRecurring(c).Get(id)
+	if err != nil {
+		respondRecurringError(c, err, "查詢定期紀錄失敗")
+		return
+	}
+	c.JSON(http.StatusOK, rule)
+}
+
+// CreateRecurringRule 新增定期紀錄規則
+// 原因：frequency 為 daily、weekly、monthly 或 yearly，搭配 interval 表示每幾個單位一次；
+// start_date 早於今天時，排程會補上之前每一期的紀錄
+func CreateRecurringRule(c *gin.Context) {
+	var input models.RecurringRuleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "請填寫所有必填欄位"})
+		return
+	}
+
+	rule, err := bookRecurring(c).Create(input)
+	if err != nil {
+		respondRecurringError(c, err, "新增定期紀錄失敗")
+		return
+	}
+	c.JSON(http.StatusCreated, rule)
+}
+
+// UpdateRecurringRule 更新定期紀錄規則
+// 原因：已新增紀錄的期數不會重複新增，下次日期為新排程中不早於原本下次日期的第一次
+func UpdateRecurringRule(c *gin.Context) {
+	id, ok := paramID(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": services.ErrRuleNotFound.Error()})
+		return
+	}
+
+	var input models.RecurringRuleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "請填寫所有必填欄位"})
+		return
+	}
+
+	rule, err := bookRecurring(c).Update(id, input)
+	if err != nil {
+		respondRecurringError(c, err, "更新定期紀錄失敗")
+		return
+	}
+	c.JSON(http.StatusOK, rule)
+}
+
+// DeleteRecurringRule 刪除定期紀錄規則（已新增的紀錄保留）
+func DeleteRecurringRule(c *gin.Context) {
+	id, ok := paramID(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": services.ErrRuleNotFound.Error()})
+		return
+	}
+
+	if err := bookRecurring(c).Delete(id); err != nil {
+		respondRecurringError(c, err, "刪除定期紀錄失敗")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "刪除成功"})
+}
+
+// PauseRecurringRule 暫停定期紀錄規則
+func PauseRecurringRule(c *gin.Context) {
+	setRecurringPaused(c, true)
+}
+
+// ResumeRecurringRule 恢復定期紀錄規則，暫停期間的期數不會補上
+func ResumeRecurringRule(c *gin.Context) {
+	setRecurringPaused(c, false)
+}
+
+func setRecurringPaused(c *gin.Context, paused bool) {
+	id, ok := paramID(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": services.ErrRuleNotFound.Error()})
+		return
+	}
+
+	rule, err := bookRecurring(c).SetPaused(id, paused)
+	if err != nil {
+		respondRecurringError(c, err, "更新定期紀錄失敗")
+		return
+	}
+	c.JSON(http.StatusOK, rule)
+}
+
+// SkipRecurringRule 略過下一次（不新增紀錄）
+func SkipRecurringRule(c *gin.Context) {
+	id, ok := paramID(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": services.ErrRuleNotFound.Error()})
+		return
+	}
+
+	rule, err := bookRecurring(c).Skip(id)
+	if err != nil {
+		respondRecurringError(c, err, "更新定期紀錄失敗")
+		return
+	}
+	c.JSON(http.StatusOK, rule)
+}
+
+// respondRecurringError 依定期紀錄服務的錯誤種類回覆對應的 HTTP 狀態
+func respondRecurringError(c *gin.Context, err error, fallback string) {
+	switch err {
+	case services.ErrRuleNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case services.ErrRuleFinished:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case services.ErrAccountNotFound, services.ErrCategoryNotFound, services.ErrInvalidAmount, services.ErrAmountPrecision,
+		services.ErrInvalidType, services.ErrInvalidFrequency, services.ErrInvalidDate, services.ErrEndBeforeStart:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -1,15 +1,20 @@
 package controllers
 
 import (
-	"accountbook/initializers"
+	"accountbook/models"
+	"accountbook/services"
+	"errors"
 	"net/http"
-	"strings"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 // GetStatistics 取得指定月份的分類統計
 // 原因：統計頁需要各分類的金額與佔比，用於圓餅圖展示
+// 轉帳預設不計入收支，帶 include_transfers=true 才納入
+// 金額換算為 currency 參數指定的幣別（預設為系統預設幣別）
 func GetStatistics(c *gin.Context) {
 	month := c.Query("month")
 	year := c.Query("year")
@@ -21,69 +26,49 @@ func GetStatistics(c *gin.Context) {
 		return
 	}
 
-	// 建立動態 WHERE 條件
-	var conditions []string
-	var params []interface{}
-
+	filter := models.StatisticFilter{Month: month, Year: year}
 	if month != "" {
-		conditions = append(conditions, "strftime('%Y-%m', r.date) = ?")
-		params = append(params, month)
-	} else {
-		conditions = append(conditions, "strftime('%Y', r.date) = ?")
-		params = append(params, year)
-	}
-
-	if accountID != "" {
-		conditions = append(conditions, "r.account_id = ?")
-		params = append(params, accountID)
+		filter.Year = ""
 	}
+	filter.AccountID, _ = strconv.Atoi(accountID)
+	filter.CategoryID, _ = strconv.Atoi(categoryID)
+	filter.IncludeTransfers = c.Query("include_transfers") == "true"
 
-	if categoryID != "" {
-		conditions = append(conditions, "r.category_id = ?")
-		params = append(params, categoryID)
+	base, ok := baseCurrency(c)
+	if !ok {
+		return
 	}
 
-	whereClause := strings.Join(conditions, " AND ")
-
-	// 查詢各分類的支出統計
-	rows, err := initializers.DB.Query(`
-		SELECT c.id, c.name, r.type, SUM(r.amount) as total
-		FROM records r
-		JOIN categories c ON r.category_id = c.id
-		WHERE `+whereClause+`
-		GROUP BY c.id, c.name, r.type
-		ORDER BY total DESC
-	`, params...)
+	// 查詢各分類的收支統計，再換算為同一幣別
+	totals, err := bookStore(c).Records().CategoryTotals(filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "查詢統計失敗"})
 		return
 	}
-	defer rows.Close()
+	totals, err = services.Exchange.ConvertCategoryTotals(totals, base, periodEnd(month, year))
+	if err != nil {
+		respondConvertError(c, err)
+		return
+	}
 
 	type CategoryStat struct {
-		ID         int     `json:"id"`
-		Name       string  `json:"name"`
-		Amount     float64 `json:"amount"`
-		Percentage float64 `json:"percentage"`
+		ID         int          `json:"id"`
+		Name       string       `json:"name"`
+		Amount     models.Money `json:"amount"`
+		Percentage float64      `json:"percentage"`
 	}
 
 	var expenseCategories []CategoryStat
 	var incomeCategories []CategoryStat
-	var totalIncome, totalExpense float64
-
-	for rows.Next() {
-		var id int
-		var name, recordType string
-		var total float64
-		if err := rows.Scan(&id, &name, &recordType, &total); err != nil {
-			continue
-		}
-		stat := CategoryStat{ID: id, Name: name, Amount: total}
-		if recordType == "收入" {
-			totalIncome += total
+	var totalIncome, totalExpense models.Money
+
+	for _, t := range totals {
+		stat := CategoryStat{ID: t.CategoryID, Name: t.CategoryName, Amount: t.Total}
+		if t.Type == "收入" {
+			totalIncome += t.Total
 			incomeCategories = append(incomeCategories, stat)
 		} else {
-			totalExpense += total
+			totalExpense += t.Total
 			expenseCategories = append(expenseCategories, stat)
 		}
 	}
@@ -91,12 +76,12 @@ func GetStatistics(c *gin.Context) {
 	// 計算各分類的百分比
 	for i := range expenseCategories {
 		if totalExpense > 0 {
-			expenseCategories[i].Percentage = expenseCategories[i].Amount / totalExpense * 100
+			expenseCategories[i].Percentage = expenseCategories[i].Amount.Float64() / totalExpense.Float64() * 100
 		}
 	}
 	for i := range incomeCategories {
 		if totalIncome > 0 {
-			incomeCategories[i].Percentage = incomeCategories[i].Amount / totalIncome * 100
+			incomeCategories[i].Percentage = incomeCategories[i].Amount.Float64() / totalIncome.Float64() * 100
 		}
 	}
 
@@ -108,6 +93,7 @@ func GetStatistics(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"period":             period,
+		"currency":           base,
 		"total_income":       totalIncome,
 		"total_expense":      totalExpense,
 		"expense_categories": expenseCategories,
@@ -116,7 +102,7 @@ func GetStatistics(c *gin.Context) {
 }
 
 // GetSummary 取得指定月份的收支總計
-// 原因：前端統計頁頂部的總覽數字
+// 原因：前端統計頁頂部的總覽數字（轉帳與幣別規則同 GetStatistics）
 func GetSummary(c *gin.Context) {
 	month := c.Query("month")
 	if month == "" {
@@ -124,22 +110,114 @@ func GetSummary(c *gin.Context) {
 		return
 	}
 
-	var totalIncome, totalExpense float64
-
-	initializers.DB.QueryRow(`
-		SELECT COALESCE(SUM(amount), 0) FROM records
-		WHERE strftime('%Y-%m', date) = ? AND type = '收入'
-	`, month).Scan(&totalIncome)
+	base, ok := baseCurrency(c)
+	if !ok {
+		return
+	}
 
-	initializers.DB.QueryRow(`
-		SELECT COALESCE(SUM(amount), 0) FROM records
-		WHERE strftime('%Y-%m', date) = ? AND type = '支出'
-	`, month).Scan(&totalExpense)
+	filter := models.StatisticFilter{
+		Month:            month,
+		IncludeTransfers: c.Query("include_transfers") == "true",
+	}
+	totals, err := bookStore(c).Records().TypeTotals(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查詢統計失敗"})
+		return
+	}
+	totalIncome, totalExpense, err := services.Exchange.ConvertTypeTotals(totals, base, periodEnd(month, ""))
+	if err != nil {
+		respondConvertError(c, err)
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"month":         month,
+		"currency":      base,
 		"total_income":  totalIncome,
 		"total_expense": totalExpense,
 		"balance":       totalIncome - totalExpense,
 	})
 }
+
+// GetStatisticsChart 取得統計圖表（PNG）
+// 原因：網頁與 Bot 共用同一張圖，數字與 GetStatistics 相同
+// chart 為 pie（分類佔比，預設）、bar（每日或每月收支）或 line（資產餘額變化）
+// type 為 expense（預設）或 income，幣別與帳戶篩選同 GetStatistics
+func GetStatisticsChart(c *gin.Context) {
+	period := c.Query("month")
+	if period == "" {
+		period = c.Query("year")
+	}
+	if period == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "請提供 month 或 year 參數"})
+		return
+	}
+
+	recordType := "支出"
+	switch c.DefaultQuery("type", "expense") {
+	case "expense", "支出":
+	case "income", "收入":
+		recordType = "收入"
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrInvalidType.Error()})
+		return
+	}
+
+	base, ok := baseCurrency(c)
+	if !ok {
+		return
+	}
+
+	req := models.ChartRequest{
+		Kind:     c.DefaultQuery("chart", models.ChartPie),
+		Period:   period,
+		Type:     recordType,
+		Currency: base,
+	}
+	req.AccountID, _ = strconv.Atoi(c.Query("account_id"))
+
+	chart, err := bookCharts(c).Render(req)
+	switch {
+	case err == services.ErrInvalidChart || err == services.ErrInvalidPeriod:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		respondConvertError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "image/png", chart.PNG)
+}
+
+// baseCurrency 取得統計換算的目標幣別，格式錯誤時直接回覆 400
+func baseCurrency(c *gin.Context) (string, bool) {
+	code := c.DefaultQuery("currency", models.DefaultCurrency)
+	base, ok := models.NormalizeCurrency(code)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrInvalidCurrency.Error()})
+		return "", false
+	}
+	return base, true
+}
+
+// periodEnd 統計期間的最後一天，作為換算匯率的基準日
+// 原因：同一期間使用同一個匯率，總額才會等於各分類加總
+func periodEnd(month, year string) string {
+	if month != "" {
+		if t, err := time.Parse("2006-01", month); err == nil {
+			return t.AddDate(0, 1, -1).Format("2006-01-02")
+		}
+		return month + "-31"
+	}
+	return year + "-12-31"
+}
+
+// respondConvertError 回覆匯率換算失敗（缺少匯率時回 400 並說明缺少哪組匯率）
+func respondConvertError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrRateNotFound) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "查詢統計失敗"})
+}
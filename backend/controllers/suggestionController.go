@@ -0,0 +1,25 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetSuggestion 依項目名稱建議分類與帳戶
+// 原因：前端輸入項目後自動選好分類與帳戶；沒有同名紀錄時 samples 為 0，不帶分類與帳戶
+func GetSuggestion(c *gin.Context) {
+	item := strings.TrimSpace(c.Query("item"))
+	if item == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "請提供 item 參數"})
+		return
+	}
+
+	suggestion, err := bookSuggestions(c).Suggest(item)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查詢建議失敗"})
+		return
+	}
+	c.JSON(http.StatusOK, suggestion)
+}
@@ -1,8 +1,7 @@
 package controllers
 
 import (
-	"accountbook/initializers"
-	"fmt"
+	"accountbook/models"
 	"net/http"
 	"time"
 
@@ -11,15 +10,16 @@ import (
 
 // TransferInput 轉帳輸入資料
 type TransferInput struct {
-	Date          string  `json:"date"`
-	FromAccountID int     `json:"from_account_id" binding:"required"`
-	ToAccountID   int     `json:"to_account_id" binding:"required"`
-	Amount        float64 `json:"amount" binding:"required"`
-	Note          string  `json:"note"`
+	Date          string       `json:"date"`
+	FromAccountID int          `json:"from_account_id" binding:"required"`
+	ToAccountID   int          `json:"to_account_id" binding:"required"`
+	Amount        models.Money `json:"amount" binding:"required"`
+	ToAmount      models.Money `json:"to_amount"` // 跨幣別時的轉入金額，省略則依匯率換算
+	Note          string       `json:"note"`
 }
 
 // CreateTransfer 新增轉帳紀錄
-// 更新兩個帳戶餘額，並建立兩筆 records 以便在日曆中顯示
+// 由記帳服務更新兩個帳戶餘額，並建立兩筆以 transfer_id 連結的 records 以便在日曆中顯示
 func CreateTransfer(c *gin.Context) {
 	var input TransferInput
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -27,112 +27,122 @@ func CreateTransfer(c *gin.Context) {
 		return
 	}
 
-	if input.FromAccountID == input.ToAccountID {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "轉出與轉入帳戶不能相同"})
-		return
-	}
-
-	if input.Amount <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "金額必須大於 0"})
-		return
-	}
-
 	// 預設日期為今天
 	if input.Date == "" {
 		input.Date = time.Now().Format("2006-01-02")
 	}
 
-	// 取得帳戶名稱
-	var fromName, toName string
-	initializers.DB.QueryRow("SELECT name FROM accounts WHERE id = ?", input.FromAccountID).Scan(&fromName)
-	initializers.DB.QueryRow("SELECT name FROM accounts WHERE id = ?", input.ToAccountID).Scan(&toName)
-
-	if fromName == "" || toName == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "帳戶不存在"})
+	transfer := &models.Transfer{
+		Date:          input.Date,
+		FromAccountID: input.FromAccountID,
+		ToAccountID:   input.ToAccountID,
+		Amount:        input.Amount,
+		ToAmount:      input.ToAmount,
+		Note:          input.Note,
+	}
+	if err := bookLedger(c).PostTransfer(transfer); err != nil {
+		respondLedgerError(c, err, "轉帳失敗")
 		return
 	}
 
-	// 取得或建立「轉帳」分類
-	categoryID := getTransferCategoryID()
+	// 取得帳戶名稱回傳
+	store := bookStore(c)
+	from, _ := store.Accounts().Get(input.FromAccountID)
+	to, _ := store.Accounts().Get(input.ToAccountID)
 
-	tx, err := initializers.DB.Begin()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "交易開始失敗"})
+	c.JSON(http.StatusCreated, gin.H{
+		"message":      "轉帳成功",
+		"id":           transfer.ID,
+		"date":         input.Date,
+		"from_account": from.Name,
+		"to_account":   to.Name,
+		"amount":       transfer.Amount,
+		"to_amount":    transfer.ToAmount,
+		"note":         input.Note,
+	})
+}
+
+// GetTransfer 取得單一轉帳（含轉出、轉入兩筆紀錄）
+func GetTransfer(c *gin.Context) {
+	id, ok := paramID(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "找不到該轉帳"})
 		return
 	}
 
-	now := time.Now().Format("2006-01-02 15:04:05")
-
-	// 扣除轉出帳戶餘額
-	_, err = tx.Exec("UPDATE accounts SET balance = balance - ?, updated_at = ? WHERE id = ?",
-		input.Amount, now, input.FromAccountID)
+	store := bookStore(c)
+	t, err := store.Transfers().Get(id)
 	if err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "轉帳失敗"})
+		c.JSON(http.StatusNotFound, gin.H{"error": "找不到該轉帳"})
 		return
 	}
 
-	// 增加轉入帳戶餘額
-	_, err = tx.Exec("UPDATE accounts SET balance = balance + ?, updated_at = ? WHERE id = ?",
-		input.Amount, now, input.ToAccountID)
+	t.Records, err = store.Records().ListByTransfer(id)
 	if err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "轉帳失敗"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查詢轉帳失敗"})
 		return
 	}
 
-	// 建立轉出紀錄（支出）
-	outItem := fmt.Sprintf("轉帳至 %s", toName)
-	_, err = tx.Exec(
-		"INSERT INTO records (date, account_id, type, amount, item, category_id, note, created_at, updated_at) VALUES (?, ?, '支出', ?, ?, ?, ?, ?, ?)",
-		input.Date, input.FromAccountID, input.Amount, outItem, categoryID, input.Note, now, now,
-	)
-	if err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "建立轉帳紀錄失敗"})
+	c.JSON(http.StatusOK, t)
+}
+
+// UpdateTransfer 更新轉帳
+// 原因：兩筆紀錄與兩個帳戶餘額需一起修改，避免只改到其中一邊
+func UpdateTransfer(c *gin.Context) {
+	id, ok := paramID(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "找不到該轉帳"})
 		return
 	}
 
-	// 建立轉入紀錄（收入）
-	inItem := fmt.Sprintf("從 %s 轉入", fromName)
-	_, err = tx.Exec(
-		"INSERT INTO records (date, account_id, type, amount, item, category_id, note, created_at, updated_at) VALUES (?, ?, '收入', ?, ?, ?, ?, ?, ?)",
-		input.Date, input.ToAccountID, input.Amount, inItem, categoryID, input.Note, now, now,
-	)
-	if err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "建立轉帳紀錄失敗"})
+	var input TransferInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "輸入格式錯誤，請確認必填欄位"})
 		return
 	}
 
-	if err = tx.Commit(); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "交易提交失敗"})
+	// 未提供日期時沿用原本的日期（讀出的 DATE 可能帶有時間部分，只取日期）
+	if input.Date == "" {
+		existing, err := bookStore(c).Transfers().Get(id)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "找不到該轉帳"})
+			return
+		}
+		input.Date = existing.Date
+		if len(input.Date) > 10 {
+			input.Date = input.Date[:10]
+		}
+	}
+
+	transfer := &models.Transfer{
+		ID:            id,
+		Date:          input.Date,
+		FromAccountID: input.FromAccountID,
+		ToAccountID:   input.ToAccountID,
+		Amount:        input.Amount,
+		ToAmount:      input.ToAmount,
+		Note:          input.Note,
+	}
+	if err := bookLedger(c).AmendTransfer(transfer); err != nil {
+		respondLedgerError(c, err, "更新轉帳失敗")
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"message":      "轉帳成功",
-		"date":         input.Date,
-		"from_account": fromName,
-		"to_account":   toName,
-		"amount":       input.Amount,
-		"note":         input.Note,
-	})
+	c.JSON(http.StatusOK, gin.H{"message": "更新成功"})
 }
 
-// getTransferCategoryID 取得或建立「轉帳」分類的 ID
-func getTransferCategoryID() int {
-	var id int
-	err := initializers.DB.QueryRow("SELECT id FROM categories WHERE name = '轉帳'").Scan(&id)
-	if err == nil {
-		return id
+// DeleteTransfer 刪除轉帳，兩筆紀錄一併刪除並回滾帳戶餘額
+func DeleteTransfer(c *gin.Context) {
+	id, ok := paramID(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "找不到該轉帳"})
+		return
 	}
 
-	// 不存在則建立
-	result, err := initializers.DB.Exec("INSERT INTO categories (name, sort_order) VALUES ('轉帳', 999)")
-	if err != nil {
-		return 1 // fallback
+	if err := bookLedger(c).VoidTransfer(id); err != nil {
+		respondLedgerError(c, err, "刪除轉帳失敗")
+		return
 	}
-	newID, _ := result.LastInsertId()
-	return int(newID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "刪除成功"})
 }
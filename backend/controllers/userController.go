@@ -0,0 +1,228 @@
+package controllers
+
+import (
+	"accountbook/models"
+	"accountbook/repository"
+	"accountbook/services"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// userIDKey gin.Context 中存放目前使用者 ID 的鍵
+const userIDKey = "userID"
+
+// CurrentUser 依 X-User-ID 標頭決定目前請求的使用者（關閉認證時由 Authenticate 使用）
+// 原因：前端未指定使用者時視為預設使用者，升級前的前端不需修改即可使用
+func CurrentUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := models.DefaultUserID
+		if header := c.GetHeader("X-User-ID"); header != "" {
+			id, err := strconv.Atoi(header)
+			if err != nil || id <= 0 {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "X-User-ID 格式錯誤"})
+				return
+			}
+			if _, err := repository.Default.Users().Get(id); err != nil {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": services.ErrUserNotFound.Error()})
+				return
+			}
+			userID = id
+		}
+		c.Set(userIDKey, userID)
+		c.Next()
+	}
+}
+
+// currentUserID 取得目前請求的使用者 ID（未經過 CurrentUser 時為預設使用者）
+func currentUserID(c *gin.Context) int {
+	if id, ok := c.Get(userIDKey); ok {
+		return id.(int)
+	}
+	return models.DefaultUserID
+}
+
+// GetUsers 取得所有使用者
+func GetUsers(c *gin.Context) {
+	users, err := repository.Default.Users().List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查詢使用者失敗"})
+		return
+	}
+	if users == nil {
+		users = []models.User{}
+	}
+	c.JSON(http.StatusOK, users)
+}
+
+// GetMe 取得目前請求的使用者
+func GetMe(c *gin.Context) {
+	u, err := repository.Default.Users().Get(currentUserID(c))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": services.ErrUserNotFound.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, u)
+}
+
+// CreateUser 新增使用者（限管理員）
+// 原因：新使用者會一併建立個人帳本；提供密碼時一併設定，之後即可登入
+func CreateUser(c *gin.Context) {
+	var input struct {
+		Username    string `json:"username" binding:"required"`
+		DisplayName string `json:"display_name"`
+		Password    string `json:"password"`
+		IsAdmin     bool   `json:"is_admin"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "請提供使用者名稱"})
+		return
+	}
+	if input.Password != "" {
+		if err := services.ValidatePassword(input.Password); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	user := &models.User{Username: input.Username, DisplayName: input.DisplayName, IsAdmin: input.IsAdmin}
+	if err := services.Users.CreateUser(user); err != nil {
+		respondUserError(c, err, "新增使用者失敗")
+		return
+	}
+	if input.Password != "" {
+		if err := services.Auth.SetPassword(user.ID, input.Password); err != nil {
+			respondAuthError(c, err, "設定密碼失敗")
+			return
+		}
+		user.HasPassword = true
+	}
+	c.JSON(http.StatusCreated, user)
+}
+
+// UpdateUser 修改使用者顯示名稱（本人或管理員）
+func UpdateUser(c *gin.Context) {
+	id, ok := paramID(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": services.ErrUserNotFound.Error()})
+		return
+	}
+	if !requireSelf(c, id) {
+		return
+	}
+
+	var input struct {
+		DisplayName string `json:"display_name" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "請提供顯示名稱"})
+		return
+	}
+
+	if err := repository.Default.Users().SetDisplayName(id, input.DisplayName); err != nil {
+		respondUserError(c, err, "更新使用者失敗")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "更新成功"})
+}
+
+// GetUserTelegramChats 取得使用者綁定的 Telegram 聊天室（本人或管理員）
+func GetUserTelegramChats(c *gin.Context) {
+	id, ok := paramID(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": services.ErrUserNotFound.Error()})
+		return
+	}
+	if !requireSelf(c, id) {
+		return
+	}
+
+	chats, err := repository.Default.TelegramChats().ListByUser(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查詢聊天室失敗"})
+		return
+	}
+	if chats == nil {
+		chats = []models.TelegramChat{}
+	}
+	c.JSON(http.StatusOK, chats)
+}
+
+// LinkTelegramChat 將 Telegram 聊天室綁定到使用者（限管理員）
+// 原因：之後該聊天室透過 Bot 新增的紀錄都會記到此使用者；綁定的聊天室即可使用 Bot，
+// 一般使用者需在聊天室輸入 /pair 配對，證明自己在該聊天室中
+func LinkTelegramChat(c *gin.Context) {
+	id, ok := paramID(c)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": services.ErrUserNotFound.Error()})
+		return
+	}
+
+	var input struct {
+		ChatID int64 `json:"chat_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "請提供 chat_id"})
+		return
+	}
+
+	if err := services.Users.LinkChat(input.ChatID, id); err != nil {
+		respondUserError(c, err, "綁定聊天室失敗")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "綁定成功", "chat_id": input.ChatID, "user_id": id})
+}
+
+// CreateTelegramPairCode 產生目前使用者的 Telegram 配對碼
+// 原因：在聊天室輸入 /pair <配對碼> 即可綁定，不需先查詢 chat ID
+func CreateTelegramPairCode(c *gin.Context) {
+	code, expiresAt, err := services.TelegramAuth.CreatePairCode(currentUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "產生配對碼失敗"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": code, "expires_at": expiresAt.UTC().Format(time.RFC3339)})
+}
+
+// UnlinkTelegramChat 解除 Telegram 聊天室的綁定（之後需重新配對才能使用 Bot），限綁定的使用者或管理員
+func UnlinkTelegramChat(c *gin.Context) {
+	chatID, err := strconv.ParseInt(c.Param("chat_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "找不到該聊天室"})
+		return
+	}
+	chat, err := repository.Default.TelegramChats().Get(chatID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "找不到該聊天室"})
+		return
+	}
+	if !requireSelf(c, chat.UserID) {
+		return
+	}
+
+	if err := repository.Default.TelegramChats().Unlink(chatID); err != nil {
+		if err == repository.ErrNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "找不到該聊天室"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "解除綁定失敗"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "已解除綁定"})
+}
+
+// respondUserError 依使用者服務的錯誤回覆對應的 HTTP 狀態
+func respondUserError(c *gin.Context, err error, fallback string) {
+	switch err {
+	case services.ErrUserNotFound, repository.ErrNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": services.ErrUserNotFound.Error()})
+	case services.ErrInvalidUsername, services.ErrUsernameTaken:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case services.ErrChatLinked:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -0,0 +1,29 @@
+package initializers
+
+import (
+	"accountbook/models"
+	"log"
+	"strconv"
+	"strings"
+)
+
+// LoadCurrencySettings 依環境變數設定預設幣別與各幣別的小數位數
+// DEFAULT_CURRENCY=TWD
+// CURRENCY_DECIMALS=TWD:0,USD:2（逗號分隔，未列出的幣別沿用內建值）
+func LoadCurrencySettings() {
+	models.DefaultCurrency = strings.ToUpper(GetEnv("DEFAULT_CURRENCY", models.DefaultCurrency))
+
+	setting := GetEnv("CURRENCY_DECIMALS", "")
+	if setting == "" {
+		return
+	}
+	for _, pair := range strings.Split(setting, ",") {
+		code, value, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		decimals, err := strconv.Atoi(strings.TrimSpace(value))
+		if !ok || code == "" || err != nil {
+			log.Printf("CURRENCY_DECIMALS 格式錯誤，略過：%s", pair)
+			continue
+		}
+		models.SetCurrencyDecimals(strings.TrimSpace(code), decimals)
+	}
+}
@@ -1,6 +1,7 @@
 package initializers
 
 import (
+	"accountbook/models"
 	"database/sql"
 	"log"
 
@@ -11,8 +12,22 @@ import (
 var DB *sql.DB
 
 // InitDB 初始化 SQLite 資料庫連線與資料表
-// 原因：程式啟動時建立連線，並確保資料表結構存在
+// 原因：程式啟動時建立連線，並確保資料表結構為最新版本
 func InitDB(dbPath string) {
+	OpenDB(dbPath)
+
+	// 檢查並套用 schema migration
+	runMigrations()
+
+	// 插入預設資料
+	insertDefaults()
+
+	log.Println("資料庫初始化完成")
+}
+
+// OpenDB 開啟 SQLite 資料庫連線（不套用 migration）
+// 原因：migrate 子指令需要在不自動升級的情況下檢視或操作 schema 版本
+func OpenDB(dbPath string) {
 	var err error
 	DB, err = sql.Open("sqlite", dbPath+"?_pragma=foreign_keys(1)")
 	if err != nil {
@@ -23,110 +38,40 @@ func InitDB(dbPath string) {
 	if err = DB.Ping(); err != nil {
 		log.Fatalf("資料庫連線失敗: %v", err)
 	}
-
-	// 建立資料表結構
-	createTables()
-
-	// 插入預設資料
-	insertDefaults()
-
-	log.Println("資料庫初始化完成")
 }
 
-// createTables 建立所有資料表
-// 原因：使用 IF NOT EXISTS 確保重複執行不會報錯
-func createTables() {
-	statements := []string{
-		// 帳戶資料表
-		`CREATE TABLE IF NOT EXISTS accounts (
-			id          INTEGER PRIMARY KEY AUTOINCREMENT,
-			name        TEXT    NOT NULL UNIQUE,
-			balance     REAL    NOT NULL DEFAULT 0,
-			sort_order  INTEGER NOT NULL DEFAULT 0,
-			created_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
-			updated_at  DATETIME DEFAULT CURRENT_TIMESTAMP
-		)`,
-
-		// 分類資料表
-		`CREATE TABLE IF NOT EXISTS categories (
-			id          INTEGER PRIMARY KEY AUTOINCREMENT,
-			name        TEXT    NOT NULL UNIQUE,
-			sort_order  INTEGER NOT NULL DEFAULT 0,
-			created_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
-			updated_at  DATETIME DEFAULT CURRENT_TIMESTAMP
-		)`,
-
-		// 記帳紀錄資料表
-		`CREATE TABLE IF NOT EXISTS records (
-			id          INTEGER PRIMARY KEY AUTOINCREMENT,
-			date        DATE    NOT NULL,
-			account_id  INTEGER NOT NULL,
-			type        TEXT    NOT NULL DEFAULT '支出',
-			amount      REAL    NOT NULL,
-			item        TEXT    NOT NULL,
-			category_id INTEGER NOT NULL,
-			note        TEXT    DEFAULT '',
-			created_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
-			updated_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
-			FOREIGN KEY (account_id)  REFERENCES accounts(id),
-			FOREIGN KEY (category_id) REFERENCES categories(id)
-		)`,
-
-		// 索引：加速日期查詢（首頁行事曆最常用）
-		`CREATE INDEX IF NOT EXISTS idx_records_date ON records(date)`,
-
-		// 索引：加速分類統計
-		`CREATE INDEX IF NOT EXISTS idx_records_category ON records(category_id)`,
-
-		// 索引：加速帳戶查詢
-		`CREATE INDEX IF NOT EXISTS idx_records_account ON records(account_id)`,
+// runMigrations 套用尚未執行的 migration
+// 原因：資料庫版本高於程式時拒絕啟動，避免舊版程式寫壞新版資料
+func runMigrations() {
+	applied, err := MigrateUp(DB)
+	if err != nil {
+		log.Fatalf("套用 migration 失敗: %v", err)
 	}
-
-	for _, stmt := range statements {
-		if _, err := DB.Exec(stmt); err != nil {
-			log.Fatalf("建立資料表失敗: %v\nSQL: %s", err, stmt)
-		}
+	if applied > 0 {
+		log.Printf("已套用 %d 個 migration，目前 schema 版本：%d", applied, LatestVersion())
 	}
 }
 
 // insertDefaults 插入預設資料
-// 原因：首次啟動時提供預設帳戶與分類，避免空白系統
+// 原因：首次啟動時為預設帳本提供預設帳戶與分類，避免空白系統
 // 若已有資料則不插入，避免重啟時覆蓋使用者自訂資料
+// 其他帳本的預設資料於建立帳本時一併建立
 func insertDefaults() {
 	// 若已有帳戶則跳過
 	var accountCount int
-	DB.QueryRow("SELECT COUNT(*) FROM accounts").Scan(&accountCount)
+	DB.QueryRow("SELECT COUNT(*) FROM accounts WHERE book_id = ?", models.DefaultBookID).Scan(&accountCount)
 	if accountCount == 0 {
-		accounts := []struct {
-			name      string
-			sortOrder int
-		}{
-			{"現金", 0},
-			{"信用卡", 1},
-			{"銀行帳戶", 2},
-		}
-		for _, a := range accounts {
-			DB.Exec("INSERT INTO accounts (name, balance, sort_order) VALUES (?, 0, ?)", a.name, a.sortOrder)
+		for i, name := range models.DefaultAccountNames {
+			DB.Exec("INSERT INTO accounts (book_id, name, balance, currency, sort_order) VALUES (?, ?, 0, ?, ?)", models.DefaultBookID, name, models.DefaultCurrency, i)
 		}
 	}
 
 	// 若已有分類則跳過
 	var categoryCount int
-	DB.QueryRow("SELECT COUNT(*) FROM categories").Scan(&categoryCount)
+	DB.QueryRow("SELECT COUNT(*) FROM categories WHERE book_id = ?", models.DefaultBookID).Scan(&categoryCount)
 	if categoryCount == 0 {
-		categories := []struct {
-			name      string
-			sortOrder int
-		}{
-			{"飲食", 0},
-			{"交通", 1},
-			{"服飾", 2},
-			{"3C", 3},
-			{"娛樂", 4},
-			{"其他", 5},
-		}
-		for _, c := range categories {
-			DB.Exec("INSERT INTO categories (name, sort_order) VALUES (?, ?)", c.name, c.sortOrder)
+		for i, name := range models.DefaultCategoryNames {
+			DB.Exec("INSERT INTO categories (book_id, name, sort_order) VALUES (?, ?, ?)", models.DefaultBookID, name, i)
 		}
 	}
 }
@@ -0,0 +1,258 @@
+package initializers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Migration 單一版本的資料庫結構變更
+// 原因：CREATE TABLE IF NOT EXISTS 無法為既有資料庫新增欄位，需以版本號逐步升級
+type Migration struct {
+	Version int
+	Name    string
+	Up      []string
+	Down    []string // 可為空，代表此版本不支援降版
+}
+
+// MigrationStatus 單一 migration 的套用狀態
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt string
+}
+
+// ensureMigrationTable 建立 schema_migrations 資料表
+// 原因：記錄已套用的版本，重啟時只執行尚未套用的 migration
+func ensureMigrationTable(db *sql.DB) error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version     INTEGER PRIMARY KEY,
+		name        TEXT    NOT NULL,
+		applied_at  DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	return err
+}
+
+// LatestVersion 取得目前程式支援的最新 schema 版本
+func LatestVersion() int {
+	if len(migrations) == 0 {
+		return 0
+	}
+	return migrations[len(migrations)-1].Version
+}
+
+// CurrentVersion 取得資料庫目前的 schema 版本
+func CurrentVersion(db *sql.DB) (int, error) {
+	if err := ensureMigrationTable(db); err != nil {
+		return 0, err
+	}
+	var version int
+	err := db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version)
+	return version, err
+}
+
+// MigrateUp 依序套用所有尚未執行的 migration，回傳套用的數量
+func MigrateUp(db *sql.DB) (int, error) {
+	current, err := CurrentVersion(db)
+	if err != nil {
+		return 0, fmt.Errorf("讀取 schema 版本失敗: %v", err)
+	}
+	if current > LatestVersion() {
+		return 0, fmt.Errorf("資料庫版本 %d 高於程式支援的版本 %d，請更新程式", current, LatestVersion())
+	}
+
+	applied := 0
+	for _, m := range migrations {
+		if m.Version <= current {
+			continue
+		}
+		if err := applyMigration(db, m, true); err != nil {
+			return applied, err
+		}
+		applied++
+	}
+	return applied, nil
+}
+
+// MigrateDown 回滾最新一個已套用的 migration
+func MigrateDown(db *sql.DB) (*Migration, error) {
+	current, err := CurrentVersion(db)
+	if err != nil {
+		return nil, fmt.Errorf("讀取 schema 版本失敗: %v", err)
+	}
+	if current == 0 {
+		return nil, fmt.Errorf("沒有可回滾的 migration")
+	}
+
+	for i := range migrations {
+		m := migrations[i]
+		if m.Version != current {
+			continue
+		}
+		if len(m.Down) == 0 {
+			return nil, fmt.Errorf("migration %d (%s) 不支援回滾", m.Version, m.Name)
+		}
+		if err := applyMigration(db, m, false); err != nil {
+			return nil, err
+		}
+		return &m, nil
+	}
+	return nil, fmt.Errorf("資料庫版本 %d 不在程式已知的 migration 中", current)
+}
+
+// MigrationStatuses 列出所有 migration 及其套用狀態
+func MigrationStatuses(db *sql.DB) ([]MigrationStatus, error) {
+	if err := ensureMigrationTable(db); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query("SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	appliedAt := make(map[int]string)
+	for rows.Next() {
+		var version int
+		var at string
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		appliedAt[version] = at
+	}
+
+	var statuses []MigrationStatus
+	for _, m := range migrations {
+		at, ok := appliedAt[m.Version]
+		statuses = append(statuses, MigrationStatus{
+			Version:   m.Version,
+			Name:      m.Name,
+			Applied:   ok,
+			AppliedAt: at,
+		})
+	}
+	return statuses, nil
+}
+
+// applyMigration 在單一 Transaction 中執行 migration 並更新版本紀錄
+// 原因：SQLite 變更欄位型別需重建資料表，必須暫時關閉外鍵檢查；
+// PRAGMA foreign_keys 無法在 Transaction 內切換，因此固定使用同一條連線，
+// 並於提交前以 foreign_key_check 確認資料仍然一致
+func applyMigration(db *sql.DB, m Migration, up bool) error {
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "PRAGMA foreign_keys = OFF"); err != nil {
+		return err
+	}
+	defer conn.ExecContext(ctx, "PRAGMA foreign_keys = ON")
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	statements := m.Up
+	if !up {
+		statements = m.Down
+	}
+	for _, stmt := range statements {
+		if _, err := tx.Exec(stmt); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d (%s) 執行失敗: %v\nSQL: %s", m.Version, m.Name, err, stmt)
+		}
+	}
+
+	if err := checkForeignKeys(tx); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("migration %d (%s) 外鍵檢查失敗: %v", m.Version, m.Name, err)
+	}
+
+	if up {
+		_, err = tx.Exec("INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)",
+			m.Version, m.Name, time.Now().Format("2006-01-02 15:04:05"))
+	} else {
+		_, err = tx.Exec("DELETE FROM schema_migrations WHERE version = ?", m.Version)
+	}
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("更新 schema 版本失敗: %v", err)
+	}
+
+	return tx.Commit()
+}
+
+// checkForeignKeys 確認目前資料沒有違反外鍵約束
+func checkForeignKeys(tx *sql.Tx) error {
+	rows, err := tx.Query("PRAGMA foreign_key_check")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		var table, parent string
+		var rowID sql.NullInt64
+		var fkID int
+		rows.Scan(&table, &rowID, &parent, &fkID)
+		return fmt.Errorf("資料表 %s 第 %d 列參照的 %s 不存在", table, rowID.Int64, parent)
+	}
+	return rows.Err()
+}
+
+// RunMigrateCommand 執行 migrate 子指令
+// 用法：accountbook-server migrate [status|up|down]
+// 原因：Docker 部署升級前可先查看或手動套用 schema 變更
+func RunMigrateCommand(args []string) {
+	action := "status"
+	if len(args) > 0 {
+		action = args[0]
+	}
+
+	switch action {
+	case "status":
+		statuses, err := MigrationStatuses(DB)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "讀取 migration 狀態失敗: %v\n", err)
+			os.Exit(1)
+		}
+		current, _ := CurrentVersion(DB)
+		fmt.Printf("資料庫版本：%d（程式支援：%d）\n", current, LatestVersion())
+		for _, s := range statuses {
+			mark := "[ ]"
+			if s.Applied {
+				mark = "[x]"
+			}
+			fmt.Printf("%s %04d %s %s\n", mark, s.Version, s.Name, s.AppliedAt)
+		}
+
+	case "up":
+		applied, err := MigrateUp(DB)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		current, _ := CurrentVersion(DB)
+		fmt.Printf("已套用 %d 個 migration，目前版本：%d\n", applied, current)
+
+	case "down":
+		m, err := MigrateDown(DB)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("已回滾 migration %04d %s\n", m.Version, m.Name)
+
+	default:
+		fmt.Fprintf(os.Stderr, "未知的 migrate 指令：%s（可用：status、up、down）\n", action)
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,901 @@
+package initializers
+
+// migrations 所有 schema 版本，依版本號遞增排列
+// 原因：新增欄位或資料表時只需在尾端追加新版本，既有資料庫啟動時會自動升級
+// 注意：已發布的 migration 不可修改，只能新增
+var migrations = []Migration{
+	{
+		// 初始結構：沿用原本 createTables 的 IF NOT EXISTS，既有資料庫可直接接軌
+		Version: 1,
+		Name:    "initial_schema",
+		Up: []string{
+			// 帳戶資料表
+			`CREATE TABLE IF NOT EXISTS accounts (
+				id          INTEGER PRIMARY KEY AUTOINCREMENT,
+				name        TEXT    NOT NULL UNIQUE,
+				balance     REAL    NOT NULL DEFAULT 0,
+				sort_order  INTEGER NOT NULL DEFAULT 0,
+				created_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+				updated_at  DATETIME DEFAULT CURRENT_TIMESTAMP
+			)`,
+
+			// 分類資料表
+			`CREATE TABLE IF NOT EXISTS categories (
+				id          INTEGER PRIMARY KEY AUTOINCREMENT,
+				name        TEXT    NOT NULL UNIQUE,
+				sort_order  INTEGER NOT NULL DEFAULT 0,
+				created_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+				updated_at  DATETIME DEFAULT CURRENT_TIMESTAMP
+			)`,
+
+			// 記帳紀錄資料表
+			`CREATE TABLE IF NOT EXISTS records (
+				id          INTEGER PRIMARY KEY AUTOINCREMENT,
+				date        DATE    NOT NULL,
+				account_id  INTEGER NOT NULL,
+				type        TEXT    NOT NULL DEFAULT '支出',
+				amount      REAL    NOT NULL,
+				item        TEXT    NOT NULL,
+				category_id INTEGER NOT NULL,
+				note        TEXT    DEFAULT '',
+				created_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+				updated_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (account_id)  REFERENCES accounts(id),
+				FOREIGN KEY (category_id) REFERENCES categories(id)
+			)`,
+
+			// 索引：加速日期查詢（首頁行事曆最常用）
+			`CREATE INDEX IF NOT EXISTS idx_records_date ON records(date)`,
+
+			// 索引：加速分類統計
+			`CREATE INDEX IF NOT EXISTS idx_records_category ON records(category_id)`,
+
+			// 索引：加速帳戶查詢
+			`CREATE INDEX IF NOT EXISTS idx_records_account ON records(account_id)`,
+		},
+	},
+	{
+		// 帳戶期初餘額：balance 為 opening_balance + Σ收入 − Σ支出 的快取
+		// 原因：既有帳戶以目前餘額反推期初餘額，升級後餘額不變
+		Version: 2,
+		Name:    "account_opening_balance",
+		Up: []string{
+			`ALTER TABLE accounts ADD COLUMN opening_balance REAL NOT NULL DEFAULT 0`,
+			`UPDATE accounts SET opening_balance = balance - COALESCE((
+				SELECT SUM(CASE WHEN r.type = '支出' THEN -r.amount ELSE r.amount END)
+				FROM records r WHERE r.account_id = accounts.id
+			), 0)`,
+		},
+		Down: []string{
+			`ALTER TABLE accounts DROP COLUMN opening_balance`,
+		},
+	},
+	{
+		// 轉帳資料表：以 records.transfer_id 連結轉出、轉入兩筆紀錄
+		// 原因：舊版轉帳為兩筆互不相關的紀錄，刪除其中一筆會留下半筆轉帳
+		// 既有資料：由舊版轉帳程式連續建立的「轉帳至 X」「從 Y 轉入」兩筆紀錄配對為一筆轉帳
+		Version: 3,
+		Name:    "transfers",
+		Up: []string{
+			`CREATE TABLE transfers (
+				id              INTEGER PRIMARY KEY AUTOINCREMENT,
+				date            DATE    NOT NULL,
+				from_account_id INTEGER NOT NULL,
+				to_account_id   INTEGER NOT NULL,
+				amount          REAL    NOT NULL,
+				note            TEXT    DEFAULT '',
+				created_at      DATETIME DEFAULT CURRENT_TIMESTAMP,
+				updated_at      DATETIME DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (from_account_id) REFERENCES accounts(id),
+				FOREIGN KEY (to_account_id)   REFERENCES accounts(id)
+			)`,
+			`ALTER TABLE records ADD COLUMN transfer_id INTEGER REFERENCES transfers(id)`,
+			`CREATE INDEX idx_records_transfer ON records(transfer_id)`,
+
+			// 配對舊版轉帳（轉帳 ID 沿用轉出紀錄的 ID）
+			`INSERT INTO transfers (id, date, from_account_id, to_account_id, amount, note, created_at, updated_at)
+			SELECT o.id, o.date, o.account_id, i.account_id, o.amount, COALESCE(o.note, ''), o.created_at, o.updated_at
+			FROM records o
+			JOIN records i ON i.id = o.id + 1
+			JOIN categories c ON c.id = o.category_id AND c.id = i.category_id
+			WHERE c.name = '轉帳'
+				AND o.type = '支出' AND i.type = '收入'
+				AND o.date = i.date AND o.amount = i.amount
+				AND o.item LIKE '轉帳至 %' AND i.item LIKE '從 % 轉入'`,
+			`UPDATE records SET transfer_id = id
+			WHERE id IN (SELECT id FROM transfers)`,
+			`UPDATE records SET transfer_id = id - 1
+			WHERE transfer_id IS NULL AND type = '收入' AND (id - 1) IN (SELECT id FROM transfers)`,
+		},
+		Down: []string{
+			// transfer_id 具外鍵約束無法直接 DROP COLUMN，需重建 records
+			`CREATE TABLE records_old (
+				id          INTEGER PRIMARY KEY AUTOINCREMENT,
+				date        DATE    NOT NULL,
+				account_id  INTEGER NOT NULL,
+				type        TEXT    NOT NULL DEFAULT '支出',
+				amount      REAL    NOT NULL,
+				item        TEXT    NOT NULL,
+				category_id INTEGER NOT NULL,
+				note        TEXT    DEFAULT '',
+				created_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+				updated_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (account_id)  REFERENCES accounts(id),
+				FOREIGN KEY (category_id) REFERENCES categories(id)
+			)`,
+			`INSERT INTO records_old (id, date, account_id, type, amount, item, category_id, note, created_at, updated_at)
+			SELECT id, date, account_id, type, amount, item, category_id, note, created_at, updated_at FROM records`,
+			`DROP TABLE records`,
+			`ALTER TABLE records_old RENAME TO records`,
+			`CREATE INDEX idx_records_date ON records(date)`,
+			`CREATE INDEX idx_records_category ON records(category_id)`,
+			`CREATE INDEX idx_records_account ON records(account_id)`,
+			`DROP TABLE transfers`,
+		},
+	},
+	{
+		// 金額改為整數（以 1/100 為單位，對應 models.Money）
+		// 原因：REAL 反覆加減會累積誤差；SQLite 無法直接修改欄位型別，需重建資料表
+		Version: 4,
+		Name:    "integer_money",
+		Up: []string{
+			// 先確認所有金額都能以 1/100 精確表示，有更細的小數時整個 migration 失敗而不是默默捨去
+			// 原因：REAL 的 12.34 實際為 12.3399999…，乘 100 後四捨五入即可還原，但 0.001 這類值無法轉換
+			`CREATE TABLE money_migration_guard (
+				value REAL CHECK (ABS(value * 100 - ROUND(value * 100)) < 0.000001)
+			)`,
+			`INSERT INTO money_migration_guard SELECT balance FROM accounts`,
+			`INSERT INTO money_migration_guard SELECT opening_balance FROM accounts`,
+			`INSERT INTO money_migration_guard SELECT amount FROM records`,
+			`INSERT INTO money_migration_guard SELECT amount FROM transfers`,
+			`DROP TABLE money_migration_guard`,
+
+			`CREATE TABLE accounts_new (
+				id              INTEGER PRIMARY KEY AUTOINCREMENT,
+				name            TEXT    NOT NULL UNIQUE,
+				balance         INTEGER NOT NULL DEFAULT 0,
+				opening_balance INTEGER NOT NULL DEFAULT 0,
+				sort_order      INTEGER NOT NULL DEFAULT 0,
+				created_at      DATETIME DEFAULT CURRENT_TIMESTAMP,
+				updated_at      DATETIME DEFAULT CURRENT_TIMESTAMP
+			)`,
+			`INSERT INTO accounts_new (id, name, balance, opening_balance, sort_order, created_at, updated_at)
+			SELECT id, name, CAST(ROUND(balance * 100) AS INTEGER), CAST(ROUND(opening_balance * 100) AS INTEGER), sort_order, created_at, updated_at FROM accounts`,
+			`DROP TABLE accounts`,
+			`ALTER TABLE accounts_new RENAME TO accounts`,
+
+			`CREATE TABLE transfers_new (
+				id              INTEGER PRIMARY KEY AUTOINCREMENT,
+				date            DATE    NOT NULL,
+				from_account_id INTEGER NOT NULL,
+				to_account_id   INTEGER NOT NULL,
+				amount          INTEGER NOT NULL,
+				note            TEXT    DEFAULT '',
+				created_at      DATETIME DEFAULT CURRENT_TIMESTAMP,
+				updated_at      DATETIME DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (from_account_id) REFERENCES accounts(id),
+				FOREIGN KEY (to_account_id)   REFERENCES accounts(id)
+			)`,
+			`INSERT INTO transfers_new (id, date, from_account_id, to_account_id, amount, note, created_at, updated_at)
+			SELECT id, date, from_account_id, to_account_id, CAST(ROUND(amount * 100) AS INTEGER), note, created_at, updated_at FROM transfers`,
+			`DROP TABLE transfers`,
+			`ALTER TABLE transfers_new RENAME TO transfers`,
+
+			`CREATE TABLE records_new (
+				id          INTEGER PRIMARY KEY AUTOINCREMENT,
+				date        DATE    NOT NULL,
+				account_id  INTEGER NOT NULL,
+				type        TEXT    NOT NULL DEFAULT '支出',
+				amount      INTEGER NOT NULL,
+				item        TEXT    NOT NULL,
+				category_id INTEGER NOT NULL,
+				note        TEXT    DEFAULT '',
+				transfer_id INTEGER,
+				created_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+				updated_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (account_id)  REFERENCES accounts(id),
+				FOREIGN KEY (category_id) REFERENCES categories(id),
+				FOREIGN KEY (transfer_id) REFERENCES transfers(id)
+			)`,
+			`INSERT INTO records_new (id, date, account_id, type, amount, item, category_id, note, transfer_id, created_at, updated_at)
+			SELECT id, date, account_id, type, CAST(ROUND(amount * 100) AS INTEGER), item, category_id, note, transfer_id, created_at, updated_at FROM records`,
+			`DROP TABLE records`,
+			`ALTER TABLE records_new RENAME TO records`,
+			`CREATE INDEX idx_records_date ON records(date)`,
+			`CREATE INDEX idx_records_category ON records(category_id)`,
+			`CREATE INDEX idx_records_account ON records(account_id)`,
+			`CREATE INDEX idx_records_transfer ON records(transfer_id)`,
+		},
+		Down: []string{
+			`CREATE TABLE accounts_new (
+				id              INTEGER PRIMARY KEY AUTOINCREMENT,
+				name            TEXT    NOT NULL UNIQUE,
+				balance         REAL    NOT NULL DEFAULT 0,
+				opening_balance REAL    NOT NULL DEFAULT 0,
+				sort_order      INTEGER NOT NULL DEFAULT 0,
+				created_at      DATETIME DEFAULT CURRENT_TIMESTAMP,
+				updated_at      DATETIME DEFAULT CURRENT_TIMESTAMP
+			)`,
+			`INSERT INTO accounts_new (id, name, balance, opening_balance, sort_order, created_at, updated_at)
+			SELECT id, name, balance / 100.0, opening_balance / 100.0, sort_order, created_at, updated_at FROM accounts`,
+			`DROP TABLE accounts`,
+			`ALTER TABLE accounts_new RENAME TO accounts`,
+
+			`CREATE TABLE transfers_new (
+				id              INTEGER PRIMARY KEY AUTOINCREMENT,
+				date            DATE    NOT NULL,
+				from_account_id INTEGER NOT NULL,
+				to_account_id   INTEGER NOT NULL,
+				amount          REAL    NOT NULL,
+				note            TEXT    DEFAULT '',
+				created_at      DATETIME DEFAULT CURRENT_TIMESTAMP,
+				updated_at      DATETIME DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (from_account_id) REFERENCES accounts(id),
+				FOREIGN KEY (to_account_id)   REFERENCES accounts(id)
+			)`,
+			`INSERT INTO transfers_new (id, date, from_account_id, to_account_id, amount, note, created_at, updated_at)
+			SELECT id, date, from_account_id, to_account_id, amount / 100.0, note, created_at, updated_at FROM transfers`,
+			`DROP TABLE transfers`,
+			`ALTER TABLE transfers_new RENAME TO transfers`,
+
+			`CREATE TABLE records_new (
+				id          INTEGER PRIMARY KEY AUTOINCREMENT,
+				date        DATE    NOT NULL,
+				account_id  INTEGER NOT NULL,
+				type        TEXT    NOT NULL DEFAULT '支出',
+				amount      REAL    NOT NULL,
+				item        TEXT    NOT NULL,
+				category_id INTEGER NOT NULL,
+				note        TEXT    DEFAULT '',
+				transfer_id INTEGER,
+				created_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+				updated_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (account_id)  REFERENCES accounts(id),
+				FOREIGN KEY (category_id) REFERENCES categories(id),
+				FOREIGN KEY (transfer_id) REFERENCES transfers(id)
+			)`,
+			`INSERT INTO records_new (id, date, account_id, type, amount, item, category_id, note, transfer_id, created_at, updated_at)
+			SELECT id, date, account_id, type, amount / 100.0, item, category_id, note, transfer_id, created_at, updated_at FROM records`,
+			`DROP TABLE records`,
+			`ALTER TABLE records_new RENAME TO records`,
+			`CREATE INDEX idx_records_date ON records(date)`,
+			`CREATE INDEX idx_records_category ON records(category_id)`,
+			`CREATE INDEX idx_records_account ON records(account_id)`,
+			`CREATE INDEX idx_records_transfer ON records(transfer_id)`,
+		},
+	},
+	{
+		// 多幣別：帳戶幣別、跨幣別轉帳的轉入金額、匯率資料表
+		// 原因：既有帳戶皆為台幣；既有轉帳兩端同幣別，轉入金額等於轉出金額
+		Version: 5,
+		Name:    "currencies",
+		Up: []string{
+			`ALTER TABLE accounts ADD COLUMN currency TEXT NOT NULL DEFAULT 'TWD'`,
+			`ALTER TABLE transfers ADD COLUMN to_amount INTEGER NOT NULL DEFAULT 0`,
+			`UPDATE transfers SET to_amount = amount`,
+
+			// 匯率：date 當天 1 單位 from_currency 可換得 rate 單位 to_currency
+			`CREATE TABLE exchange_rates (
+				id            INTEGER PRIMARY KEY AUTOINCREMENT,
+				date          DATE    NOT NULL,
+				from_currency TEXT    NOT NULL,
+				to_currency   TEXT    NOT NULL,
+				rate          REAL    NOT NULL,
+				created_at    DATETIME DEFAULT CURRENT_TIMESTAMP,
+				updated_at    DATETIME DEFAULT CURRENT_TIMESTAMP,
+				UNIQUE (date, from_currency, to_currency)
+			)`,
+			`CREATE INDEX idx_exchange_rates_pair ON exchange_rates(from_currency, to_currency, date)`,
+		},
+		Down: []string{
+			`DROP TABLE exchange_rates`,
+			`ALTER TABLE transfers DROP COLUMN to_amount`,
+			`ALTER TABLE accounts DROP COLUMN currency`,
+		},
+	},
+	{
+		// 多使用者：帳戶、分類、轉帳與紀錄加上 user_id，帳戶與分類名稱改為同一使用者內不可重複
+		// 原因：既有資料全部歸屬於預設使用者（id 1）；SQLite 無法修改 UNIQUE 約束，需重建資料表
+		// 注意：降版時若不同使用者有同名的帳戶或分類，會因唯一約束而失敗
+		Version: 6,
+		Name:    "users",
+		Up: []string{
+			`CREATE TABLE users (
+				id           INTEGER PRIMARY KEY AUTOINCREMENT,
+				username     TEXT    NOT NULL UNIQUE,
+				display_name TEXT    NOT NULL DEFAULT '',
+				created_at   DATETIME DEFAULT CURRENT_TIMESTAMP,
+				updated_at   DATETIME DEFAULT CURRENT_TIMESTAMP
+			)`,
+			`INSERT INTO users (id, username, display_name) VALUES (1, 'admin', '預設使用者')`,
+
+			// Telegram 聊天室對應的使用者
+			`CREATE TABLE telegram_chats (
+				chat_id    INTEGER PRIMARY KEY,
+				user_id    INTEGER NOT NULL,
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+			)`,
+			`CREATE INDEX idx_telegram_chats_user ON telegram_chats(user_id)`,
+
+			`CREATE TABLE accounts_new (
+				id              INTEGER PRIMARY KEY AUTOINCREMENT,
+				user_id         INTEGER NOT NULL,
+				name            TEXT    NOT NULL,
+				currency        TEXT    NOT NULL DEFAULT 'TWD',
+				balance         INTEGER NOT NULL DEFAULT 0,
+				opening_balance INTEGER NOT NULL DEFAULT 0,
+				sort_order      INTEGER NOT NULL DEFAULT 0,
+				created_at      DATETIME DEFAULT CURRENT_TIMESTAMP,
+				updated_at      DATETIME DEFAULT CURRENT_TIMESTAMP,
+				UNIQUE (user_id, name),
+				FOREIGN KEY (user_id) REFERENCES users(id)
+			)`,
+			`INSERT INTO accounts_new (id, user_id, name, currency, balance, opening_balance, sort_order, created_at, updated_at)
+			SELECT id, 1, name, currency, balance, opening_balance, sort_order, created_at, updated_at FROM accounts`,
+			`DROP TABLE accounts`,
+			`ALTER TABLE accounts_new RENAME TO accounts`,
+
+			`CREATE TABLE categories_new (
+				id          INTEGER PRIMARY KEY AUTOINCREMENT,
+				user_id     INTEGER NOT NULL,
+				name        TEXT    NOT NULL,
+				sort_order  INTEGER NOT NULL DEFAULT 0,
+				created_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+				updated_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+				UNIQUE (user_id, name),
+				FOREIGN KEY (user_id) REFERENCES users(id)
+			)`,
+			`INSERT INTO categories_new (id, user_id, name, sort_order, created_at, updated_at)
+			SELECT id, 1, name, sort_order, created_at, updated_at FROM categories`,
+			`DROP TABLE categories`,
+			`ALTER TABLE categories_new RENAME TO categories`,
+
+			`CREATE TABLE transfers_new (
+				id              INTEGER PRIMARY KEY AUTOINCREMENT,
+				user_id         INTEGER NOT NULL,
+				date            DATE    NOT NULL,
+				from_account_id INTEGER NOT NULL,
+				to_account_id   INTEGER NOT NULL,
+				amount          INTEGER NOT NULL,
+				to_amount       INTEGER NOT NULL DEFAULT 0,
+				note            TEXT    DEFAULT '',
+				created_at      DATETIME DEFAULT CURRENT_TIMESTAMP,
+				updated_at      DATETIME DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (user_id)         REFERENCES users(id),
+				FOREIGN KEY (from_account_id) REFERENCES accounts(id),
+				FOREIGN KEY (to_account_id)   REFERENCES accounts(id)
+			)`,
+			`INSERT INTO transfers_new (id, user_id, date, from_account_id, to_account_id, amount, to_amount, note, created_at, updated_at)
+			SELECT id, 1, date, from_account_id, to_account_id, amount, to_amount, note, created_at, updated_at FROM transfers`,
+			`DROP TABLE transfers`,
+			`ALTER TABLE transfers_new RENAME TO transfers`,
+
+			`CREATE TABLE records_new (
+				id          INTEGER PRIMARY KEY AUTOINCREMENT,
+				user_id     INTEGER NOT NULL,
+				date        DATE    NOT NULL,
+				account_id  INTEGER NOT NULL,
+				type        TEXT    NOT NULL DEFAULT '支出',
+				amount      INTEGER NOT NULL,
+				item        TEXT    NOT NULL,
+				category_id INTEGER NOT NULL,
+				note        TEXT    DEFAULT '',
+				transfer_id INTEGER,
+				created_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+				updated_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (user_id)     REFERENCES users(id),
+				FOREIGN KEY (account_id)  REFERENCES accounts(id),
+				FOREIGN KEY (category_id) REFERENCES categories(id),
+				FOREIGN KEY (transfer_id) REFERENCES transfers(id)
+			)`,
+			`INSERT INTO records_new (id, user_id, date, account_id, type, amount, item, category_id, note, transfer_id, created_at, updated_at)
+			SELECT id, 1, date, account_id, type, amount, item, category_id, note, transfer_id, created_at, updated_at FROM records`,
+			`DROP TABLE records`,
+			`ALTER TABLE records_new RENAME TO records`,
+			`CREATE INDEX idx_records_date ON records(date)`,
+			`CREATE INDEX idx_records_category ON records(category_id)`,
+			`CREATE INDEX idx_records_account ON records(account_id)`,
+			`CREATE INDEX idx_records_transfer ON records(transfer_id)`,
+			`CREATE INDEX idx_records_user_date ON records(user_id, date)`,
+		},
+		Down: []string{
+			`CREATE TABLE accounts_new (
+				id              INTEGER PRIMARY KEY AUTOINCREMENT,
+				name            TEXT    NOT NULL UNIQUE,
+				balance         INTEGER NOT NULL DEFAULT 0,
+				opening_balance INTEGER NOT NULL DEFAULT 0,
+				sort_order      INTEGER NOT NULL DEFAULT 0,
+				created_at      DATETIME DEFAULT CURRENT_TIMESTAMP,
+				updated_at      DATETIME DEFAULT CURRENT_TIMESTAMP,
+				currency        TEXT    NOT NULL DEFAULT 'TWD'
+			)`,
+			`INSERT INTO accounts_new (id, name, balance, opening_balance, sort_order, created_at, updated_at, currency)
+			SELECT id, name, balance, opening_balance, sort_order, created_at, updated_at, currency FROM accounts`,
+			`DROP TABLE accounts`,
+			`ALTER TABLE accounts_new RENAME TO accounts`,
+
+			`CREATE TABLE categories_new (
+				id          INTEGER PRIMARY KEY AUTOINCREMENT,
+				name        TEXT    NOT NULL UNIQUE,
+				sort_order  INTEGER NOT NULL DEFAULT 0,
+				created_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+				updated_at  DATETIME DEFAULT CURRENT_TIMESTAMP
+			)`,
+			`INSERT INTO categories_new (id, name, sort_order, created_at, updated_at)
+			SELECT id, name, sort_order, created_at, updated_at FROM categories`,
+			`DROP TABLE categories`,
+			`ALTER TABLE categories_new RENAME TO categories`,
+
+			`CREATE TABLE transfers_new (
+				id              INTEGER PRIMARY KEY AUTOINCREMENT,
+				date            DATE    NOT NULL,
+				from_account_id INTEGER NOT NULL,
+				to_account_id   INTEGER NOT NULL,
+				amount          INTEGER NOT NULL,
+				note            TEXT    DEFAULT '',
+				created_at      DATETIME DEFAULT CURRENT_TIMESTAMP,
+				updated_at      DATETIME DEFAULT CURRENT_TIMESTAMP,
+				to_amount       INTEGER NOT NULL DEFAULT 0,
+				FOREIGN KEY (from_account_id) REFERENCES accounts(id),
+				FOREIGN KEY (to_account_id)   REFERENCES accounts(id)
+			)`,
+			`INSERT INTO transfers_new (id, date, from_account_id, to_account_id, amount, note, created_at, updated_at, to_amount)
+			SELECT id, date, from_account_id, to_account_id, amount, note, created_at, updated_at, to_amount FROM transfers`,
+			`DROP TABLE transfers`,
+			`ALTER TABLE transfers_new RENAME TO transfers`,
+
+			`CREATE TABLE records_new (
+				id          INTEGER PRIMARY KEY AUTOINCREMENT,
+				date        DATE    NOT NULL,
+				account_id  INTEGER NOT NULL,
+				type        TEXT    NOT NULL DEFAULT '支出',
+				amount      INTEGER NOT NULL,
+				item        TEXT    NOT NULL,
+				category_id INTEGER NOT NULL,
+				note        TEXT    DEFAULT '',
+				transfer_id INTEGER,
+				created_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+				updated_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (account_id)  REFERENCES accounts(id),
+				FOREIGN KEY (category_id) REFERENCES categories(id),
+				FOREIGN KEY (transfer_id) REFERENCES transfers(id)
+			)`,
+			`INSERT INTO records_new (id, date, account_id, type, amount, item, category_id, note, transfer_id, created_at, updated_at)
+			SELECT id, date, account_id, type, amount, item, category_id, note, transfer_id, created_at, updated_at FROM records`,
+			`DROP TABLE records`,
+			`ALTER TABLE records_new RENAME TO records`,
+			`CREATE INDEX idx_records_date ON records(date)`,
+			`CREATE INDEX idx_records_category ON records(category_id)`,
+			`CREATE INDEX idx_records_account ON records(account_id)`,
+			`CREATE INDEX idx_records_transfer ON records(transfer_id)`,
+
+			`DROP TABLE telegram_chats`,
+			`DROP TABLE users`,
+		},
+	},
+	{
+		// 共用帳本：books 與 book_members，帳戶與分類改為屬於帳本，轉帳與紀錄加上 book_id（user_id 保留為建立者）
+		// 原因：每位既有使用者建立一本同 id 的個人帳本並成為擁有者，既有資料歸入各自的個人帳本
+		// 注意：降版時帳戶與分類歸屬於帳本的第一位擁有者，若因此出現同名會因唯一約束而失敗
+		Version: 7,
+		Name:    "books",
+		Up: []string{
+			`CREATE TABLE books (
+				id         INTEGER PRIMARY KEY AUTOINCREMENT,
+				name       TEXT    NOT NULL,
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+			)`,
+			`INSERT INTO books (id, name)
+			SELECT id, CASE WHEN display_name != '' THEN display_name ELSE username END || '的帳本' FROM users`,
+
+			// 帳本成員與角色：owner 可管理成員，editor 可記帳，viewer 只能查看
+			`CREATE TABLE book_members (
+				book_id    INTEGER NOT NULL,
+				user_id    INTEGER NOT NULL,
+				role       TEXT    NOT NULL CHECK (role IN ('owner', 'editor', 'viewer')),
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				PRIMARY KEY (book_id, user_id),
+				FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE,
+				FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+			)`,
+			`INSERT INTO book_members (book_id, user_id, role) SELECT id, id, 'owner' FROM users`,
+			`CREATE INDEX idx_book_members_user ON book_members(user_id)`,
+
+			// 聊天室以 /book 切換的目前帳本，NULL 代表使用個人帳本
+			`ALTER TABLE telegram_chats ADD COLUMN book_id INTEGER REFERENCES books(id) ON DELETE SET NULL`,
+
+			`CREATE TABLE accounts_new (
+				id              INTEGER PRIMARY KEY AUTOINCREMENT,
+				book_id         INTEGER NOT NULL,
+				name            TEXT    NOT NULL,
+				currency        TEXT    NOT NULL DEFAULT 'TWD',
+				balance         INTEGER NOT NULL DEFAULT 0,
+				opening_balance INTEGER NOT NULL DEFAULT 0,
+				sort_order      INTEGER NOT NULL DEFAULT 0,
+				created_at      DATETIME DEFAULT CURRENT_TIMESTAMP,
+				updated_at      DATETIME DEFAULT CURRENT_TIMESTAMP,
+				UNIQUE (book_id, name),
+				FOREIGN KEY (book_id) REFERENCES books(id)
+			)`,
+			`INSERT INTO accounts_new (id, book_id, name, currency, balance, opening_balance, sort_order, created_at, updated_at)
+			SELECT id, user_id, name, currency, balance, opening_balance, sort_order, created_at, updated_at FROM accounts`,
+			`DROP TABLE accounts`,
+			`ALTER TABLE accounts_new RENAME TO accounts`,
+
+			`CREATE TABLE categories_new (
+				id          INTEGER PRIMARY KEY AUTOINCREMENT,
+				book_id     INTEGER NOT NULL,
+				name        TEXT    NOT NULL,
+				sort_order  INTEGER NOT NULL DEFAULT 0,
+				created_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+				updated_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+				UNIQUE (book_id, name),
+				FOREIGN KEY (book_id) REFERENCES books(id)
+			)`,
+			`INSERT INTO categories_new (id, book_id, name, sort_order, created_at, updated_at)
+			SELECT id, user_id, name, sort_order, created_at, updated_at FROM categories`,
+			`DROP TABLE categories`,
+			`ALTER TABLE categories_new RENAME TO categories`,
+
+			`CREATE TABLE transfers_new (
+				id              INTEGER PRIMARY KEY AUTOINCREMENT,
+				book_id         INTEGER NOT NULL,
+				user_id         INTEGER NOT NULL,
+				date            DATE    NOT NULL,
+				from_account_id INTEGER NOT NULL,
+				to_account_id   INTEGER NOT NULL,
+				amount          INTEGER NOT NULL,
+				to_amount       INTEGER NOT NULL DEFAULT 0,
+				note            TEXT    DEFAULT '',
+				created_at      DATETIME DEFAULT CURRENT_TIMESTAMP,
+				updated_at      DATETIME DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (book_id)         REFERENCES books(id),
+				FOREIGN KEY (user_id)         REFERENCES users(id),
+				FOREIGN KEY (from_account_id) REFERENCES accounts(id),
+				FOREIGN KEY (to_account_id)   REFERENCES accounts(id)
+			)`,
+			`INSERT INTO transfers_new (id, book_id, user_id, date, from_account_id, to_account_id, amount, to_amount, note, created_at, updated_at)
+			SELECT id, user_id, user_id, date, from_account_id, to_account_id, amount, to_amount, note, created_at, updated_at FROM transfers`,
+			`DROP TABLE transfers`,
+			`ALTER TABLE transfers_new RENAME TO transfers`,
+
+			`CREATE TABLE records_new (
+				id          INTEGER PRIMARY KEY AUTOINCREMENT,
+				book_id     INTEGER NOT NULL,
+				user_id     INTEGER NOT NULL,
+				date        DATE    NOT NULL,
+				account_id  INTEGER NOT NULL,
+				type        TEXT    NOT NULL DEFAULT '支出',
+				amount      INTEGER NOT NULL,
+				item        TEXT    NOT NULL,
+				category_id INTEGER NOT NULL,
+				note        TEXT    DEFAULT '',
+				transfer_id INTEGER,
+				created_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+				updated_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (book_id)     REFERENCES books(id),
+				FOREIGN KEY (user_id)     REFERENCES users(id),
+				FOREIGN KEY (account_id)  REFERENCES accounts(id),
+				FOREIGN KEY (category_id) REFERENCES categories(id),
+				FOREIGN KEY (transfer_id) REFERENCES transfers(id)
+			)`,
+			`INSERT INTO records_new (id, book_id, user_id, date, account_id, type, amount, item, category_id, note, transfer_id, created_at, updated_at)
+			SELECT id, user_id, user_id, date, account_id, type, amount, item, category_id, note, transfer_id, created_at, updated_at FROM records`,
+			`DROP TABLE records`,
+			`ALTER TABLE records_new RENAME TO records`,
+			`CREATE INDEX idx_records_date ON records(date)`,
+			`CREATE INDEX idx_records_category ON records(category_id)`,
+			`CREATE INDEX idx_records_account ON records(account_id)`,
+			`CREATE INDEX idx_records_transfer ON records(transfer_id)`,
+			`CREATE INDEX idx_records_book_date ON records(book_id, date)`,
+		},
+		Down: []string{
+			`CREATE TABLE accounts_new (
+				id              INTEGER PRIMARY KEY AUTOINCREMENT,
+				user_id         INTEGER NOT NULL,
+				name            TEXT    NOT NULL,
+				currency        TEXT    NOT NULL DEFAULT 'TWD',
+				balance         INTEGER NOT NULL DEFAULT 0,
+				opening_balance INTEGER NOT NULL DEFAULT 0,
+				sort_order      INTEGER NOT NULL DEFAULT 0,
+				created_at      DATETIME DEFAULT CURRENT_TIMESTAMP,
+				updated_at      DATETIME DEFAULT CURRENT_TIMESTAMP,
+				UNIQUE (user_id, name),
+				FOREIGN KEY (user_id) REFERENCES users(id)
+			)`,
+			`INSERT INTO accounts_new (id, user_id, name, currency, balance, opening_balance, sort_order, created_at, updated_at)
+			SELECT id, COALESCE((SELECT MIN(m.user_id) FROM book_members m WHERE m.book_id = accounts.book_id AND m.role = 'owner'), 1),
+				name, currency, balance, opening_balance, sort_order, created_at, updated_at FROM accounts`,
+			`DROP TABLE accounts`,
+			`ALTER TABLE accounts_new RENAME TO accounts`,
+
+			`CREATE TABLE categories_new (
+				id          INTEGER PRIMARY KEY AUTOINCREMENT,
+				user_id     INTEGER NOT NULL,
+				name        TEXT    NOT NULL,
+				sort_order  INTEGER NOT NULL DEFAULT 0,
+				created_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+				updated_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+				UNIQUE (user_id, name),
+				FOREIGN KEY (user_id) REFERENCES users(id)
+			)`,
+			`INSERT INTO categories_new (id, user_id, name, sort_order, created_at, updated_at)
+			SELECT id, COALESCE((SELECT MIN(m.user_id) FROM book_members m WHERE m.book_id = categories.book_id AND m.role = 'owner'), 1),
+				name, sort_order, created_at, updated_at FROM categories`,
+			`DROP TABLE categories`,
+			`ALTER TABLE categories_new RENAME TO categories`,
+
+			// 轉帳與紀錄改歸屬於帳戶的擁有者，與帳戶保持一致
+			`CREATE TABLE transfers_new (
+				id              INTEGER PRIMARY KEY AUTOINCREMENT,
+				user_id         INTEGER NOT NULL,
+				date            DATE    NOT NULL,
+				from_account_id INTEGER NOT NULL,
+				to_account_id   INTEGER NOT NULL,
+				amount          INTEGER NOT NULL,
+				to_amount       INTEGER NOT NULL DEFAULT 0,
+				note            TEXT    DEFAULT '',
+				created_at      DATETIME DEFAULT CURRENT_TIMESTAMP,
+				updated_at      DATETIME DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (user_id)         REFERENCES users(id),
+				FOREIGN KEY (from_account_id) REFERENCES accounts(id),
+				FOREIGN KEY (to_account_id)   REFERENCES accounts(id)
+			)`,
+			`INSERT INTO transfers_new (id, user_id, date, from_account_id, to_account_id, amount, to_amount, note, created_at, updated_at)
+			SELECT t.id, a.user_id, t.date, t.from_account_id, t.to_account_id, t.amount, t.to_amount, t.note, t.created_at, t.updated_at
+			FROM transfers t JOIN accounts a ON a.id = t.from_account_id`,
+			`DROP TABLE transfers`,
+			`ALTER TABLE transfers_new RENAME TO transfers`,
+
+			`CREATE TABLE records_new (
+				id          INTEGER PRIMARY KEY AUTOINCREMENT,
+				user_id     INTEGER NOT NULL,
+				date        DATE    NOT NULL,
+				account_id  INTEGER NOT NULL,
+				type        TEXT    NOT NULL DEFAULT '支出',
+				amount      INTEGER NOT NULL,
+				item        TEXT    NOT NULL,
+				category_id INTEGER NOT NULL,
+				note        TEXT    DEFAULT '',
+				transfer_id INTEGER,
+				created_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+				updated_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (user_id)     REFERENCES users(id),
+				FOREIGN KEY (account_id)  REFERENCES accounts(id),
+				FOREIGN KEY (category_id) REFERENCES categories(id),
+				FOREIGN KEY (transfer_id) REFERENCES transfers(id)
+			)`,
+			`INSERT INTO records_new (id, user_id, date, account_id, type, amount, item, category_id, note, transfer_id, created_at, updated_at)
+			SELECT r.id, a.user_id, r.date, r.account_id, r.type, r.amount, r.item, r.category_id, r.note, r.transfer_id, r.created_at, r.updated_at
+			FROM records r JOIN accounts a ON a.id = r.account_id`,
+			`DROP TABLE records`,
+			`ALTER TABLE records_new RENAME TO records`,
+			`CREATE INDEX idx_records_date ON records(date)`,
+			`CREATE INDEX idx_records_category ON records(category_id)`,
+			`CREATE INDEX idx_records_account ON records(account_id)`,
+			`CREATE INDEX idx_records_transfer ON records(transfer_id)`,
+			`CREATE INDEX idx_records_user_date ON records(user_id, date)`,
+
+			`CREATE TABLE telegram_chats_new (
+				chat_id    INTEGER PRIMARY KEY,
+				user_id    INTEGER NOT NULL,
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+			)`,
+			`INSERT INTO telegram_chats_new (chat_id, user_id, created_at) SELECT chat_id, user_id, created_at FROM telegram_chats`,
+			`DROP TABLE telegram_chats`,
+			`ALTER TABLE telegram_chats_new RENAME TO telegram_chats`,
+			`CREATE INDEX idx_telegram_chats_user ON telegram_chats(user_id)`,
+
+			`DROP TABLE book_members`,
+			`DROP TABLE books`,
+		},
+	},
+	{
+		// API 認證：使用者密碼、管理員標記與登入 token
+		// 原因：既有使用者尚未設定密碼（空字串代表無法以密碼登入），預設使用者（id 1）為管理員
+		Version: 8,
+		Name:    "auth",
+		Up: []string{
+			`ALTER TABLE users ADD COLUMN password_hash TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE users ADD COLUMN is_admin INTEGER NOT NULL DEFAULT 0`,
+			`UPDATE users SET is_admin = 1 WHERE id = 1`,
+
+			// kind：session 為登入取得的 JWT（以 id 作為 jti），personal 為給腳本使用的長效 token
+			// token_hash 只存 SHA-256，原始 token 只在建立時回傳一次
+			`CREATE TABLE api_tokens (
+				id           INTEGER PRIMARY KEY AUTOINCREMENT,
+				user_id      INTEGER NOT NULL,
+				kind         TEXT    NOT NULL CHECK (kind IN ('session', 'personal')),
+				name         TEXT    NOT NULL DEFAULT '',
+				token_hash   TEXT    UNIQUE,
+				prefix       TEXT    NOT NULL DEFAULT '',
+				expires_at   DATETIME,
+				last_used_at DATETIME,
+				revoked_at   DATETIME,
+				created_at   DATETIME DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+			)`,
+			`CREATE INDEX idx_api_tokens_user ON api_tokens(user_id)`,
+		},
+		Down: []string{
+			`DROP TABLE api_tokens`,
+			`ALTER TABLE users DROP COLUMN is_admin`,
+			`ALTER TABLE users DROP COLUMN password_hash`,
+		},
+	},
+	{
+		// 系統設定（鍵值對）
+		// 原因：Bot 以 long polling 接收訊息時需記住已處理的 update_id，重啟後才不會重複處理
+		Version: 9,
+		Name:    "settings",
+		Up: []string{
+			`CREATE TABLE settings (
+				key        TEXT PRIMARY KEY,
+				value      TEXT NOT NULL,
+				updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+			)`,
+		},
+		Down: []string{
+			`DROP TABLE settings`,
+		},
+	},
+	{
+		// Bot 會話（新增紀錄或轉帳的草稿）
+		// 原因：原本只存在記憶體，重啟後草稿遺失、預覽訊息的按鈕全部失效；
+		// 帳戶與分類不加外鍵，草稿中的帳戶被刪除時於送出才檢查
+		Version: 10,
+		Name:    "bot_sessions",
+		Up: []string{
+			`CREATE TABLE bot_sessions (
+				chat_id       INTEGER PRIMARY KEY,
+				user_id       INTEGER NOT NULL,
+				book_id       INTEGER NOT NULL,
+				mode          TEXT    NOT NULL,
+				state         TEXT    NOT NULL DEFAULT '',
+				date          TEXT    NOT NULL DEFAULT '',
+				account_id    INTEGER NOT NULL DEFAULT 0,
+				to_account_id INTEGER NOT NULL DEFAULT 0,
+				type          TEXT    NOT NULL DEFAULT '',
+				amount        INTEGER NOT NULL DEFAULT 0,
+				item          TEXT    NOT NULL DEFAULT '',
+				category_id   INTEGER NOT NULL DEFAULT 0,
+				note          TEXT    NOT NULL DEFAULT '',
+				message_id    INTEGER NOT NULL DEFAULT 0,
+				prompt_msg_id INTEGER NOT NULL DEFAULT 0,
+				updated_at    DATETIME NOT NULL,
+				FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+				FOREIGN KEY (book_id) REFERENCES books(id) ON DELETE CASCADE
+			)`,
+		},
+		Down: []string{
+			`DROP TABLE bot_sessions`,
+		},
+	},
+	{
+		// 以 Bot 修改既有紀錄時記錄紀錄 ID，0 代表新增紀錄
+		// 原因：紀錄可能在修改期間被刪除，不加外鍵，送出時由記帳服務回報找不到紀錄
+		Version: 11,
+		Name:    "bot_sessions_record_id",
+		Up: []string{
+			`ALTER TABLE bot_sessions ADD COLUMN record_id INTEGER NOT NULL DEFAULT 0`,
+		},
+		Down: []string{
+			`ALTER TABLE bot_sessions DROP COLUMN record_id`,
+		},
+	},
+	{
+		// 依項目名稱查詢過去的紀錄（分類與帳戶建議），不分大小寫
+		Version: 12,
+		Name:    "records_item_index",
+		Up: []string{
+			`CREATE INDEX idx_records_item ON records(book_id, item COLLATE NOCASE)`,
+		},
+		Down: []string{
+			`DROP INDEX idx_records_item`,
+		},
+	},
+	{
+		// 每月預算：category_id 為 NULL 代表整本帳本的總預算，每本帳本的每個分類至多一筆
+		Version: 13,
+		Name:    "budgets",
+		Up: []string{
+			`CREATE TABLE budgets (
+				id          INTEGER PRIMARY KEY AUTOINCREMENT,
+				book_id     INTEGER NOT NULL,
+				category_id INTEGER,
+				amount      INTEGER NOT NULL,
+				rollover    INTEGER NOT NULL DEFAULT 0,
+				start_month TEXT    NOT NULL,
+				created_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+				updated_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (book_id)     REFERENCES books(id) ON DELETE CASCADE,
+				FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE CASCADE
+			)`,
+			`CREATE UNIQUE INDEX idx_budgets_category ON budgets(book_id, IFNULL(category_id, 0))`,
+		},
+		Down: []string{
+			`DROP TABLE budgets`,
+		},
+	},
+	{
+		// 定期紀錄規則：排程依 next_date 自動新增紀錄，停機期間錯過的次數於啟動後補上
+		// 原因：日期以 TEXT 儲存，讀回時維持 2006-01-02 格式，可直接與今天的日期字串比較
+		Version: 14,
+		Name:    "recurring_rules",
+		Up: []string{
+			`CREATE TABLE recurring_rules (
+				id          INTEGER PRIMARY KEY AUTOINCREMENT,
+				book_id     INTEGER NOT NULL,
+				user_id     INTEGER NOT NULL,
+				item        TEXT    NOT NULL,
+				account_id  INTEGER NOT NULL,
+				category_id INTEGER NOT NULL,
+				type        TEXT    NOT NULL DEFAULT '支出',
+				amount      INTEGER NOT NULL,
+				note        TEXT    NOT NULL DEFAULT '',
+				frequency   TEXT    NOT NULL CHECK (frequency IN ('daily', 'weekly', 'monthly', 'yearly')),
+				interval    INTEGER NOT NULL DEFAULT 1,
+				start_date  TEXT    NOT NULL,
+				end_date    TEXT    NOT NULL DEFAULT '',
+				next_date   TEXT    NOT NULL,
+				occurrences INTEGER NOT NULL DEFAULT 0,
+				paused      INTEGER NOT NULL DEFAULT 0,
+				created_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+				updated_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (book_id)     REFERENCES books(id) ON DELETE CASCADE,
+				FOREIGN KEY (user_id)     REFERENCES users(id) ON DELETE CASCADE,
+				FOREIGN KEY (account_id)  REFERENCES accounts(id) ON DELETE CASCADE,
+				FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE CASCADE
+			)`,
+			`CREATE INDEX idx_recurring_rules_next ON recurring_rules(paused, next_date)`,
+		},
+		Down: []string{
+			`DROP TABLE recurring_rules`,
+		},
+	},
+	{
+		// Bot 定期摘要的訂閱：每個聊天室的每種摘要至多一筆
+		// 原因：允許清單中的聊天室不一定有綁定紀錄，chat_id 不加外鍵
+		Version: 15,
+		Name:    "digest_subscriptions",
+		Up: []string{
+			`CREATE TABLE digest_subscriptions (
+				chat_id    INTEGER NOT NULL,
+				kind       TEXT    NOT NULL CHECK (kind IN ('daily', 'weekly', 'monthly')),
+				send_time  TEXT    NOT NULL,
+				last_sent  TEXT    NOT NULL DEFAULT '',
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+				PRIMARY KEY (chat_id, kind)
+			)`,
+		},
+		Down: []string{
+			`DROP TABLE digest_subscriptions`,
+		},
+	},
+	{
+		// 帳戶與分類的別名：account_id 與 category_id 恰有一個不為 NULL，同一帳本內名稱不分大小寫不可重複
+		Version: 16,
+		Name:    "aliases",
+		Up: []string{
+			`CREATE TABLE aliases (
+				id          INTEGER PRIMARY KEY AUTOINCREMENT,
+				book_id     INTEGER NOT NULL,
+				name        TEXT    NOT NULL,
+				account_id  INTEGER,
+				category_id INTEGER,
+				created_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+				FOREIGN KEY (book_id)     REFERENCES books(id) ON DELETE CASCADE,
+				FOREIGN KEY (account_id)  REFERENCES accounts(id) ON DELETE CASCADE,
+				FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE CASCADE,
+				CHECK ((account_id IS NULL) <> (category_id IS NULL))
+			)`,
+			`CREATE UNIQUE INDEX idx_aliases_name ON aliases(book_id, name COLLATE NOCASE)`,
+		},
+		Down: []string{
+			`DROP TABLE aliases`,
+		},
+	},
+}
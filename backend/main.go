@@ -4,8 +4,19 @@ import (
 	"accountbook/bot"
 	"accountbook/controllers"
 	"accountbook/initializers"
+	"accountbook/repository"
 	"accountbook/services"
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
 	"log"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // 原因：容器映像可能沒有時區資料，內嵌後 TZ 與 BOT_TIMEZONE 才能使用 Asia/Taipei 等時區
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -14,61 +25,210 @@ import (
 func init() {
 	// 載入環境變數
 	initializers.LoadEnv()
+	initializers.LoadCurrencySettings()
+}
 
-	// 初始化資料庫
+func main() {
 	dbPath := initializers.GetEnv("DB_PATH", "./data/accountbook.db")
+
+	// migrate 子指令：只開啟連線，由指令決定是否套用 migration
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		initializers.OpenDB(dbPath)
+		initializers.RunMigrateCommand(os.Args[2:])
+		return
+	}
+
+	// 定期紀錄與定期摘要共用的時區
+	timezone := loadTimezone()
+
+	// 初始化資料庫
 	initializers.InitDB(dbPath)
-}
+	repository.Default = repository.NewSQLiteStore(initializers.DB)
+	services.Ledger = services.NewLedgerService(repository.Default)
+	services.Exchange = services.NewExchangeService(repository.Default)
+	services.Users = services.NewUserService(repository.Default)
+	services.Books = services.NewBookService(repository.Default)
+	services.Suggestions = services.NewSuggestionService(repository.Default)
+	services.Aliases = services.NewAliasService(repository.Default)
+	services.Budgets = services.NewBudgetService(repository.Default)
+	services.Recurring = services.NewRecurringService(repository.Default, timezone)
+	services.Charts = services.NewChartService(repository.Default)
+	services.Auth = services.NewAuthService(repository.Default, loadAuthConfig())
+	services.TelegramAuth = services.NewTelegramAuthService(repository.Default, loadAllowedChats())
+
+	// passwd 子指令：由標準輸入讀取新密碼並設定（忘記密碼或尚未設定時使用）
+	if len(os.Args) > 1 && os.Args[1] == "passwd" {
+		runPasswdCommand(os.Args[2:])
+		return
+	}
+
+	if services.Auth.Enabled() {
+		if err := services.Auth.BootstrapAdmin(initializers.GetEnv("ADMIN_PASSWORD", "")); err != nil {
+			log.Fatalf("設定預設使用者密碼失敗: %v", err)
+		}
+	}
+
+	// 啟動時檢查帳戶餘額是否與紀錄一致（僅記錄，不修正）
+	if initializers.GetEnv("RECONCILE_ON_STARTUP", "false") == "true" {
+		services.Ledger.LogBalanceDiscrepancies()
+	}
 
-func main() {
 	r := gin.Default()
 
-	// 設定 CORS，允許前端跨域呼叫
-	r.Use(cors.Default())
+	// 設定 CORS，允許前端跨域呼叫（CORS_ALLOW_ORIGINS 以逗號分隔，預設允許所有來源）
+	// 原因：認證使用 Authorization 標頭而非 Cookie，需明確允許此標頭
+	corsConfig := cors.DefaultConfig()
+	corsConfig.AllowHeaders = append(corsConfig.AllowHeaders, "Authorization", "X-User-ID", "X-Book-ID")
+	if origins := initializers.GetEnv("CORS_ALLOW_ORIGINS", "*"); origins == "*" {
+		corsConfig.AllowAllOrigins = true
+	} else {
+		corsConfig.AllowOrigins = strings.Split(origins, ",")
+	}
+	r.Use(cors.New(corsConfig))
 
-	api := r.Group("/api")
+	// 不需登入的路由
+	public := r.Group("/api")
 	{
 		// 健康檢查
-		api.GET("/ping", func(c *gin.Context) {
+		public.GET("/ping", func(c *gin.Context) {
 			c.JSON(200, gin.H{"message": "pong"})
 		})
 
+		// 登入
+		public.GET("/auth/config", controllers.GetAuthConfig)
+		public.POST("/auth/login", controllers.Login)
+
+		// Telegram Webhook（由 Telegram 呼叫，不帶使用者 token）
+		public.POST("/telegram/webhook", bot.HandleWebhook)
+	}
+
+	// 其餘路由需登入：以 token 決定目前使用者（關閉認證時依 X-User-ID）
+	api := r.Group("/api")
+	api.Use(controllers.Authenticate())
+	{
+		// 登出與 API token
+		api.POST("/auth/logout", controllers.Logout)
+		api.GET("/auth/tokens", controllers.GetTokens)
+		api.POST("/auth/tokens", controllers.CreateToken)
+		api.DELETE("/auth/tokens/:id", controllers.RevokeToken)
+
+		// 帳本資料：依 X-Book-ID 決定目前帳本，所有帳戶、分類與紀錄的操作都限定在此帳本
+		// 新增、修改、刪除需為擁有者或編輯者（檢視者只能查詢）
+		book := api.Group("")
+		book.Use(controllers.CurrentBook())
+		edit := controllers.RequireEditor()
+
 		// 紀錄相關路由
-		api.GET("/records", controllers.GetRecords)
-		api.GET("/records/:id", controllers.GetRecord)
-		api.POST("/records", controllers.CreateRecord)
-		api.PUT("/records/:id", controllers.UpdateRecord)
-		api.DELETE("/records/:id", controllers.DeleteRecord)
+		book.GET("/records", controllers.GetRecords)
+		book.GET("/records/:id", controllers.GetRecord)
+		book.POST("/records", edit, controllers.CreateRecord)
+		book.PUT("/records/:id", edit, controllers.UpdateRecord)
+		book.DELETE("/records/:id", edit, controllers.DeleteRecord)
+		book.GET("/suggestions", controllers.GetSuggestion)
 
 		// 帳戶相關路由
-		api.GET("/accounts", controllers.GetAccounts)
-		api.GET("/accounts/:id", controllers.GetAccount)
-		api.POST("/accounts", controllers.CreateAccount)
-		api.PUT("/accounts/:id", controllers.UpdateAccount)
-		api.DELETE("/accounts/:id", controllers.DeleteAccount)
+		book.GET("/accounts", controllers.GetAccounts)
+		book.GET("/accounts/:id", controllers.GetAccount)
+		book.GET("/accounts/:id/reconcile", controllers.ReconcileAccount)
+		book.POST("/accounts/reconcile", edit, controllers.ReconcileAccounts)
+		book.POST("/accounts", edit, controllers.CreateAccount)
+		book.PUT("/accounts/:id", edit, controllers.UpdateAccount)
+		book.DELETE("/accounts/:id", edit, controllers.DeleteAccount)
 
 		// 分類相關路由
-		api.GET("/categories", controllers.GetCategories)
-		api.POST("/categories", controllers.CreateCategory)
-		api.PUT("/categories/:id", controllers.UpdateCategory)
-		api.DELETE("/categories/:id", controllers.DeleteCategory)
+		book.GET("/categories", controllers.GetCategories)
+		book.POST("/categories", edit, controllers.CreateCategory)
+		book.PUT("/categories/:id", edit, controllers.UpdateCategory)
+		book.DELETE("/categories/:id", edit, controllers.DeleteCategory)
+
+		// 帳戶與分類別名路由（Bot 快捷輸入使用）
+		book.GET("/aliases", controllers.GetAliases)
+		book.POST("/aliases", edit, controllers.CreateAlias)
+		book.DELETE("/aliases/:id", edit, controllers.DeleteAlias)
 
 		// 轉帳路由
-		api.POST("/transfer", controllers.CreateTransfer)
+		book.POST("/transfer", edit, controllers.CreateTransfer)
+		book.GET("/transfers/:id", controllers.GetTransfer)
+		book.PUT("/transfers/:id", edit, controllers.UpdateTransfer)
+		book.DELETE("/transfers/:id", edit, controllers.DeleteTransfer)
 
 		// 統計相關路由
-		api.GET("/statistics", controllers.GetStatistics)
-		api.GET("/statistics/summary", controllers.GetSummary)
+		book.GET("/statistics", controllers.GetStatistics)
+		book.GET("/statistics/summary", controllers.GetSummary)
+		book.GET("/statistics/chart.png", controllers.GetStatisticsChart)
+
+		// 預算相關路由
+		book.GET("/budgets", controllers.GetBudgets)
+		book.GET("/budgets/status", controllers.GetBudgetStatus)
+		book.POST("/budgets", edit, controllers.CreateBudget)
+		book.PUT("/budgets/:id", edit, controllers.UpdateBudget)
+		book.DELETE("/budgets/:id", edit, controllers.DeleteBudget)
 
-		// Telegram Webhook
-		api.POST("/telegram/webhook", bot.HandleWebhook)
+		// 定期紀錄相關路由
+		book.GET("/recurring", controllers.GetRecurringRules)
+		book.GET("/recurring/:id", controllers.GetRecurringRule)
+		book.POST("/recurring", edit, controllers.CreateRecurringRule)
+		book.PUT("/recurring/:id", edit, controllers.UpdateRecurringRule)
+		book.DELETE("/recurring/:id", edit, controllers.DeleteRecurringRule)
+		book.POST("/recurring/:id/pause", edit, controllers.PauseRecurringRule)
+		book.POST("/recurring/:id/resume", edit, controllers.ResumeRecurringRule)
+		book.POST("/recurring/:id/skip", edit, controllers.SkipRecurringRule)
+
+		// 匯率相關路由（所有帳本共用，修改限管理員）
+		admin := controllers.RequireAdmin()
+		api.GET("/exchange-rates", controllers.GetExchangeRates)
+		api.POST("/exchange-rates", admin, controllers.CreateExchangeRate)
+		api.POST("/exchange-rates/import", admin, controllers.ImportExchangeRates)
+		api.DELETE("/exchange-rates/:id", admin, controllers.DeleteExchangeRate)
+
+		// 使用者相關路由（修改限本人或管理員，直接綁定聊天室限管理員）
+		api.GET("/users", controllers.GetUsers)
+		api.GET("/users/me", controllers.GetMe)
+		api.POST("/users", admin, controllers.CreateUser)
+		api.PUT("/users/:id", controllers.UpdateUser)
+		api.PUT("/users/:id/password", controllers.ChangePassword)
+		api.GET("/users/:id/telegram-chats", controllers.GetUserTelegramChats)
+		api.POST("/users/:id/telegram-chats", admin, controllers.LinkTelegramChat)
+		api.DELETE("/telegram-chats/:chat_id", controllers.UnlinkTelegramChat)
+		api.POST("/telegram/pair-code", controllers.CreateTelegramPairCode)
+
+		// 帳本與成員路由（權限由帳本服務依目前使用者的角色檢查）
+		api.GET("/books", controllers.GetBooks)
+		api.POST("/books", controllers.CreateBook)
+		api.PUT("/books/:id", controllers.UpdateBook)
+		api.GET("/books/:id/members", controllers.GetBookMembers)
+		api.POST("/books/:id/members", controllers.AddBookMember)
+		api.PUT("/books/:id/members/:user_id", controllers.UpdateBookMember)
+		api.DELETE("/books/:id/members/:user_id", controllers.RemoveBookMember)
 	}
 
-	// 啟動 Telegram Bot Webhook（若有設定 token）
+	// 定期紀錄排程：啟動時先補上停機期間錯過的期數，之後每小時檢查一次
+	go services.Recurring.RunScheduler()
+
+	// 啟動 Telegram Bot（若有設定 token）
+	// TELEGRAM_MODE=webhook（預設）需設定公開的 TELEGRAM_WEBHOOK_URL；polling 以 getUpdates 主動取得訊息
 	token := initializers.GetEnv("TELEGRAM_BOT_TOKEN", "")
 	webhookURL := initializers.GetEnv("TELEGRAM_WEBHOOK_URL", "")
-	if token != "" && webhookURL != "" {
-		services.SetupWebhook(token, webhookURL)
+	services.Telegram = services.NewTelegramClient(loadTelegramConfig(token))
+	if token != "" {
+		// 還原重啟前進行中的操作，並定期取消閒置逾時的會話
+		bot.SessionTTL = loadSessionTTL()
+		bot.UndoWindow = loadUndoWindow()
+		bot.RestoreSessions()
+		go bot.RunSessionJanitor()
+
+		// 定期摘要：每分鐘檢查一次，發送到期的每日、每週、每月摘要
+		bot.Timezone = timezone
+		go bot.RunDigestScheduler()
+	}
+	switch mode := initializers.GetEnv("TELEGRAM_MODE", "webhook"); {
+	case token == "":
+	case mode == "polling":
+		go bot.RunPolling()
+	case mode != "webhook":
+		log.Fatalf("TELEGRAM_MODE 只能是 webhook 或 polling: %s", mode)
+	case webhookURL != "":
+		services.SetupWebhook(webhookURL, loadWebhookSecret())
 	}
 
 	// 啟動伺服器
@@ -76,3 +236,137 @@ func main() {
 	log.Printf("伺服器啟動於 :%s", port)
 	r.Run(":" + port)
 }
+
+// loadAuthConfig 讀取認證設定
+// 原因：未設定 AUTH_SECRET 時以亂數產生，重啟後所有登入都需重新登入（個人 token 不受影響）
+func loadAuthConfig() services.AuthConfig {
+	config := services.AuthConfig{
+		Enabled: initializers.GetEnv("AUTH_ENABLED", "true") == "true",
+		Secret:  []byte(initializers.GetEnv("AUTH_SECRET", "")),
+	}
+	if !config.Enabled {
+		log.Println("警告：已關閉 API 認證（AUTH_ENABLED=false），任何能連到此服務的人都能存取資料")
+	}
+	if len(config.Secret) == 0 {
+		config.Secret = make([]byte, 32)
+		rand.Read(config.Secret)
+		log.Println("未設定 AUTH_SECRET，使用隨機金鑰（重啟後需重新登入）")
+	}
+
+	ttl, err := time.ParseDuration(initializers.GetEnv("AUTH_SESSION_TTL", "720h"))
+	if err != nil || ttl <= 0 {
+		log.Fatalf("AUTH_SESSION_TTL 格式錯誤: %v", err)
+	}
+	config.SessionTTL = ttl
+	return config
+}
+
+// loadTelegramConfig 讀取 Telegram API 設定
+// 原因：TELEGRAM_API_URL 可指向自架的 Bot API 伺服器，或離線開發時的假伺服器（cmd/faketelegram）
+func loadTelegramConfig(token string) services.TelegramConfig {
+	timeout, err := time.ParseDuration(initializers.GetEnv("TELEGRAM_API_TIMEOUT", "10s"))
+	if err != nil || timeout <= 0 {
+		log.Fatalf("TELEGRAM_API_TIMEOUT 格式錯誤: %v", err)
+	}
+	return services.TelegramConfig{
+		Token:   token,
+		BaseURL: initializers.GetEnv("TELEGRAM_API_URL", services.DefaultTelegramBaseURL),
+		Timeout: timeout,
+	}
+}
+
+// loadSessionTTL 讀取 Bot 會話的閒置逾時（BOT_SESSION_TTL，例如 30m、2h）
+// 原因：Telegram 超過 48 小時的訊息無法編輯，逾時過長時預覽訊息無法改為已取消
+func loadSessionTTL() time.Duration {
+	ttl, err := time.ParseDuration(initializers.GetEnv("BOT_SESSION_TTL", "1h"))
+	if err != nil || ttl <= 0 {
+		log.Fatalf("BOT_SESSION_TTL 格式錯誤: %v", err)
+	}
+	if ttl > 47*time.Hour {
+		log.Println("警告：BOT_SESSION_TTL 超過 47 小時，逾時的預覽訊息可能已無法編輯")
+	}
+	return ttl
+}
+
+// loadUndoWindow 讀取 Bot 送出後可復原的期限（BOT_UNDO_WINDOW，例如 10m，0 代表關閉）
+func loadUndoWindow() time.Duration {
+	window, err := time.ParseDuration(initializers.GetEnv("BOT_UNDO_WINDOW", "10m"))
+	if err != nil || window < 0 {
+		log.Fatalf("BOT_UNDO_WINDOW 格式錯誤: %v", err)
+	}
+	if window > 47*time.Hour {
+		log.Println("警告：BOT_UNDO_WINDOW 超過 47 小時，過期的成功訊息可能已無法移除復原按鈕")
+	}
+	return window
+}
+
+// loadTimezone 讀取定期紀錄與定期摘要使用的時區（BOT_TIMEZONE，例如 Asia/Taipei，預設為系統時區）
+func loadTimezone() *time.Location {
+	name := initializers.GetEnv("BOT_TIMEZONE", "")
+	if name == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		log.Fatalf("BOT_TIMEZONE 格式錯誤: %v", err)
+	}
+	return loc
+}
+
+// webhookSecretPattern Telegram 接受的 secret_token 格式
+var webhookSecretPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,256}$`)
+
+// loadWebhookSecret 讀取 webhook 密鑰
+// 原因：每次啟動都會重新註冊 webhook，未設定 TELEGRAM_WEBHOOK_SECRET 時以亂數產生即可
+func loadWebhookSecret() string {
+	secret := initializers.GetEnv("TELEGRAM_WEBHOOK_SECRET", "")
+	if secret == "" {
+		buf := make([]byte, 32)
+		rand.Read(buf)
+		return hex.EncodeToString(buf)
+	}
+	if !webhookSecretPattern.MatchString(secret) {
+		log.Fatal("TELEGRAM_WEBHOOK_SECRET 只能包含英文字母、數字、底線或連字號（1～256 字）")
+	}
+	return secret
+}
+
+// loadAllowedChats 讀取不需配對即可使用 Bot 的聊天室（TELEGRAM_ALLOWED_CHATS，以逗號分隔）
+// 原因：這些聊天室未綁定時記到預設使用者，相容升級前只有一位使用者的部署
+func loadAllowedChats() []int64 {
+	var chats []int64
+	for _, field := range strings.Split(initializers.GetEnv("TELEGRAM_ALLOWED_CHATS", ""), ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			log.Fatalf("TELEGRAM_ALLOWED_CHATS 格式錯誤: %s", field)
+		}
+		chats = append(chats, id)
+	}
+	return chats
+}
+
+// runPasswdCommand 執行 passwd 子指令，例如 echo 'new-password' | ./server passwd admin
+// 原因：密碼不放在命令列參數，避免留在 shell 歷史紀錄與行程列表中
+func runPasswdCommand(args []string) {
+	if len(args) != 1 {
+		fmt.Println("用法：passwd <使用者名稱>（新密碼由標準輸入讀取）")
+		os.Exit(1)
+	}
+	user, err := repository.Default.Users().FindByUsername(args[0])
+	if err != nil {
+		fmt.Println("找不到使用者：" + args[0])
+		os.Exit(1)
+	}
+
+	fmt.Print("請輸入新密碼：")
+	password, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err := services.Auth.SetPassword(user.ID, strings.TrimRight(password, "\r\n")); err != nil {
+		fmt.Println("\n設定密碼失敗：" + err.Error())
+		os.Exit(1)
+	}
+	fmt.Println("\n已更新 " + user.Username + " 的密碼")
+}
@@ -2,11 +2,30 @@ package models
 
 // Account 帳戶模型
 // 原因：對應 accounts 資料表，記錄不同支付方式及各自餘額
+// Balance 為 OpeningBalance + Σ收入 − Σ支出 的快取，可透過對帳重新計算
+// 帳戶的餘額與紀錄金額皆以 Currency 計價
 type Account struct {
-	ID        int     `json:"id"`
-	Name      string  `json:"name"`
-	Balance   float64 `json:"balance"`
-	SortOrder int     `json:"sort_order"`
-	CreatedAt string  `json:"created_at"`
-	UpdatedAt string  `json:"updated_at"`
+	ID             int    `json:"id"`
+	BookID         int    `json:"-"`
+	Name           string `json:"name"`
+	Currency       string `json:"currency"`
+	Balance        Money  `json:"balance"`
+	OpeningBalance Money  `json:"opening_balance"`
+	SortOrder      int    `json:"sort_order"`
+	CreatedAt      string `json:"created_at"`
+	UpdatedAt      string `json:"updated_at"`
+}
+
+// ReconcileReport 單一帳戶的對帳結果
+// 原因：比對快取餘額與依紀錄重新計算的餘額，找出不一致
+type ReconcileReport struct {
+	AccountID      int    `json:"account_id"`
+	AccountName    string `json:"account_name"`
+	OpeningBalance Money  `json:"opening_balance"`
+	TotalIncome    Money  `json:"total_income"`
+	TotalExpense   Money  `json:"total_expense"`
+	Expected       Money  `json:"expected_balance"` // 期初餘額 + Σ收入 − Σ支出
+	Actual         Money  `json:"actual_balance"`   // accounts.balance 目前的值
+	Discrepancy    Money  `json:"discrepancy"`      // Actual − Expected
+	Repaired       bool   `json:"repaired"`
 }
@@ -0,0 +1,21 @@
+package models
+
+// Alias 帳戶或分類的別名
+// 原因：Bot 快捷輸入可用簡短或慣用的說法指定帳戶與分類，例如「卡」代表信用卡、「早餐」代表飲食
+// AccountID 與 CategoryID 恰有一個不為 0
+type Alias struct {
+	ID         int    `json:"id"`
+	BookID     int    `json:"-"`
+	Name       string `json:"name"`
+	AccountID  int    `json:"account_id,omitempty"`
+	CategoryID int    `json:"category_id,omitempty"`
+	TargetName string `json:"target_name"` // 對應的帳戶或分類名稱
+	CreatedAt  string `json:"created_at"`
+}
+
+// AliasInput 新增別名的輸入資料，account_id 與 category_id 擇一
+type AliasInput struct {
+	Name       string `json:"name" binding:"required"`
+	AccountID  int    `json:"account_id"`
+	CategoryID int    `json:"category_id"`
+}
@@ -0,0 +1,22 @@
+package models
+
+// API token 種類
+const (
+	TokenSession  = "session"  // 以密碼登入取得的 JWT，到期後需重新登入
+	TokenPersonal = "personal" // 個人 API token，給腳本等長期使用
+)
+
+// APIToken 登入 token 的紀錄
+// 原因：對應 api_tokens 資料表，JWT 的 jti 與個人 token 的雜湊都對應到此紀錄，撤銷後立即失效
+type APIToken struct {
+	ID         int     `json:"id"`
+	UserID     int     `json:"user_id"`
+	Kind       string  `json:"kind"`
+	Name       string  `json:"name"`
+	TokenHash  string  `json:"-"`
+	Prefix     string  `json:"prefix,omitempty"` // 個人 token 的開頭幾碼，方便辨識
+	ExpiresAt  *string `json:"expires_at"`       // nil 代表永不過期
+	LastUsedAt *string `json:"last_used_at"`
+	RevokedAt  *string `json:"revoked_at"`
+	CreatedAt  string  `json:"created_at"`
+}
@@ -0,0 +1,47 @@
+package models
+
+// 帳本成員角色
+const (
+	RoleOwner  = "owner"  // 擁有者：可修改帳本內容並管理成員
+	RoleEditor = "editor" // 編輯者：可修改帳本內容
+	RoleViewer = "viewer" // 檢視者：只能查看
+)
+
+// DefaultBookID 預設帳本（預設使用者的個人帳本）
+// 原因：升級前的資料全部歸屬於此帳本
+const DefaultBookID = 1
+
+// ValidRole 是否為合法的角色
+func ValidRole(role string) bool {
+	return role == RoleOwner || role == RoleEditor || role == RoleViewer
+}
+
+// CanEdit 角色是否可以修改帳本內容（新增、修改、刪除紀錄等）
+func CanEdit(role string) bool {
+	return role == RoleOwner || role == RoleEditor
+}
+
+// Book 帳本模型
+// 原因：對應 books 資料表，帳戶、分類與紀錄皆屬於某個帳本，多位使用者可共用同一本帳
+type Book struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// BookWithRole 帶有目前使用者角色的帳本
+type BookWithRole struct {
+	Book
+	Role string `json:"role"`
+}
+
+// BookMember 帳本成員
+type BookMember struct {
+	BookID      int    `json:"book_id"`
+	UserID      int    `json:"user_id"`
+	Username    string `json:"username"`
+	DisplayName string `json:"display_name"`
+	Role        string `json:"role"`
+	CreatedAt   string `json:"created_at"`
+}
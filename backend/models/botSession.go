@@ -0,0 +1,23 @@
+package models
+
+// BotSession Telegram Bot 進行中的互動式操作（新增紀錄或轉帳的草稿）
+// 原因：對應 bot_sessions 資料表，重啟後可接續未完成的操作，預覽訊息上的按鈕仍可使用
+type BotSession struct {
+	ChatID      int64  `json:"chat_id"`
+	UserID      int    `json:"user_id"`
+	BookID      int    `json:"book_id"`
+	Mode        string `json:"mode"`
+	State       string `json:"state"`
+	Date        string `json:"date"`
+	AccountID   int    `json:"account_id"`
+	ToAccountID int    `json:"to_account_id"`
+	Type        string `json:"type"`
+	Amount      Money  `json:"amount"`
+	Item        string `json:"item"`
+	CategoryID  int    `json:"category_id"`
+	Note        string `json:"note"`
+	MessageID   int    `json:"message_id"`
+	PromptMsgID int    `json:"prompt_msg_id"`
+	RecordID    int    `json:"record_id"`  // 修改中的紀錄，0 代表新增
+	UpdatedAt   string `json:"updated_at"` // UTC 的 RFC3339，用於判斷閒置逾時
+}
@@ -0,0 +1,46 @@
+package models
+
+// Budget 每月預算
+// 原因：統計只能看到花了多少，預算讓使用者知道還剩多少可以花
+// 金額以預設幣別計價，其他幣別的支出依月底匯率換算
+type Budget struct {
+	ID           int    `json:"id"`
+	BookID       int    `json:"-"`
+	CategoryID   int    `json:"category_id"`   // 0 代表整本帳本的總預算
+	CategoryName string `json:"category_name"` // 總預算為空字串
+	Amount       Money  `json:"amount"`        // 每月金額
+	// Rollover 是否將上個月剩餘（或超支）的金額滾入下個月
+	Rollover   bool   `json:"rollover"`
+	StartMonth string `json:"start_month"` // 開始生效的月份（2006-01），滾存由此月起算
+	CreatedAt  string `json:"created_at"`
+	UpdatedAt  string `json:"updated_at"`
+}
+
+// BudgetInput 新增/更新預算的輸入資料
+type BudgetInput struct {
+	CategoryID int    `json:"category_id"` // 省略或 0 為總預算
+	Amount     Money  `json:"amount" binding:"required"`
+	Rollover   bool   `json:"rollover"`
+	StartMonth string `json:"start_month"` // 省略時為本月
+}
+
+// BudgetStatus 預算在某個月份的使用情況
+type BudgetStatus struct {
+	BudgetID     int     `json:"budget_id"`
+	CategoryID   int     `json:"category_id"`
+	CategoryName string  `json:"category_name"`
+	Month        string  `json:"month"`
+	Currency     string  `json:"currency"`
+	Amount       Money   `json:"amount"`    // 每月金額
+	Carry        Money   `json:"carry"`     // 前幾個月滾入的金額（超支時為負數），未啟用滾存時為 0
+	Budgeted     Money   `json:"budgeted"`  // 本月可用金額（Amount + Carry）
+	Spent        Money   `json:"spent"`     // 本月支出（不含轉帳）
+	Remaining    Money   `json:"remaining"` // 超支時為負數
+	Percent      float64 `json:"percent"`   // Spent / Budgeted × 100，可超過 100
+}
+
+// BudgetAlert 一筆紀錄使預算跨過的門檻
+type BudgetAlert struct {
+	Status    BudgetStatus
+	Threshold int // 80 或 100
+}
@@ -0,0 +1,30 @@
+package models
+
+// 統計圖表的種類
+const (
+	ChartPie  = "pie"  // 各分類佔比
+	ChartBar  = "bar"  // 每日（年度為每月）收支
+	ChartLine = "line" // 資產餘額變化
+)
+
+// ChartKinds 所有圖表種類
+var ChartKinds = []string{ChartPie, ChartBar, ChartLine}
+
+// ChartRequest 產生統計圖表的條件
+// 原因：網頁與 Bot 共用同一組條件，圖片內容才會一致
+type ChartRequest struct {
+	Kind      string // pie、bar 或 line
+	Period    string // 2006-01 為月份，2006 為年度
+	Type      string // 收入或支出（pie、bar 使用）
+	Currency  string // 換算的目標幣別
+	AccountID int    // 0 代表不篩選
+}
+
+// Chart 產生的圖表
+// 原因：圖片上只能顯示英數字，圓餅圖的分類以編號對照 Categories（與圖例順序相同）
+type Chart struct {
+	Request    ChartRequest
+	PNG        []byte
+	Total      Money           // pie、bar 為期間的總額，line 為最後一點的餘額
+	Categories []CategoryTotal // pie 的各分類（金額由大到小）
+}
@@ -0,0 +1,51 @@
+package models
+
+import "strings"
+
+// DefaultCurrency 預設幣別（ISO 4217 代碼）
+// 原因：尚未指定幣別的帳戶與輸入一律以此幣別處理
+var DefaultCurrency = "TWD"
+
+// currencyDecimals 各幣別允許的小數位數，未列出的幣別為 MaxDecimals
+var currencyDecimals = map[string]int{
+	"TWD": 2,
+	"USD": 2,
+	"EUR": 2,
+	"CNY": 2,
+	"HKD": 2,
+	"JPY": 0,
+	"KRW": 0,
+}
+
+// CurrencyDecimals 取得幣別允許的小數位數
+func CurrencyDecimals(code string) int {
+	if d, ok := currencyDecimals[strings.ToUpper(code)]; ok {
+		return d
+	}
+	return MaxDecimals
+}
+
+// SetCurrencyDecimals 設定幣別允許的小數位數（0～MaxDecimals）
+func SetCurrencyDecimals(code string, decimals int) {
+	if decimals < 0 {
+		decimals = 0
+	}
+	if decimals > MaxDecimals {
+		decimals = MaxDecimals
+	}
+	currencyDecimals[strings.ToUpper(code)] = decimals
+}
+
+// NormalizeCurrency 將幣別代碼轉為大寫，並確認為 3 個英文字母
+func NormalizeCurrency(code string) (string, bool) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) != 3 {
+		return "", false
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return "", false
+		}
+	}
+	return code, true
+}
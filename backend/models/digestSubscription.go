@@ -0,0 +1,23 @@
+package models
+
+// Bot 定期摘要的種類
+const (
+	DigestDaily   = "daily"   // 每天：昨天的紀錄
+	DigestWeekly  = "weekly"  // 每週一：上週各分類的收支
+	DigestMonthly = "monthly" // 每月 1 號：上個月的月報
+)
+
+// DigestKinds 所有摘要種類（依發送頻率由高到低）
+var DigestKinds = []string{DigestDaily, DigestWeekly, DigestMonthly}
+
+// DigestSubscription 聊天室訂閱的定期摘要
+// 原因：對應 digest_subscriptions 資料表，每個聊天室的每種摘要至多一筆
+type DigestSubscription struct {
+	ChatID   int64  `json:"chat_id"`
+	Kind     string `json:"kind"`
+	SendTime string `json:"send_time"` // 發送時間（15:04），以設定的時區計算
+	// LastSent 最近一次已發送的期間（daily 為當天日期，weekly 為該週週一，monthly 為該月 1 號）
+	// 原因：排程依此判斷本期是否已發送，重啟後不會重複發送
+	LastSent  string `json:"last_sent"`
+	CreatedAt string `json:"created_at"`
+}
@@ -0,0 +1,13 @@
+package models
+
+// ExchangeRate 匯率
+// 原因：對應 exchange_rates 資料表，Date 當天 1 單位 FromCurrency 可換得 Rate 單位 ToCurrency
+type ExchangeRate struct {
+	ID           int     `json:"id"`
+	Date         string  `json:"date"`
+	FromCurrency string  `json:"from_currency"`
+	ToCurrency   string  `json:"to_currency"`
+	Rate         float64 `json:"rate"`
+	CreatedAt    string  `json:"created_at"`
+	UpdatedAt    string  `json:"updated_at"`
+}
@@ -0,0 +1,152 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Money 金額，以 1/100 為單位的整數儲存（例如 12.34 元存為 1234）
+// 原因：float64 反覆加減會累積誤差，整數運算才能讓餘額與紀錄完全一致
+// 各幣別允許的小數位數（0～2）由 CurrencyDecimals 決定，只限制輸入與顯示，不影響儲存單位
+type Money int64
+
+// MoneyScale 每一元對應的最小單位數
+const MoneyScale = 100
+
+// MaxDecimals 儲存單位可表示的最多小數位數
+const MaxDecimals = 2
+
+// ErrMoneyFormat 金額格式錯誤
+var ErrMoneyFormat = errors.New("金額格式錯誤")
+
+// MoneyFromFloat 將浮點數金額四捨五入為 Money
+// 原因：統計百分比、匯率換算等計算仍以浮點數進行，結果需轉回整數
+func MoneyFromFloat(f float64) Money {
+	return Money(math.Round(f * MoneyScale))
+}
+
+// Float64 轉為浮點數（僅用於計算比例等不需精確的場合）
+func (m Money) Float64() float64 {
+	return float64(m) / MoneyScale
+}
+
+// ParseMoney 解析十進位金額字串，小數位數不可超過 decimals
+// 原因：直接解析字串而非經過 float64，避免 0.1 之類的值產生誤差
+func ParseMoney(s string, decimals int) (Money, error) {
+	s = strings.TrimSpace(s)
+	negative := false
+	switch {
+	case strings.HasPrefix(s, "-"):
+		negative = true
+		s = s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+
+	whole, frac, hasPoint := strings.Cut(s, ".")
+	if whole == "" && frac == "" || hasPoint && frac == "" {
+		return 0, ErrMoneyFormat
+	}
+	if !isDigits(whole) || !isDigits(frac) {
+		return 0, ErrMoneyFormat
+	}
+	if decimals > MaxDecimals {
+		decimals = MaxDecimals
+	}
+	if len(strings.TrimRight(frac, "0")) > decimals {
+		if decimals == 0 {
+			return 0, fmt.Errorf("金額不可有小數")
+		}
+		return 0, fmt.Errorf("金額最多 %d 位小數", decimals)
+	}
+
+	// 補齊或截去尾端的 0，使小數部分剛好 MaxDecimals 位
+	frac = strings.TrimRight(frac, "0")
+	frac += strings.Repeat("0", MaxDecimals-len(frac))
+
+	units, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil {
+		return 0, ErrMoneyFormat
+	}
+	if negative {
+		units = -units
+	}
+	return Money(units), nil
+}
+
+// isDigits 字串是否只包含 0-9（空字串視為成立）
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// FitsDecimals 金額是否能以指定的小數位數表示
+func (m Money) FitsDecimals(decimals int) bool {
+	if decimals >= MaxDecimals {
+		return true
+	}
+	step := Money(math.Pow10(MaxDecimals - decimals))
+	return m%step == 0
+}
+
+// Round 四捨五入到指定的小數位數
+// 原因：匯率換算後的金額需符合目標幣別的小數位數
+func (m Money) Round(decimals int) Money {
+	if decimals >= MaxDecimals {
+		return m
+	}
+	step := math.Pow10(MaxDecimals - decimals)
+	return Money(math.Round(float64(m)/step) * step)
+}
+
+// String 以最少必要的小數位數顯示，例如 150、12.5、-0.05
+func (m Money) String() string {
+	sign := ""
+	units := int64(m)
+	if units < 0 {
+		sign = "-"
+		units = -units
+	}
+
+	whole := units / MoneyScale
+	frac := units % MoneyScale
+	if frac == 0 {
+		return fmt.Sprintf("%s%d", sign, whole)
+	}
+	return strings.TrimRight(fmt.Sprintf("%s%d.%02d", sign, whole, frac), "0")
+}
+
+// Format 以固定小數位數顯示，例如 Format(2) → 12.50
+func (m Money) Format(decimals int) string {
+	if decimals > MaxDecimals {
+		decimals = MaxDecimals
+	}
+	return strconv.FormatFloat(m.Float64(), 'f', decimals, 64)
+}
+
+// MarshalJSON 輸出為 JSON 數字（例如 12.5）
+// 原因：維持與原本 float64 相同的 API 格式，前端不需修改
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON 接受 JSON 數字或字串，小數位數超過 MaxDecimals 時回傳錯誤
+func (m *Money) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "null" {
+		return nil
+	}
+	parsed, err := ParseMoney(s, MaxDecimals)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
@@ -3,41 +3,59 @@ package models
 // Record 記帳紀錄模型
 // 原因：對應 records 資料表，為系統核心資料結構
 type Record struct {
-	ID         int     `json:"id"`
-	Date       string  `json:"date"`
-	AccountID  int     `json:"account_id"`
-	Type       string  `json:"type"`
-	Amount     float64 `json:"amount"`
-	Item       string  `json:"item"`
-	CategoryID int     `json:"category_id"`
-	Note       string  `json:"note"`
-	CreatedAt  string  `json:"created_at"`
-	UpdatedAt  string  `json:"updated_at"`
+	ID         int    `json:"id"`
+	BookID     int    `json:"-"`
+	UserID     int    `json:"-"` // 建立此紀錄的使用者
+	Date       string `json:"date"`
+	AccountID  int    `json:"account_id"`
+	Type       string `json:"type"`
+	Amount     Money  `json:"amount"`
+	Item       string `json:"item"`
+	CategoryID int    `json:"category_id"`
+	Note       string `json:"note"`
+	TransferID *int   `json:"transfer_id,omitempty"` // 轉帳產生的紀錄指向所屬轉帳，一般紀錄為 nil
+	CreatedAt  string `json:"created_at"`
+	UpdatedAt  string `json:"updated_at"`
 }
 
 // RecordWithNames 帶有帳戶與分類名稱的紀錄
 // 原因：API 回應時需要顯示名稱而非僅 ID
 type RecordWithNames struct {
-	ID           int     `json:"id"`
-	Date         string  `json:"date"`
-	AccountID    int     `json:"account_id"`
-	AccountName  string  `json:"account_name"`
-	Type         string  `json:"type"`
-	Amount       float64 `json:"amount"`
-	Item         string  `json:"item"`
-	CategoryID   int     `json:"category_id"`
-	CategoryName string  `json:"category_name"`
-	Note         string  `json:"note"`
+	ID           int    `json:"id"`
+	Date         string `json:"date"`
+	AccountID    int    `json:"account_id"`
+	AccountName  string `json:"account_name"`
+	Currency     string `json:"currency"` // 帳戶幣別，金額以此幣別計價
+	Type         string `json:"type"`
+	Amount       Money  `json:"amount"`
+	Item         string `json:"item"`
+	CategoryID   int    `json:"category_id"`
+	CategoryName string `json:"category_name"`
+	Note         string `json:"note"`
+	TransferID   *int   `json:"transfer_id,omitempty"`
 }
 
 // RecordInput 新增/更新紀錄的輸入資料
 // 原因：分離輸入與輸出結構，避免欄位混淆
 type RecordInput struct {
-	Date       string  `json:"date" binding:"required"`
-	AccountID  int     `json:"account_id" binding:"required"`
-	Type       string  `json:"type"`
-	Amount     float64 `json:"amount" binding:"required"`
-	Item       string  `json:"item" binding:"required"`
-	CategoryID int     `json:"category_id" binding:"required"`
-	Note       string  `json:"note"`
+	Date       string `json:"date" binding:"required"`
+	AccountID  int    `json:"account_id" binding:"required"`
+	Type       string `json:"type"`
+	Amount     Money  `json:"amount" binding:"required"`
+	Item       string `json:"item" binding:"required"`
+	CategoryID int    `json:"category_id"` // 新增時可省略，依過去同名紀錄建議
+	Note       string `json:"note"`
+}
+
+// ToRecord 轉換為 Record（不含 ID 與時間戳記）
+func (in RecordInput) ToRecord() *Record {
+	return &Record{
+		Date:       in.Date,
+		AccountID:  in.AccountID,
+		Type:       in.Type,
+		Amount:     in.Amount,
+		Item:       in.Item,
+		CategoryID: in.CategoryID,
+		Note:       in.Note,
+	}
 }
@@ -0,0 +1,57 @@
+package models
+
+// 定期紀錄的重複頻率
+const (
+	FrequencyDaily   = "daily"
+	FrequencyWeekly  = "weekly"
+	FrequencyMonthly = "monthly"
+	FrequencyYearly  = "yearly"
+)
+
+// RecurringRule 定期紀錄規則（房租、訂閱、薪資等）
+// 原因：每期的紀錄由排程自動新增，不需每個月手動輸入
+// 第 n 次的日期為 StartDate 加上 n × Interval 個頻率單位；月底日期遇到較短的月份時改為該月最後一天
+type RecurringRule struct {
+	ID           int    `json:"id"`
+	BookID       int    `json:"-"`
+	UserID       int    `json:"-"` // 建立規則的使用者，自動新增的紀錄記錄為此使用者建立
+	Item         string `json:"item"`
+	AccountID    int    `json:"account_id"`
+	AccountName  string `json:"account_name"`
+	Currency     string `json:"currency"` // 帳戶幣別，金額以此幣別計價
+	CategoryID   int    `json:"category_id"`
+	CategoryName string `json:"category_name"`
+	Type         string `json:"type"`
+	Amount       Money  `json:"amount"`
+	Note         string `json:"note"`
+	Frequency    string `json:"frequency"` // daily、weekly、monthly、yearly
+	Interval     int    `json:"interval"`  // 每幾個頻率單位一次
+	StartDate    string `json:"start_date"`
+	EndDate      string `json:"end_date"` // 空字串代表不限
+	// NextDate 下一次新增紀錄的日期；超過 EndDate 代表規則已結束
+	NextDate string `json:"next_date"`
+	// Occurrences 已經過的次數（含跳過），NextDate 為第 Occurrences 次的日期
+	Occurrences int    `json:"occurrences"`
+	Paused      bool   `json:"paused"`
+	CreatedAt   string `json:"created_at"`
+	UpdatedAt   string `json:"updated_at"`
+}
+
+// Finished 規則是否已超過結束日期
+func (r *RecurringRule) Finished() bool {
+	return r.EndDate != "" && r.NextDate > r.EndDate
+}
+
+// RecurringRuleInput 新增/更新定期紀錄規則的輸入資料
+type RecurringRuleInput struct {
+	Item       string `json:"item" binding:"required"`
+	AccountID  int    `json:"account_id" binding:"required"`
+	CategoryID int    `json:"category_id" binding:"required"`
+	Type       string `json:"type"`
+	Amount     Money  `json:"amount" binding:"required"`
+	Note       string `json:"note"`
+	Frequency  string `json:"frequency" binding:"required"`
+	Interval   int    `json:"interval"`   // 省略時為 1
+	StartDate  string `json:"start_date"` // 省略時為今天
+	EndDate    string `json:"end_date"`
+}
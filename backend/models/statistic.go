@@ -0,0 +1,43 @@
+package models
+
+// StatisticFilter 統計查詢條件
+// 原因：統計頁可依月份或年份，搭配帳戶、分類篩選；Bot 的每週摘要依日期區間統計
+type StatisticFilter struct {
+	Month      string // 格式 2006-01，與 Year 擇一
+	Year       string // 格式 2006
+	From       string // 格式 2006-01-02，與 To 一起指定時取代 Month 與 Year
+	To         string // 格式 2006-01-02（含當天）
+	AccountID  int    // 0 代表不篩選
+	CategoryID int    // 0 代表不篩選
+	Type       string // 收入或支出，空字串代表不篩選
+
+	// IncludeTransfers 是否計入轉帳產生的紀錄
+	// 原因：轉帳只是資金移動，預設不算收入或支出
+	IncludeTransfers bool
+}
+
+// CategoryTotal 單一分類在某類型（收入/支出）下的加總
+// 原因：不同幣別的金額不能直接相加，依幣別分開統計後再換算
+type CategoryTotal struct {
+	CategoryID   int
+	CategoryName string
+	Type         string
+	Currency     string
+	Total        Money
+}
+
+// TypeTotal 單一幣別的收入與支出總額
+type TypeTotal struct {
+	Currency string
+	Income   Money
+	Expense  Money
+}
+
+// DailyTotal 單日某類型（收入/支出）某幣別的加總
+// 原因：行事曆每日摘要需要的資料，依幣別分開以便換算後再合併
+type DailyTotal struct {
+	Date     string
+	Type     string
+	Currency string
+	Total    Money
+}
@@ -0,0 +1,14 @@
+package models
+
+// Suggestion 依過去同名紀錄推測的分類與帳戶
+// 原因：同一個項目（如「咖啡」）通常記在同一個分類與帳戶，新增時可自動帶入
+type Suggestion struct {
+	Item               string  `json:"item"`
+	CategoryID         int     `json:"category_id,omitempty"`
+	CategoryName       string  `json:"category_name,omitempty"`
+	CategoryConfidence float64 `json:"category_confidence"` // 建議分類在同名紀錄中的加權比例（0～1）
+	AccountID          int     `json:"account_id,omitempty"`
+	AccountName        string  `json:"account_name,omitempty"`
+	AccountConfidence  float64 `json:"account_confidence"`
+	Samples            int     `json:"samples"` // 參考的紀錄筆數，0 代表沒有同名紀錄
+}
@@ -0,0 +1,27 @@
+package models
+
+// Transfer 帳戶間轉帳
+// 原因：轉帳會同時影響兩個帳戶，需以單一結構描述兩端；
+// 對應 transfers 資料表，轉出、轉入兩筆 records 以 transfer_id 指向此筆轉帳
+type Transfer struct {
+	ID            int    `json:"id"`
+	BookID        int    `json:"-"`
+	UserID        int    `json:"-"` // 建立此轉帳的使用者
+	Date          string `json:"date"`
+	FromAccountID int    `json:"from_account_id"`
+	ToAccountID   int    `json:"to_account_id"`
+	Amount        Money  `json:"amount"`    // 轉出金額（轉出帳戶幣別）
+	ToAmount      Money  `json:"to_amount"` // 轉入金額（轉入帳戶幣別），同幣別時等於 Amount
+	Note          string `json:"note"`
+	CreatedAt     string `json:"created_at"`
+	UpdatedAt     string `json:"updated_at"`
+}
+
+// TransferWithNames 帶有帳戶名稱的轉帳
+// 原因：API 回應時需要顯示帳戶名稱，並附上轉出、轉入兩筆紀錄
+type TransferWithNames struct {
+	Transfer
+	FromAccountName string            `json:"from_account_name"`
+	ToAccountName   string            `json:"to_account_name"`
+	Records         []RecordWithNames `json:"records,omitempty"`
+}
@@ -0,0 +1,32 @@
+package models
+
+// DefaultUserID 預設使用者
+// 原因：尚未綁定的 Telegram 聊天室視為此使用者
+const DefaultUserID = 1
+
+// DefaultAccountNames 新帳本的預設帳戶（依排序）
+var DefaultAccountNames = []string{"現金", "信用卡", "銀行帳戶"}
+
+// DefaultCategoryNames 新帳本的預設分類（依排序）
+var DefaultCategoryNames = []string{"飲食", "交通", "服飾", "3C", "娛樂", "其他"}
+
+// User 使用者模型
+// 原因：對應 users 資料表，使用者透過帳本成員身分存取帳戶、分類與紀錄
+type User struct {
+	ID          int    `json:"id"`
+	Username    string `json:"username"`
+	DisplayName string `json:"display_name"`
+	IsAdmin     bool   `json:"is_admin"`     // 管理員可建立使用者、修改他人資料與匯率
+	HasPassword bool   `json:"has_password"` // 是否已設定密碼（未設定時無法以密碼登入）
+	CreatedAt   string `json:"created_at"`
+	UpdatedAt   string `json:"updated_at"`
+}
+
+// TelegramChat Telegram 聊天室與使用者的對應
+// 原因：Bot 收到訊息時依聊天室找出使用者，以及以 /book 切換的目前帳本
+type TelegramChat struct {
+	ChatID    int64  `json:"chat_id"`
+	UserID    int    `json:"user_id"`
+	BookID    int    `json:"book_id,omitempty"` // 0 代表未切換，使用者的個人帳本
+	CreatedAt string `json:"created_at"`
+}
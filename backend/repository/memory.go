@@ -0,0 +1,1677 @@
+package repository
+
+import (
+	"accountbook/models"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// 確認 MemoryStore 實作完整的 Store 介面
+var _ Store = (*MemoryStore)(nil)
+
+// MemoryStore 以記憶體實作的 Store
+// 原因：單元測試不需建立 SQLite 檔案即可驗證記帳流程
+type MemoryStore struct {
+	mu     *sync.Mutex
+	data   *memoryData
+	inTx   bool // Transaction 內已持有鎖，不可重複加鎖
+	bookID int  // 0 代表不限帳本
+	userID int  // 新增紀錄與轉帳時記錄的操作者
+}
+
+// memoryData 所有資料表的內容
+type memoryData struct {
+	accounts   map[int]models.Account
+	categories map[int]models.Category
+	aliases    map[int]models.Alias
+	records    map[int]models.Record
+	transfers  map[int]models.Transfer
+	budgets    map[int]models.Budget
+	recurring  map[int]models.RecurringRule
+	rates      map[int]models.ExchangeRate
+	users      map[int]models.User
+	passwords  map[int]string // 使用者的密碼雜湊
+	tokens     map[int]models.APIToken
+	books      map[int]models.Book
+	members    map[[2]int]models.BookMember // key 為 {book_id, user_id}
+	chats      map[int64]models.TelegramChat
+	sessions   map[int64]models.BotSession
+	digests    map[digestKey]models.DigestSubscription
+	settings   map[string]string
+	nextID     map[string]int
+}
+
+// NewMemoryStore 建立空的 MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		mu: &sync.Mutex{},
+		data: &memoryData{
+			accounts:   make(map[int]models.Account),
+			categories: make(map[int]models.Category),
+			aliases:    make(map[int]models.Alias),
+			records:    make(map[int]models.Record),
+			transfers:  make(map[int]models.Transfer),
+			budgets:    make(map[int]models.Budget),
+			recurring:  make(map[int]models.RecurringRule),
+			rates:      make(map[int]models.ExchangeRate),
+			users:      make(map[int]models.User),
+			passwords:  make(map[int]string),
+			tokens:     make(map[int]models.APIToken),
+			books:      make(map[int]models.Book),
+			members:    make(map[[2]int]models.BookMember),
+			chats:      make(map[int64]models.TelegramChat),
+			sessions:   make(map[int64]models.BotSession),
+			digests:    make(map[digestKey]models.DigestSubscription),
+			settings:   make(map[string]string),
+			nextID:     make(map[string]int),
+		},
+	}
+}
+
+func (s *MemoryStore) Accounts() AccountStore           { return &memoryAccounts{s} }
+func (s *MemoryStore) Categories() CategoryStore        { return &memoryCategories{s} }
+func (s *MemoryStore) Aliases() AliasStore              { return &memoryAliases{s} }
+func (s *MemoryStore) Records() RecordStore             { return &memoryRecords{s} }
+func (s *MemoryStore) Transfers() TransferStore         { return &memoryTransfers{s} }
+func (s *MemoryStore) Budgets() BudgetStore             { return &memoryBudgets{s} }
+func (s *MemoryStore) Recurring() RecurringStore        { return &memoryRecurring{s} }
+func (s *MemoryStore) ExchangeRates() ExchangeRateStore { return &memoryExchangeRates{s} }
+func (s *MemoryStore) Users() UserStore                 { return &memoryUsers{s} }
+func (s *MemoryStore) APITokens() APITokenStore         { return &memoryAPITokens{s} }
+func (s *MemoryStore) Books() BookStore                 { return &memoryBooks{s} }
+func (s *MemoryStore) TelegramChats() TelegramChatStore { return &memoryTelegramChats{s} }
+func (s *MemoryStore) BotSessions() BotSessionStore     { return &memoryBotSessions{s} }
+func (s *MemoryStore) Digests() DigestStore             { return &memoryDigests{s} }
+func (s *MemoryStore) Settings() SettingStore           { return &memorySettings{s} }
+
+// ForBook 回傳限定帳本的 Store（共用同一份資料與鎖）
+func (s *MemoryStore) ForBook(bookID, userID int) Store {
+	return &MemoryStore{mu: s.mu, data: s.data, inTx: s.inTx, bookID: bookID, userID: userID}
+}
+
+// BookID 目前限定的帳本
+func (s *MemoryStore) BookID() int { return s.bookID }
+
+// UserID 目前的操作者
+func (s *MemoryStore) UserID() int { return s.userID }
+
+// owns 資料是否屬於目前限定的帳本
+func (s *MemoryStore) owns(bookID int) bool {
+	return s.bookID == 0 || s.bookID == bookID
+}
+
+// owner 新增帳戶、分類時寫入的 book_id，不限帳本的 Store 不可新增
+func (s *MemoryStore) owner() (int, error) {
+	if s.bookID == 0 {
+		return 0, ErrNoBook
+	}
+	return s.bookID, nil
+}
+
+// author 新增紀錄、轉帳時寫入的 book_id 與 user_id
+func (s *MemoryStore) author() (int, int, error) {
+	if s.bookID == 0 || s.userID == 0 {
+		return 0, 0, ErrNoBook
+	}
+	return s.bookID, s.userID, nil
+}
+
+// WithTx 在資料副本上執行 fn，成功才寫回
+func (s *MemoryStore) WithTx(fn func(tx Store) error) error {
+	if s.inTx {
+		return fn(s)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := s.data.clone()
+	if err := fn(&MemoryStore{mu: s.mu, data: snapshot, inTx: true, bookID: s.bookID, userID: s.userID}); err != nil {
+		return err
+	}
+	*s.data = *snapshot
+	return nil
+}
+
+// lock 取得鎖並回傳解鎖函式（Transaction 內為空操作）
+func (s *MemoryStore) lock() func() {
+	if s.inTx {
+		return func() {}
+	}
+	s.mu.Lock()
+	return s.mu.Unlock
+}
+
+func (d *memoryData) clone() *memoryData {
+	c := &memoryData{
+		accounts:   make(map[int]models.Account, len(d.accounts)),
+		categories: make(map[int]models.Category, len(d.categories)),
+		aliases:    make(map[int]models.Alias, len(d.aliases)),
+		records:    make(map[int]models.Record, len(d.records)),
+		transfers:  make(map[int]models.Transfer, len(d.transfers)),
+		budgets:    make(map[int]models.Budget, len(d.budgets)),
+		recurring:  make(map[int]models.RecurringRule, len(d.recurring)),
+		rates:      make(map[int]models.ExchangeRate, len(d.rates)),
+		users:      make(map[int]models.User, len(d.users)),
+		passwords:  make(map[int]string, len(d.passwords)),
+		tokens:     make(map[int]models.APIToken, len(d.tokens)),
+		books:      make(map[int]models.Book, len(d.books)),
+		members:    make(map[[2]int]models.BookMember, len(d.members)),
+		chats:      make(map[int64]models.TelegramChat, len(d.chats)),
+		sessions:   make(map[int64]models.BotSession, len(d.sessions)),
+		digests:    make(map[digestKey]models.DigestSubscription, len(d.digests)),
+		settings:   make(map[string]string, len(d.settings)),
+		nextID:     make(map[string]int, len(d.nextID)),
+	}
+	for k, v := range d.accounts {
+		c.accounts[k] = v
+	}
+	for k, v := range d.categories {
+		c.categories[k] = v
+	}
+	for k, v := range d.aliases {
+		c.aliases[k] = v
+	}
+	for k, v := range d.records {
+		c.records[k] = v
+	}
+	for k, v := range d.transfers {
+		c.transfers[k] = v
+	}
+	for k, v := range d.budgets {
+		c.budgets[k] = v
+	}
+	for k, v := range d.recurring {
+		c.recurring[k] = v
+	}
+	for k, v := range d.rates {
+		c.rates[k] = v
+	}
+	for k, v := range d.users {
+		c.users[k] = v
+	}
+	for k, v := range d.passwords {
+		c.passwords[k] = v
+	}
+	for k, v := range d.tokens {
+		c.tokens[k] = v
+	}
+	for k, v := range d.books {
+		c.books[k] = v
+	}
+	for k, v := range d.members {
+		c.members[k] = v
+	}
+	for k, v := range d.chats {
+		c.chats[k] = v
+	}
+	for k, v := range d.sessions {
+		c.sessions[k] = v
+	}
+	for k, v := range d.digests {
+		c.digests[k] = v
+	}
+	for k, v := range d.settings {
+		c.settings[k] = v
+	}
+	for k, v := range d.nextID {
+		c.nextID[k] = v
+	}
+	return c
+}
+
+// newID 模擬 AUTOINCREMENT
+func (d *memoryData) newID(table string) int {
+	d.nextID[table]++
+	return d.nextID[table]
+}
+
+// === 帳戶 ===
+
+type memoryAccounts struct{ s *MemoryStore }
+
+// get 取得目前帳本的帳戶
+func (m *memoryAccounts) get(id int) (models.Account, bool) {
+	a, ok := m.s.data.accounts[id]
+	if !ok || !m.s.owns(a.BookID) {
+		return models.Account{}, false
+	}
+	return a, true
+}
+
+// findByName 同一使用者內依名稱尋找帳戶
+func (m *memoryAccounts) findByName(bookID int, name string) (*models.Account, bool) {
+	for _, a := range m.s.data.accounts {
+		if a.BookID == bookID && a.Name == name {
+			return &a, true
+		}
+	}
+	return nil, false
+}
+
+func (m *memoryAccounts) List() ([]models.Account, error) {
+	defer m.s.lock()()
+	var accounts []models.Account
+	for _, a := range m.s.data.accounts {
+		if m.s.owns(a.BookID) {
+			accounts = append(accounts, a)
+		}
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		if accounts[i].SortOrder != accounts[j].SortOrder {
+			return accounts[i].SortOrder < accounts[j].SortOrder
+		}
+		return accounts[i].ID < accounts[j].ID
+	})
+	return accounts, nil
+}
+
+func (m *memoryAccounts) Get(id int) (*models.Account, error) {
+	defer m.s.lock()()
+	a, ok := m.get(id)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &a, nil
+}
+
+func (m *memoryAccounts) FindByName(name string) (*models.Account, error) {
+	defer m.s.lock()()
+	for _, a := range m.s.data.accounts {
+		if m.s.owns(a.BookID) && a.Name == name {
+			return &a, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (m *memoryAccounts) Create(a *models.Account) error {
+	defer m.s.lock()()
+	bookID, err := m.s.owner()
+	if err != nil {
+		return err
+	}
+	if _, exists := m.findByName(bookID, a.Name); exists {
+		return ErrDuplicate
+	}
+	maxOrder := -1
+	for _, existing := range m.s.data.accounts {
+		if existing.BookID == bookID && existing.SortOrder > maxOrder {
+			maxOrder = existing.SortOrder
+		}
+	}
+
+	ts := now()
+	a.ID = m.s.data.newID("accounts")
+	a.BookID = bookID
+	a.SortOrder = maxOrder + 1
+	a.CreatedAt = ts
+	a.UpdatedAt = ts
+	m.s.data.accounts[a.ID] = *a
+	return nil
+}
+
+func (m *memoryAccounts) Rename(id int, name string) error {
+	defer m.s.lock()()
+	a, ok := m.get(id)
+	if !ok {
+		return ErrNotFound
+	}
+	if existing, exists := m.findByName(a.BookID, name); exists && existing.ID != id {
+		return ErrDuplicate
+	}
+	a.Name = name
+	a.UpdatedAt = now()
+	m.s.data.accounts[id] = a
+	return nil
+}
+
+func (m *memoryAccounts) SetCurrency(id int, currency string) error {
+	defer m.s.lock()()
+	a, ok := m.get(id)
+	if !ok {
+		return ErrNotFound
+	}
+	a.Currency = currency
+	a.UpdatedAt = now()
+	m.s.data.accounts[id] = a
+	return nil
+}
+
+func (m *memoryAccounts) SetBalance(id int, balance models.Money) error {
+	defer m.s.lock()()
+	a, ok := m.get(id)
+	if !ok {
+		return ErrNotFound
+	}
+	a.Balance = balance
+	a.UpdatedAt = now()
+	m.s.data.accounts[id] = a
+	return nil
+}
+
+func (m *memoryAccounts) SetOpeningBalance(id int, openingBalance models.Money) error {
+	defer m.s.lock()()
+	a, ok := m.get(id)
+	if !ok {
+		return ErrNotFound
+	}
+	a.OpeningBalance = openingBalance
+	a.UpdatedAt = now()
+	m.s.data.accounts[id] = a
+	return nil
+}
+
+func (m *memoryAccounts) AdjustBalance(id int, delta models.Money) error {
+	defer m.s.lock()()
+	a, ok := m.get(id)
+	if !ok {
+		return ErrNotFound
+	}
+	a.Balance += delta
+	a.UpdatedAt = now()
+	m.s.data.accounts[id] = a
+	return nil
+}
+
+func (m *memoryAccounts) Delete(id int) error {
+	defer m.s.lock()()
+	if _, ok := m.get(id); !ok {
+		return ErrNotFound
+	}
+	delete(m.s.data.accounts, id)
+	// 與 SQLite 的 ON DELETE CASCADE 一致，一併刪除帳戶的別名與定期紀錄規則
+	for aliasID, a := range m.s.data.aliases {
+		if a.AccountID == id {
+			delete(m.s.data.aliases, aliasID)
+		}
+	}
+	for ruleID, r := range m.s.data.recurring {
+		if r.AccountID == id {
+			delete(m.s.data.recurring, ruleID)
+		}
+	}
+	return nil
+}
+
+// === 分類 ===
+
+type memoryCategories struct{ s *MemoryStore }
+
+// get 取得目前帳本的分類
+func (m *memoryCategories) get(id int) (models.Category, bool) {
+	c, ok := m.s.data.categories[id]
+	if !ok || !m.s.owns(c.BookID) {
+		return models.Category{}, false
+	}
+	return c, true
+}
+
+func (m *memoryCategories) List() ([]models.Category, error) {
+	defer m.s.lock()()
+	var categories []models.Category
+	for _, c := range m.s.data.categories {
+		if m.s.owns(c.BookID) {
+			categories = append(categories, c)
+		}
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		if categories[i].SortOrder != categories[j].SortOrder {
+			return categories[i].SortOrder < categories[j].SortOrder
+		}
+		return categories[i].ID < categories[j].ID
+	})
+	return categories, nil
+}
+
+func (m *memoryCategories) Get(id int) (*models.Category, error) {
+	defer m.s.lock()()
+	c, ok := m.get(id)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &c, nil
+}
+
+func (m *memoryCategories) FindByName(name string) (*models.Category, error) {
+	defer m.s.lock()()
+	return m.findByName(name)
+}
+
+func (m *memoryCategories) findByName(name string) (*models.Category, error) {
+	for _, c := range m.s.data.categories {
+		if m.s.owns(c.BookID) && c.Name == name {
+			return &c, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (m *memoryCategories) Create(c *models.Category) error {
+	defer m.s.lock()()
+	maxOrder := -1
+	for _, existing := range m.s.data.categories {
+		if existing.BookID == m.s.bookID && existing.SortOrder > maxOrder {
+			maxOrder = existing.SortOrder
+		}
+	}
+	c.SortOrder = maxOrder + 1
+	return m.insert(c)
+}
+
+func (m *memoryCategories) FindOrCreate(name string, sortOrder int) (*models.Category, error) {
+	defer m.s.lock()()
+	if c, err := m.findByName(name); err == nil {
+		return c, nil
+	}
+	c := &models.Category{Name: name, SortOrder: sortOrder}
+	if err := m.insert(c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (m *memoryCategories) insert(c *models.Category) error {
+	bookID, err := m.s.owner()
+	if err != nil {
+		return err
+	}
+	if _, err := m.findByName(c.Name); err == nil {
+		return ErrDuplicate
+	}
+	ts := now()
+	c.ID = m.s.data.newID("categories")
+	c.BookID = bookID
+	c.CreatedAt = ts
+	c.UpdatedAt = ts
+	m.s.data.categories[c.ID] = *c
+	return nil
+}
+
+func (m *memoryCategories) Rename(id int, name string) error {
+	defer m.s.lock()()
+	c, ok := m.get(id)
+	if !ok {
+		return ErrNotFound
+	}
+	if existing, err := m.findByName(name); err == nil && existing.ID != id {
+		return ErrDuplicate
+	}
+	c.Name = name
+	c.UpdatedAt = now()
+	m.s.data.categories[id] = c
+	return nil
+}
+
+func (m *memoryCategories) Delete(id int) error {
+	defer m.s.lock()()
+	if _, ok := m.get(id); !ok {
+		return ErrNotFound
+	}
+	delete(m.s.data.categories, id)
+	// 與 SQLite 的 ON DELETE CASCADE 一致，一併刪除分類的別名、預算與定期紀錄規則
+	for aliasID, a := range m.s.data.aliases {
+		if a.CategoryID == id {
+			delete(m.s.data.aliases, aliasID)
+		}
+	}
+	for budgetID, b := range m.s.data.budgets {
+		if b.CategoryID == id {
+			delete(m.s.data.budgets, budgetID)
+		}
+	}
+	for ruleID, r := range m.s.data.recurring {
+		if r.CategoryID == id {
+			delete(m.s.data.recurring, ruleID)
+		}
+	}
+	return nil
+}
+
+// === 別名 ===
+
+type memoryAliases struct{ s *MemoryStore }
+
+// withTarget 補上帳戶或分類名稱
+func (m *memoryAliases) withTarget(a models.Alias) models.Alias {
+	if a.AccountID != 0 {
+		a.TargetName = m.s.data.accounts[a.AccountID].Name
+	} else {
+		a.TargetName = m.s.data.categories[a.CategoryID].Name
+	}
+	return a
+}
+
+func (m *memoryAliases) List() ([]models.Alias, error) {
+	defer m.s.lock()()
+	var aliases []models.Alias
+	for _, a := range m.s.data.aliases {
+		if m.s.owns(a.BookID) {
+			aliases = append(aliases, m.withTarget(a))
+		}
+	}
+	sort.Slice(aliases, func(i, j int) bool { return aliases[i].Name < aliases[j].Name })
+	return aliases, nil
+}
+
+func (m *memoryAliases) Create(a *models.Alias) error {
+	defer m.s.lock()()
+	bookID, err := m.s.owner()
+	if err != nil {
+		return err
+	}
+	if _, ok := m.s.data.accounts[a.AccountID]; a.AccountID != 0 && !ok {
+		return ErrNotFound
+	}
+	if _, ok := m.s.data.categories[a.CategoryID]; a.CategoryID != 0 && !ok {
+		return ErrNotFound
+	}
+	for _, existing := range m.s.data.aliases {
+		if existing.BookID == bookID && strings.EqualFold(existing.Name, a.Name) {
+			return ErrDuplicate
+		}
+	}
+	a.ID = m.s.data.newID("aliases")
+	a.BookID = bookID
+	a.CreatedAt = now()
+	m.s.data.aliases[a.ID] = *a
+	*a = m.withTarget(*a)
+	return nil
+}
+
+func (m *memoryAliases) Delete(id int) error {
+	defer m.s.lock()()
+	if a, ok := m.s.data.aliases[id]; !ok || !m.s.owns(a.BookID) {
+		return ErrNotFound
+	}
+	delete(m.s.data.aliases, id)
+	return nil
+}
+
+// === 紀錄 ===
+
+type memoryRecords struct{ s *MemoryStore }
+
+// withNames 補上帳戶與分類名稱
+func (m *memoryRecords) withNames(r models.Record) models.RecordWithNames {
+	return models.RecordWithNames{
+		ID:           r.ID,
+		Date:         r.Date,
+		AccountID:    r.AccountID,
+		AccountName:  m.s.data.accounts[r.AccountID].Name,
+		Currency:     m.s.data.accounts[r.AccountID].Currency,
+		Type:         r.Type,
+		Amount:       r.Amount,
+		Item:         r.Item,
+		CategoryID:   r.CategoryID,
+		CategoryName: m.s.data.categories[r.CategoryID].Name,
+		Note:         r.Note,
+		TransferID:   r.TransferID,
+	}
+}
+
+// filter 依條件篩選紀錄，依 ID 由新到舊排序
+func (m *memoryRecords) filter(match func(r models.Record) bool) []models.Record {
+	var records []models.Record
+	for _, r := range m.s.data.records {
+		if m.s.owns(r.BookID) && match(r) {
+			records = append(records, r)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].ID > records[j].ID })
+	return records
+}
+
+func (m *memoryRecords) Get(id int) (*models.RecordWithNames, error) {
+	defer m.s.lock()()
+	r, ok := m.s.data.records[id]
+	if !ok || !m.s.owns(r.BookID) {
+		return nil, ErrNotFound
+	}
+	rn := m.withNames(r)
+	return &rn, nil
+}
+
+func (m *memoryRecords) ListByDate(date string) ([]models.RecordWithNames, error) {
+	defer m.s.lock()()
+	var result []models.RecordWithNames
+	for _, r := range m.filter(func(r models.Record) bool { return r.Date == date }) {
+		result = append(result, m.withNames(r))
+	}
+	return result, nil
+}
+
+func (m *memoryRecords) Recent(offset, limit int) ([]models.RecordWithNames, int, error) {
+	defer m.s.lock()()
+	records := m.filter(func(models.Record) bool { return true })
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Date > records[j].Date })
+
+	var result []models.RecordWithNames
+	for i := offset; i < len(records) && i < offset+limit; i++ {
+		result = append(result, m.withNames(records[i]))
+	}
+	return result, len(records), nil
+}
+
+func (m *memoryRecords) ListByStatistic(filter models.StatisticFilter, offset, limit int) ([]models.RecordWithNames, int, error) {
+	defer m.s.lock()()
+	records := m.filter(func(r models.Record) bool { return matchStatistic(r, filter) })
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Date > records[j].Date })
+
+	var result []models.RecordWithNames
+	for i := offset; i < len(records) && i < offset+limit; i++ {
+		result = append(result, m.withNames(records[i]))
+	}
+	return result, len(records), nil
+}
+
+func (m *memoryRecords) ListByItem(item string, limit int) ([]models.RecordWithNames, error) {
+	defer m.s.lock()()
+	records := m.filter(func(r models.Record) bool { return r.TransferID == nil && strings.EqualFold(r.Item, item) })
+	sort.SliceStable(records, func(i, j int) bool {
+		if records[i].Date != records[j].Date {
+			return records[i].Date > records[j].Date
+		}
+		return records[i].ID > records[j].ID
+	})
+
+	var result []models.RecordWithNames
+	for i := 0; i < len(records) && i < limit; i++ {
+		result = append(result, m.withNames(records[i]))
+	}
+	return result, nil
+}
+
+func (m *memoryRecords) ListByTransfer(transferID int) ([]models.RecordWithNames, error) {
+	defer m.s.lock()()
+	records := m.filter(func(r models.Record) bool { return r.TransferID != nil && *r.TransferID == transferID })
+	sort.Slice(records, func(i, j int) bool { return records[i].ID < records[j].ID })
+
+	var result []models.RecordWithNames
+	for _, r := range records {
+		result = append(result, m.withNames(r))
+	}
+	return result, nil
+}
+
+func (m *memoryRecords) DailyTotals(month string) ([]models.DailyTotal, error) {
+	defer m.s.lock()()
+	sums := make(map[[3]string]models.Money)
+	for _, r := range m.filter(func(r models.Record) bool { return r.TransferID == nil && strings.HasPrefix(r.Date, month+"-") }) {
+		sums[[3]string{r.Date, r.Type, m.s.data.accounts[r.AccountID].Currency}] += r.Amount
+	}
+
+	var totals []models.DailyTotal
+	for key, total := range sums {
+		totals = append(totals, models.DailyTotal{Date: key[0], Type: key[1], Currency: key[2], Total: total})
+	}
+	sort.Slice(totals, func(i, j int) bool {
+		if totals[i].Date != totals[j].Date {
+			return totals[i].Date < totals[j].Date
+		}
+		if totals[i].Type != totals[j].Type {
+			return totals[i].Type < totals[j].Type
+		}
+		return totals[i].Currency < totals[j].Currency
+	})
+	return totals, nil
+}
+
+// matchStatistic 判斷紀錄是否符合統計條件
+func matchStatistic(r models.Record, filter models.StatisticFilter) bool {
+	prefix := filter.Year + "-"
+	if filter.Month != "" {
+		prefix = filter.Month + "-"
+	}
+	if filter.From != "" {
+		if r.Date < filter.From || r.Date > filter.To {
+			return false
+		}
+	} else if !strings.HasPrefix(r.Date, prefix) {
+		return false
+	}
+	if filter.AccountID != 0 && r.AccountID != filter.AccountID {
+		return false
+	}
+	if filter.CategoryID != 0 && r.CategoryID != filter.CategoryID {
+		return false
+	}
+	if filter.Type != "" && r.Type != filter.Type {
+		return false
+	}
+	return filter.IncludeTransfers || r.TransferID == nil
+}
+
+func (m *memoryRecords) CategoryTotals(filter models.StatisticFilter) ([]models.CategoryTotal, error) {
+	defer m.s.lock()()
+	type key struct {
+		categoryID int
+		recordType string
+		currency   string
+	}
+	sums := make(map[key]models.Money)
+	for _, r := range m.filter(func(r models.Record) bool { return matchStatistic(r, filter) }) {
+		sums[key{r.CategoryID, r.Type, m.s.data.accounts[r.AccountID].Currency}] += r.Amount
+	}
+
+	var totals []models.CategoryTotal
+	for k, total := range sums {
+		totals = append(totals, models.CategoryTotal{
+			CategoryID:   k.categoryID,
+			CategoryName: m.s.data.categories[k.categoryID].Name,
+			Type:         k.recordType,
+			Currency:     k.currency,
+			Total:        total,
+		})
+	}
+	sort.Slice(totals, func(i, j int) bool { return totals[i].Total > totals[j].Total })
+	return totals, nil
+}
+
+func (m *memoryRecords) TypeTotals(filter models.StatisticFilter) ([]models.TypeTotal, error) {
+	defer m.s.lock()()
+	sums := make(map[string]*models.TypeTotal)
+	for _, r := range m.filter(func(r models.Record) bool { return matchStatistic(r, filter) }) {
+		currency := m.s.data.accounts[r.AccountID].Currency
+		t, ok := sums[currency]
+		if !ok {
+			t = &models.TypeTotal{Currency: currency}
+			sums[currency] = t
+		}
+		switch r.Type {
+		case "收入":
+			t.Income += r.Amount
+		case "支出":
+			t.Expense += r.Amount
+		}
+	}
+
+	var totals []models.TypeTotal
+	for _, t := range sums {
+		totals = append(totals, *t)
+	}
+	sort.Slice(totals, func(i, j int) bool { return totals[i].Currency < totals[j].Currency })
+	return totals, nil
+}
+
+func (m *memoryRecords) AccountTotals(accountID int) (income, expense models.Money, err error) {
+	defer m.s.lock()()
+	for _, r := range m.filter(func(r models.Record) bool { return r.AccountID == accountID }) {
+		if r.Type == "支出" {
+			expense += r.Amount
+		} else {
+			income += r.Amount
+		}
+	}
+	return income, expense, nil
+}
+
+func (m *memoryRecords) CountByAccount(accountID int) (int, error) {
+	defer m.s.lock()()
+	return len(m.filter(func(r models.Record) bool { return r.AccountID == accountID })), nil
+}
+
+func (m *memoryRecords) CountByCategory(categoryID int) (int, error) {
+	defer m.s.lock()()
+	return len(m.filter(func(r models.Record) bool { return r.CategoryID == categoryID })), nil
+}
+
+// checkReferences 模擬外鍵約束
+func (m *memoryRecords) checkReferences(r *models.Record) error {
+	if _, ok := m.s.data.accounts[r.AccountID]; !ok {
+		return ErrNotFound
+	}
+	if _, ok := m.s.data.categories[r.CategoryID]; !ok {
+		return ErrNotFound
+	}
+	if r.TransferID != nil {
+		if _, ok := m.s.data.transfers[*r.TransferID]; !ok {
+			return ErrNotFound
+		}
+	}
+	return nil
+}
+
+func (m *memoryRecords) Insert(r *models.Record) error {
+	defer m.s.lock()()
+	bookID, userID, err := m.s.author()
+	if err != nil {
+		return err
+	}
+	if err := m.checkReferences(r); err != nil {
+		return err
+	}
+	ts := now()
+	r.ID = m.s.data.newID("records")
+	r.BookID = bookID
+	r.UserID = userID
+	r.CreatedAt = ts
+	r.UpdatedAt = ts
+	m.s.data.records[r.ID] = *r
+	return nil
+}
+
+func (m *memoryRecords) Update(r *models.Record) error {
+	defer m.s.lock()()
+	old, ok := m.s.data.records[r.ID]
+	if !ok || !m.s.owns(old.BookID) {
+		return ErrNotFound
+	}
+	if err := m.checkReferences(r); err != nil {
+		return err
+	}
+	r.BookID = old.BookID
+	r.UserID = old.UserID
+	r.TransferID = old.TransferID // 與 SQLite 一致，Update 不變更 transfer_id
+	r.CreatedAt = old.CreatedAt
+	r.UpdatedAt = now()
+	m.s.data.records[r.ID] = *r
+	return nil
+}
+
+func (m *memoryRecords) Delete(id int) error {
+	defer m.s.lock()()
+	if r, ok := m.s.data.records[id]; !ok || !m.s.owns(r.BookID) {
+		return ErrNotFound
+	}
+	delete(m.s.data.records, id)
+	return nil
+}
+
+// === 轉帳 ===
+
+type memoryTransfers struct{ s *MemoryStore }
+
+func (m *memoryTransfers) Get(id int) (*models.TransferWithNames, error) {
+	defer m.s.lock()()
+	t, ok := m.s.data.transfers[id]
+	if !ok || !m.s.owns(t.BookID) {
+		return nil, ErrNotFound
+	}
+	return &models.TransferWithNames{
+		Transfer:        t,
+		FromAccountName: m.s.data.accounts[t.FromAccountID].Name,
+		ToAccountName:   m.s.data.accounts[t.ToAccountID].Name,
+	}, nil
+}
+
+// checkAccounts 模擬外鍵約束
+func (m *memoryTransfers) checkAccounts(t *models.Transfer) error {
+	if _, ok := m.s.data.accounts[t.FromAccountID]; !ok {
+		return ErrNotFound
+	}
+	if _, ok := m.s.data.accounts[t.ToAccountID]; !ok {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (m *memoryTransfers) Insert(t *models.Transfer) error {
+	defer m.s.lock()()
+	bookID, userID, err := m.s.author()
+	if err != nil {
+		return err
+	}
+	if err := m.checkAccounts(t); err != nil {
+		return err
+	}
+	ts := now()
+	t.ID = m.s.data.newID("transfers")
+	t.BookID = bookID
+	t.UserID = userID
+	t.CreatedAt = ts
+	t.UpdatedAt = ts
+	m.s.data.transfers[t.ID] = *t
+	return nil
+}
+
+func (m *memoryTransfers) Update(t *models.Transfer) error {
+	defer m.s.lock()()
+	old, ok := m.s.data.transfers[t.ID]
+	if !ok || !m.s.owns(old.BookID) {
+		return ErrNotFound
+	}
+	if err := m.checkAccounts(t); err != nil {
+		return err
+	}
+	t.BookID = old.BookID
+	t.UserID = old.UserID
+	t.CreatedAt = old.CreatedAt
+	t.UpdatedAt = now()
+	m.s.data.transfers[t.ID] = *t
+	return nil
+}
+
+func (m *memoryTransfers) Delete(id int) error {
+	defer m.s.lock()()
+	if t, ok := m.s.data.transfers[id]; !ok || !m.s.owns(t.BookID) {
+		return ErrNotFound
+	}
+	delete(m.s.data.transfers, id)
+	return nil
+}
+
+// === 預算 ===
+
+type memoryBudgets struct{ s *MemoryStore }
+
+// withName 補上分類名稱
+func (m *memoryBudgets) withName(b models.Budget) models.Budget {
+	b.CategoryName = m.s.data.categories[b.CategoryID].Name
+	return b
+}
+
+func (m *memoryBudgets) List() ([]models.Budget, error) {
+	defer m.s.lock()()
+	var budgets []models.Budget
+	for _, b := range m.s.data.budgets {
+		if m.s.owns(b.BookID) {
+			budgets = append(budgets, m.withName(b))
+		}
+	}
+	sort.Slice(budgets, func(i, j int) bool {
+		if (budgets[i].CategoryID == 0) != (budgets[j].CategoryID == 0) {
+			return budgets[i].CategoryID == 0
+		}
+		oi := m.s.data.categories[budgets[i].CategoryID].SortOrder
+		oj := m.s.data.categories[budgets[j].CategoryID].SortOrder
+		if oi != oj {
+			return oi < oj
+		}
+		return budgets[i].ID < budgets[j].ID
+	})
+	return budgets, nil
+}
+
+func (m *memoryBudgets) Get(id int) (*models.Budget, error) {
+	defer m.s.lock()()
+	b, ok := m.s.data.budgets[id]
+	if !ok || !m.s.owns(b.BookID) {
+		return nil, ErrNotFound
+	}
+	b = m.withName(b)
+	return &b, nil
+}
+
+func (m *memoryBudgets) FindByCategory(categoryID int) (*models.Budget, error) {
+	defer m.s.lock()()
+	return m.findByCategory(categoryID)
+}
+
+func (m *memoryBudgets) findByCategory(categoryID int) (*models.Budget, error) {
+	for _, b := range m.s.data.budgets {
+		if m.s.owns(b.BookID) && b.CategoryID == categoryID {
+			b = m.withName(b)
+			return &b, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (m *memoryBudgets) Create(b *models.Budget) error {
+	defer m.s.lock()()
+	bookID, err := m.s.owner()
+	if err != nil {
+		return err
+	}
+	if _, ok := m.s.data.categories[b.CategoryID]; b.CategoryID != 0 && !ok {
+		return ErrNotFound
+	}
+	if _, err := m.findByCategory(b.CategoryID); err == nil {
+		return ErrDuplicate
+	}
+	ts := now()
+	b.ID = m.s.data.newID("budgets")
+	b.BookID = bookID
+	b.CreatedAt = ts
+	b.UpdatedAt = ts
+	m.s.data.budgets[b.ID] = *b
+	return nil
+}
+
+func (m *memoryBudgets) Update(b *models.Budget) error {
+	defer m.s.lock()()
+	old, ok := m.s.data.budgets[b.ID]
+	if !ok || !m.s.owns(old.BookID) {
+		return ErrNotFound
+	}
+	old.Amount = b.Amount
+	old.Rollover = b.Rollover
+	old.StartMonth = b.StartMonth
+	old.UpdatedAt = now()
+	m.s.data.budgets[b.ID] = old
+	return nil
+}
+
+func (m *memoryBudgets) Delete(id int) error {
+	defer m.s.lock()()
+	if b, ok := m.s.data.budgets[id]; !ok || !m.s.owns(b.BookID) {
+		return ErrNotFound
+	}
+	delete(m.s.data.budgets, id)
+	return nil
+}
+
+// === 定期紀錄規則 ===
+
+type memoryRecurring struct{ s *MemoryStore }
+
+// withNames 補上帳戶與分類名稱
+func (m *memoryRecurring) withNames(r models.RecurringRule) models.RecurringRule {
+	r.AccountName = m.s.data.accounts[r.AccountID].Name
+	r.Currency = m.s.data.accounts[r.AccountID].Currency
+	r.CategoryName = m.s.data.categories[r.CategoryID].Name
+	return r
+}
+
+// filter 列出目前帳本符合條件的規則（依下次日期排序）
+func (m *memoryRecurring) filter(match func(r models.RecurringRule) bool) []models.RecurringRule {
+	var rules []models.RecurringRule
+	for _, r := range m.s.data.recurring {
+		if m.s.owns(r.BookID) && match(r) {
+			rules = append(rules, m.withNames(r))
+		}
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		if rules[i].NextDate != rules[j].NextDate {
+			return rules[i].NextDate < rules[j].NextDate
+		}
+		return rules[i].ID < rules[j].ID
+	})
+	return rules
+}
+
+func (m *memoryRecurring) List() ([]models.RecurringRule, error) {
+	defer m.s.lock()()
+	return m.filter(func(models.RecurringRule) bool { return true }), nil
+}
+
+func (m *memoryRecurring) Due(date string) ([]models.RecurringRule, error) {
+	defer m.s.lock()()
+	return m.filter(func(r models.RecurringRule) bool {
+		return !r.Paused && r.NextDate <= date && !r.Finished()
+	}), nil
+}
+
+func (m *memoryRecurring) Get(id int) (*models.RecurringRule, error) {
+	defer m.s.lock()()
+	r, ok := m.s.data.recurring[id]
+	if !ok || !m.s.owns(r.BookID) {
+		return nil, ErrNotFound
+	}
+	r = m.withNames(r)
+	return &r, nil
+}
+
+// checkReferences 模擬外鍵約束
+func (m *memoryRecurring) checkReferences(r *models.RecurringRule) error {
+	if _, ok := m.s.data.accounts[r.AccountID]; !ok {
+		return ErrNotFound
+	}
+	if _, ok := m.s.data.categories[r.CategoryID]; !ok {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (m *memoryRecurring) Create(r *models.RecurringRule) error {
+	defer m.s.lock()()
+	bookID, userID, err := m.s.author()
+	if err != nil {
+		return err
+	}
+	if err := m.checkReferences(r); err != nil {
+		return err
+	}
+	ts := now()
+	r.ID = m.s.data.newID("recurring_rules")
+	r.BookID = bookID
+	r.UserID = userID
+	r.CreatedAt = ts
+	r.UpdatedAt = ts
+	m.s.data.recurring[r.ID] = *r
+	return nil
+}
+
+func (m *memoryRecurring) Update(r *models.RecurringRule) error {
+	defer m.s.lock()()
+	old, ok := m.s.data.recurring[r.ID]
+	if !ok || !m.s.owns(old.BookID) {
+		return ErrNotFound
+	}
+	if err := m.checkReferences(r); err != nil {
+		return err
+	}
+	r.BookID = old.BookID
+	r.UserID = old.UserID
+	r.CreatedAt = old.CreatedAt
+	r.UpdatedAt = now()
+	m.s.data.recurring[r.ID] = *r
+	return nil
+}
+
+func (m *memoryRecurring) Delete(id int) error {
+	defer m.s.lock()()
+	if r, ok := m.s.data.recurring[id]; !ok || !m.s.owns(r.BookID) {
+		return ErrNotFound
+	}
+	delete(m.s.data.recurring, id)
+	return nil
+}
+
+// === 匯率 ===
+
+type memoryExchangeRates struct{ s *MemoryStore }
+
+func (m *memoryExchangeRates) List(fromCurrency, toCurrency string) ([]models.ExchangeRate, error) {
+	defer m.s.lock()()
+	var rates []models.ExchangeRate
+	for _, r := range m.s.data.rates {
+		if fromCurrency != "" && r.FromCurrency != fromCurrency {
+			continue
+		}
+		if toCurrency != "" && r.ToCurrency != toCurrency {
+			continue
+		}
+		rates = append(rates, r)
+	}
+	sort.Slice(rates, func(i, j int) bool {
+		if rates[i].Date != rates[j].Date {
+			return rates[i].Date > rates[j].Date
+		}
+		if rates[i].FromCurrency != rates[j].FromCurrency {
+			return rates[i].FromCurrency < rates[j].FromCurrency
+		}
+		return rates[i].ToCurrency < rates[j].ToCurrency
+	})
+	return rates, nil
+}
+
+func (m *memoryExchangeRates) Find(fromCurrency, toCurrency, date string) (*models.ExchangeRate, error) {
+	defer m.s.lock()()
+	var found *models.ExchangeRate
+	for _, r := range m.s.data.rates {
+		if r.FromCurrency != fromCurrency || r.ToCurrency != toCurrency || r.Date > date {
+			continue
+		}
+		if found == nil || r.Date > found.Date {
+			r := r
+			found = &r
+		}
+	}
+	if found == nil {
+		return nil, ErrNotFound
+	}
+	return found, nil
+}
+
+func (m *memoryExchangeRates) Save(r *models.ExchangeRate) error {
+	defer m.s.lock()()
+	ts := now()
+	for id, existing := range m.s.data.rates {
+		if existing.Date == r.Date && existing.FromCurrency == r.FromCurrency && existing.ToCurrency == r.ToCurrency {
+			r.ID = id
+			r.CreatedAt = existing.CreatedAt
+			r.UpdatedAt = ts
+			m.s.data.rates[id] = *r
+			return nil
+		}
+	}
+	r.ID = m.s.data.newID("exchange_rates")
+	r.CreatedAt = ts
+	r.UpdatedAt = ts
+	m.s.data.rates[r.ID] = *r
+	return nil
+}
+
+func (m *memoryExchangeRates) Delete(id int) error {
+	defer m.s.lock()()
+	if _, ok := m.s.data.rates[id]; !ok {
+		return ErrNotFound
+	}
+	delete(m.s.data.rates, id)
+	return nil
+}
+
+// === 使用者 ===
+
+type memoryUsers struct{ s *MemoryStore }
+
+func (m *memoryUsers) List() ([]models.User, error) {
+	defer m.s.lock()()
+	var users []models.User
+	for _, u := range m.s.data.users {
+		users = append(users, u)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	return users, nil
+}
+
+func (m *memoryUsers) Get(id int) (*models.User, error) {
+	defer m.s.lock()()
+	u, ok := m.s.data.users[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &u, nil
+}
+
+func (m *memoryUsers) FindByUsername(username string) (*models.User, error) {
+	defer m.s.lock()()
+	for _, u := range m.s.data.users {
+		if u.Username == username {
+			return &u, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (m *memoryUsers) Create(u *models.User) error {
+	defer m.s.lock()()
+	for _, existing := range m.s.data.users {
+		if existing.Username == u.Username {
+			return ErrDuplicate
+		}
+	}
+	ts := now()
+	u.ID = m.s.data.newID("users")
+	u.CreatedAt = ts
+	u.UpdatedAt = ts
+	m.s.data.users[u.ID] = *u
+	return nil
+}
+
+func (m *memoryUsers) SetDisplayName(id int, displayName string) error {
+	defer m.s.lock()()
+	u, ok := m.s.data.users[id]
+	if !ok {
+		return ErrNotFound
+	}
+	u.DisplayName = displayName
+	u.UpdatedAt = now()
+	m.s.data.users[id] = u
+	return nil
+}
+
+func (m *memoryUsers) PasswordHash(id int) (string, error) {
+	defer m.s.lock()()
+	if _, ok := m.s.data.users[id]; !ok {
+		return "", ErrNotFound
+	}
+	return m.s.data.passwords[id], nil
+}
+
+func (m *memoryUsers) SetPasswordHash(id int, hash string) error {
+	defer m.s.lock()()
+	u, ok := m.s.data.users[id]
+	if !ok {
+		return ErrNotFound
+	}
+	m.s.data.passwords[id] = hash
+	u.HasPassword = hash != ""
+	u.UpdatedAt = now()
+	m.s.data.users[id] = u
+	return nil
+}
+
+// === 登入 token ===
+
+type memoryAPITokens struct{ s *MemoryStore }
+
+func (m *memoryAPITokens) Get(id int) (*models.APIToken, error) {
+	defer m.s.lock()()
+	t, ok := m.s.data.tokens[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &t, nil
+}
+
+func (m *memoryAPITokens) FindByHash(tokenHash string) (*models.APIToken, error) {
+	defer m.s.lock()()
+	for _, t := range m.s.data.tokens {
+		if t.TokenHash != "" && t.TokenHash == tokenHash {
+			return &t, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (m *memoryAPITokens) ListByUser(userID int) ([]models.APIToken, error) {
+	defer m.s.lock()()
+	var tokens []models.APIToken
+	for _, t := range m.s.data.tokens {
+		if t.UserID == userID && t.RevokedAt == nil {
+			tokens = append(tokens, t)
+		}
+	}
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].ID > tokens[j].ID })
+	return tokens, nil
+}
+
+func (m *memoryAPITokens) Create(t *models.APIToken) error {
+	defer m.s.lock()()
+	if _, ok := m.s.data.users[t.UserID]; !ok {
+		return ErrNotFound
+	}
+	t.ID = m.s.data.newID("api_tokens")
+	t.CreatedAt = now()
+	m.s.data.tokens[t.ID] = *t
+	return nil
+}
+
+func (m *memoryAPITokens) Revoke(id int) error {
+	defer m.s.lock()()
+	t, ok := m.s.data.tokens[id]
+	if !ok || t.RevokedAt != nil {
+		return ErrNotFound
+	}
+	ts := now()
+	t.RevokedAt = &ts
+	m.s.data.tokens[id] = t
+	return nil
+}
+
+func (m *memoryAPITokens) Touch(id int) error {
+	defer m.s.lock()()
+	t, ok := m.s.data.tokens[id]
+	if !ok {
+		return ErrNotFound
+	}
+	ts := now()
+	t.LastUsedAt = &ts
+	m.s.data.tokens[id] = t
+	return nil
+}
+
+// === 帳本 ===
+
+type memoryBooks struct{ s *MemoryStore }
+
+func (m *memoryBooks) Get(id int) (*models.Book, error) {
+	defer m.s.lock()()
+	b, ok := m.s.data.books[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &b, nil
+}
+
+func (m *memoryBooks) ListByUser(userID int) ([]models.BookWithRole, error) {
+	defer m.s.lock()()
+	var books []models.BookWithRole
+	for key, member := range m.s.data.members {
+		if key[1] == userID {
+			books = append(books, models.BookWithRole{Book: m.s.data.books[key[0]], Role: member.Role})
+		}
+	}
+	sort.Slice(books, func(i, j int) bool { return books[i].ID < books[j].ID })
+	return books, nil
+}
+
+func (m *memoryBooks) Create(b *models.Book) error {
+	defer m.s.lock()()
+	ts := now()
+	b.ID = m.s.data.newID("books")
+	b.CreatedAt = ts
+	b.UpdatedAt = ts
+	m.s.data.books[b.ID] = *b
+	return nil
+}
+
+func (m *memoryBooks) Rename(id int, name string) error {
+	defer m.s.lock()()
+	b, ok := m.s.data.books[id]
+	if !ok {
+		return ErrNotFound
+	}
+	b.Name = name
+	b.UpdatedAt = now()
+	m.s.data.books[id] = b
+	return nil
+}
+
+// roleOrder 成員排序：擁有者、編輯者、檢視者
+var roleOrder = map[string]int{models.RoleOwner: 0, models.RoleEditor: 1, models.RoleViewer: 2}
+
+func (m *memoryBooks) Members(bookID int) ([]models.BookMember, error) {
+	defer m.s.lock()()
+	var members []models.BookMember
+	for key, member := range m.s.data.members {
+		if key[0] == bookID {
+			u := m.s.data.users[key[1]]
+			member.Username = u.Username
+			member.DisplayName = u.DisplayName
+			members = append(members, member)
+		}
+	}
+	sort.Slice(members, func(i, j int) bool {
+		if roleOrder[members[i].Role] != roleOrder[members[j].Role] {
+			return roleOrder[members[i].Role] < roleOrder[members[j].Role]
+		}
+		return members[i].UserID < members[j].UserID
+	})
+	return members, nil
+}
+
+func (m *memoryBooks) Role(bookID, userID int) (string, error) {
+	defer m.s.lock()()
+	member, ok := m.s.data.members[[2]int{bookID, userID}]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return member.Role, nil
+}
+
+func (m *memoryBooks) SetMember(bookID, userID int, role string) error {
+	defer m.s.lock()()
+	if _, ok := m.s.data.books[bookID]; !ok {
+		return ErrNotFound
+	}
+	if _, ok := m.s.data.users[userID]; !ok {
+		return ErrNotFound
+	}
+	key := [2]int{bookID, userID}
+	member, ok := m.s.data.members[key]
+	if !ok {
+		member = models.BookMember{BookID: bookID, UserID: userID, CreatedAt: now()}
+	}
+	member.Role = role
+	m.s.data.members[key] = member
+	return nil
+}
+
+func (m *memoryBooks) RemoveMember(bookID, userID int) error {
+	defer m.s.lock()()
+	key := [2]int{bookID, userID}
+	if _, ok := m.s.data.members[key]; !ok {
+		return ErrNotFound
+	}
+	delete(m.s.data.members, key)
+	return nil
+}
+
+// === Telegram 聊天室 ===
+
+type memoryTelegramChats struct{ s *MemoryStore }
+
+func (m *memoryTelegramChats) Get(chatID int64) (*models.TelegramChat, error) {
+	defer m.s.lock()()
+	c, ok := m.s.data.chats[chatID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &c, nil
+}
+
+func (m *memoryTelegramChats) ListByUser(userID int) ([]models.TelegramChat, error) {
+	defer m.s.lock()()
+	var chats []models.TelegramChat
+	for _, c := range m.s.data.chats {
+		if c.UserID == userID {
+			chats = append(chats, c)
+		}
+	}
+	sort.Slice(chats, func(i, j int) bool { return chats[i].ChatID < chats[j].ChatID })
+	return chats, nil
+}
+
+func (m *memoryTelegramChats) Link(chatID int64, userID int) error {
+	defer m.s.lock()()
+	if _, ok := m.s.data.users[userID]; !ok {
+		return ErrNotFound
+	}
+	c := models.TelegramChat{ChatID: chatID, UserID: userID, CreatedAt: now()}
+	if old, ok := m.s.data.chats[chatID]; ok && old.UserID == userID {
+		c.BookID = old.BookID
+	}
+	m.s.data.chats[chatID] = c
+	return nil
+}
+
+func (m *memoryTelegramChats) SetBook(chatID int64, bookID int) error {
+	defer m.s.lock()()
+	c, ok := m.s.data.chats[chatID]
+	if !ok {
+		return ErrNotFound
+	}
+	if _, exists := m.s.data.books[bookID]; bookID != 0 && !exists {
+		return ErrNotFound
+	}
+	c.BookID = bookID
+	m.s.data.chats[chatID] = c
+	return nil
+}
+
+func (m *memoryTelegramChats) Unlink(chatID int64) error {
+	defer m.s.lock()()
+	if _, ok := m.s.data.chats[chatID]; !ok {
+		return ErrNotFound
+	}
+	delete(m.s.data.chats, chatID)
+	return nil
+}
+
+// === Bot 會話 ===
+
+type memoryBotSessions struct{ s *MemoryStore }
+
+func (m *memoryBotSessions) List() ([]models.BotSession, error) {
+	defer m.s.lock()()
+	var sessions []models.BotSession
+	for _, b := range m.s.data.sessions {
+		sessions = append(sessions, b)
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].ChatID < sessions[j].ChatID })
+	return sessions, nil
+}
+
+func (m *memoryBotSessions) Save(b *models.BotSession) error {
+	defer m.s.lock()()
+	m.s.data.sessions[b.ChatID] = *b
+	return nil
+}
+
+func (m *memoryBotSessions) Delete(chatID int64) error {
+	defer m.s.lock()()
+	delete(m.s.data.sessions, chatID)
+	return nil
+}
+
+// === 定期摘要訂閱 ===
+
+// digestKey 定期摘要訂閱的主鍵
+type digestKey struct {
+	chatID int64
+	kind   string
+}
+
+type memoryDigests struct{ s *MemoryStore }
+
+// list 列出符合條件的訂閱，依聊天室與每天、每週、每月排列
+func (m *memoryDigests) list(match func(d models.DigestSubscription) bool) []models.DigestSubscription {
+	var subs []models.DigestSubscription
+	for _, d := range m.s.data.digests {
+		if match(d) {
+			subs = append(subs, d)
+		}
+	}
+	order := func(kind string) int {
+		for i, k := range models.DigestKinds {
+			if k == kind {
+				return i
+			}
+		}
+		return len(models.DigestKinds)
+	}
+	sort.Slice(subs, func(i, j int) bool {
+		if subs[i].ChatID != subs[j].ChatID {
+			return subs[i].ChatID < subs[j].ChatID
+		}
+		return order(subs[i].Kind) < order(subs[j].Kind)
+	})
+	return subs
+}
+
+func (m *memoryDigests) List() ([]models.DigestSubscription, error) {
+	defer m.s.lock()()
+	return m.list(func(models.DigestSubscription) bool { return true }), nil
+}
+
+func (m *memoryDigests) ListByChat(chatID int64) ([]models.DigestSubscription, error) {
+	defer m.s.lock()()
+	return m.list(func(d models.DigestSubscription) bool { return d.ChatID == chatID }), nil
+}
+
+func (m *memoryDigests) Save(d *models.DigestSubscription) error {
+	defer m.s.lock()()
+	key := digestKey{d.ChatID, d.Kind}
+	d.CreatedAt = now()
+	if old, ok := m.s.data.digests[key]; ok {
+		d.CreatedAt = old.CreatedAt
+	}
+	m.s.data.digests[key] = *d
+	return nil
+}
+
+func (m *memoryDigests) MarkSent(chatID int64, kind, period string) error {
+	defer m.s.lock()()
+	key := digestKey{chatID, kind}
+	d, ok := m.s.data.digests[key]
+	if !ok {
+		return ErrNotFound
+	}
+	d.LastSent = period
+	m.s.data.digests[key] = d
+	return nil
+}
+
+func (m *memoryDigests) Delete(chatID int64, kind string) error {
+	defer m.s.lock()()
+	key := digestKey{chatID, kind}
+	if _, ok := m.s.data.digests[key]; !ok {
+		return ErrNotFound
+	}
+	delete(m.s.data.digests, key)
+	return nil
+}
+
+// === 系統設定 ===
+
+type memorySettings struct{ s *MemoryStore }
+
+func (m *memorySettings) Get(key string) (string, error) {
+	defer m.s.lock()()
+	value, ok := m.s.data.settings[key]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+func (m *memorySettings) Set(key, value string) error {
+	defer m.s.lock()()
+	m.s.data.settings[key] = value
+	return nil
+}
@@ -0,0 +1,262 @@
+package repository
+
+import (
+	"accountbook/models"
+	"errors"
+)
+
+// ErrNotFound 查無資料
+var ErrNotFound = errors.New("資料不存在")
+
+// ErrDuplicate 名稱重複（違反唯一約束）
+var ErrDuplicate = errors.New("名稱已存在")
+
+// ErrNoBook 未指定帳本與使用者的 Store 不可新增帳戶、分類、紀錄或轉帳
+var ErrNoBook = errors.New("未指定帳本")
+
+// Default 全域資料存取實例（不限帳本）
+// 原因：controllers 與 bot 共用同一個 Store，測試時可替換為 MemoryStore；
+// 存取帳本資料前需先以 ForBook 取得限定帳本的 Store
+var Default Store
+
+// Store 所有資料存取的進入點
+type Store interface {
+	// Accounts、Categories、Aliases、Records、Transfers、Budgets、Recurring 只能存取目前帳本的資料
+	Accounts() AccountStore
+	Categories() CategoryStore
+	Aliases() AliasStore
+	Records() RecordStore
+	Transfers() TransferStore
+	Budgets() BudgetStore
+	Recurring() RecurringStore
+	// ExchangeRates、Users、APITokens、Books、TelegramChats、BotSessions、Digests、Settings 為所有帳本共用
+	ExchangeRates() ExchangeRateStore
+	Users() UserStore
+	APITokens() APITokenStore
+	Books() BookStore
+	TelegramChats() TelegramChatStore
+	BotSessions() BotSessionStore
+	Digests() DigestStore
+	Settings() SettingStore
+
+	// ForBook 回傳只能存取指定帳本資料的 Store（沿用目前的 Transaction）
+	// userID 為操作者，新增的紀錄與轉帳會記錄為此使用者建立
+	ForBook(bookID, userID int) Store
+	// BookID 目前限定的帳本，0 代表不限帳本（僅供系統層級操作，例如啟動時對帳）
+	BookID() int
+	// UserID 目前的操作者，0 代表未指定
+	UserID() int
+
+	// WithTx 在同一個 Transaction 中執行 fn，fn 回傳錯誤時全部回滾
+	// 原因：新增紀錄與調整帳戶餘額必須同時成功或同時失敗
+	WithTx(fn func(tx Store) error) error
+}
+
+// AccountStore 帳戶資料存取
+type AccountStore interface {
+	// List 依 sort_order 排序列出所有帳戶
+	List() ([]models.Account, error)
+	Get(id int) (*models.Account, error)
+	FindByName(name string) (*models.Account, error)
+	// Create 新增帳戶並排在最後，成功後寫回 ID 與 SortOrder
+	Create(a *models.Account) error
+	Rename(id int, name string) error
+	SetCurrency(id int, currency string) error
+	SetBalance(id int, balance models.Money) error
+	SetOpeningBalance(id int, openingBalance models.Money) error
+	// AdjustBalance 以差額調整餘額（收入為正、支出為負）
+	AdjustBalance(id int, delta models.Money) error
+	Delete(id int) error
+}
+
+// CategoryStore 分類資料存取
+type CategoryStore interface {
+	// List 依 sort_order 排序列出所有分類
+	List() ([]models.Category, error)
+	Get(id int) (*models.Category, error)
+	FindByName(name string) (*models.Category, error)
+	// Create 新增分類並排在最後，成功後寫回 ID 與 SortOrder
+	Create(c *models.Category) error
+	// FindOrCreate 取得指定名稱的分類，不存在則以指定排序建立
+	FindOrCreate(name string, sortOrder int) (*models.Category, error)
+	Rename(id int, name string) error
+	Delete(id int) error
+}
+
+// AliasStore 帳戶與分類的別名資料存取
+// 注意：刪除帳戶或分類時一併刪除其別名
+type AliasStore interface {
+	// List 依名稱列出所有別名，含對應的帳戶或分類名稱
+	List() ([]models.Alias, error)
+	// Create 新增別名，名稱（不分大小寫）已存在時回傳 ErrDuplicate，成功後寫回 ID
+	Create(a *models.Alias) error
+	Delete(id int) error
+}
+
+// RecordStore 記帳紀錄資料存取
+// 注意：此層只負責 records 資料表，帳戶餘額的同步由呼叫端處理
+type RecordStore interface {
+	Get(id int) (*models.RecordWithNames, error)
+	// ListByDate 列出指定日期的紀錄（新建立的在前）
+	ListByDate(date string) ([]models.RecordWithNames, error)
+	// Recent 依日期由新到舊分頁列出紀錄，並回傳總筆數
+	Recent(offset, limit int) ([]models.RecordWithNames, int, error)
+	// DailyTotals 指定月份（2006-01）每日各類型、各幣別的加總，不計入轉帳
+	DailyTotals(month string) ([]models.DailyTotal, error)
+	// CategoryTotals 依條件統計各分類、類型、幣別的加總（金額由大到小）
+	CategoryTotals(filter models.StatisticFilter) ([]models.CategoryTotal, error)
+	// ListByStatistic 依統計條件分頁列出紀錄（日期由新到舊），並回傳總筆數
+	ListByStatistic(filter models.StatisticFilter, offset, limit int) ([]models.RecordWithNames, int, error)
+	// ListByItem 列出項目名稱相同（不分大小寫）的一般紀錄（不含轉帳），由新到舊最多 limit 筆
+	ListByItem(item string, limit int) ([]models.RecordWithNames, error)
+	// ListByTransfer 列出指定轉帳的紀錄（轉出在前）
+	ListByTransfer(transferID int) ([]models.RecordWithNames, error)
+	// TypeTotals 依條件（月份或年份）統計各幣別的收入與支出總額
+	TypeTotals(filter models.StatisticFilter) ([]models.TypeTotal, error)
+	// AccountTotals 指定帳戶所有紀錄的收入與支出總額
+	AccountTotals(accountID int) (income, expense models.Money, err error)
+	CountByAccount(accountID int) (int, error)
+	CountByCategory(categoryID int) (int, error)
+	// Insert 新增紀錄（含 transfer_id），成功後寫回 ID
+	Insert(r *models.Record) error
+	// Update 更新紀錄，不會變更 transfer_id
+	Update(r *models.Record) error
+	Delete(id int) error
+}
+
+// TransferStore 轉帳資料存取
+// 注意：只負責 transfers 資料表，兩筆紀錄與帳戶餘額由呼叫端處理
+type TransferStore interface {
+	Get(id int) (*models.TransferWithNames, error)
+	// Insert 新增轉帳，成功後寫回 ID
+	Insert(t *models.Transfer) error
+	Update(t *models.Transfer) error
+	Delete(id int) error
+}
+
+// BudgetStore 每月預算資料存取
+type BudgetStore interface {
+	// List 列出所有預算（總預算在前，其餘依分類排序），含分類名稱
+	List() ([]models.Budget, error)
+	Get(id int) (*models.Budget, error)
+	// FindByCategory 取得分類的預算，categoryID 為 0 時取得總預算
+	FindByCategory(categoryID int) (*models.Budget, error)
+	// Create 新增預算，同一分類已有預算時回傳 ErrDuplicate，成功後寫回 ID
+	Create(b *models.Budget) error
+	// Update 更新金額、滾存與開始月份，不會變更分類
+	Update(b *models.Budget) error
+	Delete(id int) error
+}
+
+// RecurringStore 定期紀錄規則資料存取
+type RecurringStore interface {
+	// List 依下次日期列出所有規則，含帳戶與分類名稱
+	List() ([]models.RecurringRule, error)
+	// Due 列出未暫停、未結束且下次日期在 date（含）之前的規則（不限帳本的 Store 列出所有帳本）
+	Due(date string) ([]models.RecurringRule, error)
+	Get(id int) (*models.RecurringRule, error)
+	// Create 新增規則（記錄目前的操作者），成功後寫回 ID
+	Create(r *models.RecurringRule) error
+	// Update 更新規則的所有欄位（含下次日期、次數與暫停狀態）
+	Update(r *models.RecurringRule) error
+	Delete(id int) error
+}
+
+// ExchangeRateStore 匯率資料存取
+type ExchangeRateStore interface {
+	// List 依日期由新到舊列出匯率，幣別為空字串代表不篩選
+	List(fromCurrency, toCurrency string) ([]models.ExchangeRate, error)
+	// Find 取得指定幣別在 date 當天或之前最近一筆匯率
+	Find(fromCurrency, toCurrency, date string) (*models.ExchangeRate, error)
+	// Save 新增匯率，同一天同幣別已存在時覆寫 rate，成功後寫回 ID
+	Save(r *models.ExchangeRate) error
+	Delete(id int) error
+}
+
+// UserStore 使用者資料存取
+type UserStore interface {
+	// List 依 ID 排序列出所有使用者
+	List() ([]models.User, error)
+	Get(id int) (*models.User, error)
+	FindByUsername(username string) (*models.User, error)
+	// Create 新增使用者（含 IsAdmin），成功後寫回 ID
+	Create(u *models.User) error
+	SetDisplayName(id int, displayName string) error
+	// PasswordHash 取得密碼雜湊，未設定密碼時為空字串
+	PasswordHash(id int) (string, error)
+	SetPasswordHash(id int, hash string) error
+}
+
+// APITokenStore 登入 token 資料存取
+type APITokenStore interface {
+	Get(id int) (*models.APIToken, error)
+	FindByHash(tokenHash string) (*models.APIToken, error)
+	// ListByUser 列出使用者尚未撤銷的 token（新建立的在前）
+	ListByUser(userID int) ([]models.APIToken, error)
+	// Create 新增 token，成功後寫回 ID
+	Create(t *models.APIToken) error
+	// Revoke 撤銷 token，已撤銷時回傳 ErrNotFound
+	Revoke(id int) error
+	// Touch 更新最後使用時間
+	Touch(id int) error
+}
+
+// BookStore 帳本與成員資料存取
+type BookStore interface {
+	Get(id int) (*models.Book, error)
+	// ListByUser 依 ID 排序列出使用者所屬的帳本與其角色
+	ListByUser(userID int) ([]models.BookWithRole, error)
+	// Create 新增帳本，成功後寫回 ID
+	Create(b *models.Book) error
+	Rename(id int, name string) error
+	// Members 列出帳本成員（擁有者在前）
+	Members(bookID int) ([]models.BookMember, error)
+	// Role 取得使用者在帳本中的角色，不是成員時回傳 ErrNotFound
+	Role(bookID, userID int) (string, error)
+	// SetMember 新增成員，已是成員時改為新的角色
+	SetMember(bookID, userID int, role string) error
+	RemoveMember(bookID, userID int) error
+}
+
+// TelegramChatStore Telegram 聊天室與使用者的對應
+type TelegramChatStore interface {
+	// Get 取得聊天室的綁定，未綁定時回傳 ErrNotFound
+	Get(chatID int64) (*models.TelegramChat, error)
+	// ListByUser 列出使用者綁定的聊天室
+	ListByUser(userID int) ([]models.TelegramChat, error)
+	// Link 將聊天室綁定到使用者，已綁定其他使用者時改為新的使用者並清除切換的帳本
+	Link(chatID int64, userID int) error
+	// SetBook 設定聊天室目前的帳本，0 代表改回個人帳本；未綁定時回傳 ErrNotFound
+	SetBook(chatID int64, bookID int) error
+	Unlink(chatID int64) error
+}
+
+// BotSessionStore Telegram Bot 會話（每個聊天室至多一筆）
+type BotSessionStore interface {
+	List() ([]models.BotSession, error)
+	// Save 新增或覆寫聊天室的會話
+	Save(s *models.BotSession) error
+	// Delete 刪除聊天室的會話，不存在時不視為錯誤
+	Delete(chatID int64) error
+}
+
+// DigestStore Bot 定期摘要的訂閱
+type DigestStore interface {
+	// List 依聊天室與種類列出所有訂閱
+	List() ([]models.DigestSubscription, error)
+	// ListByChat 列出聊天室的訂閱（依每天、每週、每月排列）
+	ListByChat(chatID int64) ([]models.DigestSubscription, error)
+	// Save 新增或覆寫聊天室該種類的訂閱
+	Save(d *models.DigestSubscription) error
+	// MarkSent 記錄已發送的期間
+	MarkSent(chatID int64, kind, period string) error
+	// Delete 取消訂閱，未訂閱時回傳 ErrNotFound
+	Delete(chatID int64, kind string) error
+}
+
+// SettingStore 系統設定（鍵值對）
+type SettingStore interface {
+	// Get 取得設定值，未設定時回傳 ErrNotFound
+	Get(key string) (string, error)
+	Set(key, value string) error
+}
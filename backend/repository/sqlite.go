@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// querier *sql.DB 與 *sql.Tx 共同的查詢介面
+// 原因：同一組 SQL 實作可同時用於一般查詢與 Transaction 內
+type querier interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// 確認 SQLiteStore 實作完整的 Store 介面
+var _ Store = (*SQLiteStore)(nil)
+
+// SQLiteStore 以 SQLite 實作的 Store
+type SQLiteStore struct {
+	db    *sql.DB
+	q     querier
+	scope scope // 限定的帳本與操作者，零值代表不限帳本
+}
+
+// NewSQLiteStore 建立 SQLite Store（不限帳本）
+func NewSQLiteStore(db *sql.DB) *SQLiteStore {
+	return &SQLiteStore{db: db, q: db}
+}
+
+func (s *SQLiteStore) Accounts() AccountStore           { return &sqliteAccounts{q: s.q, scope: s.scope} }
+func (s *SQLiteStore) Categories() CategoryStore        { return &sqliteCategories{q: s.q, scope: s.scope} }
+func (s *SQLiteStore) Aliases() AliasStore              { return &sqliteAliases{q: s.q, scope: s.scope} }
+func (s *SQLiteStore) Records() RecordStore             { return &sqliteRecords{q: s.q, scope: s.scope} }
+func (s *SQLiteStore) Transfers() TransferStore         { return &sqliteTransfers{q: s.q, scope: s.scope} }
+func (s *SQLiteStore) Budgets() BudgetStore             { return &sqliteBudgets{q: s.q, scope: s.scope} }
+func (s *SQLiteStore) Recurring() RecurringStore        { return &sqliteRecurring{q: s.q, scope: s.scope} }
+func (s *SQLiteStore) ExchangeRates() ExchangeRateStore { return &sqliteExchangeRates{q: s.q} }
+func (s *SQLiteStore) Users() UserStore                 { return &sqliteUsers{q: s.q} }
+func (s *SQLiteStore) APITokens() APITokenStore         { return &sqliteAPITokens{q: s.q} }
+func (s *SQLiteStore) Books() BookStore                 { return &sqliteBooks{q: s.q} }
+func (s *SQLiteStore) TelegramChats() TelegramChatStore { return &sqliteTelegramChats{q: s.q} }
+func (s *SQLiteStore) BotSessions() BotSessionStore     { return &sqliteBotSessions{q: s.q} }
+func (s *SQLiteStore) Digests() DigestStore             { return &sqliteDigests{q: s.q} }
+func (s *SQLiteStore) Settings() SettingStore           { return &sqliteSettings{q: s.q} }
+
+// ForBook 回傳限定帳本的 Store
+func (s *SQLiteStore) ForBook(bookID, userID int) Store {
+	return &SQLiteStore{db: s.db, q: s.q, scope: scope{book: bookID, user: userID}}
+}
+
+// BookID 目前限定的帳本
+func (s *SQLiteStore) BookID() int { return s.scope.book }
+
+// UserID 目前的操作者
+func (s *SQLiteStore) UserID() int { return s.scope.user }
+
+// WithTx 開啟 Transaction 執行 fn
+// 原因：已在 Transaction 中時直接沿用，讓呼叫端可自由組合
+func (s *SQLiteStore) WithTx(fn func(tx Store) error) error {
+	if _, ok := s.q.(*sql.Tx); ok {
+		return fn(s)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := fn(&SQLiteStore{db: s.db, q: tx, scope: s.scope}); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// scope 資料表查詢限定的帳本（book 為 0 代表不限帳本）與新增資料時記錄的操作者
+type scope struct {
+	book int
+	user int
+}
+
+// owned 產生限定帳本的 SQL 條件，例如 owned("r.book_id") → "r.book_id = 3"
+// 原因：每個查詢都要加上同一個條件，直接帶入整數比逐一附加參數簡單，且不會有注入問題
+func (sc scope) owned(column string) string {
+	if sc.book == 0 {
+		return "1 = 1"
+	}
+	return column + " = " + strconv.Itoa(sc.book)
+}
+
+// owner 新增帳戶、分類時寫入的 book_id，不限帳本的 Store 不可新增
+func (sc scope) owner() (int, error) {
+	if sc.book == 0 {
+		return 0, ErrNoBook
+	}
+	return sc.book, nil
+}
+
+// author 新增紀錄、轉帳時寫入的 book_id 與 user_id，需同時指定帳本與操作者
+func (sc scope) author() (bookID, userID int, err error) {
+	if sc.book == 0 || sc.user == 0 {
+		return 0, 0, ErrNoBook
+	}
+	return sc.book, sc.user, nil
+}
+
+// now 取得目前時間字串（與資料表 DATETIME 欄位格式一致）
+func now() string {
+	return time.Now().Format("2006-01-02 15:04:05")
+}
+
+// translateError 將 SQLite 錯誤轉換為 repository 定義的錯誤
+func translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+		return ErrDuplicate
+	}
+	return err
+}
+
+// checkAffected 確認 UPDATE/DELETE 有影響到資料列
+func checkAffected(result sql.Result, err error) error {
+	if err != nil {
+		return translateError(err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"accountbook/models"
+	"database/sql"
+)
+
+// sqliteAPITokens 登入 token 資料表的 SQLite 實作
+type sqliteAPITokens struct {
+	q querier
+}
+
+const apiTokenColumns = "id, user_id, kind, name, COALESCE(token_hash, ''), prefix, expires_at, last_used_at, revoked_at, created_at"
+
+func scanAPIToken(scan func(dest ...interface{}) error) (*models.APIToken, error) {
+	var t models.APIToken
+	var expiresAt, lastUsedAt, revokedAt sql.NullString
+	if err := scan(&t.ID, &t.UserID, &t.Kind, &t.Name, &t.TokenHash, &t.Prefix, &expiresAt, &lastUsedAt, &revokedAt, &t.CreatedAt); err != nil {
+		return nil, translateError(err)
+	}
+	t.ExpiresAt = nullString(expiresAt)
+	t.LastUsedAt = nullString(lastUsedAt)
+	t.RevokedAt = nullString(revokedAt)
+	return &t, nil
+}
+
+// nullString 將可為 NULL 的欄位轉為指標
+func nullString(v sql.NullString) *string {
+	if !v.Valid {
+		return nil
+	}
+	return &v.String
+}
+
+func (s *sqliteAPITokens) Get(id int) (*models.APIToken, error) {
+	return scanAPIToken(s.q.QueryRow("SELECT "+apiTokenColumns+" FROM api_tokens WHERE id = ?", id).Scan)
+}
+
+func (s *sqliteAPITokens) FindByHash(tokenHash string) (*models.APIToken, error) {
+	return scanAPIToken(s.q.QueryRow("SELECT "+apiTokenColumns+" FROM api_tokens WHERE token_hash = ?", tokenHash).Scan)
+}
+
+func (s *sqliteAPITokens) ListByUser(userID int) ([]models.APIToken, error) {
+	rows, err := s.q.Query("SELECT "+apiTokenColumns+" FROM api_tokens WHERE user_id = ? AND revoked_at IS NULL ORDER BY id DESC", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []models.APIToken
+	for rows.Next() {
+		t, err := scanAPIToken(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, *t)
+	}
+	return tokens, rows.Err()
+}
+
+func (s *sqliteAPITokens) Create(t *models.APIToken) error {
+	// 登入 token 沒有雜湊（以 JWT 簽章驗證），存 NULL 以免違反唯一約束
+	var tokenHash interface{}
+	if t.TokenHash != "" {
+		tokenHash = t.TokenHash
+	}
+
+	ts := now()
+	result, err := s.q.Exec(
+		"INSERT INTO api_tokens (user_id, kind, name, token_hash, prefix, expires_at, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		t.UserID, t.Kind, t.Name, tokenHash, t.Prefix, t.ExpiresAt, ts,
+	)
+	if err != nil {
+		return translateError(err)
+	}
+
+	id, _ := result.LastInsertId()
+	t.ID = int(id)
+	t.CreatedAt = ts
+	return nil
+}
+
+func (s *sqliteAPITokens) Revoke(id int) error {
+	return checkAffected(s.q.Exec("UPDATE api_tokens SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL", now(), id))
+}
+
+func (s *sqliteAPITokens) Touch(id int) error {
+	return checkAffected(s.q.Exec("UPDATE api_tokens SET last_used_at = ? WHERE id = ?", now(), id))
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"accountbook/models"
+)
+
+// sqliteAccounts 帳戶資料表的 SQLite 實作
+type sqliteAccounts struct {
+	q querier
+	scope
+}
+
+const accountColumns = "id, book_id, name, currency, balance, opening_balance, sort_order, created_at, updated_at"
+
+func scanAccount(scan func(dest ...interface{}) error) (*models.Account, error) {
+	var a models.Account
+	if err := scan(&a.ID, &a.BookID, &a.Name, &a.Currency, &a.Balance, &a.OpeningBalance, &a.SortOrder, &a.CreatedAt, &a.UpdatedAt); err != nil {
+		return nil, translateError(err)
+	}
+	return &a, nil
+}
+
+func (s *sqliteAccounts) List() ([]models.Account, error) {
+	rows, err := s.q.Query("SELECT " + accountColumns + " FROM accounts WHERE " + s.owned("book_id") + " ORDER BY sort_order, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var accounts []models.Account
+	for rows.Next() {
+		a, err := scanAccount(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, *a)
+	}
+	return accounts, rows.Err()
+}
+
+func (s *sqliteAccounts) Get(id int) (*models.Account, error) {
+	return scanAccount(s.q.QueryRow("SELECT "+accountColumns+" FROM accounts WHERE id = ? AND "+s.owned("book_id"), id).Scan)
+}
+
+func (s *sqliteAccounts) FindByName(name string) (*models.Account, error) {
+	return scanAccount(s.q.QueryRow("SELECT "+accountColumns+" FROM accounts WHERE name = ? AND "+s.owned("book_id"), name).Scan)
+}
+
+func (s *sqliteAccounts) Create(a *models.Account) error {
+	bookID, err := s.owner()
+	if err != nil {
+		return err
+	}
+
+	// 取得目前最大排序值，新帳戶排在最後
+	var maxOrder int
+	if err := s.q.QueryRow("SELECT COALESCE(MAX(sort_order), -1) FROM accounts WHERE book_id = ?", bookID).Scan(&maxOrder); err != nil {
+		return err
+	}
+
+	ts := now()
+	result, err := s.q.Exec(
+		"INSERT INTO accounts (book_id, name, currency, balance, opening_balance, sort_order, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		bookID, a.Name, a.Currency, a.Balance, a.OpeningBalance, maxOrder+1, ts, ts,
+	)
+	if err != nil {
+		return translateError(err)
+	}
+
+	id, _ := result.LastInsertId()
+	a.ID = int(id)
+	a.BookID = bookID
+	a.SortOrder = maxOrder + 1
+	a.CreatedAt = ts
+	a.UpdatedAt = ts
+	return nil
+}
+
+func (s *sqliteAccounts) Rename(id int, name string) error {
+	return checkAffected(s.q.Exec("UPDATE accounts SET name = ?, updated_at = ? WHERE id = ? AND "+s.owned("book_id"), name, now(), id))
+}
+
+func (s *sqliteAccounts) SetCurrency(id int, currency string) error {
+	return checkAffected(s.q.Exec("UPDATE accounts SET currency = ?, updated_at = ? WHERE id = ? AND "+s.owned("book_id"), currency, now(), id))
+}
+
+func (s *sqliteAccounts) SetBalance(id int, balance models.Money) error {
+	return checkAffected(s.q.Exec("UPDATE accounts SET balance = ?, updated_at = ? WHERE id = ? AND "+s.owned("book_id"), balance, now(), id))
+}
+
+func (s *sqliteAccounts) SetOpeningBalance(id int, openingBalance models.Money) error {
+	return checkAffected(s.q.Exec("UPDATE accounts SET opening_balance = ?, updated_at = ? WHERE id = ? AND "+s.owned("book_id"), openingBalance, now(), id))
+}
+
+func (s *sqliteAccounts) AdjustBalance(id int, delta models.Money) error {
+	return checkAffected(s.q.Exec("UPDATE accounts SET balance = balance + ?, updated_at = ? WHERE id = ? AND "+s.owned("book_id"), delta, now(), id))
+}
+
+func (s *sqliteAccounts) Delete(id int) error {
+	return checkAffected(s.q.Exec("DELETE FROM accounts WHERE id = ? AND "+s.owned("book_id"), id))
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"accountbook/models"
+	"database/sql"
+)
+
+// sqliteAliases 別名資料表的 SQLite 實作
+type sqliteAliases struct {
+	q querier
+	scope
+}
+
+// aliasQuery 別名連同對應帳戶或分類名稱的查詢
+const aliasQuery = `
+	SELECT al.id, al.book_id, al.name, al.account_id, al.category_id, COALESCE(a.name, c.name, ''), al.created_at
+	FROM aliases al
+	LEFT JOIN accounts a ON al.account_id = a.id
+	LEFT JOIN categories c ON al.category_id = c.id
+`
+
+func scanAlias(scan func(dest ...interface{}) error) (*models.Alias, error) {
+	var a models.Alias
+	var accountID, categoryID sql.NullInt64
+	if err := scan(&a.ID, &a.BookID, &a.Name, &accountID, &categoryID, &a.TargetName, &a.CreatedAt); err != nil {
+		return nil, translateError(err)
+	}
+	a.AccountID = int(accountID.Int64)
+	a.CategoryID = int(categoryID.Int64)
+	return &a, nil
+}
+
+// nullID ID 為 0 時寫入 NULL
+func nullID(id int) interface{} {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
+
+func (s *sqliteAliases) List() ([]models.Alias, error) {
+	rows, err := s.q.Query(aliasQuery + "WHERE " + s.owned("al.book_id") + " ORDER BY al.name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var aliases []models.Alias
+	for rows.Next() {
+		a, err := scanAlias(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		aliases = append(aliases, *a)
+	}
+	return aliases, rows.Err()
+}
+
+func (s *sqliteAliases) Create(a *models.Alias) error {
+	bookID, err := s.owner()
+	if err != nil {
+		return err
+	}
+
+	ts := now()
+	result, err := s.q.Exec(
+		"INSERT INTO aliases (book_id, name, account_id, category_id, created_at) VALUES (?, ?, ?, ?, ?)",
+		bookID, a.Name, nullID(a.AccountID), nullID(a.CategoryID), ts,
+	)
+	if err != nil {
+		return translateError(err)
+	}
+
+	id, _ := result.LastInsertId()
+	created, err := scanAlias(s.q.QueryRow(aliasQuery+"WHERE al.id = ?", id).Scan)
+	if err != nil {
+		return err
+	}
+	*a = *created
+	return nil
+}
+
+func (s *sqliteAliases) Delete(id int) error {
+	return checkAffected(s.q.Exec("DELETE FROM aliases WHERE id = ? AND "+s.owned("book_id"), id))
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"accountbook/models"
+)
+
+// sqliteBooks 帳本與成員資料表的 SQLite 實作
+type sqliteBooks struct {
+	q querier
+}
+
+func (s *sqliteBooks) Get(id int) (*models.Book, error) {
+	var b models.Book
+	err := s.q.QueryRow("SELECT id, name, created_at, updated_at FROM books WHERE id = ?", id).
+		Scan(&b.ID, &b.Name, &b.CreatedAt, &b.UpdatedAt)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &b, nil
+}
+
+func (s *sqliteBooks) ListByUser(userID int) ([]models.BookWithRole, error) {
+	rows, err := s.q.Query(`
+		SELECT b.id, b.name, b.created_at, b.updated_at, m.role
+		FROM books b
+		JOIN book_members m ON m.book_id = b.id
+		WHERE m.user_id = ?
+		ORDER BY b.id
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var books []models.BookWithRole
+	for rows.Next() {
+		var b models.BookWithRole
+		if err := rows.Scan(&b.ID, &b.Name, &b.CreatedAt, &b.UpdatedAt, &b.Role); err != nil {
+			return nil, err
+		}
+		books = append(books, b)
+	}
+	return books, rows.Err()
+}
+
+func (s *sqliteBooks) Create(b *models.Book) error {
+	ts := now()
+	result, err := s.q.Exec("INSERT INTO books (name, created_at, updated_at) VALUES (?, ?, ?)", b.Name, ts, ts)
+	if err != nil {
+		return translateError(err)
+	}
+
+	id, _ := result.LastInsertId()
+	b.ID = int(id)
+	b.CreatedAt = ts
+	b.UpdatedAt = ts
+	return nil
+}
+
+func (s *sqliteBooks) Rename(id int, name string) error {
+	return checkAffected(s.q.Exec("UPDATE books SET name = ?, updated_at = ? WHERE id = ?", name, now(), id))
+}
+
+func (s *sqliteBooks) Members(bookID int) ([]models.BookMember, error) {
+	rows, err := s.q.Query(`
+		SELECT m.book_id, m.user_id, u.username, u.display_name, m.role, m.created_at
+		FROM book_members m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.book_id = ?
+		ORDER BY CASE m.role WHEN 'owner' THEN 0 WHEN 'editor' THEN 1 ELSE 2 END, m.user_id
+	`, bookID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []models.BookMember
+	for rows.Next() {
+		var m models.BookMember
+		if err := rows.Scan(&m.BookID, &m.UserID, &m.Username, &m.DisplayName, &m.Role, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+	return members, rows.Err()
+}
+
+func (s *sqliteBooks) Role(bookID, userID int) (string, error) {
+	var role string
+	err := s.q.QueryRow("SELECT role FROM book_members WHERE book_id = ? AND user_id = ?", bookID, userID).Scan(&role)
+	return role, translateError(err)
+}
+
+func (s *sqliteBooks) SetMember(bookID, userID int, role string) error {
+	_, err := s.q.Exec(`
+		INSERT INTO book_members (book_id, user_id, role, created_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (book_id, user_id) DO UPDATE SET role = excluded.role
+	`, bookID, userID, role, now())
+	return translateError(err)
+}
+
+func (s *sqliteBooks) RemoveMember(bookID, userID int) error {
+	return checkAffected(s.q.Exec("DELETE FROM book_members WHERE book_id = ? AND user_id = ?", bookID, userID))
+}
@@ -0,0 +1,43 @@
+package repository
+
+import "accountbook/models"
+
+// sqliteBotSessions Bot 會話資料表的 SQLite 實作
+type sqliteBotSessions struct {
+	q querier
+}
+
+const botSessionColumns = "chat_id, user_id, book_id, mode, state, date, account_id, to_account_id, type, amount, item, category_id, note, message_id, prompt_msg_id, record_id, updated_at"
+
+func (s *sqliteBotSessions) List() ([]models.BotSession, error) {
+	rows, err := s.q.Query("SELECT " + botSessionColumns + " FROM bot_sessions ORDER BY chat_id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []models.BotSession
+	for rows.Next() {
+		var b models.BotSession
+		if err := rows.Scan(&b.ChatID, &b.UserID, &b.BookID, &b.Mode, &b.State, &b.Date, &b.AccountID, &b.ToAccountID,
+			&b.Type, &b.Amount, &b.Item, &b.CategoryID, &b.Note, &b.MessageID, &b.PromptMsgID, &b.RecordID, &b.UpdatedAt); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, b)
+	}
+	return sessions, rows.Err()
+}
+
+func (s *sqliteBotSessions) Save(b *models.BotSession) error {
+	_, err := s.q.Exec(`
+		INSERT OR REPLACE INTO bot_sessions (`+botSessionColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, b.ChatID, b.UserID, b.BookID, b.Mode, b.State, b.Date, b.AccountID, b.ToAccountID,
+		b.Type, b.Amount, b.Item, b.CategoryID, b.Note, b.MessageID, b.PromptMsgID, b.RecordID, b.UpdatedAt)
+	return translateError(err)
+}
+
+func (s *sqliteBotSessions) Delete(chatID int64) error {
+	_, err := s.q.Exec("DELETE FROM bot_sessions WHERE chat_id = ?", chatID)
+	return translateError(err)
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"accountbook/models"
+	"database/sql"
+)
+
+// sqliteBudgets 預算資料表的 SQLite 實作
+type sqliteBudgets struct {
+	q querier
+	scope
+}
+
+// budgetQuery 預算連同分類名稱的查詢（總預算沒有分類）
+const budgetQuery = `
+	SELECT b.id, b.book_id, b.category_id, COALESCE(c.name, ''), b.amount, b.rollover, b.start_month, b.created_at, b.updated_at
+	FROM budgets b
+	LEFT JOIN categories c ON b.category_id = c.id
+`
+
+func scanBudget(scan func(dest ...interface{}) error) (*models.Budget, error) {
+	var b models.Budget
+	var categoryID sql.NullInt64
+	if err := scan(&b.ID, &b.BookID, &categoryID, &b.CategoryName, &b.Amount, &b.Rollover, &b.StartMonth, &b.CreatedAt, &b.UpdatedAt); err != nil {
+		return nil, translateError(err)
+	}
+	b.CategoryID = int(categoryID.Int64)
+	return &b, nil
+}
+
+// nullCategory 總預算（categoryID 為 0）寫入 NULL
+func nullCategory(categoryID int) interface{} {
+	if categoryID == 0 {
+		return nil
+	}
+	return categoryID
+}
+
+func (s *sqliteBudgets) List() ([]models.Budget, error) {
+	rows, err := s.q.Query(budgetQuery + "WHERE " + s.owned("b.book_id") + " ORDER BY b.category_id IS NOT NULL, c.sort_order, b.id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var budgets []models.Budget
+	for rows.Next() {
+		b, err := scanBudget(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		budgets = append(budgets, *b)
+	}
+	return budgets, rows.Err()
+}
+
+func (s *sqliteBudgets) Get(id int) (*models.Budget, error) {
+	return scanBudget(s.q.QueryRow(budgetQuery+"WHERE b.id = ? AND "+s.owned("b.book_id"), id).Scan)
+}
+
+func (s *sqliteBudgets) FindByCategory(categoryID int) (*models.Budget, error) {
+	return scanBudget(s.q.QueryRow(budgetQuery+"WHERE IFNULL(b.category_id, 0) = ? AND "+s.owned("b.book_id"), categoryID).Scan)
+}
+
+func (s *sqliteBudgets) Create(b *models.Budget) error {
+	bookID, err := s.owner()
+	if err != nil {
+		return err
+	}
+
+	ts := now()
+	result, err := s.q.Exec(
+		"INSERT INTO budgets (book_id, category_id, amount, rollover, start_month, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		bookID, nullCategory(b.CategoryID), b.Amount, b.Rollover, b.StartMonth, ts, ts,
+	)
+	if err != nil {
+		return translateError(err)
+	}
+
+	id, _ := result.LastInsertId()
+	b.ID = int(id)
+	b.BookID = bookID
+	b.CreatedAt = ts
+	b.UpdatedAt = ts
+	return nil
+}
+
+func (s *sqliteBudgets) Update(b *models.Budget) error {
+	return checkAffected(s.q.Exec(
+		"UPDATE budgets SET amount = ?, rollover = ?, start_month = ?, updated_at = ? WHERE id = ? AND "+s.owned("book_id"),
+		b.Amount, b.Rollover, b.StartMonth, now(), b.ID,
+	))
+}
+
+func (s *sqliteBudgets) Delete(id int) error {
+	return checkAffected(s.q.Exec("DELETE FROM budgets WHERE id = ? AND "+s.owned("book_id"), id))
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"accountbook/models"
+)
+
+// sqliteCategories 分類資料表的 SQLite 實作
+type sqliteCategories struct {
+	q querier
+	scope
+}
+
+const categoryColumns = "id, book_id, name, sort_order, created_at, updated_at"
+
+func scanCategory(scan func(dest ...interface{}) error) (*models.Category, error) {
+	var c models.Category
+	if err := scan(&c.ID, &c.BookID, &c.Name, &c.SortOrder, &c.CreatedAt, &c.UpdatedAt); err != nil {
+		return nil, translateError(err)
+	}
+	return &c, nil
+}
+
+func (s *sqliteCategories) List() ([]models.Category, error) {
+	rows, err := s.q.Query("SELECT " + categoryColumns + " FROM categories WHERE " + s.owned("book_id") + " ORDER BY sort_order, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []models.Category
+	for rows.Next() {
+		c, err := scanCategory(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, *c)
+	}
+	return categories, rows.Err()
+}
+
+func (s *sqliteCategories) Get(id int) (*models.Category, error) {
+	return scanCategory(s.q.QueryRow("SELECT "+categoryColumns+" FROM categories WHERE id = ? AND "+s.owned("book_id"), id).Scan)
+}
+
+func (s *sqliteCategories) FindByName(name string) (*models.Category, error) {
+	return scanCategory(s.q.QueryRow("SELECT "+categoryColumns+" FROM categories WHERE name = ? AND "+s.owned("book_id"), name).Scan)
+}
+
+func (s *sqliteCategories) Create(c *models.Category) error {
+	bookID, err := s.owner()
+	if err != nil {
+		return err
+	}
+
+	// 新分類排在最後
+	var maxOrder int
+	if err := s.q.QueryRow("SELECT COALESCE(MAX(sort_order), -1) FROM categories WHERE book_id = ?", bookID).Scan(&maxOrder); err != nil {
+		return err
+	}
+	c.SortOrder = maxOrder + 1
+	return s.insert(c)
+}
+
+func (s *sqliteCategories) FindOrCreate(name string, sortOrder int) (*models.Category, error) {
+	c, err := s.FindByName(name)
+	if err != ErrNotFound {
+		return c, err
+	}
+
+	c = &models.Category{Name: name, SortOrder: sortOrder}
+	if err := s.insert(c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (s *sqliteCategories) insert(c *models.Category) error {
+	bookID, err := s.owner()
+	if err != nil {
+		return err
+	}
+
+	ts := now()
+	result, err := s.q.Exec(
+		"INSERT INTO categories (book_id, name, sort_order, created_at, updated_at) VALUES (?, ?, ?, ?, ?)",
+		bookID, c.Name, c.SortOrder, ts, ts,
+	)
+	if err != nil {
+		return translateError(err)
+	}
+
+	id, _ := result.LastInsertId()
+	c.ID = int(id)
+	c.BookID = bookID
+	c.CreatedAt = ts
+	c.UpdatedAt = ts
+	return nil
+}
+
+func (s *sqliteCategories) Rename(id int, name string) error {
+	return checkAffected(s.q.Exec("UPDATE categories SET name = ?, updated_at = ? WHERE id = ? AND "+s.owned("book_id"), name, now(), id))
+}
+
+func (s *sqliteCategories) Delete(id int) error {
+	return checkAffected(s.q.Exec("DELETE FROM categories WHERE id = ? AND "+s.owned("book_id"), id))
+}
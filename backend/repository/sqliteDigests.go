@@ -0,0 +1,60 @@
+package repository
+
+import "accountbook/models"
+
+// sqliteDigests 定期摘要訂閱資料表的 SQLite 實作
+type sqliteDigests struct {
+	q querier
+}
+
+const digestColumns = "chat_id, kind, send_time, last_sent, created_at"
+
+// digestOrder 依每天、每週、每月排列
+const digestOrder = "CASE kind WHEN 'daily' THEN 0 WHEN 'weekly' THEN 1 ELSE 2 END"
+
+func (s *sqliteDigests) query(query string, args ...interface{}) ([]models.DigestSubscription, error) {
+	rows, err := s.q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subs []models.DigestSubscription
+	for rows.Next() {
+		var d models.DigestSubscription
+		if err := rows.Scan(&d.ChatID, &d.Kind, &d.SendTime, &d.LastSent, &d.CreatedAt); err != nil {
+			return nil, err
+		}
+		subs = append(subs, d)
+	}
+	return subs, rows.Err()
+}
+
+func (s *sqliteDigests) List() ([]models.DigestSubscription, error) {
+	return s.query("SELECT " + digestColumns + " FROM digest_subscriptions ORDER BY chat_id, " + digestOrder)
+}
+
+func (s *sqliteDigests) ListByChat(chatID int64) ([]models.DigestSubscription, error) {
+	return s.query("SELECT "+digestColumns+" FROM digest_subscriptions WHERE chat_id = ? ORDER BY "+digestOrder, chatID)
+}
+
+func (s *sqliteDigests) Save(d *models.DigestSubscription) error {
+	ts := now()
+	_, err := s.q.Exec(`
+		INSERT INTO digest_subscriptions (chat_id, kind, send_time, last_sent, created_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (chat_id, kind) DO UPDATE SET send_time = excluded.send_time, last_sent = excluded.last_sent
+	`, d.ChatID, d.Kind, d.SendTime, d.LastSent, ts)
+	if err != nil {
+		return translateError(err)
+	}
+	d.CreatedAt = ts
+	return nil
+}
+
+func (s *sqliteDigests) MarkSent(chatID int64, kind, period string) error {
+	return checkAffected(s.q.Exec("UPDATE digest_subscriptions SET last_sent = ? WHERE chat_id = ? AND kind = ?", period, chatID, kind))
+}
+
+func (s *sqliteDigests) Delete(chatID int64, kind string) error {
+	return checkAffected(s.q.Exec("DELETE FROM digest_subscriptions WHERE chat_id = ? AND kind = ?", chatID, kind))
+}
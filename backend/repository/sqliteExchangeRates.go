@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"accountbook/models"
+	"strings"
+)
+
+// sqliteExchangeRates 匯率資料表的 SQLite 實作
+type sqliteExchangeRates struct {
+	q querier
+}
+
+const exchangeRateColumns = "id, date, from_currency, to_currency, rate, created_at, updated_at"
+
+func scanExchangeRate(scan func(dest ...interface{}) error) (*models.ExchangeRate, error) {
+	var r models.ExchangeRate
+	if err := scan(&r.ID, &r.Date, &r.FromCurrency, &r.ToCurrency, &r.Rate, &r.CreatedAt, &r.UpdatedAt); err != nil {
+		return nil, translateError(err)
+	}
+	return &r, nil
+}
+
+func (s *sqliteExchangeRates) List(fromCurrency, toCurrency string) ([]models.ExchangeRate, error) {
+	var conditions []string
+	var params []interface{}
+	if fromCurrency != "" {
+		conditions = append(conditions, "from_currency = ?")
+		params = append(params, fromCurrency)
+	}
+	if toCurrency != "" {
+		conditions = append(conditions, "to_currency = ?")
+		params = append(params, toCurrency)
+	}
+
+	query := "SELECT " + exchangeRateColumns + " FROM exchange_rates"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	rows, err := s.q.Query(query+" ORDER BY date DESC, from_currency, to_currency", params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rates []models.ExchangeRate
+	for rows.Next() {
+		r, err := scanExchangeRate(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		rates = append(rates, *r)
+	}
+	return rates, rows.Err()
+}
+
+func (s *sqliteExchangeRates) Find(fromCurrency, toCurrency, date string) (*models.ExchangeRate, error) {
+	return scanExchangeRate(s.q.QueryRow(
+		"SELECT "+exchangeRateColumns+" FROM exchange_rates WHERE from_currency = ? AND to_currency = ? AND date <= ? ORDER BY date DESC LIMIT 1",
+		fromCurrency, toCurrency, date,
+	).Scan)
+}
+
+func (s *sqliteExchangeRates) Save(r *models.ExchangeRate) error {
+	ts := now()
+	err := s.q.QueryRow(`
+		INSERT INTO exchange_rates (date, from_currency, to_currency, rate, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (date, from_currency, to_currency) DO UPDATE SET rate = excluded.rate, updated_at = excluded.updated_at
+		RETURNING id, created_at, updated_at
+	`, r.Date, r.FromCurrency, r.ToCurrency, r.Rate, ts, ts).Scan(&r.ID, &r.CreatedAt, &r.UpdatedAt)
+	return translateError(err)
+}
+
+func (s *sqliteExchangeRates) Delete(id int) error {
+	return checkAffected(s.q.Exec("DELETE FROM exchange_rates WHERE id = ?", id))
+}
@@ -0,0 +1,269 @@
+package repository
+
+import (
+	"accountbook/models"
+	"strings"
+)
+
+// sqliteRecords 記帳紀錄資料表的 SQLite 實作
+type sqliteRecords struct {
+	q querier
+	scope
+}
+
+// recordWithNamesQuery 紀錄連同帳戶、分類名稱的查詢
+const recordWithNamesQuery = `
+	SELECT r.id, r.date, r.account_id, a.name, a.currency, r.type, r.amount, r.item, r.category_id, c.name, r.note, r.transfer_id
+	FROM records r
+	JOIN accounts a ON r.account_id = a.id
+	JOIN categories c ON r.category_id = c.id
+`
+
+func scanRecordWithNames(scan func(dest ...interface{}) error) (*models.RecordWithNames, error) {
+	var r models.RecordWithNames
+	if err := scan(&r.ID, &r.Date, &r.AccountID, &r.AccountName, &r.Currency, &r.Type, &r.Amount, &r.Item, &r.CategoryID, &r.CategoryName, &r.Note, &r.TransferID); err != nil {
+		return nil, translateError(err)
+	}
+	return &r, nil
+}
+
+func (s *sqliteRecords) queryRecords(query string, args ...interface{}) ([]models.RecordWithNames, error) {
+	rows, err := s.q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []models.RecordWithNames
+	for rows.Next() {
+		r, err := scanRecordWithNames(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, *r)
+	}
+	return records, rows.Err()
+}
+
+func (s *sqliteRecords) Get(id int) (*models.RecordWithNames, error) {
+	return scanRecordWithNames(s.q.QueryRow(recordWithNamesQuery+"WHERE r.id = ? AND "+s.owned("r.book_id"), id).Scan)
+}
+
+func (s *sqliteRecords) ListByDate(date string) ([]models.RecordWithNames, error) {
+	return s.queryRecords(recordWithNamesQuery+"WHERE r.date = ? AND "+s.owned("r.book_id")+" ORDER BY r.created_at DESC, r.id DESC", date)
+}
+
+func (s *sqliteRecords) Recent(offset, limit int) ([]models.RecordWithNames, int, error) {
+	var total int
+	if err := s.q.QueryRow("SELECT COUNT(*) FROM records WHERE " + s.owned("book_id")).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	if total == 0 {
+		return nil, 0, nil
+	}
+
+	records, err := s.queryRecords(recordWithNamesQuery+"WHERE "+s.owned("r.book_id")+" ORDER BY r.date DESC, r.id DESC LIMIT ? OFFSET ?", limit, offset)
+	return records, total, err
+}
+
+func (s *sqliteRecords) ListByStatistic(filter models.StatisticFilter, offset, limit int) ([]models.RecordWithNames, int, error) {
+	where, params := s.statisticConditions(filter)
+	var total int
+	if err := s.q.QueryRow("SELECT COUNT(*) FROM records r WHERE "+where, params...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	if total == 0 {
+		return nil, 0, nil
+	}
+
+	records, err := s.queryRecords(recordWithNamesQuery+"WHERE "+where+" ORDER BY r.date DESC, r.id DESC LIMIT ? OFFSET ?", append(params, limit, offset)...)
+	return records, total, err
+}
+
+func (s *sqliteRecords) ListByItem(item string, limit int) ([]models.RecordWithNames, error) {
+	return s.queryRecords(recordWithNamesQuery+"WHERE r.item = ? COLLATE NOCASE AND r.transfer_id IS NULL AND "+s.owned("r.book_id")+" ORDER BY r.date DESC, r.id DESC LIMIT ?", item, limit)
+}
+
+func (s *sqliteRecords) ListByTransfer(transferID int) ([]models.RecordWithNames, error) {
+	return s.queryRecords(recordWithNamesQuery+"WHERE r.transfer_id = ? AND "+s.owned("r.book_id")+" ORDER BY r.id", transferID)
+}
+
+func (s *sqliteRecords) DailyTotals(month string) ([]models.DailyTotal, error) {
+	rows, err := s.q.Query(`
+		SELECT r.date, r.type, a.currency, SUM(r.amount) as total
+		FROM records r
+		JOIN accounts a ON r.account_id = a.id
+		WHERE strftime('%Y-%m', r.date) = ? AND r.transfer_id IS NULL AND `+s.owned("r.book_id")+`
+		GROUP BY r.date, r.type, a.currency
+		ORDER BY r.date
+	`, month)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var totals []models.DailyTotal
+	for rows.Next() {
+		var t models.DailyTotal
+		if err := rows.Scan(&t.Date, &t.Type, &t.Currency, &t.Total); err != nil {
+			return nil, err
+		}
+		totals = append(totals, t)
+	}
+	return totals, rows.Err()
+}
+
+// statisticConditions 依統計條件建立 WHERE 子句（records 別名為 r）
+func (s *sqliteRecords) statisticConditions(filter models.StatisticFilter) (string, []interface{}) {
+	conditions := []string{s.owned("r.book_id")}
+	var params []interface{}
+
+	switch {
+	case filter.From != "":
+		conditions = append(conditions, "r.date BETWEEN ? AND ?")
+		params = append(params, filter.From, filter.To)
+	case filter.Month != "":
+		conditions = append(conditions, "strftime('%Y-%m', r.date) = ?")
+		params = append(params, filter.Month)
+	default:
+		conditions = append(conditions, "strftime('%Y', r.date) = ?")
+		params = append(params, filter.Year)
+	}
+
+	if filter.AccountID != 0 {
+		conditions = append(conditions, "r.account_id = ?")
+		params = append(params, filter.AccountID)
+	}
+
+	if filter.CategoryID != 0 {
+		conditions = append(conditions, "r.category_id = ?")
+		params = append(params, filter.CategoryID)
+	}
+
+	if filter.Type != "" {
+		conditions = append(conditions, "r.type = ?")
+		params = append(params, filter.Type)
+	}
+
+	if !filter.IncludeTransfers {
+		conditions = append(conditions, "r.transfer_id IS NULL")
+	}
+
+	return strings.Join(conditions, " AND "), params
+}
+
+func (s *sqliteRecords) CategoryTotals(filter models.StatisticFilter) ([]models.CategoryTotal, error) {
+	where, params := s.statisticConditions(filter)
+
+	rows, err := s.q.Query(`
+		SELECT c.id, c.name, r.type, a.currency, SUM(r.amount) as total
+		FROM records r
+		JOIN categories c ON r.category_id = c.id
+		JOIN accounts a ON r.account_id = a.id
+		WHERE `+where+`
+		GROUP BY c.id, c.name, r.type, a.currency
+		ORDER BY total DESC
+	`, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var totals []models.CategoryTotal
+	for rows.Next() {
+		var t models.CategoryTotal
+		if err := rows.Scan(&t.CategoryID, &t.CategoryName, &t.Type, &t.Currency, &t.Total); err != nil {
+			return nil, err
+		}
+		totals = append(totals, t)
+	}
+	return totals, rows.Err()
+}
+
+func (s *sqliteRecords) TypeTotals(filter models.StatisticFilter) ([]models.TypeTotal, error) {
+	where, params := s.statisticConditions(filter)
+	rows, err := s.q.Query(`
+		SELECT
+			a.currency,
+			COALESCE(SUM(CASE WHEN r.type = '收入' THEN r.amount END), 0),
+			COALESCE(SUM(CASE WHEN r.type = '支出' THEN r.amount END), 0)
+		FROM records r
+		JOIN accounts a ON r.account_id = a.id
+		WHERE `+where+`
+		GROUP BY a.currency
+		ORDER BY a.currency
+	`, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var totals []models.TypeTotal
+	for rows.Next() {
+		var t models.TypeTotal
+		if err := rows.Scan(&t.Currency, &t.Income, &t.Expense); err != nil {
+			return nil, err
+		}
+		totals = append(totals, t)
+	}
+	return totals, rows.Err()
+}
+
+func (s *sqliteRecords) AccountTotals(accountID int) (income, expense models.Money, err error) {
+	err = s.q.QueryRow(`
+		SELECT
+			COALESCE(SUM(CASE WHEN type = '支出' THEN 0 ELSE amount END), 0),
+			COALESCE(SUM(CASE WHEN type = '支出' THEN amount END), 0)
+		FROM records
+		WHERE account_id = ? AND `+s.owned("book_id")+`
+	`, accountID).Scan(&income, &expense)
+	return income, expense, err
+}
+
+func (s *sqliteRecords) CountByAccount(accountID int) (int, error) {
+	var count int
+	err := s.q.QueryRow("SELECT COUNT(*) FROM records WHERE account_id = ? AND "+s.owned("book_id"), accountID).Scan(&count)
+	return count, err
+}
+
+func (s *sqliteRecords) CountByCategory(categoryID int) (int, error) {
+	var count int
+	err := s.q.QueryRow("SELECT COUNT(*) FROM records WHERE category_id = ? AND "+s.owned("book_id"), categoryID).Scan(&count)
+	return count, err
+}
+
+func (s *sqliteRecords) Insert(r *models.Record) error {
+	bookID, userID, err := s.author()
+	if err != nil {
+		return err
+	}
+
+	ts := now()
+	result, err := s.q.Exec(
+		"INSERT INTO records (book_id, user_id, date, account_id, type, amount, item, category_id, note, transfer_id, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		bookID, userID, r.Date, r.AccountID, r.Type, r.Amount, r.Item, r.CategoryID, r.Note, r.TransferID, ts, ts,
+	)
+	if err != nil {
+		return translateError(err)
+	}
+
+	id, _ := result.LastInsertId()
+	r.ID = int(id)
+	r.BookID = bookID
+	r.UserID = userID
+	r.CreatedAt = ts
+	r.UpdatedAt = ts
+	return nil
+}
+
+func (s *sqliteRecords) Update(r *models.Record) error {
+	r.UpdatedAt = now()
+	return checkAffected(s.q.Exec(
+		"UPDATE records SET date=?, account_id=?, type=?, amount=?, item=?, category_id=?, note=?, updated_at=? WHERE id=? AND "+s.owned("book_id"),
+		r.Date, r.AccountID, r.Type, r.Amount, r.Item, r.CategoryID, r.Note, r.UpdatedAt, r.ID,
+	))
+}
+
+func (s *sqliteRecords) Delete(id int) error {
+	return checkAffected(s.q.Exec("DELETE FROM records WHERE id = ? AND "+s.owned("book_id"), id))
+}
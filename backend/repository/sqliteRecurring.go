@@ -0,0 +1,99 @@
+package repository
+
+import "accountbook/models"
+
+// sqliteRecurring 定期紀錄規則資料表的 SQLite 實作
+type sqliteRecurring struct {
+	q querier
+	scope
+}
+
+// recurringQuery 規則連同帳戶、分類名稱的查詢
+const recurringQuery = `
+	SELECT rr.id, rr.book_id, rr.user_id, rr.item, rr.account_id, a.name, a.currency, rr.category_id, c.name, rr.type, rr.amount, rr.note,
+		rr.frequency, rr.interval, rr.start_date, rr.end_date, rr.next_date, rr.occurrences, rr.paused, rr.created_at, rr.updated_at
+	FROM recurring_rules rr
+	JOIN accounts a ON rr.account_id = a.id
+	JOIN categories c ON rr.category_id = c.id
+`
+
+func scanRecurringRule(scan func(dest ...interface{}) error) (*models.RecurringRule, error) {
+	var r models.RecurringRule
+	if err := scan(&r.ID, &r.BookID, &r.UserID, &r.Item, &r.AccountID, &r.AccountName, &r.Currency, &r.CategoryID, &r.CategoryName, &r.Type, &r.Amount, &r.Note,
+		&r.Frequency, &r.Interval, &r.StartDate, &r.EndDate, &r.NextDate, &r.Occurrences, &r.Paused, &r.CreatedAt, &r.UpdatedAt); err != nil {
+		return nil, translateError(err)
+	}
+	return &r, nil
+}
+
+func (s *sqliteRecurring) queryRules(query string, args ...interface{}) ([]models.RecurringRule, error) {
+	rows, err := s.q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []models.RecurringRule
+	for rows.Next() {
+		r, err := scanRecurringRule(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, *r)
+	}
+	return rules, rows.Err()
+}
+
+func (s *sqliteRecurring) List() ([]models.RecurringRule, error) {
+	return s.queryRules(recurringQuery + "WHERE " + s.owned("rr.book_id") + " ORDER BY rr.next_date, rr.id")
+}
+
+func (s *sqliteRecurring) Due(date string) ([]models.RecurringRule, error) {
+	return s.queryRules(recurringQuery+`
+		WHERE rr.paused = 0 AND rr.next_date <= ? AND (rr.end_date = '' OR rr.next_date <= rr.end_date) AND `+s.owned("rr.book_id")+`
+		ORDER BY rr.next_date, rr.id`, date)
+}
+
+func (s *sqliteRecurring) Get(id int) (*models.RecurringRule, error) {
+	return scanRecurringRule(s.q.QueryRow(recurringQuery+"WHERE rr.id = ? AND "+s.owned("rr.book_id"), id).Scan)
+}
+
+func (s *sqliteRecurring) Create(r *models.RecurringRule) error {
+	bookID, userID, err := s.author()
+	if err != nil {
+		return err
+	}
+
+	ts := now()
+	result, err := s.q.Exec(`
+		INSERT INTO recurring_rules (book_id, user_id, item, account_id, category_id, type, amount, note,
+			frequency, interval, start_date, end_date, next_date, occurrences, paused, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, bookID, userID, r.Item, r.AccountID, r.CategoryID, r.Type, r.Amount, r.Note,
+		r.Frequency, r.Interval, r.StartDate, r.EndDate, r.NextDate, r.Occurrences, r.Paused, ts, ts)
+	if err != nil {
+		return translateError(err)
+	}
+
+	id, _ := result.LastInsertId()
+	r.ID = int(id)
+	r.BookID = bookID
+	r.UserID = userID
+	r.CreatedAt = ts
+	r.UpdatedAt = ts
+	return nil
+}
+
+func (s *sqliteRecurring) Update(r *models.RecurringRule) error {
+	return checkAffected(s.q.Exec(`
+		UPDATE recurring_rules SET item = ?, account_id = ?, category_id = ?, type = ?, amount = ?, note = ?,
+			frequency = ?, interval = ?, start_date = ?, end_date = ?, next_date = ?, occurrences = ?, paused = ?, updated_at = ?
+		WHERE id = ? AND `+s.owned("book_id"),
+		r.Item, r.AccountID, r.CategoryID, r.Type, r.Amount, r.Note,
+		r.Frequency, r.Interval, r.StartDate, r.EndDate, r.NextDate, r.Occurrences, r.Paused, now(), r.ID,
+	))
+}
+
+func (s *sqliteRecurring) Delete(id int) error {
+	return checkAffected(s.q.Exec("DELETE FROM recurring_rules WHERE id = ? AND "+s.owned("book_id"), id))
+}
@@ -0,0 +1,20 @@
+package repository
+
+// sqliteSettings 系統設定資料表的 SQLite 實作
+type sqliteSettings struct {
+	q querier
+}
+
+func (s *sqliteSettings) Get(key string) (string, error) {
+	var value string
+	err := s.q.QueryRow("SELECT value FROM settings WHERE key = ?", key).Scan(&value)
+	return value, translateError(err)
+}
+
+func (s *sqliteSettings) Set(key, value string) error {
+	_, err := s.q.Exec(`
+		INSERT INTO settings (key, value, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT (key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at
+	`, key, value, now())
+	return translateError(err)
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"accountbook/models"
+	"database/sql"
+)
+
+// sqliteTelegramChats 聊天室對應資料表的 SQLite 實作
+type sqliteTelegramChats struct {
+	q querier
+}
+
+const telegramChatColumns = "chat_id, user_id, book_id, created_at"
+
+func scanTelegramChat(scan func(dest ...interface{}) error) (*models.TelegramChat, error) {
+	var c models.TelegramChat
+	var bookID sql.NullInt64
+	if err := scan(&c.ChatID, &c.UserID, &bookID, &c.CreatedAt); err != nil {
+		return nil, translateError(err)
+	}
+	c.BookID = int(bookID.Int64)
+	return &c, nil
+}
+
+func (s *sqliteTelegramChats) Get(chatID int64) (*models.TelegramChat, error) {
+	return scanTelegramChat(s.q.QueryRow("SELECT "+telegramChatColumns+" FROM telegram_chats WHERE chat_id = ?", chatID).Scan)
+}
+
+func (s *sqliteTelegramChats) ListByUser(userID int) ([]models.TelegramChat, error) {
+	rows, err := s.q.Query("SELECT "+telegramChatColumns+" FROM telegram_chats WHERE user_id = ? ORDER BY created_at, chat_id", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var chats []models.TelegramChat
+	for rows.Next() {
+		c, err := scanTelegramChat(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		chats = append(chats, *c)
+	}
+	return chats, rows.Err()
+}
+
+func (s *sqliteTelegramChats) Link(chatID int64, userID int) error {
+	// 改綁其他使用者時，原本切換的帳本不一定可存取，一併清除
+	_, err := s.q.Exec(`
+		INSERT INTO telegram_chats (chat_id, user_id, created_at) VALUES (?, ?, ?)
+		ON CONFLICT (chat_id) DO UPDATE SET
+			book_id = CASE WHEN user_id = excluded.user_id THEN book_id END,
+			user_id = excluded.user_id,
+			created_at = excluded.created_at
+	`, chatID, userID, now())
+	return translateError(err)
+}
+
+func (s *sqliteTelegramChats) SetBook(chatID int64, bookID int) error {
+	var book interface{}
+	if bookID != 0 {
+		book = bookID
+	}
+	return checkAffected(s.q.Exec("UPDATE telegram_chats SET book_id = ? WHERE chat_id = ?", book, chatID))
+}
+
+func (s *sqliteTelegramChats) Unlink(chatID int64) error {
+	return checkAffected(s.q.Exec("DELETE FROM telegram_chats WHERE chat_id = ?", chatID))
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"accountbook/models"
+)
+
+// sqliteTransfers 轉帳資料表的 SQLite 實作
+type sqliteTransfers struct {
+	q querier
+	scope
+}
+
+func (s *sqliteTransfers) Get(id int) (*models.TransferWithNames, error) {
+	var t models.TransferWithNames
+	err := s.q.QueryRow(`
+		SELECT t.id, t.book_id, t.user_id, t.date, t.from_account_id, f.name, t.to_account_id, a.name, t.amount, t.to_amount, t.note, t.created_at, t.updated_at
+		FROM transfers t
+		JOIN accounts f ON t.from_account_id = f.id
+		JOIN accounts a ON t.to_account_id = a.id
+		WHERE t.id = ? AND `+s.owned("t.book_id")+`
+	`, id).Scan(&t.ID, &t.BookID, &t.UserID, &t.Date, &t.FromAccountID, &t.FromAccountName, &t.ToAccountID, &t.ToAccountName,
+		&t.Amount, &t.ToAmount, &t.Note, &t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &t, nil
+}
+
+func (s *sqliteTransfers) Insert(t *models.Transfer) error {
+	bookID, userID, err := s.author()
+	if err != nil {
+		return err
+	}
+
+	ts := now()
+	result, err := s.q.Exec(
+		"INSERT INTO transfers (book_id, user_id, date, from_account_id, to_account_id, amount, to_amount, note, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		bookID, userID, t.Date, t.FromAccountID, t.ToAccountID, t.Amount, t.ToAmount, t.Note, ts, ts,
+	)
+	if err != nil {
+		return translateError(err)
+	}
+
+	id, _ := result.LastInsertId()
+	t.ID = int(id)
+	t.BookID = bookID
+	t.UserID = userID
+	t.CreatedAt = ts
+	t.UpdatedAt = ts
+	return nil
+}
+
+func (s *sqliteTransfers) Update(t *models.Transfer) error {
+	t.UpdatedAt = now()
+	return checkAffected(s.q.Exec(
+		"UPDATE transfers SET date=?, from_account_id=?, to_account_id=?, amount=?, to_amount=?, note=?, updated_at=? WHERE id=? AND "+s.owned("book_id"),
+		t.Date, t.FromAccountID, t.ToAccountID, t.Amount, t.ToAmount, t.Note, t.UpdatedAt, t.ID,
+	))
+}
+
+func (s *sqliteTransfers) Delete(id int) error {
+	return checkAffected(s.q.Exec("DELETE FROM transfers WHERE id = ? AND "+s.owned("book_id"), id))
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"accountbook/models"
+)
+
+// sqliteUsers 使用者資料表的 SQLite 實作
+type sqliteUsers struct {
+	q querier
+}
+
+const userColumns = "id, username, display_name, is_admin, password_hash != '', created_at, updated_at"
+
+func scanUser(scan func(dest ...interface{}) error) (*models.User, error) {
+	var u models.User
+	if err := scan(&u.ID, &u.Username, &u.DisplayName, &u.IsAdmin, &u.HasPassword, &u.CreatedAt, &u.UpdatedAt); err != nil {
+		return nil, translateError(err)
+	}
+	return &u, nil
+}
+
+func (s *sqliteUsers) List() ([]models.User, error) {
+	rows, err := s.q.Query("SELECT " + userColumns + " FROM users ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []models.User
+	for rows.Next() {
+		u, err := scanUser(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, *u)
+	}
+	return users, rows.Err()
+}
+
+func (s *sqliteUsers) Get(id int) (*models.User, error) {
+	return scanUser(s.q.QueryRow("SELECT "+userColumns+" FROM users WHERE id = ?", id).Scan)
+}
+
+func (s *sqliteUsers) FindByUsername(username string) (*models.User, error) {
+	return scanUser(s.q.QueryRow("SELECT "+userColumns+" FROM users WHERE username = ?", username).Scan)
+}
+
+func (s *sqliteUsers) Create(u *models.User) error {
+	ts := now()
+	result, err := s.q.Exec(
+		"INSERT INTO users (username, display_name, is_admin, created_at, updated_at) VALUES (?, ?, ?, ?, ?)",
+		u.Username, u.DisplayName, u.IsAdmin, ts, ts,
+	)
+	if err != nil {
+		return translateError(err)
+	}
+
+	id, _ := result.LastInsertId()
+	u.ID = int(id)
+	u.CreatedAt = ts
+	u.UpdatedAt = ts
+	return nil
+}
+
+func (s *sqliteUsers) SetDisplayName(id int, displayName string) error {
+	return checkAffected(s.q.Exec("UPDATE users SET display_name = ?, updated_at = ? WHERE id = ?", displayName, now(), id))
+}
+
+func (s *sqliteUsers) PasswordHash(id int) (string, error) {
+	var hash string
+	err := s.q.QueryRow("SELECT password_hash FROM users WHERE id = ?", id).Scan(&hash)
+	return hash, translateError(err)
+}
+
+func (s *sqliteUsers) SetPasswordHash(id int, hash string) error {
+	return checkAffected(s.q.Exec("UPDATE users SET password_hash = ?, updated_at = ? WHERE id = ?", hash, now(), id))
+}
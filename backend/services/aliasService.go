@@ -0,0 +1,112 @@
+package services
+
+import (
+	"accountbook/models"
+	"accountbook/repository"
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+// 別名相關錯誤
+var (
+	ErrAliasNotFound = errors.New("找不到該別名")
+	ErrAliasExists   = errors.New("別名已被使用（與既有的別名、帳戶或分類名稱相同）")
+	ErrInvalidAlias  = errors.New("別名需為 1～20 字，且不可包含空白、「#」、「|」、「｜」或「//」")
+	ErrAliasTarget   = errors.New("請指定 account_id 或 category_id 其中一個")
+)
+
+// aliasMaxLength 別名的最大字數
+const aliasMaxLength = 20
+
+// Aliases 全域別名服務
+var Aliases *AliasService
+
+// AliasService 帳戶與分類的別名
+// 原因：Bot 快捷輸入以空白分詞，並以「#」「|」「//」標示分類與備註，別名不可包含這些符號
+type AliasService struct {
+	store repository.Store
+}
+
+// NewAliasService 建立別名服務
+func NewAliasService(store repository.Store) *AliasService {
+	return &AliasService{store: store}
+}
+
+// ForBook 回傳只能存取指定帳本別名的服務
+func (s *AliasService) ForBook(bookID, userID int) *AliasService {
+	return &AliasService{store: s.store.ForBook(bookID, userID)}
+}
+
+// List 依名稱列出所有別名
+func (s *AliasService) List() ([]models.Alias, error) {
+	return s.store.Aliases().List()
+}
+
+// Create 新增別名
+// 原因：別名與帳戶、分類名稱相同時無法判斷指的是哪一個，一律拒絕
+func (s *AliasService) Create(in models.AliasInput) (*models.Alias, error) {
+	a := &models.Alias{Name: strings.TrimSpace(in.Name), AccountID: in.AccountID, CategoryID: in.CategoryID}
+	if a.Name == "" || utf8.RuneCountInString(a.Name) > aliasMaxLength ||
+		strings.ContainsAny(a.Name, " \t\n#|｜") || strings.Contains(a.Name, "//") {
+		return nil, ErrInvalidAlias
+	}
+	if (a.AccountID == 0) == (a.CategoryID == 0) {
+		return nil, ErrAliasTarget
+	}
+
+	err := s.store.WithTx(func(tx repository.Store) error {
+		if a.AccountID != 0 {
+			if _, err := tx.Accounts().Get(a.AccountID); err != nil {
+				return notFoundAs(err, ErrAccountNotFound)
+			}
+		} else if _, err := tx.Categories().Get(a.CategoryID); err != nil {
+			return notFoundAs(err, ErrCategoryNotFound)
+		}
+		taken, err := nameTaken(tx, a.Name)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return ErrAliasExists
+		}
+
+		err = tx.Aliases().Create(a)
+		if err == repository.ErrDuplicate {
+			return ErrAliasExists
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Delete 刪除別名
+func (s *AliasService) Delete(id int) error {
+	return notFoundAs(s.store.Aliases().Delete(id), ErrAliasNotFound)
+}
+
+// nameTaken 名稱是否與帳本中的帳戶或分類名稱相同（不分大小寫）
+func nameTaken(store repository.Store, name string) (bool, error) {
+	accounts, err := store.Accounts().List()
+	if err != nil {
+		return false, err
+	}
+	for _, a := range accounts {
+		if strings.EqualFold(a.Name, name) {
+			return true, nil
+		}
+	}
+	categories, err := store.Categories().List()
+	if err != nil {
+		return false, err
+	}
+	for _, c := range categories {
+		if strings.EqualFold(c.Name, name) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
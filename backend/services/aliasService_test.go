@@ -0,0 +1,78 @@
+package services
+
+import (
+	"accountbook/models"
+	"testing"
+)
+
+func TestCreateAlias(t *testing.T) {
+	store, _ := newTestLedger(t)
+	aliases := NewAliasService(store)
+
+	card, err := aliases.Create(models.AliasInput{Name: " 卡 ", AccountID: testCard})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if card.Name != "卡" || card.AccountID != testCard || card.TargetName != "信用卡" {
+		t.Errorf("新增的別名 = %+v", card)
+	}
+	if _, err := aliases.Create(models.AliasInput{Name: "早餐", CategoryID: testFood}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		input models.AliasInput
+		want  error
+	}{
+		{"別名重複", models.AliasInput{Name: "卡", CategoryID: testOthers}, ErrAliasExists},
+		{"英文別名", models.AliasInput{Name: "Card", AccountID: testCard}, nil},
+		{"與帳戶名稱相同", models.AliasInput{Name: "現金", CategoryID: testFood}, ErrAliasExists},
+		{"與分類名稱相同", models.AliasInput{Name: "飲食", AccountID: testCash}, ErrAliasExists},
+		{"空白", models.AliasInput{Name: "  ", AccountID: testCash}, ErrInvalidAlias},
+		{"包含空白", models.AliasInput{Name: "信 用", AccountID: testCard}, ErrInvalidAlias},
+		{"包含 #", models.AliasInput{Name: "#吃", CategoryID: testFood}, ErrInvalidAlias},
+		{"包含備註分隔符號", models.AliasInput{Name: "吃//", CategoryID: testFood}, ErrInvalidAlias},
+		{"過長", models.AliasInput{Name: "一二三四五六七八九十一二三四五六七八九十一", CategoryID: testFood}, ErrInvalidAlias},
+		{"未指定對象", models.AliasInput{Name: "錢包"}, ErrAliasTarget},
+		{"同時指定帳戶與分類", models.AliasInput{Name: "錢包", AccountID: testCash, CategoryID: testFood}, ErrAliasTarget},
+		{"帳戶不存在", models.AliasInput{Name: "錢包", AccountID: 99}, ErrAccountNotFound},
+		{"分類不存在", models.AliasInput{Name: "宵夜", CategoryID: 99}, ErrCategoryNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := aliases.Create(tt.input); err != tt.want {
+				t.Errorf("Create(%+v) = %v，預期 %v", tt.input, err, tt.want)
+			}
+		})
+	}
+
+	if _, err := aliases.Create(models.AliasInput{Name: "CARD", CategoryID: testFood}); err != ErrAliasExists {
+		t.Errorf("大小寫不同的重複別名 = %v，預期 %v", err, ErrAliasExists)
+	}
+}
+
+func TestAliasesAreScopedAndFollowTarget(t *testing.T) {
+	store, _ := newTestLedger(t)
+	aliases := NewAliasService(store)
+	if _, err := aliases.Create(models.AliasInput{Name: "早餐", CategoryID: testFood}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	// 其他帳本看不到，也不能刪除
+	other := NewAliasService(store.ForBook(99, 1))
+	if list, _ := other.List(); len(list) != 0 {
+		t.Errorf("其他帳本的別名 = %+v", list)
+	}
+	if err := other.Delete(1); err != ErrAliasNotFound {
+		t.Errorf("刪除其他帳本的別名 = %v，預期 %v", err, ErrAliasNotFound)
+	}
+
+	// 刪除分類時一併刪除其別名
+	if err := store.Categories().Delete(testFood); err != nil {
+		t.Fatalf("刪除分類失敗: %v", err)
+	}
+	if list, _ := aliases.List(); len(list) != 0 {
+		t.Errorf("刪除分類後的別名 = %+v", list)
+	}
+}
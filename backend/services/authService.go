@@ -0,0 +1,248 @@
+package services
+
+import (
+	"accountbook/models"
+	"accountbook/repository"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// 認證相關錯誤
+var (
+	ErrInvalidCredentials = errors.New("帳號或密碼錯誤")
+	ErrUnauthorized       = errors.New("登入已失效，請重新登入")
+	ErrWeakPassword       = errors.New("密碼至少需要 8 個字元")
+	ErrWrongPassword      = errors.New("目前的密碼不正確")
+	ErrTokenNotFound      = errors.New("找不到該 token")
+	ErrTokenName          = errors.New("請提供 token 名稱")
+)
+
+// minPasswordLength 密碼最少字元數
+const minPasswordLength = 8
+
+// touchInterval 更新 token 最後使用時間的最短間隔
+// 原因：每個請求都寫入資料庫太頻繁，分鐘級的精確度已足夠辨識閒置的 token
+const touchInterval = time.Minute
+
+// Auth 全域認證服務
+var Auth *AuthService
+
+// AuthConfig 認證設定
+type AuthConfig struct {
+	Enabled    bool          // 關閉時沿用 X-User-ID 標頭（僅限內網使用）
+	Secret     []byte        // JWT 簽章金鑰
+	SessionTTL time.Duration // 登入 token 的有效期間
+}
+
+// AuthService 密碼登入與 API token
+// 原因：登入取得的 JWT 與個人 token 都對應到 api_tokens 的紀錄，撤銷後立即失效
+type AuthService struct {
+	store  repository.Store
+	config AuthConfig
+}
+
+// NewAuthService 建立認證服務
+func NewAuthService(store repository.Store, config AuthConfig) *AuthService {
+	return &AuthService{store: store, config: config}
+}
+
+// Enabled 是否需要登入才能使用 API
+func (s *AuthService) Enabled() bool {
+	return s.config.Enabled
+}
+
+// Login 以帳號密碼登入，回傳 JWT 與對應的 token 紀錄
+func (s *AuthService) Login(username, password string) (string, *models.APIToken, error) {
+	user, err := s.store.Users().FindByUsername(strings.TrimSpace(username))
+	if err != nil {
+		if err == repository.ErrNotFound {
+			// 仍計算一次雜湊，避免以回應時間判斷帳號是否存在
+			hashPassword(password)
+			return "", nil, ErrInvalidCredentials
+		}
+		return "", nil, err
+	}
+	hash, err := s.store.Users().PasswordHash(user.ID)
+	if err != nil {
+		return "", nil, err
+	}
+	if !verifyPassword(hash, password) {
+		return "", nil, ErrInvalidCredentials
+	}
+
+	issued := time.Now().UTC()
+	expires := issued.Add(s.config.SessionTTL)
+	t := &models.APIToken{UserID: user.ID, Kind: models.TokenSession, Name: "登入", ExpiresAt: formatTokenTime(expires)}
+	if err := s.store.APITokens().Create(t); err != nil {
+		return "", nil, err
+	}
+
+	token, err := signJWT(s.config.Secret, sessionClaims{
+		Subject:  strconv.Itoa(user.ID),
+		ID:       strconv.Itoa(t.ID),
+		IssuedAt: issued.Unix(),
+		Expires:  expires.Unix(),
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return token, t, nil
+}
+
+// CreatePersonalToken 建立個人 API token，ttl 為 0 代表永不過期
+// 注意：原始 token 只在此回傳一次，資料庫只保存雜湊
+func (s *AuthService) CreatePersonalToken(userID int, name string, ttl time.Duration) (string, *models.APIToken, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", nil, ErrTokenName
+	}
+
+	token, err := newPersonalToken()
+	if err != nil {
+		return "", nil, err
+	}
+	t := &models.APIToken{
+		UserID:    userID,
+		Kind:      models.TokenPersonal,
+		Name:      name,
+		TokenHash: hashToken(token),
+		Prefix:    token[:len(personalTokenPrefix)+4],
+	}
+	if ttl > 0 {
+		t.ExpiresAt = formatTokenTime(time.Now().UTC().Add(ttl))
+	}
+	if err := s.store.APITokens().Create(t); err != nil {
+		return "", nil, err
+	}
+	return token, t, nil
+}
+
+// Authenticate 驗證 JWT 或個人 token，回傳對應的 token 紀錄
+// 原因：不論簽章是否有效，都需確認紀錄尚未撤銷、未過期
+func (s *AuthService) Authenticate(token string) (*models.APIToken, error) {
+	var t *models.APIToken
+	var err error
+	if strings.HasPrefix(token, personalTokenPrefix) {
+		t, err = s.store.APITokens().FindByHash(hashToken(token))
+	} else {
+		claims, parseErr := parseJWT(s.config.Secret, token)
+		if parseErr != nil {
+			return nil, ErrUnauthorized
+		}
+		id, _ := strconv.Atoi(claims.ID)
+		t, err = s.store.APITokens().Get(id)
+		if err == nil && (t.Kind != models.TokenSession || strconv.Itoa(t.UserID) != claims.Subject) {
+			return nil, ErrUnauthorized
+		}
+	}
+	if err != nil {
+		return nil, notFoundAs(err, ErrUnauthorized)
+	}
+
+	now := time.Now().UTC()
+	if t.RevokedAt != nil {
+		return nil, ErrUnauthorized
+	}
+	if t.ExpiresAt != nil {
+		if expires, err := parseTokenTime(*t.ExpiresAt); err != nil || now.After(expires) {
+			return nil, ErrUnauthorized
+		}
+	}
+
+	if t.LastUsedAt == nil || tokenUsedBefore(*t.LastUsedAt, now.Add(-touchInterval)) {
+		if err := s.store.APITokens().Touch(t.ID); err != nil {
+			log.Printf("更新 token %d 使用時間失敗: %v", t.ID, err)
+		}
+	}
+	return t, nil
+}
+
+// Revoke 撤銷 token，只能撤銷自己的 token（管理員可撤銷任何人的）
+func (s *AuthService) Revoke(actor *models.User, tokenID int) error {
+	t, err := s.store.APITokens().Get(tokenID)
+	if err != nil {
+		return notFoundAs(err, ErrTokenNotFound)
+	}
+	if t.UserID != actor.ID && !actor.IsAdmin {
+		return ErrTokenNotFound
+	}
+	return notFoundAs(s.store.APITokens().Revoke(tokenID), ErrTokenNotFound)
+}
+
+// ValidatePassword 檢查密碼長度
+func ValidatePassword(password string) error {
+	if utf8.RuneCountInString(password) < minPasswordLength {
+		return ErrWeakPassword
+	}
+	return nil
+}
+
+// SetPassword 直接設定密碼（管理員重設或首次設定）
+func (s *AuthService) SetPassword(userID int, password string) error {
+	if err := ValidatePassword(password); err != nil {
+		return err
+	}
+	hash, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+	return notFoundAs(s.store.Users().SetPasswordHash(userID, hash), ErrUserNotFound)
+}
+
+// ChangePassword 使用者修改自己的密碼，已設定密碼時需提供目前的密碼
+func (s *AuthService) ChangePassword(userID int, current, password string) error {
+	hash, err := s.store.Users().PasswordHash(userID)
+	if err != nil {
+		return notFoundAs(err, ErrUserNotFound)
+	}
+	if hash != "" && !verifyPassword(hash, current) {
+		return ErrWrongPassword
+	}
+	return s.SetPassword(userID, password)
+}
+
+// BootstrapAdmin 預設使用者尚未設定密碼時，以環境變數提供的密碼設定
+// 原因：升級後第一次啟動需要有可登入的帳號，已設定過的密碼不會被覆寫
+func (s *AuthService) BootstrapAdmin(password string) error {
+	hash, err := s.store.Users().PasswordHash(models.DefaultUserID)
+	if err != nil || hash != "" {
+		return err
+	}
+	if password == "" {
+		log.Println("警告：預設使用者尚未設定密碼，請設定 ADMIN_PASSWORD 或執行 passwd 指令，否則無法登入")
+		return nil
+	}
+	if err := s.SetPassword(models.DefaultUserID, password); err != nil {
+		return err
+	}
+	log.Println("已以 ADMIN_PASSWORD 設定預設使用者的密碼")
+	return nil
+}
+
+// formatTokenTime 到期時間一律以 UTC 的 RFC3339 儲存
+func formatTokenTime(t time.Time) *string {
+	s := t.UTC().Format(time.RFC3339)
+	return &s
+}
+
+// parseTokenTime 解析資料庫中的時間（RFC3339 或 SQLite 的 DATETIME 格式，皆視為 UTC）
+func parseTokenTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02 15:04:05", value)
+}
+
+// tokenUsedBefore 最後使用時間是否早於 threshold
+// 注意：last_used_at 以本地時間寫入，讀回時依格式可能被視為 UTC，因此只以本地時間比較
+func tokenUsedBefore(lastUsed string, threshold time.Time) bool {
+	t, err := time.ParseInLocation("2006-01-02 15:04:05", strings.Replace(strings.TrimSuffix(lastUsed, "Z"), "T", " ", 1), time.Local)
+	if err != nil {
+		return true
+	}
+	return t.Before(threshold)
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// 密碼雜湊參數（PBKDF2-SHA256）
+// 原因：只使用標準函式庫，迭代次數依 OWASP 建議；參數存在雜湊字串中，日後調整不影響既有密碼
+const (
+	passwordIterations = 600000
+	passwordSaltSize   = 16
+	passwordKeySize    = 32
+)
+
+// personalTokenPrefix 個人 API token 的開頭
+// 原因：與 JWT 一眼可分，外洩時也容易以字串搜尋找到
+const personalTokenPrefix = "abk_"
+
+var errMalformedToken = errors.New("token 格式錯誤")
+
+// hashPassword 產生密碼雜湊，格式為 pbkdf2-sha256$迭代次數$salt$hash
+func hashPassword(password string) (string, error) {
+	salt := make([]byte, passwordSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, passwordIterations, passwordKeySize)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("pbkdf2-sha256$%d$%s$%s", passwordIterations,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// verifyPassword 比對密碼與雜湊，雜湊為空或格式錯誤時一律不符
+func verifyPassword(encoded, password string) bool {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 4 || parts[0] != "pbkdf2-sha256" {
+		return false
+	}
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations <= 0 {
+		return false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	want, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return false
+	}
+	got, err := pbkdf2.Key(sha256.New, password, salt, iterations, len(want))
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(got, want) == 1
+}
+
+// newPersonalToken 產生個人 API token（abk_ 加上 32 bytes 亂數）
+func newPersonalToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return personalTokenPrefix + base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashToken 個人 token 存入資料庫前的雜湊
+// 原因：token 本身已是高熵亂數，SHA-256 即足以避免資料庫外洩時直接被使用
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// sessionClaims 登入 JWT 的內容
+type sessionClaims struct {
+	Subject  string `json:"sub"` // 使用者 ID
+	ID       string `json:"jti"` // api_tokens.id，撤銷時以此查詢
+	IssuedAt int64  `json:"iat"`
+	Expires  int64  `json:"exp"`
+}
+
+// jwtHeader 固定使用 HS256
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// signJWT 以 HMAC-SHA256 簽署 JWT
+func signJWT(secret []byte, claims sessionClaims) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	unsigned := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + jwtSignature(secret, unsigned), nil
+}
+
+// parseJWT 驗證簽章並解析內容（不檢查到期時間與撤銷狀態）
+// 原因：只接受自己簽發的 HS256 header，避免 alg 被竄改為 none 等攻擊
+func parseJWT(secret []byte, token string) (*sessionClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != jwtHeader {
+		return nil, errMalformedToken
+	}
+	unsigned := parts[0] + "." + parts[1]
+	if !hmac.Equal([]byte(parts[2]), []byte(jwtSignature(secret, unsigned))) {
+		return nil, errMalformedToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, errMalformedToken
+	}
+	var claims sessionClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, errMalformedToken
+	}
+	return &claims, nil
+}
+
+func jwtSignature(secret []byte, unsigned string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(unsigned))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,241 @@
+package services
+
+import (
+	"accountbook/models"
+	"accountbook/repository"
+	"errors"
+	"strings"
+)
+
+// 帳本相關錯誤
+var (
+	ErrBookNotFound  = errors.New("帳本不存在或沒有存取權限")
+	ErrReadOnly      = errors.New("檢視者無法修改帳本內容")
+	ErrOwnerRequired = errors.New("只有帳本擁有者可以管理帳本")
+	ErrLastOwner     = errors.New("帳本至少需要一位擁有者")
+	ErrInvalidRole   = errors.New("角色必須為 owner、editor 或 viewer")
+	ErrBookName      = errors.New("請提供帳本名稱")
+)
+
+// Books 全域帳本服務
+var Books *BookService
+
+// BookService 帳本與成員服務
+// 原因：帳戶、分類與紀錄屬於帳本，存取前需確認使用者是帳本成員及其角色
+type BookService struct {
+	store repository.Store
+}
+
+// NewBookService 建立帳本服務
+func NewBookService(store repository.Store) *BookService {
+	return &BookService{store: store}
+}
+
+// ChatBook 聊天室目前使用的帳本
+type ChatBook struct {
+	UserID int    // 聊天室綁定的使用者
+	BookID int    // 目前的帳本
+	Role   string // 使用者在此帳本的角色
+}
+
+// CanEdit 是否可以新增、修改、刪除紀錄
+func (c ChatBook) CanEdit() bool {
+	return models.CanEdit(c.Role)
+}
+
+// createBook 在 tx 中建立帳本、擁有者與預設帳戶、分類
+func createBook(tx repository.Store, ownerID int, name string) (*models.Book, error) {
+	b := &models.Book{Name: name}
+	if err := tx.Books().Create(b); err != nil {
+		return nil, err
+	}
+	if err := tx.Books().SetMember(b.ID, ownerID, models.RoleOwner); err != nil {
+		return nil, err
+	}
+
+	owned := tx.ForBook(b.ID, ownerID)
+	for _, name := range models.DefaultAccountNames {
+		if err := owned.Accounts().Create(&models.Account{Name: name, Currency: models.DefaultCurrency}); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range models.DefaultCategoryNames {
+		if err := owned.Categories().Create(&models.Category{Name: name}); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// CreateBook 建立帳本（含預設帳戶與分類），建立者為擁有者
+func (s *BookService) CreateBook(ownerID int, name string) (*models.Book, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrBookName
+	}
+
+	var book *models.Book
+	err := s.store.WithTx(func(tx repository.Store) error {
+		b, err := createBook(tx, ownerID, name)
+		book = b
+		return err
+	})
+	return book, err
+}
+
+// Role 取得使用者在帳本中的角色，不是成員時回傳 ErrBookNotFound
+// 原因：不區分帳本不存在與非成員，避免洩漏其他人的帳本是否存在
+func (s *BookService) Role(bookID, userID int) (string, error) {
+	role, err := s.store.Books().Role(bookID, userID)
+	return role, notFoundAs(err, ErrBookNotFound)
+}
+
+// DefaultBook 使用者未指定帳本時使用的帳本：自己擁有的第一本，沒有則為第一本加入的帳本
+func (s *BookService) DefaultBook(userID int) (int, error) {
+	books, err := s.store.Books().ListByUser(userID)
+	if err != nil {
+		return 0, err
+	}
+	for _, b := range books {
+		if b.Role == models.RoleOwner {
+			return b.ID, nil
+		}
+	}
+	if len(books) == 0 {
+		return 0, ErrBookNotFound
+	}
+	return books[0].ID, nil
+}
+
+// Rename 修改帳本名稱（限擁有者）
+func (s *BookService) Rename(bookID, actorID int, name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return ErrBookName
+	}
+	if err := s.requireOwner(bookID, actorID); err != nil {
+		return err
+	}
+	return notFoundAs(s.store.Books().Rename(bookID, name), ErrBookNotFound)
+}
+
+// SetMember 新增成員或修改成員角色（限擁有者）
+// 原因：擁有者降為其他角色時需確認帳本仍有其他擁有者
+func (s *BookService) SetMember(bookID, actorID, userID int, role string) error {
+	if !models.ValidRole(role) {
+		return ErrInvalidRole
+	}
+	if err := s.requireOwner(bookID, actorID); err != nil {
+		return err
+	}
+	if _, err := s.store.Users().Get(userID); err != nil {
+		return notFoundAs(err, ErrUserNotFound)
+	}
+
+	return s.store.WithTx(func(tx repository.Store) error {
+		if role != models.RoleOwner {
+			if err := checkOtherOwner(tx, bookID, userID); err != nil {
+				return err
+			}
+		}
+		return tx.Books().SetMember(bookID, userID, role)
+	})
+}
+
+// RemoveMember 移除成員（限擁有者，或成員自行退出）
+func (s *BookService) RemoveMember(bookID, actorID, userID int) error {
+	if actorID != userID {
+		if err := s.requireOwner(bookID, actorID); err != nil {
+			return err
+		}
+	}
+
+	return s.store.WithTx(func(tx repository.Store) error {
+		if err := checkOtherOwner(tx, bookID, userID); err != nil {
+			return err
+		}
+		return notFoundAs(tx.Books().RemoveMember(bookID, userID), ErrUserNotFound)
+	})
+}
+
+// requireOwner 確認操作者是帳本擁有者
+func (s *BookService) requireOwner(bookID, actorID int) error {
+	role, err := s.Role(bookID, actorID)
+	if err != nil {
+		return err
+	}
+	if role != models.RoleOwner {
+		return ErrOwnerRequired
+	}
+	return nil
+}
+
+// checkOtherOwner 確認除了 userID 之外帳本仍有其他擁有者
+// 原因：userID 不是擁有者時不影響；是唯一的擁有者時不可降級或移除
+func checkOtherOwner(tx repository.Store, bookID, userID int) error {
+	members, err := tx.Books().Members(bookID)
+	if err != nil {
+		return err
+	}
+	isOwner, owners := false, 0
+	for _, m := range members {
+		if m.Role == models.RoleOwner {
+			owners++
+			if m.UserID == userID {
+				isOwner = true
+			}
+		}
+	}
+	if isOwner && owners == 1 {
+		return ErrLastOwner
+	}
+	return nil
+}
+
+// ChatBook 取得 Telegram 聊天室目前的使用者與帳本
+// 原因：未綁定的聊天室歸屬於預設使用者；未切換或已失去權限的帳本改用個人帳本
+func (s *BookService) ChatBook(chatID int64) (*ChatBook, error) {
+	cb := &ChatBook{UserID: models.DefaultUserID}
+	chat, err := s.store.TelegramChats().Get(chatID)
+	if err != nil && err != repository.ErrNotFound {
+		return nil, err
+	}
+	if chat != nil {
+		cb.UserID = chat.UserID
+		cb.BookID = chat.BookID
+	}
+
+	if cb.BookID != 0 {
+		if cb.Role, err = s.Role(cb.BookID, cb.UserID); err == nil {
+			return cb, nil
+		} else if err != ErrBookNotFound {
+			return nil, err
+		}
+	}
+
+	if cb.BookID, err = s.DefaultBook(cb.UserID); err != nil {
+		return nil, err
+	}
+	if cb.Role, err = s.Role(cb.BookID, cb.UserID); err != nil {
+		return nil, err
+	}
+	return cb, nil
+}
+
+// SwitchChatBook 切換聊天室目前的帳本
+// 原因：未綁定的聊天室先綁定到目前的使用者（預設使用者），才能記住切換的帳本
+func (s *BookService) SwitchChatBook(chatID int64, userID, bookID int) error {
+	if _, err := s.Role(bookID, userID); err != nil {
+		return err
+	}
+	return s.store.WithTx(func(tx repository.Store) error {
+		if _, err := tx.TelegramChats().Get(chatID); err == repository.ErrNotFound {
+			if err := tx.TelegramChats().Link(chatID, userID); err != nil {
+				return err
+			}
+		} else if err != nil {
+			return err
+		}
+		return tx.TelegramChats().SetBook(chatID, bookID)
+	})
+}
@@ -0,0 +1,296 @@
+package services
+
+import (
+	"accountbook/models"
+	"accountbook/repository"
+	"errors"
+	"math"
+	"strings"
+	"time"
+)
+
+// 預算服務的錯誤
+var (
+	ErrBudgetNotFound = errors.New("找不到該預算")
+	ErrBudgetExists   = errors.New("此分類已有預算")
+	ErrInvalidMonth   = errors.New("月份格式錯誤，應為 YYYY-MM")
+)
+
+// budgetThresholds 提醒的門檻（百分比），由高到低
+var budgetThresholds = []int{100, 80}
+
+// Budgets 全域預算服務
+var Budgets *BudgetService
+
+// BudgetService 每月預算的設定與使用情況
+type BudgetService struct {
+	store repository.Store
+}
+
+// NewBudgetService 建立預算服務
+func NewBudgetService(store repository.Store) *BudgetService {
+	return &BudgetService{store: store}
+}
+
+// ForBook 回傳只能存取指定帳本預算的服務
+func (s *BudgetService) ForBook(bookID, userID int) *BudgetService {
+	return &BudgetService{store: s.store.ForBook(bookID, userID)}
+}
+
+// List 列出所有預算
+func (s *BudgetService) List() ([]models.Budget, error) {
+	return s.store.Budgets().List()
+}
+
+// Create 新增預算，開始月份省略時為本月
+func (s *BudgetService) Create(in models.BudgetInput) (*models.Budget, error) {
+	b := &models.Budget{CategoryID: in.CategoryID, Amount: in.Amount, Rollover: in.Rollover, StartMonth: in.StartMonth}
+	if err := s.validate(b); err != nil {
+		return nil, err
+	}
+	if b.CategoryID != 0 {
+		if _, err := s.store.Categories().Get(b.CategoryID); err != nil {
+			return nil, notFoundAs(err, ErrCategoryNotFound)
+		}
+	}
+
+	err := s.store.Budgets().Create(b)
+	if err == repository.ErrDuplicate {
+		return nil, ErrBudgetExists
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.store.Budgets().Get(b.ID)
+}
+
+// Update 更新預算的金額、滾存與開始月份
+// 原因：分類不可變更，要改分類請刪除後重新新增，避免與既有預算衝突
+func (s *BudgetService) Update(id int, in models.BudgetInput) (*models.Budget, error) {
+	b, err := s.store.Budgets().Get(id)
+	if err != nil {
+		return nil, notFoundAs(err, ErrBudgetNotFound)
+	}
+	b.Amount, b.Rollover = in.Amount, in.Rollover
+	if in.StartMonth != "" {
+		b.StartMonth = in.StartMonth
+	}
+	if err := s.validate(b); err != nil {
+		return nil, err
+	}
+	if err := s.store.Budgets().Update(b); err != nil {
+		return nil, notFoundAs(err, ErrBudgetNotFound)
+	}
+	return s.store.Budgets().Get(id)
+}
+
+// Delete 刪除預算
+func (s *BudgetService) Delete(id int) error {
+	return notFoundAs(s.store.Budgets().Delete(id), ErrBudgetNotFound)
+}
+
+// validate 檢查金額與開始月份（省略時填入本月）
+func (s *BudgetService) validate(b *models.Budget) error {
+	if err := validateAmount(b.Amount); err != nil {
+		return err
+	}
+	if err := checkPrecision(b.Amount, models.DefaultCurrency); err != nil {
+		return err
+	}
+	if b.StartMonth == "" {
+		b.StartMonth = time.Now().Format("2006-01")
+	}
+	if _, err := time.Parse("2006-01", b.StartMonth); err != nil {
+		return ErrInvalidMonth
+	}
+	return nil
+}
+
+// Status 各預算在指定月份（2006-01）的使用情況，尚未開始生效的預算不列出
+func (s *BudgetService) Status(month string) ([]models.BudgetStatus, error) {
+	if _, err := time.Parse("2006-01", month); err != nil {
+		return nil, ErrInvalidMonth
+	}
+	budgets, err := s.store.Budgets().List()
+	if err != nil {
+		return nil, err
+	}
+
+	spending := newMonthlySpending(s.store)
+	statuses := []models.BudgetStatus{}
+	for _, b := range budgets {
+		if month < b.StartMonth {
+			continue
+		}
+		status, err := s.status(b, month, spending)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, *status)
+	}
+	return statuses, nil
+}
+
+// status 計算單一預算在指定月份的使用情況
+// 啟用滾存時，從開始月份起逐月累計「每月金額 − 當月支出」，超支會減少之後的可用金額
+func (s *BudgetService) status(b models.Budget, month string, spending *monthlySpending) (*models.BudgetStatus, error) {
+	var carry models.Money
+	if b.Rollover {
+		for m := b.StartMonth; m < month; m = nextMonth(m) {
+			spent, err := spending.spent(m, b.CategoryID)
+			if err != nil {
+				return nil, err
+			}
+			carry += b.Amount - spent
+		}
+	}
+
+	spent, err := spending.spent(month, b.CategoryID)
+	if err != nil {
+		return nil, err
+	}
+	budgeted := b.Amount + carry
+	return &models.BudgetStatus{
+		BudgetID:     b.ID,
+		CategoryID:   b.CategoryID,
+		CategoryName: b.CategoryName,
+		Month:        month,
+		Currency:     models.DefaultCurrency,
+		Amount:       b.Amount,
+		Carry:        carry,
+		Budgeted:     budgeted,
+		Spent:        spent,
+		Remaining:    budgeted - spent,
+		Percent:      budgetPercent(spent, budgeted),
+	}, nil
+}
+
+// Alerts 檢查已寫入的紀錄是否使分類預算或總預算跨過 80% 或 100%
+// previous 為修改前的紀錄，新增紀錄時為 nil
+// 原因：只在跨過門檻的那一筆提醒，之後的紀錄不重複提醒；收入與轉帳不影響預算
+// 修改紀錄時只計算相對於舊紀錄增加的支出，未增加支出的修改不會再次提醒
+func (s *BudgetService) Alerts(r *models.Record, previous *models.RecordWithNames) ([]models.BudgetAlert, error) {
+	if r.Type != "支出" || r.TransferID != nil || len(r.Date) < 7 {
+		return nil, nil
+	}
+	month := r.Date[:7]
+
+	account, err := s.store.Accounts().Get(r.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	amount, err := convert(s.store, r.Amount, account.Currency, models.DefaultCurrency, monthEnd(month))
+	if err != nil {
+		return nil, err
+	}
+
+	spending := newMonthlySpending(s.store)
+	var alerts []models.BudgetAlert
+	for _, categoryID := range []int{r.CategoryID, 0} {
+		b, err := s.store.Budgets().FindByCategory(categoryID)
+		if err == repository.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if month < b.StartMonth {
+			continue
+		}
+
+		status, err := s.status(*b, month, spending)
+		if err != nil {
+			return nil, err
+		}
+		counted, err := s.previousSpending(previous, month, categoryID)
+		if err != nil {
+			return nil, err
+		}
+		before := budgetPercent(status.Spent-amount+counted, status.Budgeted)
+		for _, threshold := range budgetThresholds {
+			if before < float64(threshold) && status.Percent >= float64(threshold) {
+				alerts = append(alerts, models.BudgetAlert{Status: *status, Threshold: threshold})
+				break
+			}
+		}
+	}
+	return alerts, nil
+}
+
+// previousSpending 修改前的紀錄計入指定月份分類預算的支出（換算為預設幣別），categoryID 為 0 時為總預算
+func (s *BudgetService) previousSpending(previous *models.RecordWithNames, month string, categoryID int) (models.Money, error) {
+	if previous == nil || previous.Type != "支出" || previous.TransferID != nil || !strings.HasPrefix(previous.Date, month) {
+		return 0, nil
+	}
+	if categoryID != 0 && previous.CategoryID != categoryID {
+		return 0, nil
+	}
+	return convert(s.store, previous.Amount, previous.Currency, models.DefaultCurrency, monthEnd(month))
+}
+
+// budgetPercent 支出佔可用金額的百分比（四捨五入到小數一位）
+// 可用金額因滾存超支而小於等於 0 時，有支出即視為 100%
+func budgetPercent(spent, budgeted models.Money) float64 {
+	if budgeted <= 0 {
+		if spent > 0 {
+			return 100
+		}
+		return 0
+	}
+	return math.Round(spent.Float64()/budgeted.Float64()*1000) / 10
+}
+
+// monthlySpending 各月份各分類支出（換算為預設幣別）的快取
+// 原因：滾存需逐月計算，多個預算共用同一份查詢結果
+type monthlySpending struct {
+	store  repository.Store
+	months map[string]map[int]models.Money // 月份 → 分類 ID → 支出，0 為所有分類合計
+}
+
+func newMonthlySpending(store repository.Store) *monthlySpending {
+	return &monthlySpending{store: store, months: make(map[string]map[int]models.Money)}
+}
+
+// spent 指定月份分類的支出，categoryID 為 0 時為所有分類合計
+func (m *monthlySpending) spent(month string, categoryID int) (models.Money, error) {
+	if totals, ok := m.months[month]; ok {
+		return totals[categoryID], nil
+	}
+
+	totals, err := m.store.Records().CategoryTotals(models.StatisticFilter{Month: month})
+	if err != nil {
+		return 0, err
+	}
+	byCategory := make(map[int]models.Money)
+	for _, t := range totals {
+		if t.Type != "支出" {
+			continue
+		}
+		amount, err := convert(m.store, t.Total, t.Currency, models.DefaultCurrency, monthEnd(month))
+		if err != nil {
+			return 0, err
+		}
+		byCategory[t.CategoryID] += amount
+		byCategory[0] += amount
+	}
+	m.months[month] = byCategory
+	return byCategory[categoryID], nil
+}
+
+// monthEnd 月份的最後一天，作為換算匯率的基準日（與統計相同）
+func monthEnd(month string) string {
+	t, err := time.Parse("2006-01", month)
+	if err != nil {
+		return month + "-31"
+	}
+	return t.AddDate(0, 1, -1).Format("2006-01-02")
+}
+
+// nextMonth 下一個月份（2006-01）
+func nextMonth(month string) string {
+	t, err := time.Parse("2006-01", month)
+	if err != nil {
+		return "9999-12"
+	}
+	return t.AddDate(0, 1, 0).Format("2006-01")
+}
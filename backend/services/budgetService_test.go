@@ -0,0 +1,119 @@
+package services
+
+import (
+	"accountbook/models"
+	"testing"
+)
+
+// thresholds 提醒的門檻（依分類預算、總預算的順序）
+func thresholds(alerts []models.BudgetAlert) []int {
+	var got []int
+	for _, a := range alerts {
+		got = append(got, a.Threshold)
+	}
+	return got
+}
+
+func expectThresholds(t *testing.T, step string, alerts []models.BudgetAlert, want ...int) {
+	t.Helper()
+	got := thresholds(alerts)
+	if len(got) != len(want) {
+		t.Errorf("%s：提醒門檻 = %v，預期 %v", step, got, want)
+		return
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("%s：提醒門檻 = %v，預期 %v", step, got, want)
+			return
+		}
+	}
+}
+
+func TestAlertsOnNewRecords(t *testing.T) {
+	store, ledger := newTestLedger(t)
+	budgets := NewBudgetService(store)
+	if _, err := budgets.Create(models.BudgetInput{CategoryID: testFood, Amount: models.MoneyFromFloat(1000), StartMonth: "2026-10"}); err != nil {
+		t.Fatalf("新增預算失敗: %v", err)
+	}
+
+	steps := []struct {
+		name   string
+		amount float64
+		want   []int
+	}{
+		{"未達 80%", 500, nil},
+		{"跨過 80%", 300, []int{80}},
+		{"已超過 80%，未達 100%", 100, nil},
+		{"跨過 100%", 200, []int{100}},
+		{"已超支", 50, nil},
+	}
+	for _, step := range steps {
+		r := &models.Record{Date: "2026-10-10", AccountID: testCash, Type: "支出", Amount: models.MoneyFromFloat(step.amount), Item: "午餐", CategoryID: testFood}
+		if err := ledger.PostRecord(r); err != nil {
+			t.Fatalf("%s：PostRecord: %v", step.name, err)
+		}
+		alerts, err := budgets.Alerts(r, nil)
+		if err != nil {
+			t.Fatalf("%s：Alerts: %v", step.name, err)
+		}
+		expectThresholds(t, step.name, alerts, step.want...)
+	}
+}
+
+func TestAlertsOnAmendedRecord(t *testing.T) {
+	store, ledger := newTestLedger(t)
+	budgets := NewBudgetService(store)
+	for _, in := range []models.BudgetInput{
+		{CategoryID: testFood, Amount: models.MoneyFromFloat(1000), StartMonth: "2026-10"},
+		{CategoryID: 0, Amount: models.MoneyFromFloat(2000), StartMonth: "2026-10"},
+	} {
+		if _, err := budgets.Create(in); err != nil {
+			t.Fatalf("新增預算失敗: %v", err)
+		}
+	}
+
+	r := &models.Record{Date: "2026-10-10", AccountID: testCash, Type: "支出", Amount: models.MoneyFromFloat(850), Item: "聚餐", CategoryID: testFood}
+	if err := ledger.PostRecord(r); err != nil {
+		t.Fatalf("PostRecord: %v", err)
+	}
+	alerts, err := budgets.Alerts(r, nil)
+	if err != nil {
+		t.Fatalf("Alerts: %v", err)
+	}
+	expectThresholds(t, "新增", alerts, 80)
+
+	// 依序修改同一筆紀錄：只有使支出增加並跨過門檻的修改會提醒
+	steps := []struct {
+		name   string
+		change func(r *models.Record)
+		want   []int
+	}{
+		{"只修改項目", func(r *models.Record) { r.Item = "家庭聚餐" }, nil},
+		{"金額增加但未跨過新門檻", func(r *models.Record) { r.Amount = models.MoneyFromFloat(900) }, nil},
+		{"金額減少", func(r *models.Record) { r.Amount = models.MoneyFromFloat(700) }, nil},
+		{"金額增加並再次跨過 80%", func(r *models.Record) { r.Amount = models.MoneyFromFloat(800) }, []int{80}},
+		{"金額增加並跨過 100%", func(r *models.Record) { r.Amount = models.MoneyFromFloat(1600) }, []int{100, 80}},
+		{"超支後修改備註", func(r *models.Record) { r.Note = "含酒水" }, nil},
+		{"改為收入", func(r *models.Record) { r.Type = "收入" }, nil},
+		{"改回支出", func(r *models.Record) { r.Type = "支出" }, []int{100, 80}},
+		{"移到其他分類", func(r *models.Record) { r.CategoryID = testOthers }, nil},
+		{"移回原分類", func(r *models.Record) { r.CategoryID = testFood }, []int{100}},
+		{"移到上個月", func(r *models.Record) { r.Date = "2026-09-30" }, nil},
+		{"移回本月", func(r *models.Record) { r.Date = "2026-10-10" }, []int{100, 80}},
+	}
+	for _, step := range steps {
+		previous, err := store.Records().Get(r.ID)
+		if err != nil {
+			t.Fatalf("%s：查詢紀錄失敗: %v", step.name, err)
+		}
+		step.change(r)
+		if err := ledger.AmendRecord(r); err != nil {
+			t.Fatalf("%s：AmendRecord: %v", step.name, err)
+		}
+		alerts, err := budgets.Alerts(r, previous)
+		if err != nil {
+			t.Fatalf("%s：Alerts: %v", step.name, err)
+		}
+		expectThresholds(t, step.name, alerts, step.want...)
+	}
+}
@@ -0,0 +1,236 @@
+package services
+
+import (
+	"accountbook/charts"
+	"accountbook/models"
+	"accountbook/repository"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// 圖表服務的錯誤
+var (
+	ErrInvalidChart  = errors.New("圖表種類錯誤，應為 pie、bar 或 line")
+	ErrInvalidPeriod = errors.New("期間格式錯誤，應為 YYYY-MM 或 YYYY")
+)
+
+// chartEpoch 計算餘額時累計紀錄的起始日（早於所有紀錄）
+const chartEpoch = "0001-01-01"
+
+// chartTypeNames 圖片標題上的類型名稱（圖片只能顯示英數字）
+var chartTypeNames = map[string]string{"收入": "INCOME", "支出": "EXPENSE"}
+
+// Charts 全域圖表服務
+var Charts *ChartService
+
+// ChartService 依統計資料產生 PNG 圖表
+// 原因：與統計頁（GetStatistics、GetSummary）相同的查詢與換算，圖表的數字才會與列表一致
+type ChartService struct {
+	store repository.Store
+}
+
+// NewChartService 建立圖表服務
+func NewChartService(store repository.Store) *ChartService {
+	return &ChartService{store: store}
+}
+
+// ForBook 回傳只能存取指定帳本資料的服務
+func (s *ChartService) ForBook(bookID, userID int) *ChartService {
+	return &ChartService{store: s.store.ForBook(bookID, userID)}
+}
+
+// Render 產生圖表，類型省略時為支出、幣別省略時為預設幣別
+func (s *ChartService) Render(req models.ChartRequest) (*models.Chart, error) {
+	if req.Type == "" {
+		req.Type = "支出"
+	}
+	if req.Currency == "" {
+		req.Currency = models.DefaultCurrency
+	}
+	if _, ok := chartTypeNames[req.Type]; !ok {
+		return nil, ErrInvalidType
+	}
+	buckets, ok := chartBuckets(req.Period)
+	if !ok {
+		return nil, ErrInvalidPeriod
+	}
+
+	switch req.Kind {
+	case models.ChartPie:
+		return s.pie(req)
+	case models.ChartBar:
+		return s.bar(req, buckets)
+	case models.ChartLine:
+		return s.line(req, buckets)
+	}
+	return nil, ErrInvalidChart
+}
+
+// pie 各分類佔該類型總額的比例，依期間最後一天的匯率換算
+func (s *ChartService) pie(req models.ChartRequest) (*models.Chart, error) {
+	filter := chartFilter(req)
+	totals, err := s.store.Records().CategoryTotals(filter)
+	if err != nil {
+		return nil, err
+	}
+	totals, err = Exchange.ConvertCategoryTotals(totals, req.Currency, chartPeriodEnd(req.Period))
+	if err != nil {
+		return nil, err
+	}
+
+	chart := &models.Chart{Request: req}
+	var values []float64
+	for _, t := range totals {
+		if t.Type != req.Type {
+			continue
+		}
+		chart.Categories = append(chart.Categories, t)
+		chart.Total += t.Total
+		values = append(values, t.Total.Float64())
+	}
+	title := fmt.Sprintf("%s %s BY CATEGORY (%s)", req.Period, chartTypeNames[req.Type], req.Currency)
+	if chart.PNG, err = charts.Pie(title, values); err != nil {
+		return nil, err
+	}
+	return chart, nil
+}
+
+// bar 月份為每日、年度為每月的收入或支出，各區間依該區間最後一天的匯率換算
+func (s *ChartService) bar(req models.ChartRequest, buckets []chartBucket) (*models.Chart, error) {
+	chart := &models.Chart{Request: req}
+	points := make([]charts.Point, 0, len(buckets))
+	for _, b := range buckets {
+		filter := chartFilter(req)
+		filter.From, filter.To = b.from, b.to
+		totals, err := s.store.Records().TypeTotals(filter)
+		if err != nil {
+			return nil, err
+		}
+		income, expense, err := Exchange.ConvertTypeTotals(totals, req.Currency, b.to)
+		if err != nil {
+			return nil, err
+		}
+		amount := expense
+		if req.Type == "收入" {
+			amount = income
+		}
+		chart.Total += amount
+		points = append(points, charts.Point{Label: b.label, Value: amount.Float64()})
+	}
+
+	every := "DAILY"
+	if len(req.Period) == len("2006") {
+		every = "MONTHLY"
+	}
+	title := fmt.Sprintf("%s %s %s (%s)", req.Period, every, chartTypeNames[req.Type], req.Currency)
+	var err error
+	if chart.PNG, err = charts.Bar(title, points); err != nil {
+		return nil, err
+	}
+	return chart, nil
+}
+
+// line 每個區間結束時的資產餘額（期初餘額 + 至當天為止的收入 − 支出，含轉帳），只畫到今天
+// 原因：各幣別的餘額依該區間最後一天的匯率換算，反映當時的資產價值
+func (s *ChartService) line(req models.ChartRequest, buckets []chartBucket) (*models.Chart, error) {
+	accounts, err := s.store.Accounts().List()
+	if err != nil {
+		return nil, err
+	}
+	opening := make(map[string]models.Money)
+	for _, a := range accounts {
+		if req.AccountID == 0 || a.ID == req.AccountID {
+			opening[a.Currency] += a.OpeningBalance
+		}
+	}
+
+	chart := &models.Chart{Request: req}
+	var points []charts.Point
+	today := time.Now().Format("2006-01-02")
+	for _, b := range buckets {
+		if b.from > today {
+			break
+		}
+		filter := models.StatisticFilter{From: chartEpoch, To: b.to, AccountID: req.AccountID, IncludeTransfers: true}
+		totals, err := s.store.Records().TypeTotals(filter)
+		if err != nil {
+			return nil, err
+		}
+		// 期初餘額併入同幣別的收入一起換算
+		remaining := make(map[string]models.Money, len(opening))
+		for currency, amount := range opening {
+			remaining[currency] = amount
+		}
+		for i := range totals {
+			totals[i].Income += remaining[totals[i].Currency]
+			delete(remaining, totals[i].Currency)
+		}
+		for currency, amount := range remaining {
+			totals = append(totals, models.TypeTotal{Currency: currency, Income: amount})
+		}
+
+		income, expense, err := Exchange.ConvertTypeTotals(totals, req.Currency, b.to)
+		if err != nil {
+			return nil, err
+		}
+		chart.Total = income - expense
+		points = append(points, charts.Point{Label: b.label, Value: chart.Total.Float64()})
+	}
+
+	title := fmt.Sprintf("%s BALANCE (%s)", req.Period, req.Currency)
+	if chart.PNG, err = charts.Line(title, points); err != nil {
+		return nil, err
+	}
+	return chart, nil
+}
+
+// chartBucket 長條圖、折線圖的一個區間（一天或一個月）
+type chartBucket struct {
+	label    string // X 軸標籤：日或月的數字
+	from, to string // 2006-01-02，含頭尾
+}
+
+// chartBuckets 期間切分的區間：月份為每一天，年度為每個月
+func chartBuckets(period string) ([]chartBucket, bool) {
+	if t, err := time.Parse("2006-01", period); err == nil {
+		var buckets []chartBucket
+		for d := t; d.Month() == t.Month(); d = d.AddDate(0, 0, 1) {
+			day := d.Format("2006-01-02")
+			buckets = append(buckets, chartBucket{label: fmt.Sprint(d.Day()), from: day, to: day})
+		}
+		return buckets, true
+	}
+	t, err := time.Parse("2006", period)
+	if err != nil {
+		return nil, false
+	}
+	buckets := make([]chartBucket, 0, 12)
+	for m := t; m.Year() == t.Year(); m = m.AddDate(0, 1, 0) {
+		buckets = append(buckets, chartBucket{
+			label: fmt.Sprint(int(m.Month())),
+			from:  m.Format("2006-01-02"),
+			to:    m.AddDate(0, 1, -1).Format("2006-01-02"),
+		})
+	}
+	return buckets, true
+}
+
+// chartFilter 圖表期間與帳戶的統計條件（不含轉帳，同統計頁預設）
+func chartFilter(req models.ChartRequest) models.StatisticFilter {
+	filter := models.StatisticFilter{AccountID: req.AccountID}
+	if len(req.Period) == len("2006-01") {
+		filter.Month = req.Period
+	} else {
+		filter.Year = req.Period
+	}
+	return filter
+}
+
+// chartPeriodEnd 期間的最後一天，作為換算匯率的基準日
+func chartPeriodEnd(period string) string {
+	if t, err := time.Parse("2006-01", period); err == nil {
+		return t.AddDate(0, 1, -1).Format("2006-01-02")
+	}
+	return period + "-12-31"
+}
@@ -0,0 +1,234 @@
+package services
+
+import (
+	"accountbook/models"
+	"accountbook/repository"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 匯率相關錯誤
+var (
+	ErrRateNotFound    = errors.New("找不到匯率")
+	ErrInvalidRate     = errors.New("匯率必須大於 0")
+	ErrInvalidCurrency = errors.New("幣別代碼必須為 3 個英文字母")
+	ErrSameCurrency    = errors.New("兩個幣別不能相同")
+	ErrInvalidDate     = errors.New("日期格式必須為 YYYY-MM-DD")
+)
+
+// ImportError CSV 匯入時某一行的錯誤
+type ImportError struct {
+	Line int
+	Err  error
+}
+
+func (e *ImportError) Error() string { return fmt.Sprintf("第 %d 行：%v", e.Line, e.Err) }
+func (e *ImportError) Unwrap() error { return e.Err }
+
+// Exchange 全域匯率服務
+var Exchange *ExchangeService
+
+// ExchangeService 匯率服務
+// 原因：跨幣別轉帳與統計換算共用同一套匯率查詢規則
+type ExchangeService struct {
+	store repository.Store
+}
+
+// NewExchangeService 建立匯率服務
+func NewExchangeService(store repository.Store) *ExchangeService {
+	return &ExchangeService{store: store}
+}
+
+// Convert 將金額依 date 當天（或之前最近）的匯率換算為另一幣別
+func (e *ExchangeService) Convert(amount models.Money, from, to, date string) (models.Money, error) {
+	return convert(e.store, amount, from, to, date)
+}
+
+// SaveRate 新增或覆寫單筆匯率
+func (e *ExchangeService) SaveRate(r *models.ExchangeRate) error {
+	if err := normalizeRate(r); err != nil {
+		return err
+	}
+	return e.store.ExchangeRates().Save(r)
+}
+
+// ImportCSV 匯入 CSV 匯率，每列格式為 date,from_currency,to_currency,rate，可有標題列
+// 原因：全部匯入成功才寫入，任一列有誤時回報行號且不留下部分資料
+func (e *ExchangeService) ImportCSV(r io.Reader) (int, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 4
+	reader.TrimLeadingSpace = true
+
+	var rates []*models.ExchangeRate
+	for line := 1; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, &ImportError{Line: line, Err: err}
+		}
+		// 略過標題列
+		if line == 1 && strings.EqualFold(strings.TrimSpace(row[0]), "date") {
+			continue
+		}
+
+		rate, err := strconv.ParseFloat(strings.TrimSpace(row[3]), 64)
+		if err != nil {
+			return 0, &ImportError{Line: line, Err: ErrInvalidRate}
+		}
+		r := &models.ExchangeRate{Date: row[0], FromCurrency: row[1], ToCurrency: row[2], Rate: rate}
+		if err := normalizeRate(r); err != nil {
+			return 0, &ImportError{Line: line, Err: err}
+		}
+		rates = append(rates, r)
+	}
+
+	err := e.store.WithTx(func(tx repository.Store) error {
+		for _, r := range rates {
+			if err := tx.ExchangeRates().Save(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(rates), nil
+}
+
+// ConvertCategoryTotals 將各幣別的分類統計換算為 base 幣別並合併（金額由大到小）
+func (e *ExchangeService) ConvertCategoryTotals(totals []models.CategoryTotal, base, date string) ([]models.CategoryTotal, error) {
+	type key struct {
+		categoryID int
+		recordType string
+	}
+	merged := make(map[key]*models.CategoryTotal)
+	var order []key
+	for _, t := range totals {
+		amount, err := e.Convert(t.Total, t.Currency, base, date)
+		if err != nil {
+			return nil, err
+		}
+		k := key{t.CategoryID, t.Type}
+		if existing, ok := merged[k]; ok {
+			existing.Total += amount
+			continue
+		}
+		t.Currency = base
+		t.Total = amount
+		merged[k] = &t
+		order = append(order, k)
+	}
+
+	result := make([]models.CategoryTotal, 0, len(order))
+	for _, k := range order {
+		result = append(result, *merged[k])
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Total > result[j].Total })
+	return result, nil
+}
+
+// ConvertTypeTotals 將各幣別的收支總額換算為 base 幣別並加總
+func (e *ExchangeService) ConvertTypeTotals(totals []models.TypeTotal, base, date string) (income, expense models.Money, err error) {
+	for _, t := range totals {
+		in, err := e.Convert(t.Income, t.Currency, base, date)
+		if err != nil {
+			return 0, 0, err
+		}
+		out, err := e.Convert(t.Expense, t.Currency, base, date)
+		if err != nil {
+			return 0, 0, err
+		}
+		income += in
+		expense += out
+	}
+	return income, expense, nil
+}
+
+// ConvertDailyTotals 將各幣別的每日收支換算為 base 幣別，同一天同類型合併（依日期排列）
+func (e *ExchangeService) ConvertDailyTotals(totals []models.DailyTotal, base, date string) ([]models.DailyTotal, error) {
+	type key struct {
+		date       string
+		recordType string
+	}
+	merged := make(map[key]*models.DailyTotal)
+	var order []key
+	for _, t := range totals {
+		amount, err := e.Convert(t.Total, t.Currency, base, date)
+		if err != nil {
+			return nil, err
+		}
+		k := key{t.Date, t.Type}
+		if existing, ok := merged[k]; ok {
+			existing.Total += amount
+			continue
+		}
+		t.Currency = base
+		t.Total = amount
+		merged[k] = &t
+		order = append(order, k)
+	}
+
+	result := make([]models.DailyTotal, 0, len(order))
+	for _, k := range order {
+		result = append(result, *merged[k])
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Date < result[j].Date })
+	return result, nil
+}
+
+// convert 換算金額：同幣別直接回傳，否則使用直接匯率，沒有時改用反向匯率
+// 結果四捨五入到目標幣別的小數位數
+func convert(store repository.Store, amount models.Money, from, to, date string) (models.Money, error) {
+	if from == to || amount == 0 {
+		return amount, nil
+	}
+	decimals := models.CurrencyDecimals(to)
+
+	if r, err := store.ExchangeRates().Find(from, to, date); err == nil {
+		return models.MoneyFromFloat(amount.Float64() * r.Rate).Round(decimals), nil
+	} else if err != repository.ErrNotFound {
+		return 0, err
+	}
+
+	if r, err := store.ExchangeRates().Find(to, from, date); err == nil {
+		return models.MoneyFromFloat(amount.Float64() / r.Rate).Round(decimals), nil
+	} else if err != repository.ErrNotFound {
+		return 0, err
+	}
+
+	return 0, fmt.Errorf("%w：%s → %s（%s 之前）", ErrRateNotFound, from, to, date)
+}
+
+// normalizeRate 檢查匯率欄位並將幣別轉為大寫
+func normalizeRate(r *models.ExchangeRate) error {
+	r.Date = strings.TrimSpace(r.Date)
+	if _, err := time.Parse("2006-01-02", r.Date); err != nil {
+		return ErrInvalidDate
+	}
+	from, ok := models.NormalizeCurrency(r.FromCurrency)
+	if !ok {
+		return ErrInvalidCurrency
+	}
+	to, ok := models.NormalizeCurrency(r.ToCurrency)
+	if !ok {
+		return ErrInvalidCurrency
+	}
+	if from == to {
+		return ErrSameCurrency
+	}
+	if r.Rate <= 0 {
+		return ErrInvalidRate
+	}
+	r.FromCurrency = from
+	r.ToCurrency = to
+	return nil
+}
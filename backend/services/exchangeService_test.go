@@ -0,0 +1,55 @@
+package services
+
+import (
+	"accountbook/models"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestConvertDailyTotals(t *testing.T) {
+	store, ledger := newTestLedger(t)
+	if err := store.Accounts().SetCurrency(testBank, "USD"); err != nil {
+		t.Fatalf("SetCurrency: %v", err)
+	}
+	exchange := NewExchangeService(store)
+	if err := exchange.SaveRate(&models.ExchangeRate{Date: "2026-09-30", FromCurrency: "USD", ToCurrency: "TWD", Rate: 30}); err != nil {
+		t.Fatalf("SaveRate: %v", err)
+	}
+
+	records := []*models.Record{
+		{Date: "2026-10-01", AccountID: testCash, Type: "支出", Amount: models.MoneyFromFloat(150), Item: "午餐", CategoryID: testFood},
+		{Date: "2026-10-01", AccountID: testBank, Type: "支出", Amount: models.MoneyFromFloat(10), Item: "訂閱", CategoryID: testOthers},
+		{Date: "2026-10-02", AccountID: testBank, Type: "收入", Amount: models.MoneyFromFloat(5), Item: "退款", CategoryID: testOthers},
+	}
+	for _, r := range records {
+		if err := ledger.PostRecord(r); err != nil {
+			t.Fatalf("PostRecord: %v", err)
+		}
+	}
+
+	totals, err := store.Records().DailyTotals("2026-10")
+	if err != nil {
+		t.Fatalf("DailyTotals: %v", err)
+	}
+	if len(totals) != 3 {
+		t.Fatalf("每日加總 = %+v，預期依幣別分成 3 筆", totals)
+	}
+
+	// 不同幣別的同日同類型金額換算後合併
+	got, err := exchange.ConvertDailyTotals(totals, "TWD", "2026-10-31")
+	if err != nil {
+		t.Fatalf("ConvertDailyTotals: %v", err)
+	}
+	want := []models.DailyTotal{
+		{Date: "2026-10-01", Type: "支出", Currency: "TWD", Total: models.MoneyFromFloat(450)},
+		{Date: "2026-10-02", Type: "收入", Currency: "TWD", Total: models.MoneyFromFloat(150)},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("換算後的每日加總 = %+v，預期 %+v", got, want)
+	}
+
+	if _, err := exchange.ConvertDailyTotals(totals, "JPY", "2026-10-31"); !errors.Is(err, ErrRateNotFound) {
+		t.Errorf("缺少匯率時的錯誤 = %v，預期 %v", err, ErrRateNotFound)
+	}
+}
@@ -0,0 +1,178 @@
+package services
+
+import (
+	"accountbook/models"
+	"accountbook/repository"
+	"errors"
+)
+
+// 記帳服務的錯誤
+// 原因：呼叫端（REST API、Telegram Bot）需依錯誤種類回覆不同訊息
+var (
+	ErrAccountNotFound  = errors.New("帳戶不存在")
+	ErrCategoryNotFound = errors.New("分類不存在")
+	ErrRecordNotFound   = errors.New("找不到該紀錄")
+	ErrInvalidAmount    = errors.New("金額必須大於 0")
+	ErrAmountPrecision  = errors.New("金額的小數位數超過幣別允許的位數")
+	ErrInvalidType      = errors.New("類型必須為收入或支出")
+	ErrSameAccount      = errors.New("轉出與轉入帳戶不能相同")
+	ErrTransferNotFound = errors.New("找不到該轉帳")
+	ErrTransferLeg      = errors.New("此紀錄屬於轉帳，請透過轉帳修改或刪除")
+)
+
+// Ledger 全域記帳服務
+var Ledger *LedgerService
+
+// LedgerService 記帳服務
+// 原因：紀錄與帳戶餘額必須一起變動，所有寫入紀錄的操作都只能透過此服務
+type LedgerService struct {
+	store repository.Store
+}
+
+// NewLedgerService 建立記帳服務
+func NewLedgerService(store repository.Store) *LedgerService {
+	return &LedgerService{store: store}
+}
+
+// ForBook 回傳只能存取指定帳本資料的記帳服務，userID 為操作者
+// 原因：帳戶、分類與紀錄的存在檢查都必須限定在同一個帳本內
+func (l *LedgerService) ForBook(bookID, userID int) *LedgerService {
+	return &LedgerService{store: l.store.ForBook(bookID, userID)}
+}
+
+// PostRecord 新增紀錄並同步帳戶餘額，成功後寫回 ID
+func (l *LedgerService) PostRecord(r *models.Record) error {
+	if err := validateRecord(r); err != nil {
+		return err
+	}
+	return l.store.WithTx(func(tx repository.Store) error {
+		return postRecord(tx, r)
+	})
+}
+
+// AmendRecord 更新紀錄
+// 原因：先回滾舊紀錄對帳戶餘額的影響，再套用新值
+func (l *LedgerService) AmendRecord(r *models.Record) error {
+	if err := validateRecord(r); err != nil {
+		return err
+	}
+	return l.store.WithTx(func(tx repository.Store) error {
+		old, err := getStandaloneRecord(tx, r.ID)
+		if err != nil {
+			return err
+		}
+		if err := checkReferences(tx, r); err != nil {
+			return err
+		}
+
+		if err := adjustBalance(tx, old.AccountID, -balanceDelta(old.Type, old.Amount)); err != nil {
+			return err
+		}
+		if err := tx.Records().Update(r); err != nil {
+			return err
+		}
+		return adjustBalance(tx, r.AccountID, balanceDelta(r.Type, r.Amount))
+	})
+}
+
+// VoidRecord 刪除紀錄並回滾其對帳戶餘額的影響
+func (l *LedgerService) VoidRecord(id int) error {
+	return l.store.WithTx(func(tx repository.Store) error {
+		old, err := getStandaloneRecord(tx, id)
+		if err != nil {
+			return err
+		}
+		if err := tx.Records().Delete(id); err != nil {
+			return err
+		}
+		return adjustBalance(tx, old.AccountID, -balanceDelta(old.Type, old.Amount))
+	})
+}
+
+// getStandaloneRecord 取得可單獨修改的紀錄
+// 原因：轉帳的其中一筆若被單獨修改或刪除，另一個帳戶會留下半筆轉帳
+func getStandaloneRecord(tx repository.Store, id int) (*models.RecordWithNames, error) {
+	r, err := tx.Records().Get(id)
+	if err != nil {
+		return nil, notFoundAs(err, ErrRecordNotFound)
+	}
+	if r.TransferID != nil {
+		return nil, ErrTransferLeg
+	}
+	return r, nil
+}
+
+// postRecord 在 Transaction 內新增紀錄並調整餘額
+func postRecord(tx repository.Store, r *models.Record) error {
+	if err := checkReferences(tx, r); err != nil {
+		return err
+	}
+	if err := tx.Records().Insert(r); err != nil {
+		return err
+	}
+	return adjustBalance(tx, r.AccountID, balanceDelta(r.Type, r.Amount))
+}
+
+// validateRecord 檢查紀錄欄位
+func validateRecord(r *models.Record) error {
+	if err := validateAmount(r.Amount); err != nil {
+		return err
+	}
+	if r.Type != "收入" && r.Type != "支出" {
+		return ErrInvalidType
+	}
+	return nil
+}
+
+// validateAmount 檢查金額為正數
+func validateAmount(amount models.Money) error {
+	if amount <= 0 {
+		return ErrInvalidAmount
+	}
+	return nil
+}
+
+// checkPrecision 檢查金額的小數位數符合帳戶幣別設定
+func checkPrecision(amount models.Money, currency string) error {
+	if !amount.FitsDecimals(models.CurrencyDecimals(currency)) {
+		return ErrAmountPrecision
+	}
+	return nil
+}
+
+// checkReferences 確認紀錄的帳戶與分類存在，且金額符合帳戶幣別
+// 原因：不依賴外鍵錯誤訊息，讓呼叫端能明確得知是哪個欄位有誤
+func checkReferences(tx repository.Store, r *models.Record) error {
+	account, err := tx.Accounts().Get(r.AccountID)
+	if err != nil {
+		return notFoundAs(err, ErrAccountNotFound)
+	}
+	if err := checkPrecision(r.Amount, account.Currency); err != nil {
+		return err
+	}
+	if _, err := tx.Categories().Get(r.CategoryID); err != nil {
+		return notFoundAs(err, ErrCategoryNotFound)
+	}
+	return nil
+}
+
+// adjustBalance 調整帳戶餘額，帳戶不存在時回傳 ErrAccountNotFound
+func adjustBalance(tx repository.Store, accountID int, delta models.Money) error {
+	return notFoundAs(tx.Accounts().AdjustBalance(accountID, delta), ErrAccountNotFound)
+}
+
+// balanceDelta 紀錄對帳戶餘額的影響：支出扣款、收入加款
+func balanceDelta(recordType string, amount models.Money) models.Money {
+	if recordType == "支出" {
+		return -amount
+	}
+	return amount
+}
+
+// notFoundAs 將 repository.ErrNotFound 轉換為指定的錯誤
+func notFoundAs(err, target error) error {
+	if err == repository.ErrNotFound {
+		return target
+	}
+	return err
+}
@@ -0,0 +1,190 @@
+package services
+
+import (
+	"accountbook/models"
+	"accountbook/repository"
+	"reflect"
+	"testing"
+)
+
+// 預設使用者的個人帳本中的帳戶與分類（依建立順序）
+const (
+	testCash   = 1 // 現金
+	testCard   = 2 // 信用卡
+	testBank   = 3 // 銀行帳戶
+	testFood   = 1 // 飲食
+	testOthers = 6 // 其他
+)
+
+// newTestLedger 建立只有預設使用者與其個人帳本（預設帳戶、分類）的 MemoryStore 與記帳服務
+func newTestLedger(t *testing.T) (repository.Store, *LedgerService) {
+	t.Helper()
+	store := repository.NewMemoryStore()
+	if err := NewUserService(store).CreateUser(&models.User{Username: "admin"}); err != nil {
+		t.Fatalf("建立使用者失敗: %v", err)
+	}
+	book := store.ForBook(models.DefaultBookID, models.DefaultUserID)
+	return book, NewLedgerService(store).ForBook(models.DefaultBookID, models.DefaultUserID)
+}
+
+// expectBalance 確認帳戶的快取餘額
+func expectBalance(t *testing.T, store repository.Store, accountID int, want models.Money) {
+	t.Helper()
+	a, err := store.Accounts().Get(accountID)
+	if err != nil {
+		t.Fatalf("查詢帳戶 %d 失敗: %v", accountID, err)
+	}
+	if a.Balance != want {
+		t.Errorf("帳戶 %d 餘額 = %s，預期 %s", accountID, a.Balance, want)
+	}
+}
+
+func TestPostRecordAdjustsBalance(t *testing.T) {
+	store, ledger := newTestLedger(t)
+
+	expense := &models.Record{Date: "2026-10-01", AccountID: testCash, Type: "支出", Amount: models.MoneyFromFloat(150), Item: "午餐", CategoryID: testFood}
+	if err := ledger.PostRecord(expense); err != nil {
+		t.Fatalf("PostRecord: %v", err)
+	}
+	if expense.ID == 0 {
+		t.Fatal("PostRecord 未寫回 ID")
+	}
+	income := &models.Record{Date: "2026-10-05", AccountID: testBank, Type: "收入", Amount: models.MoneyFromFloat(42000), Item: "薪水", CategoryID: testOthers}
+	if err := ledger.PostRecord(income); err != nil {
+		t.Fatalf("PostRecord: %v", err)
+	}
+
+	expectBalance(t, store, testCash, models.MoneyFromFloat(-150))
+	expectBalance(t, store, testBank, models.MoneyFromFloat(42000))
+}
+
+func TestPostRecordValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		record models.Record
+		want   error
+	}{
+		{"金額為 0", models.Record{AccountID: testCash, Type: "支出", CategoryID: testFood}, ErrInvalidAmount},
+		{"類型錯誤", models.Record{AccountID: testCash, Type: "借款", Amount: models.MoneyFromFloat(100), CategoryID: testFood}, ErrInvalidType},
+		{"帳戶不存在", models.Record{AccountID: 99, Type: "支出", Amount: models.MoneyFromFloat(100), CategoryID: testFood}, ErrAccountNotFound},
+		{"分類不存在", models.Record{AccountID: testCash, Type: "支出", Amount: models.MoneyFromFloat(100), CategoryID: 99}, ErrCategoryNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, ledger := newTestLedger(t)
+			r := tt.record
+			r.Date, r.Item = "2026-10-01", "測試"
+			if err := ledger.PostRecord(&r); err != tt.want {
+				t.Fatalf("PostRecord 錯誤 = %v，預期 %v", err, tt.want)
+			}
+			expectBalance(t, store, testCash, 0)
+		})
+	}
+}
+
+func TestAmendRecordMovesBalance(t *testing.T) {
+	store, ledger := newTestLedger(t)
+
+	r := &models.Record{Date: "2026-10-01", AccountID: testCash, Type: "支出", Amount: models.MoneyFromFloat(150), Item: "午餐", CategoryID: testFood}
+	if err := ledger.PostRecord(r); err != nil {
+		t.Fatalf("PostRecord: %v", err)
+	}
+
+	// 改為信用卡支付並修改金額：現金還原，信用卡扣款
+	r.AccountID, r.Amount = testCard, models.MoneyFromFloat(180)
+	if err := ledger.AmendRecord(r); err != nil {
+		t.Fatalf("AmendRecord: %v", err)
+	}
+	expectBalance(t, store, testCash, 0)
+	expectBalance(t, store, testCard, models.MoneyFromFloat(-180))
+
+	if err := ledger.VoidRecord(r.ID); err != nil {
+		t.Fatalf("VoidRecord: %v", err)
+	}
+	expectBalance(t, store, testCard, 0)
+	if err := ledger.VoidRecord(r.ID); err != ErrRecordNotFound {
+		t.Errorf("重複刪除的錯誤 = %v，預期 %v", err, ErrRecordNotFound)
+	}
+}
+
+func TestTransferLegsCannotBeChangedAlone(t *testing.T) {
+	store, ledger := newTestLedger(t)
+
+	tr := &models.Transfer{Date: "2026-10-01", FromAccountID: testBank, ToAccountID: testCash, Amount: models.MoneyFromFloat(1000)}
+	if err := ledger.PostTransfer(tr); err != nil {
+		t.Fatalf("PostTransfer: %v", err)
+	}
+	expectBalance(t, store, testBank, models.MoneyFromFloat(-1000))
+	expectBalance(t, store, testCash, models.MoneyFromFloat(1000))
+
+	legs, err := store.Records().ListByTransfer(tr.ID)
+	if err != nil || len(legs) != 2 {
+		t.Fatalf("轉帳紀錄 = %d 筆（%v），預期 2 筆", len(legs), err)
+	}
+	if err := ledger.VoidRecord(legs[0].ID); err != ErrTransferLeg {
+		t.Errorf("單獨刪除轉帳紀錄的錯誤 = %v，預期 %v", err, ErrTransferLeg)
+	}
+
+	if err := ledger.VoidTransfer(tr.ID); err != nil {
+		t.Fatalf("VoidTransfer: %v", err)
+	}
+	expectBalance(t, store, testBank, 0)
+	expectBalance(t, store, testCash, 0)
+}
+
+func TestDailyTotalsExcludeTransfers(t *testing.T) {
+	store, ledger := newTestLedger(t)
+
+	if err := ledger.PostRecord(&models.Record{Date: "2026-10-01", AccountID: testCash, Type: "支出", Amount: models.MoneyFromFloat(150), Item: "午餐", CategoryID: testFood}); err != nil {
+		t.Fatalf("PostRecord: %v", err)
+	}
+	if err := ledger.PostTransfer(&models.Transfer{Date: "2026-10-01", FromAccountID: testBank, ToAccountID: testCash, Amount: models.MoneyFromFloat(1000)}); err != nil {
+		t.Fatalf("PostTransfer: %v", err)
+	}
+
+	totals, err := store.Records().DailyTotals("2026-10")
+	if err != nil {
+		t.Fatalf("DailyTotals: %v", err)
+	}
+	want := []models.DailyTotal{{Date: "2026-10-01", Type: "支出", Currency: "TWD", Total: models.MoneyFromFloat(150)}}
+	if !reflect.DeepEqual(totals, want) {
+		t.Errorf("每日加總 = %+v，預期 %+v", totals, want)
+	}
+}
+
+func TestFailedTransferRollsBack(t *testing.T) {
+	store, ledger := newTestLedger(t)
+	if err := store.Accounts().SetCurrency(testBank, "USD"); err != nil {
+		t.Fatalf("SetCurrency: %v", err)
+	}
+
+	// 沒有 TWD → USD 的匯率：建立轉帳分類之後才失敗，整個 Transaction 應回滾
+	tr := &models.Transfer{Date: "2026-10-01", FromAccountID: testCash, ToAccountID: testBank, Amount: models.MoneyFromFloat(1000)}
+	if err := ledger.PostTransfer(tr); err == nil {
+		t.Fatal("缺少匯率的轉帳應失敗")
+	}
+	expectBalance(t, store, testCash, 0)
+	if _, err := store.Categories().FindByName(TransferCategoryName); err != repository.ErrNotFound {
+		t.Errorf("回滾後不應留下轉帳分類（錯誤 = %v）", err)
+	}
+}
+
+func TestLedgerIsScopedToBook(t *testing.T) {
+	store, _ := newTestLedger(t)
+
+	// 另一位使用者的帳本看不到預設帳本的帳戶
+	other := &models.User{Username: "bob"}
+	if err := NewUserService(store).CreateUser(other); err != nil {
+		t.Fatalf("建立使用者失敗: %v", err)
+	}
+	books, err := store.Books().ListByUser(other.ID)
+	if err != nil || len(books) != 1 {
+		t.Fatalf("bob 的帳本 = %v（%v），預期 1 本", books, err)
+	}
+	ledger := NewLedgerService(store).ForBook(books[0].ID, other.ID)
+
+	r := &models.Record{Date: "2026-10-01", AccountID: testCash, Type: "支出", Amount: models.MoneyFromFloat(100), Item: "午餐", CategoryID: testFood}
+	if err := ledger.PostRecord(r); err != ErrAccountNotFound {
+		t.Errorf("使用其他帳本的帳戶的錯誤 = %v，預期 %v", err, ErrAccountNotFound)
+	}
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"accountbook/models"
+	"accountbook/repository"
+	"log"
+)
+
+// Reconcile 依紀錄重新計算單一帳戶餘額，回報與快取餘額的差異
+func (l *LedgerService) Reconcile(accountID int) (*models.ReconcileReport, error) {
+	account, err := l.store.Accounts().Get(accountID)
+	if err != nil {
+		return nil, notFoundAs(err, ErrAccountNotFound)
+	}
+	return buildReport(l.store, account)
+}
+
+// ReconcileAll 對所有帳戶進行對帳
+// 原因：repair 為 true 時將不一致的餘額修正為重新計算的值，並在同一個 Transaction 中完成
+func (l *LedgerService) ReconcileAll(repair bool) ([]models.ReconcileReport, error) {
+	var reports []models.ReconcileReport
+	err := l.store.WithTx(func(tx repository.Store) error {
+		accounts, err := tx.Accounts().List()
+		if err != nil {
+			return err
+		}
+		for i := range accounts {
+			report, err := buildReport(tx, &accounts[i])
+			if err != nil {
+				return err
+			}
+			if repair && report.Discrepancy != 0 {
+				if err := tx.Accounts().SetBalance(report.AccountID, report.Expected); err != nil {
+					return err
+				}
+				report.Repaired = true
+			}
+			reports = append(reports, *report)
+		}
+		return nil
+	})
+	return reports, err
+}
+
+// SetAccountBalance 將帳戶餘額設為指定值
+// 原因：直接覆寫 balance 會與紀錄脫節，改為調整期初餘額讓重新計算的結果等於指定值
+func (l *LedgerService) SetAccountBalance(accountID int, balance models.Money) error {
+	return l.store.WithTx(func(tx repository.Store) error {
+		income, expense, err := tx.Records().AccountTotals(accountID)
+		if err != nil {
+			return err
+		}
+		if err := tx.Accounts().SetOpeningBalance(accountID, balance-income+expense); err != nil {
+			return notFoundAs(err, ErrAccountNotFound)
+		}
+		return tx.Accounts().SetBalance(accountID, balance)
+	})
+}
+
+// SetOpeningBalance 修改帳戶期初餘額，並依紀錄重新計算餘額
+func (l *LedgerService) SetOpeningBalance(accountID int, openingBalance models.Money) error {
+	return l.store.WithTx(func(tx repository.Store) error {
+		income, expense, err := tx.Records().AccountTotals(accountID)
+		if err != nil {
+			return err
+		}
+		if err := tx.Accounts().SetOpeningBalance(accountID, openingBalance); err != nil {
+			return notFoundAs(err, ErrAccountNotFound)
+		}
+		return tx.Accounts().SetBalance(accountID, openingBalance+income-expense)
+	})
+}
+
+// LogBalanceDiscrepancies 檢查所有帳戶餘額並記錄不一致的帳戶（不修正）
+// 原因：啟動時可選擇性執行，及早發現餘額漂移
+func (l *LedgerService) LogBalanceDiscrepancies() {
+	reports, err := l.ReconcileAll(false)
+	if err != nil {
+		log.Printf("帳戶對帳失敗: %v", err)
+		return
+	}
+
+	mismatches := 0
+	for _, r := range reports {
+		if r.Discrepancy != 0 {
+			mismatches++
+			log.Printf("帳戶餘額不一致：%s（ID %d）目前 %s，依紀錄應為 %s，差異 %s",
+				r.AccountName, r.AccountID, r.Actual, r.Expected, r.Discrepancy)
+		}
+	}
+	if mismatches == 0 {
+		log.Printf("帳戶對帳完成，%d 個帳戶餘額皆一致", len(reports))
+	}
+}
+
+// buildReport 計算單一帳戶的對帳結果
+func buildReport(store repository.Store, account *models.Account) (*models.ReconcileReport, error) {
+	income, expense, err := store.Records().AccountTotals(account.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 金額為整數，不需容許誤差
+	expected := account.OpeningBalance + income - expense
+	discrepancy := account.Balance - expected
+
+	return &models.ReconcileReport{
+		AccountID:      account.ID,
+		AccountName:    account.Name,
+		OpeningBalance: account.OpeningBalance,
+		TotalIncome:    income,
+		TotalExpense:   expense,
+		Expected:       expected,
+		Actual:         account.Balance,
+		Discrepancy:    discrepancy,
+	}, nil
+}
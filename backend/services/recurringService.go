@@ -0,0 +1,335 @@
+package services
+
+import (
+	"accountbook/models"
+	"accountbook/repository"
+	"errors"
+	"log"
+	"strings"
+	"time"
+)
+
+// 定期紀錄服務的錯誤
+var (
+	ErrRuleNotFound     = errors.New("找不到該定期紀錄")
+	ErrInvalidFrequency = errors.New("頻率必須為 daily、weekly、monthly 或 yearly")
+	ErrEndBeforeStart   = errors.New("結束日期不能早於開始日期")
+	ErrRuleFinished     = errors.New("此定期紀錄已結束")
+)
+
+// errRuleChanged 排程執行期間規則已被修改，停止處理此規則
+var errRuleChanged = errors.New("定期紀錄已被修改")
+
+// recurringCheckInterval 排程檢查到期規則的間隔
+const recurringCheckInterval = time.Hour
+
+// recurringCatchUpLimit 單一規則一次最多補上的次數
+// 原因：每天一次的規則停機很久後，避免一次寫入過多紀錄；剩下的於下次檢查時繼續補上
+const recurringCatchUpLimit = 100
+
+// Recurring 全域定期紀錄服務
+var Recurring *RecurringService
+
+// RecurringService 定期紀錄規則的設定與排程
+type RecurringService struct {
+	store repository.Store
+	loc   *time.Location // 判斷「今天」的時區
+}
+
+// NewRecurringService 建立定期紀錄服務，loc 為判斷規則是否到期的時區（與 Bot 的 BOT_TIMEZONE 相同）
+// 原因：伺服器時區與使用者不同時，午夜前後會提早或延後一天新增紀錄
+func NewRecurringService(store repository.Store, loc *time.Location) *RecurringService {
+	return &RecurringService{store: store, loc: loc}
+}
+
+// ForBook 回傳只能存取指定帳本規則的服務，userID 為操作者（新增的規則記錄為此使用者建立）
+func (s *RecurringService) ForBook(bookID, userID int) *RecurringService {
+	return &RecurringService{store: s.store.ForBook(bookID, userID), loc: s.loc}
+}
+
+// today 設定時區的今天（2006-01-02）
+func (s *RecurringService) today() string {
+	return time.Now().In(s.loc).Format("2006-01-02")
+}
+
+// List 依下次日期列出所有規則
+func (s *RecurringService) List() ([]models.RecurringRule, error) {
+	return s.store.Recurring().List()
+}
+
+// Get 取得規則
+func (s *RecurringService) Get(id int) (*models.RecurringRule, error) {
+	r, err := s.store.Recurring().Get(id)
+	return r, notFoundAs(err, ErrRuleNotFound)
+}
+
+// Create 新增規則，第一次的日期為開始日期
+// 注意：開始日期早於今天時，立即補上之前每一期的紀錄
+func (s *RecurringService) Create(in models.RecurringRuleInput) (*models.RecurringRule, error) {
+	r := &models.RecurringRule{}
+	if err := s.apply(r, in); err != nil {
+		return nil, err
+	}
+	r.NextDate = r.StartDate
+
+	if err := s.store.Recurring().Create(r); err != nil {
+		return nil, err
+	}
+	s.catchUp(r)
+	return s.Get(r.ID)
+}
+
+// Update 更新規則，下次日期改為新排程中不早於原本下次日期的第一次
+// 原因：已新增過紀錄的期數不會因修改而重複新增
+func (s *RecurringService) Update(id int, in models.RecurringRuleInput) (*models.RecurringRule, error) {
+	r, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	from := r.NextDate
+	if err := s.apply(r, in); err != nil {
+		return nil, err
+	}
+	if from < r.StartDate {
+		from = r.StartDate
+	}
+	r.Occurrences = firstOccurrenceFrom(r, from)
+	r.NextDate = occurrenceDate(r, r.Occurrences)
+
+	if err := s.store.Recurring().Update(r); err != nil {
+		return nil, notFoundAs(err, ErrRuleNotFound)
+	}
+	s.catchUp(r)
+	return s.Get(id)
+}
+
+// Delete 刪除規則（已新增的紀錄保留）
+func (s *RecurringService) Delete(id int) error {
+	return notFoundAs(s.store.Recurring().Delete(id), ErrRuleNotFound)
+}
+
+// SetPaused 暫停或恢復規則
+// 原因：恢復時略過暫停期間的期數，從今天（含）之後的第一次開始，避免一次補上暫停期間的紀錄
+func (s *RecurringService) SetPaused(id int, paused bool) (*models.RecurringRule, error) {
+	r, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if r.Paused == paused {
+		return r, nil
+	}
+	r.Paused = paused
+	if !paused {
+		if today := s.today(); r.NextDate < today {
+			r.Occurrences = firstOccurrenceFrom(r, today)
+			r.NextDate = occurrenceDate(r, r.Occurrences)
+		}
+	}
+	if err := s.store.Recurring().Update(r); err != nil {
+		return nil, notFoundAs(err, ErrRuleNotFound)
+	}
+	if !paused {
+		s.catchUp(r)
+	}
+	return s.Get(id)
+}
+
+// Skip 略過下一次（不新增紀錄），下次日期改為再下一次
+func (s *RecurringService) Skip(id int) (*models.RecurringRule, error) {
+	r, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if r.Finished() {
+		return nil, ErrRuleFinished
+	}
+	advance(r)
+	if err := s.store.Recurring().Update(r); err != nil {
+		return nil, notFoundAs(err, ErrRuleNotFound)
+	}
+	return s.Get(id)
+}
+
+// apply 檢查輸入並寫入規則欄位（不含下次日期與次數）
+func (s *RecurringService) apply(r *models.RecurringRule, in models.RecurringRuleInput) error {
+	r.Item = strings.TrimSpace(in.Item)
+	r.AccountID, r.CategoryID = in.AccountID, in.CategoryID
+	r.Type, r.Amount, r.Note = in.Type, in.Amount, in.Note
+	r.Frequency = strings.ToLower(strings.TrimSpace(in.Frequency))
+	r.Interval = in.Interval
+	r.StartDate, r.EndDate = in.StartDate, in.EndDate
+
+	if r.Type == "" {
+		r.Type = "支出"
+	}
+	if r.Interval <= 0 {
+		r.Interval = 1
+	}
+	if r.StartDate == "" {
+		r.StartDate = s.today()
+	}
+
+	if err := validateRecord(&models.Record{Type: r.Type, Amount: r.Amount}); err != nil {
+		return err
+	}
+	switch r.Frequency {
+	case models.FrequencyDaily, models.FrequencyWeekly, models.FrequencyMonthly, models.FrequencyYearly:
+	default:
+		return ErrInvalidFrequency
+	}
+	if _, err := time.Parse("2006-01-02", r.StartDate); err != nil {
+		return ErrInvalidDate
+	}
+	if r.EndDate != "" {
+		if _, err := time.Parse("2006-01-02", r.EndDate); err != nil {
+			return ErrInvalidDate
+		}
+		if r.EndDate < r.StartDate {
+			return ErrEndBeforeStart
+		}
+	}
+
+	// 與新增紀錄相同的檢查：帳戶、分類存在且金額符合帳戶幣別
+	return checkReferences(s.store, &models.Record{AccountID: r.AccountID, CategoryID: r.CategoryID, Amount: r.Amount})
+}
+
+// RunScheduler 定期新增到期的紀錄，啟動時立即檢查一次以補上停機期間錯過的期數
+func (s *RecurringService) RunScheduler() {
+	for {
+		if n, err := s.RunDue(s.today()); err != nil {
+			log.Printf("定期紀錄排程失敗: %v", err)
+		} else if n > 0 {
+			log.Printf("定期紀錄：已新增 %d 筆紀錄", n)
+		}
+		time.Sleep(recurringCheckInterval)
+	}
+}
+
+// RunDue 為所有到期的規則新增紀錄（每期一筆，日期為該期的日期），回傳新增的筆數
+// 原因：紀錄與規則的下次日期在同一個 Transaction 中更新，重啟或重複執行都不會重複新增
+// 單一規則失敗（例如建立者已不能編輯帳本）只記錄後略過，不影響其他規則
+func (s *RecurringService) RunDue(today string) (int, error) {
+	rules, err := s.store.Recurring().Due(today)
+	if err != nil {
+		return 0, err
+	}
+
+	posted := 0
+	for _, rule := range rules {
+		n, err := s.runRule(rule, today)
+		posted += n
+		if err != nil {
+			log.Printf("定期紀錄 %d（%s）新增失敗: %v", rule.ID, rule.Item, err)
+		}
+	}
+	return posted, nil
+}
+
+// catchUp 新增、修改或恢復規則後立即補上已到期的期數，不等下次排程
+// 原因：失敗時只記錄，規則已儲存，之後的排程會再嘗試
+func (s *RecurringService) catchUp(r *models.RecurringRule) {
+	if r.Paused {
+		return
+	}
+	if _, err := s.runRule(*r, s.today()); err != nil {
+		log.Printf("定期紀錄 %d（%s）新增失敗: %v", r.ID, r.Item, err)
+	}
+}
+
+// runRule 依序新增規則在 today（含）之前的每一期
+func (s *RecurringService) runRule(rule models.RecurringRule, today string) (int, error) {
+	role, err := s.store.Books().Role(rule.BookID, rule.UserID)
+	if err != nil && err != repository.ErrNotFound {
+		return 0, err
+	}
+	if !models.CanEdit(role) {
+		return 0, errors.New("建立者已不能編輯此帳本")
+	}
+
+	store := s.store.ForBook(rule.BookID, rule.UserID)
+	posted := 0
+	for rule.NextDate <= today && !rule.Finished() && posted < recurringCatchUpLimit {
+		err := store.WithTx(func(tx repository.Store) error {
+			// 規則可能在排程執行期間被暫停、略過或修改，以 Transaction 內的最新狀態為準
+			current, err := tx.Recurring().Get(rule.ID)
+			if err != nil {
+				return err
+			}
+			if current.Paused || current.NextDate != rule.NextDate {
+				return errRuleChanged
+			}
+			rule = *current
+
+			record := &models.Record{
+				Date:       rule.NextDate,
+				AccountID:  rule.AccountID,
+				Type:       rule.Type,
+				Amount:     rule.Amount,
+				Item:       rule.Item,
+				CategoryID: rule.CategoryID,
+				Note:       rule.Note,
+			}
+			if err := validateRecord(record); err != nil {
+				return err
+			}
+			if err := postRecord(tx, record); err != nil {
+				return err
+			}
+			next := rule
+			advance(&next)
+			return tx.Recurring().Update(&next)
+		})
+		if err == errRuleChanged || err == repository.ErrNotFound {
+			return posted, nil
+		}
+		if err != nil {
+			return posted, err
+		}
+		advance(&rule)
+		posted++
+	}
+	return posted, nil
+}
+
+// advance 將規則推進到下一期
+func advance(r *models.RecurringRule) {
+	r.Occurrences++
+	r.NextDate = occurrenceDate(r, r.Occurrences)
+}
+
+// firstOccurrenceFrom 規則在 date（含）之後第一次的次數
+func firstOccurrenceFrom(r *models.RecurringRule, date string) int {
+	n := 0
+	for occurrenceDate(r, n) < date {
+		n++
+	}
+	return n
+}
+
+// occurrenceDate 規則第 n 次（由 0 起算）的日期
+// 原因：每次都由開始日期推算，月底日期在短月份改為該月最後一天後，下個月仍回到原本的日期
+func occurrenceDate(r *models.RecurringRule, n int) string {
+	start, err := time.Parse("2006-01-02", r.StartDate)
+	if err != nil {
+		return "9999-12-31"
+	}
+	steps := n * r.Interval
+
+	switch r.Frequency {
+	case models.FrequencyDaily:
+		return start.AddDate(0, 0, steps).Format("2006-01-02")
+	case models.FrequencyWeekly:
+		return start.AddDate(0, 0, 7*steps).Format("2006-01-02")
+	case models.FrequencyYearly:
+		steps *= 12
+	}
+
+	// 以該月 1 號推算月份，再取原本的日期與該月天數較小者
+	first := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, steps, 0)
+	lastDay := first.AddDate(0, 1, -1).Day()
+	day := start.Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return first.AddDate(0, 0, day-1).Format("2006-01-02")
+}
@@ -0,0 +1,42 @@
+package services
+
+import (
+	"accountbook/models"
+	"testing"
+	"time"
+)
+
+func TestRecurringUsesConfiguredTimezone(t *testing.T) {
+	// 兩個時區相差 25 小時，任何時刻的「今天」都不同，也至少有一個與伺服器時區不同
+	zones := []*time.Location{
+		time.FixedZone("UTC+14", 14*60*60),
+		time.FixedZone("UTC-11", -11*60*60),
+	}
+	for _, loc := range zones {
+		t.Run(loc.String(), func(t *testing.T) {
+			store, _ := newTestLedger(t)
+			recurring := NewRecurringService(store, loc)
+
+			today := time.Now().In(loc)
+			rule, err := recurring.Create(models.RecurringRuleInput{
+				Item: "早餐", AccountID: testCash, CategoryID: testFood,
+				Amount: models.MoneyFromFloat(60), Frequency: models.FrequencyDaily,
+			})
+			if err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+
+			// 開始日期省略時為該時區的今天，且今天這一期立即新增
+			if want := today.Format("2006-01-02"); rule.StartDate != want {
+				t.Errorf("開始日期 = %s，預期 %s", rule.StartDate, want)
+			}
+			if want := today.AddDate(0, 0, 1).Format("2006-01-02"); rule.NextDate != want {
+				t.Errorf("下次日期 = %s，預期 %s", rule.NextDate, want)
+			}
+			records, err := store.Records().ListByDate(rule.StartDate)
+			if err != nil || len(records) != 1 {
+				t.Errorf("%s 的紀錄 = %d 筆（%v），預期 1 筆", rule.StartDate, len(records), err)
+			}
+		})
+	}
+}
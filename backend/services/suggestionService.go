@@ -0,0 +1,113 @@
+package services
+
+import (
+	"accountbook/models"
+	"accountbook/repository"
+	"math"
+	"strings"
+	"time"
+)
+
+// suggestionHistory 最多參考最近幾筆同名紀錄
+const suggestionHistory = 200
+
+// suggestionHalfLife 紀錄的權重每經過此時間減半
+// 原因：習慣會改變（例如換了常用的信用卡），較新的紀錄應比舊紀錄更有份量
+const suggestionHalfLife = 90 * 24 * time.Hour
+
+// Suggestions 全域分類與帳戶建議服務
+var Suggestions *SuggestionService
+
+// SuggestionService 從過去的紀錄學習「項目 → 分類」與「項目 → 帳戶」的對應
+type SuggestionService struct {
+	store repository.Store
+}
+
+// NewSuggestionService 建立建議服務
+func NewSuggestionService(store repository.Store) *SuggestionService {
+	return &SuggestionService{store: store}
+}
+
+// ForBook 回傳只參考指定帳本紀錄的建議服務
+func (s *SuggestionService) ForBook(bookID, userID int) *SuggestionService {
+	return &SuggestionService{store: s.store.ForBook(bookID, userID)}
+}
+
+// Suggest 依同名紀錄的出現次數與新舊程度，推測項目的分類與帳戶
+// 每筆紀錄的權重為 0.5^(距今天數 / 半衰期)，加總後取權重最高者；同分時取最近使用的
+func (s *SuggestionService) Suggest(item string) (*models.Suggestion, error) {
+	item = strings.TrimSpace(item)
+	suggestion := &models.Suggestion{Item: item}
+	if item == "" {
+		return suggestion, nil
+	}
+
+	records, err := s.store.Records().ListByItem(item, suggestionHistory)
+	if err != nil {
+		return nil, err
+	}
+	suggestion.Samples = len(records)
+	if len(records) == 0 {
+		return suggestion, nil
+	}
+
+	categories := newWeightedChoice()
+	accounts := newWeightedChoice()
+	now := time.Now()
+	for _, r := range records {
+		w := recencyWeight(r.Date, now)
+		categories.add(r.CategoryID, r.CategoryName, w)
+		accounts.add(r.AccountID, r.AccountName, w)
+	}
+
+	suggestion.CategoryID, suggestion.CategoryName, suggestion.CategoryConfidence = categories.best()
+	suggestion.AccountID, suggestion.AccountName, suggestion.AccountConfidence = accounts.best()
+	return suggestion, nil
+}
+
+// recencyWeight 紀錄日期的權重，未來日期或無法解析時視為今天
+func recencyWeight(date string, now time.Time) float64 {
+	if len(date) > 10 {
+		date = date[:10]
+	}
+	t, err := time.ParseInLocation("2006-01-02", date, now.Location())
+	if err != nil || t.After(now) {
+		return 1
+	}
+	return math.Pow(0.5, float64(now.Sub(t))/float64(suggestionHalfLife))
+}
+
+// weightedChoice 依加權總和選出最常用的 ID（依加入順序保留，同分時先加入者勝出）
+type weightedChoice struct {
+	order  []int
+	names  map[int]string
+	weight map[int]float64
+	total  float64
+}
+
+func newWeightedChoice() *weightedChoice {
+	return &weightedChoice{names: make(map[int]string), weight: make(map[int]float64)}
+}
+
+func (c *weightedChoice) add(id int, name string, w float64) {
+	if _, ok := c.weight[id]; !ok {
+		c.order = append(c.order, id)
+		c.names[id] = name
+	}
+	c.weight[id] += w
+	c.total += w
+}
+
+// best 回傳權重最高的 ID、名稱與佔總權重的比例
+func (c *weightedChoice) best() (int, string, float64) {
+	bestID := 0
+	for _, id := range c.order {
+		if bestID == 0 || c.weight[id] > c.weight[bestID] {
+			bestID = id
+		}
+	}
+	if bestID == 0 || c.total == 0 {
+		return 0, "", 0
+	}
+	return bestID, c.names[bestID], math.Round(c.weight[bestID]/c.total*100) / 100
+}
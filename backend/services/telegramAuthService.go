@@ -0,0 +1,120 @@
+package services
+
+import (
+	"accountbook/models"
+	"accountbook/repository"
+	"crypto/rand"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Telegram 聊天室授權相關錯誤
+var ErrInvalidPairCode = errors.New("配對碼錯誤或已過期")
+
+// pairCodeAlphabet 配對碼使用的字元（去除易混淆的 0/O、1/I，共 32 個，取亂數餘數時不會偏差）
+const pairCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// pairCodeLength 配對碼長度，32^8 種組合，有效期間內無法以猜測配對
+const pairCodeLength = 8
+
+// PairCodeTTL 配對碼有效時間
+const PairCodeTTL = 10 * time.Minute
+
+// TelegramAuth 全域 Telegram 聊天室授權服務
+var TelegramAuth *TelegramAuthService
+
+// TelegramAuthService 決定哪些聊天室可以使用 Bot
+// 原因：Bot 對任何人公開，只有已綁定使用者（以 /pair 或 API 綁定）或列在允許清單中的聊天室才能讀寫帳本
+type TelegramAuthService struct {
+	store   repository.Store
+	allowed map[int64]bool
+
+	mu    sync.Mutex
+	codes map[string]pairCode
+}
+
+// pairCode 尚未使用的配對碼
+type pairCode struct {
+	userID    int
+	expiresAt time.Time
+}
+
+// NewTelegramAuthService 建立聊天室授權服務，allowed 為不需綁定即可使用的聊天室（記到預設使用者）
+func NewTelegramAuthService(store repository.Store, allowed []int64) *TelegramAuthService {
+	s := &TelegramAuthService{
+		store:   store,
+		allowed: make(map[int64]bool),
+		codes:   make(map[string]pairCode),
+	}
+	for _, id := range allowed {
+		s.allowed[id] = true
+	}
+	return s
+}
+
+// Authorized 聊天室是否可以使用 Bot
+func (s *TelegramAuthService) Authorized(chatID int64) (bool, error) {
+	if s.allowed[chatID] {
+		return true, nil
+	}
+	_, err := s.store.TelegramChats().Get(chatID)
+	if err == repository.ErrNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// CreatePairCode 為使用者產生一次性的配對碼，在聊天室輸入 /pair <配對碼> 即可綁定
+// 原因：使用者不需查詢 chat ID，只要在已登入的網頁取得配對碼；同一使用者重新產生時舊的配對碼失效
+func (s *TelegramAuthService) CreatePairCode(userID int) (string, time.Time, error) {
+	buf := make([]byte, pairCodeLength)
+	if _, err := rand.Read(buf); err != nil {
+		return "", time.Time{}, err
+	}
+	code := make([]byte, pairCodeLength)
+	for i, b := range buf {
+		code[i] = pairCodeAlphabet[int(b)%len(pairCodeAlphabet)]
+	}
+
+	expiresAt := time.Now().Add(PairCodeTTL)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for c, p := range s.codes {
+		if p.userID == userID || time.Now().After(p.expiresAt) {
+			delete(s.codes, c)
+		}
+	}
+	s.codes[string(code)] = pairCode{userID: userID, expiresAt: expiresAt}
+	return string(code), expiresAt, nil
+}
+
+// Pair 以配對碼將聊天室綁定到產生配對碼的使用者，配對碼使用後即失效
+func (s *TelegramAuthService) Pair(chatID int64, code string) (*models.User, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+
+	s.mu.Lock()
+	p, ok := s.codes[code]
+	if ok {
+		delete(s.codes, code)
+	}
+	s.mu.Unlock()
+	if !ok || time.Now().After(p.expiresAt) {
+		return nil, ErrInvalidPairCode
+	}
+
+	var user *models.User
+	err := s.store.WithTx(func(tx repository.Store) error {
+		u, err := tx.Users().Get(p.userID)
+		if err != nil {
+			return notFoundAs(err, ErrUserNotFound)
+		}
+		user = u
+		return linkChat(tx, chatID, u.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
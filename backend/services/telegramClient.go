@@ -0,0 +1,289 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultTelegramBaseURL 官方 Bot API 位址
+const DefaultTelegramBaseURL = "https://api.telegram.org"
+
+// TelegramAPI Bot 使用到的 Telegram Bot API
+// 原因：正式環境使用 TelegramClient，離線測試時可改為指向假伺服器（services/telegramtest）或其他實作
+type TelegramAPI interface {
+	// SendMessage 發送訊息並回傳訊息 ID，keyboard 為 nil 時不附按鈕
+	SendMessage(chatID int64, text string, keyboard *InlineKeyboardMarkup) (int, error)
+	// EditMessage 編輯已發送的訊息，keyboard 為 nil 時移除按鈕
+	EditMessage(chatID int64, messageID int, text string, keyboard *InlineKeyboardMarkup) error
+	// SendPhoto 上傳 PNG 圖片並回傳訊息 ID，caption 為圖片說明，keyboard 為 nil 時不附按鈕
+	SendPhoto(chatID int64, photo []byte, caption string, keyboard *InlineKeyboardMarkup) (int, error)
+	DeleteMessage(chatID int64, messageID int) error
+	AnswerCallback(callbackQueryID, text string) error
+	SetWebhook(webhookURL, secret string) error
+	DeleteWebhook() error
+	// GetUpdates 以 long polling 取得 offset 之後的 update，最多等待 timeout 秒
+	GetUpdates(offset, timeout int) ([]json.RawMessage, error)
+}
+
+// Telegram 全域 Telegram API 實例
+var Telegram TelegramAPI
+
+// TelegramConfig Telegram API client 設定
+type TelegramConfig struct {
+	Token   string
+	BaseURL string        // 預設為官方位址
+	Timeout time.Duration // 單次請求逾時（getUpdates 另加等待時間），預設 10 秒
+	// MaxRetries 收到 429 時依 retry_after 重試的次數，0 為預設 3 次，負數代表不重試
+	MaxRetries int
+	// MaxRetryWait retry_after 超過此時間時不等待，直接回傳錯誤，預設 30 秒
+	// 原因：Bot 在處理使用者訊息時呼叫 API，不宜讓請求卡住太久
+	MaxRetryWait time.Duration
+}
+
+// TelegramError Telegram API 回傳的錯誤（HTTP 狀態非 200 或 ok 為 false）
+type TelegramError struct {
+	Method      string
+	StatusCode  int
+	ErrorCode   int
+	Description string
+	RetryAfter  int // 429 時需等待的秒數
+}
+
+func (e *TelegramError) Error() string {
+	return fmt.Sprintf("Telegram %s 失敗（%d）：%s", e.Method, e.ErrorCode, e.Description)
+}
+
+// IsTelegramError 判斷錯誤是否為指定錯誤碼的 Telegram API 錯誤
+func IsTelegramError(err error, code int) bool {
+	var tgErr *TelegramError
+	return errors.As(err, &tgErr) && tgErr.ErrorCode == code
+}
+
+// IsMessageNotModified 編輯訊息時內容與原本相同（Telegram 視為錯誤，但對 Bot 而言可以忽略）
+func IsMessageNotModified(err error) bool {
+	var tgErr *TelegramError
+	return errors.As(err, &tgErr) && strings.Contains(tgErr.Description, "message is not modified")
+}
+
+// TelegramClient 以 HTTP 呼叫 Telegram Bot API
+type TelegramClient struct {
+	config TelegramConfig
+	http   *http.Client
+}
+
+// NewTelegramClient 建立 Telegram API client，未設定的欄位使用預設值
+func NewTelegramClient(config TelegramConfig) *TelegramClient {
+	if config.BaseURL == "" {
+		config.BaseURL = DefaultTelegramBaseURL
+	}
+	config.BaseURL = strings.TrimRight(config.BaseURL, "/")
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+	if config.MaxRetries < 0 {
+		config.MaxRetries = 0
+	} else if config.MaxRetries == 0 {
+		config.MaxRetries = 3
+	}
+	if config.MaxRetryWait <= 0 {
+		config.MaxRetryWait = 30 * time.Second
+	}
+	// 逾時以每次請求的 context 控制，getUpdates 需要比一般請求更長的時間
+	return &TelegramClient{config: config, http: &http.Client{}}
+}
+
+// telegramResponse Bot API 的回應格式
+type telegramResponse struct {
+	OK          bool            `json:"ok"`
+	ErrorCode   int             `json:"error_code"`
+	Description string          `json:"description"`
+	Result      json.RawMessage `json:"result"`
+	Parameters  *struct {
+		RetryAfter int `json:"retry_after"`
+	} `json:"parameters"`
+}
+
+// sentMessage sendMessage 回傳的訊息（只需要 ID）
+type sentMessage struct {
+	MessageID int `json:"message_id"`
+}
+
+func (c *TelegramClient) SendMessage(chatID int64, text string, keyboard *InlineKeyboardMarkup) (int, error) {
+	params := map[string]interface{}{
+		"chat_id": chatID,
+		"text":    text,
+	}
+	if keyboard != nil {
+		params["reply_markup"] = keyboard
+	}
+
+	var msg sentMessage
+	if err := c.call("sendMessage", params, 0, &msg); err != nil {
+		return 0, err
+	}
+	return msg.MessageID, nil
+}
+
+func (c *TelegramClient) EditMessage(chatID int64, messageID int, text string, keyboard *InlineKeyboardMarkup) error {
+	params := map[string]interface{}{
+		"chat_id":    chatID,
+		"message_id": messageID,
+		"text":       text,
+	}
+	if keyboard != nil {
+		params["reply_markup"] = keyboard
+	}
+	return c.call("editMessageText", params, 0, nil)
+}
+
+// SendPhoto 以 multipart/form-data 上傳圖片（sendPhoto 不接受 JSON 內的檔案內容）
+func (c *TelegramClient) SendPhoto(chatID int64, photo []byte, caption string, keyboard *InlineKeyboardMarkup) (int, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	form.WriteField("chat_id", strconv.FormatInt(chatID, 10))
+	if caption != "" {
+		form.WriteField("caption", caption)
+	}
+	if keyboard != nil {
+		markup, err := json.Marshal(keyboard)
+		if err != nil {
+			return 0, err
+		}
+		form.WriteField("reply_markup", string(markup))
+	}
+	part, err := form.CreateFormFile("photo", "chart.png")
+	if err != nil {
+		return 0, err
+	}
+	part.Write(photo)
+	if err := form.Close(); err != nil {
+		return 0, err
+	}
+
+	var msg sentMessage
+	if err := c.send("sendPhoto", body.Bytes(), form.FormDataContentType(), 0, &msg); err != nil {
+		return 0, err
+	}
+	return msg.MessageID, nil
+}
+
+func (c *TelegramClient) DeleteMessage(chatID int64, messageID int) error {
+	return c.call("deleteMessage", map[string]interface{}{
+		"chat_id":    chatID,
+		"message_id": messageID,
+	}, 0, nil)
+}
+
+func (c *TelegramClient) AnswerCallback(callbackQueryID, text string) error {
+	return c.call("answerCallbackQuery", map[string]interface{}{
+		"callback_query_id": callbackQueryID,
+		"text":              text,
+	}, 0, nil)
+}
+
+func (c *TelegramClient) SetWebhook(webhookURL, secret string) error {
+	return c.call("setWebhook", map[string]interface{}{
+		"url":          webhookURL,
+		"secret_token": secret,
+	}, 0, nil)
+}
+
+// DeleteWebhook 保留未處理的訊息，由 getUpdates 接續取得
+func (c *TelegramClient) DeleteWebhook() error {
+	return c.call("deleteWebhook", map[string]interface{}{
+		"drop_pending_updates": false,
+	}, 0, nil)
+}
+
+func (c *TelegramClient) GetUpdates(offset, timeout int) ([]json.RawMessage, error) {
+	var updates []json.RawMessage
+	err := c.call("getUpdates", map[string]interface{}{
+		"offset":          offset,
+		"timeout":         timeout,
+		"allowed_updates": []string{"message", "callback_query"},
+	}, time.Duration(timeout)*time.Second, &updates)
+	return updates, err
+}
+
+// call 以 JSON 呼叫 API 並將 result 解析到 result（可為 nil），收到 429 時依 retry_after 等待後重試
+// wait 為伺服器端可能等待的時間（getUpdates 的 timeout），加在請求逾時上
+func (c *TelegramClient) call(method string, params interface{}, wait time.Duration, result interface{}) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	return c.send(method, body, "application/json", wait, result)
+}
+
+// send 送出已編碼的請求內容，收到 429 時依 retry_after 等待後重試
+func (c *TelegramClient) send(method string, body []byte, contentType string, wait time.Duration, result interface{}) error {
+	for attempt := 0; ; attempt++ {
+		err := c.do(method, body, contentType, wait, result)
+		var tgErr *TelegramError
+		if !errors.As(err, &tgErr) || tgErr.RetryAfter <= 0 || attempt >= c.config.MaxRetries {
+			return err
+		}
+		delay := time.Duration(tgErr.RetryAfter) * time.Second
+		if delay > c.config.MaxRetryWait {
+			return err
+		}
+		time.Sleep(delay)
+	}
+}
+
+func (c *TelegramClient) do(method string, body []byte, contentType string, wait time.Duration, result interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.Timeout+wait)
+	defer cancel()
+
+	endpoint := fmt.Sprintf("%s/bot%s/%s", c.config.BaseURL, c.config.Token, method)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		// url.Error 的訊息包含完整網址（含 Bot Token），不可直接寫入 log
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("Telegram %s 失敗: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	var parsed telegramResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return &TelegramError{Method: method, StatusCode: resp.StatusCode, ErrorCode: resp.StatusCode, Description: "無法解析回應"}
+	}
+	if resp.StatusCode != http.StatusOK || !parsed.OK {
+		tgErr := &TelegramError{
+			Method:      method,
+			StatusCode:  resp.StatusCode,
+			ErrorCode:   parsed.ErrorCode,
+			Description: parsed.Description,
+		}
+		if tgErr.ErrorCode == 0 {
+			tgErr.ErrorCode = resp.StatusCode
+		}
+		if parsed.Parameters != nil {
+			tgErr.RetryAfter = parsed.Parameters.RetryAfter
+		}
+		return tgErr
+	}
+
+	if result != nil && len(parsed.Result) > 0 {
+		return json.Unmarshal(parsed.Result, result)
+	}
+	return nil
+}
@@ -1,15 +1,13 @@
 package services
 
 import (
-	"bytes"
 	"encoding/json"
-	"fmt"
 	"log"
-	"net/http"
 )
 
-// TelegramToken 全域 Bot Token
-var TelegramToken string
+// WebhookSecret 向 Telegram 註冊的 webhook 密鑰
+// 原因：Telegram 推播時會帶 X-Telegram-Bot-Api-Secret-Token 標頭，用來辨別偽造的請求；未註冊 webhook 時為空字串
+var WebhookSecret string
 
 // InlineKeyboardButton Inline Keyboard 按鈕結構
 type InlineKeyboardButton struct {
@@ -23,167 +21,84 @@ type InlineKeyboardMarkup struct {
 }
 
 // SetupWebhook 設定 Telegram Bot Webhook
-// 原因：程式啟動時向 Telegram 註冊 webhook URL，讓訊息能推送到本服務
-func SetupWebhook(token, webhookURL string) {
-	TelegramToken = token
+// 原因：程式啟動時向 Telegram 註冊 webhook URL 與密鑰，讓訊息能推送到本服務
+func SetupWebhook(webhookURL, secret string) {
+	WebhookSecret = secret
 
-	url := fmt.Sprintf("https://api.telegram.org/bot%s/setWebhook", token)
-	body, _ := json.Marshal(map[string]string{
-		"url": webhookURL,
-	})
-
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(body))
-	if err != nil {
+	if err := Telegram.SetWebhook(webhookURL, secret); err != nil {
 		log.Printf("設定 Webhook 失敗: %v", err)
 		return
 	}
-	defer resp.Body.Close()
+	log.Printf("Telegram Webhook 設定成功: %s", webhookURL)
+}
 
-	if resp.StatusCode == 200 {
-		log.Printf("Telegram Webhook 設定成功: %s", webhookURL)
-	} else {
-		log.Printf("Telegram Webhook 設定失敗，狀態碼: %d", resp.StatusCode)
-	}
+// DeleteWebhook 取消 Telegram Bot Webhook，改以 long polling 接收訊息
+// 原因：已註冊 webhook 時 getUpdates 會回傳 409
+func DeleteWebhook() error {
+	return Telegram.DeleteWebhook()
+}
+
+// GetUpdates 以 long polling 取得 offset 之後的 update，最多等待 timeout 秒
+// 原因：update 的內容由 bot 套件解析，這裡只回傳原始 JSON
+func GetUpdates(offset int, timeout int) ([]json.RawMessage, error) {
+	return Telegram.GetUpdates(offset, timeout)
 }
 
 // SendMessage 發送純文字訊息
 func SendMessage(chatID int64, text string) error {
-	url := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", TelegramToken)
-	body, _ := json.Marshal(map[string]interface{}{
-		"chat_id": chatID,
-		"text":    text,
-	})
-
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(body))
-	if err != nil {
-		return fmt.Errorf("發送訊息失敗: %v", err)
-	}
-	defer resp.Body.Close()
-
-	return nil
+	_, err := Telegram.SendMessage(chatID, text, nil)
+	return err
 }
 
 // SendMessageReturningID 發送純文字訊息並回傳訊息 ID
 // 原因：發送提示訊息（如「請輸入金額：」）後需記錄 ID，使用者輸入後一併刪除
 func SendMessageReturningID(chatID int64, text string) (int, error) {
-	url := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", TelegramToken)
-	body, _ := json.Marshal(map[string]interface{}{
-		"chat_id": chatID,
-		"text":    text,
-	})
-
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(body))
-	if err != nil {
-		return 0, fmt.Errorf("發送訊息失敗: %v", err)
-	}
-	defer resp.Body.Close()
-
-	var result SendMessageResponse
-	json.NewDecoder(resp.Body).Decode(&result)
-	return result.Result.MessageID, nil
-}
-
-// SendMessageResponse sendMessage 的回應結構
-// 原因：需要取得送出的訊息 ID，後續用於 editMessageText
-type SendMessageResponse struct {
-	OK     bool `json:"ok"`
-	Result struct {
-		MessageID int `json:"message_id"`
-	} `json:"result"`
+	return Telegram.SendMessage(chatID, text, nil)
 }
 
 // SendMessageWithKeyboard 發送帶有 Inline Keyboard 的訊息，並回傳訊息 ID
 // 原因：互動式新增流程的核心，顯示預覽資訊搭配可點擊的按鈕
 func SendMessageWithKeyboard(chatID int64, text string, keyboard InlineKeyboardMarkup) (int, error) {
-	url := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", TelegramToken)
-	body, _ := json.Marshal(map[string]interface{}{
-		"chat_id":      chatID,
-		"text":         text,
-		"reply_markup": keyboard,
-	})
-
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(body))
-	if err != nil {
-		return 0, fmt.Errorf("發送訊息失敗: %v", err)
-	}
-	defer resp.Body.Close()
+	return Telegram.SendMessage(chatID, text, &keyboard)
+}
+
+// SendPhoto 上傳 PNG 圖片（附說明文字）並回傳訊息 ID
+// 原因：統計圖表以圖片發送，說明文字列出圖例編號對應的分類名稱
+func SendPhoto(chatID int64, photo []byte, caption string) (int, error) {
+	return Telegram.SendPhoto(chatID, photo, caption, nil)
+}
 
-	var result SendMessageResponse
-	json.NewDecoder(resp.Body).Decode(&result)
-	return result.Result.MessageID, nil
+// SendPhotoWithKeyboard 上傳 PNG 圖片並附上 Inline Keyboard，回傳訊息 ID
+func SendPhotoWithKeyboard(chatID int64, photo []byte, caption string, keyboard InlineKeyboardMarkup) (int, error) {
+	return Telegram.SendPhoto(chatID, photo, caption, &keyboard)
 }
 
 // EditMessageWithKeyboard 編輯已發送的訊息（更新文字與鍵盤）
-// 原因：使用者修改欄位後，更新同一則預覽訊息而非發送新訊息，保持聊天室整潔
+// 原因：使用者修改欄位後，更新同一則預覽訊息而非發送新訊息，保持聊天室整潔；內容未變時不視為錯誤
 func EditMessageWithKeyboard(chatID int64, messageID int, text string, keyboard InlineKeyboardMarkup) error {
-	url := fmt.Sprintf("https://api.telegram.org/bot%s/editMessageText", TelegramToken)
-	body, _ := json.Marshal(map[string]interface{}{
-		"chat_id":      chatID,
-		"message_id":   messageID,
-		"text":         text,
-		"reply_markup": keyboard,
-	})
-
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(body))
-	if err != nil {
-		return fmt.Errorf("編輯訊息失敗: %v", err)
+	if err := Telegram.EditMessage(chatID, messageID, text, &keyboard); err != nil && !IsMessageNotModified(err) {
+		return err
 	}
-	defer resp.Body.Close()
-
 	return nil
 }
 
 // EditMessageText 編輯已發送的訊息（僅更新文字，移除鍵盤）
 // 原因：確認送出後，將預覽訊息替換為最終結果
 func EditMessageText(chatID int64, messageID int, text string) error {
-	url := fmt.Sprintf("https://api.telegram.org/bot%s/editMessageText", TelegramToken)
-	body, _ := json.Marshal(map[string]interface{}{
-		"chat_id":    chatID,
-		"message_id": messageID,
-		"text":       text,
-	})
-
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(body))
-	if err != nil {
-		return fmt.Errorf("編輯訊息失敗: %v", err)
+	if err := Telegram.EditMessage(chatID, messageID, text, nil); err != nil && !IsMessageNotModified(err) {
+		return err
 	}
-	defer resp.Body.Close()
-
 	return nil
 }
 
 // AnswerCallbackQuery 回應 callback query（消除按鈕的 loading 狀態）
 // 原因：Telegram 要求在收到 callback_query 後回應，否則按鈕會持續轉圈
 func AnswerCallbackQuery(callbackQueryID string, text string) error {
-	url := fmt.Sprintf("https://api.telegram.org/bot%s/answerCallbackQuery", TelegramToken)
-	body, _ := json.Marshal(map[string]interface{}{
-		"callback_query_id": callbackQueryID,
-		"text":              text,
-	})
-
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(body))
-	if err != nil {
-		return fmt.Errorf("回應 callback 失敗: %v", err)
-	}
-	defer resp.Body.Close()
-
-	return nil
+	return Telegram.AnswerCallback(callbackQueryID, text)
 }
 
 // DeleteMessage 刪除訊息
 // 原因：使用者輸入欄位值後，刪除使用者的輸入訊息保持整潔
 func DeleteMessage(chatID int64, messageID int) error {
-	url := fmt.Sprintf("https://api.telegram.org/bot%s/deleteMessage", TelegramToken)
-	body, _ := json.Marshal(map[string]interface{}{
-		"chat_id":    chatID,
-		"message_id": messageID,
-	})
-
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(body))
-	if err != nil {
-		return fmt.Errorf("刪除訊息失敗: %v", err)
-	}
-	defer resp.Body.Close()
-
-	return nil
+	return Telegram.DeleteMessage(chatID, messageID)
 }
@@ -0,0 +1,468 @@
+// Package telegramtest 模擬 Telegram Bot API 的假伺服器
+// 原因：不連網也能跑完整的 Bot 流程（發送、編輯、刪除訊息、按鈕與 webhook/getUpdates），
+// 將 services.TelegramConfig.BaseURL（或 TELEGRAM_API_URL）指向此伺服器即可
+package telegramtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Button 訊息上的 Inline Keyboard 按鈕
+type Button struct {
+	Text         string `json:"text"`
+	CallbackData string `json:"callback_data"`
+}
+
+// Message Bot 發送的訊息（含之後的編輯）
+type Message struct {
+	ChatID    int64
+	MessageID int
+	Text      string // 圖片訊息為說明文字（caption）
+	Photo     []byte // sendPhoto 上傳的圖片，文字訊息為 nil
+	Buttons   [][]Button
+	Edits     int  // 被編輯的次數
+	Deleted   bool // 已被 Bot 刪除
+}
+
+// Button 依按鈕文字（包含即可）找出 callback_data
+func (m Message) Button(text string) (string, bool) {
+	for _, row := range m.Buttons {
+		for _, b := range row {
+			if strings.Contains(b.Text, text) {
+				return b.CallbackData, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Server 假的 Telegram Bot API
+type Server struct {
+	// OnMessage Bot 發送或編輯訊息時呼叫（可為 nil），供互動式工具即時顯示
+	OnMessage func(Message)
+
+	mu            sync.Mutex
+	token         string
+	nextMessageID int
+	nextUpdateID  int
+	messages      []*Message
+	updates       []json.RawMessage // 尚未由 getUpdates 取走的 update
+	notify        chan struct{}
+	answered      []string
+	webhookURL    string
+	webhookSecret string
+	rateLimited   int // 接下來要回 429 的請求數
+	retryAfter    int
+	failures      map[int64]failure // 發送訊息到這些聊天室時回傳的錯誤
+}
+
+// failure FailChat 設定的錯誤回應
+type failure struct {
+	status      int
+	description string
+}
+
+// NewServer 建立只接受指定 token 的假伺服器（尚未開始監聽，可作為 http.Handler 使用）
+func NewServer(token string) *Server {
+	return &Server{
+		token:         token,
+		nextMessageID: 1,
+		nextUpdateID:  1,
+		notify:        make(chan struct{}, 1),
+		failures:      make(map[int64]failure),
+	}
+}
+
+// Start 以 httptest 在本機隨機埠監聽，回傳 base URL 與關閉函式
+func (s *Server) Start() (string, func()) {
+	ts := httptest.NewServer(s)
+	return ts.URL, ts.Close
+}
+
+// RateLimit 接下來 n 個請求回傳 429，並要求等待 retryAfter 秒
+func (s *Server) RateLimit(n, retryAfter int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rateLimited = n
+	s.retryAfter = retryAfter
+}
+
+// FailChat 之後發送訊息到 chatID 時回傳 status 錯誤（例如 403 封鎖、400 chat not found），status 為 0 時恢復正常
+func (s *Server) FailChat(chatID int64, status int, description string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if status == 0 {
+		delete(s.failures, chatID)
+		return
+	}
+	s.failures[chatID] = failure{status: status, description: description}
+}
+
+// Webhook 目前註冊的 webhook 網址與密鑰
+func (s *Server) Webhook() (string, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.webhookURL, s.webhookSecret
+}
+
+// Messages 列出 Bot 在聊天室發送且未刪除的訊息（依發送順序）
+func (s *Server) Messages(chatID int64) []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var msgs []Message
+	for _, m := range s.messages {
+		if m.ChatID == chatID && !m.Deleted {
+			msgs = append(msgs, *m)
+		}
+	}
+	return msgs
+}
+
+// LastMessage Bot 在聊天室最後發送且未刪除的訊息
+func (s *Server) LastMessage(chatID int64) (Message, bool) {
+	msgs := s.Messages(chatID)
+	if len(msgs) == 0 {
+		return Message{}, false
+	}
+	return msgs[len(msgs)-1], true
+}
+
+// AnsweredCallbacks 已回應的 callback query ID
+func (s *Server) AnsweredCallbacks() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.answered...)
+}
+
+// SendText 模擬使用者在聊天室輸入文字，回傳使用者訊息的 ID
+// 已註冊 webhook 時同步推播（回傳時 Bot 已處理完畢），否則排入 getUpdates
+func (s *Server) SendText(chatID int64, text string) (int, error) {
+	s.mu.Lock()
+	msgID := s.nextMessageID
+	s.nextMessageID++
+	s.mu.Unlock()
+
+	return msgID, s.deliver(map[string]interface{}{
+		"message": map[string]interface{}{
+			"message_id": msgID,
+			"chat":       map[string]interface{}{"id": chatID},
+			"date":       time.Now().Unix(),
+			"text":       text,
+		},
+	})
+}
+
+// PressButton 模擬使用者點擊 Bot 訊息上的按鈕（與 Telegram 相同，附上訊息目前的內容）
+func (s *Server) PressButton(chatID int64, messageID int, data string) error {
+	s.mu.Lock()
+	queryID := fmt.Sprintf("cq%d", s.nextUpdateID)
+	var text string
+	if m := s.find(chatID, messageID); m != nil {
+		text = m.Text
+	}
+	s.mu.Unlock()
+
+	return s.deliver(map[string]interface{}{
+		"callback_query": map[string]interface{}{
+			"id": queryID,
+			"message": map[string]interface{}{
+				"message_id": messageID,
+				"chat":       map[string]interface{}{"id": chatID},
+				"text":       text,
+			},
+			"data": data,
+		},
+	})
+}
+
+// deliver 加上 update_id 後推播到 webhook，或排入 getUpdates
+func (s *Server) deliver(update map[string]interface{}) error {
+	s.mu.Lock()
+	update["update_id"] = s.nextUpdateID
+	s.nextUpdateID++
+	webhookURL, secret := s.webhookURL, s.webhookSecret
+	raw, _ := json.Marshal(update)
+	if webhookURL == "" {
+		s.updates = append(s.updates, raw)
+		s.mu.Unlock()
+		select {
+		case s.notify <- struct{}{}:
+		default:
+		}
+		return nil
+	}
+	s.mu.Unlock()
+
+	req, err := http.NewRequest(http.MethodPost, webhookURL, bytes.NewReader(raw))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if secret != "" {
+		req.Header.Set("X-Telegram-Bot-Api-Secret-Token", secret)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("webhook 回應狀態碼 %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// ServeHTTP 處理 /bot<token>/<method> 的 Bot API 請求
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	prefix := "/bot" + s.token + "/"
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	method := strings.TrimPrefix(r.URL.Path, prefix)
+
+	s.mu.Lock()
+	if s.rateLimited > 0 {
+		s.rateLimited--
+		retryAfter := s.retryAfter
+		s.mu.Unlock()
+		writeJSON(w, http.StatusTooManyRequests, map[string]interface{}{
+			"ok":          false,
+			"error_code":  http.StatusTooManyRequests,
+			"description": fmt.Sprintf("Too Many Requests: retry after %d", retryAfter),
+			"parameters":  map[string]int{"retry_after": retryAfter},
+		})
+		return
+	}
+	s.mu.Unlock()
+
+	// sendPhoto 以 multipart/form-data 上傳圖片，其餘方法為 JSON
+	if method == "sendPhoto" {
+		s.sendPhoto(w, r)
+		return
+	}
+
+	var params struct {
+		ChatID          int64           `json:"chat_id"`
+		MessageID       int             `json:"message_id"`
+		Text            string          `json:"text"`
+		ReplyMarkup     json.RawMessage `json:"reply_markup"`
+		CallbackQueryID string          `json:"callback_query_id"`
+		URL             string          `json:"url"`
+		SecretToken     string          `json:"secret_token"`
+		Offset          int             `json:"offset"`
+		Timeout         int             `json:"timeout"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		writeError(w, http.StatusBadRequest, "Bad Request: can't parse JSON")
+		return
+	}
+
+	switch method {
+	case "sendMessage":
+		s.mu.Lock()
+		if f, ok := s.failures[params.ChatID]; ok {
+			s.mu.Unlock()
+			writeError(w, f.status, f.description)
+			return
+		}
+		m := &Message{ChatID: params.ChatID, MessageID: s.nextMessageID, Text: params.Text, Buttons: parseButtons(params.ReplyMarkup)}
+		s.nextMessageID++
+		s.messages = append(s.messages, m)
+		sent := *m
+		s.mu.Unlock()
+		s.emit(sent)
+		writeResult(w, map[string]interface{}{"message_id": sent.MessageID, "chat": map[string]int64{"id": sent.ChatID}, "text": sent.Text})
+
+	case "editMessageText":
+		s.mu.Lock()
+		m := s.find(params.ChatID, params.MessageID)
+		if m == nil {
+			s.mu.Unlock()
+			writeError(w, http.StatusBadRequest, "Bad Request: message to edit not found")
+			return
+		}
+		if m.Photo != nil {
+			s.mu.Unlock()
+			writeError(w, http.StatusBadRequest, "Bad Request: there is no text in the message to edit")
+			return
+		}
+		buttons := parseButtons(params.ReplyMarkup)
+		if m.Text == params.Text && buttonsEqual(m.Buttons, buttons) {
+			s.mu.Unlock()
+			writeError(w, http.StatusBadRequest, "Bad Request: message is not modified: specified new message content and reply markup are exactly the same as a current content and reply markup of the message")
+			return
+		}
+		m.Text, m.Buttons = params.Text, buttons
+		m.Edits++
+		edited := *m
+		s.mu.Unlock()
+		s.emit(edited)
+		writeResult(w, map[string]interface{}{"message_id": edited.MessageID, "text": edited.Text})
+
+	case "deleteMessage":
+		s.mu.Lock()
+		// 使用者發送的訊息不會記錄在 messages，視為刪除成功
+		if m := s.find(params.ChatID, params.MessageID); m != nil {
+			m.Deleted = true
+		}
+		s.mu.Unlock()
+		writeResult(w, true)
+
+	case "answerCallbackQuery":
+		s.mu.Lock()
+		s.answered = append(s.answered, params.CallbackQueryID)
+		s.mu.Unlock()
+		writeResult(w, true)
+
+	case "setWebhook":
+		s.mu.Lock()
+		s.webhookURL, s.webhookSecret = params.URL, params.SecretToken
+		s.mu.Unlock()
+		writeResult(w, true)
+
+	case "deleteWebhook":
+		s.mu.Lock()
+		s.webhookURL, s.webhookSecret = "", ""
+		s.mu.Unlock()
+		writeResult(w, true)
+
+	case "getUpdates":
+		s.getUpdates(w, r, params.Offset, params.Timeout)
+
+	default:
+		writeError(w, http.StatusNotFound, "Not Found: method not found")
+	}
+}
+
+// sendPhoto 記錄上傳的圖片，caption 作為訊息文字
+func (s *Server) sendPhoto(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseMultipartForm(10 << 20); err != nil {
+		writeError(w, http.StatusBadRequest, "Bad Request: can't parse multipart form")
+		return
+	}
+	chatID, err := strconv.ParseInt(r.FormValue("chat_id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Bad Request: chat not found")
+		return
+	}
+	file, _, err := r.FormFile("photo")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Bad Request: there is no photo in the request")
+		return
+	}
+	defer file.Close()
+	photo, err := io.ReadAll(file)
+	if err != nil || len(photo) == 0 {
+		writeError(w, http.StatusBadRequest, "Bad Request: IMAGE_PROCESS_FAILED")
+		return
+	}
+
+	s.mu.Lock()
+	m := &Message{ChatID: chatID, MessageID: s.nextMessageID, Text: r.FormValue("caption"), Photo: photo,
+		Buttons: parseButtons(json.RawMessage(r.FormValue("reply_markup")))}
+	s.nextMessageID++
+	s.messages = append(s.messages, m)
+	sent := *m
+	s.mu.Unlock()
+	s.emit(sent)
+	writeResult(w, map[string]interface{}{"message_id": sent.MessageID, "chat": map[string]int64{"id": sent.ChatID}, "caption": sent.Text})
+}
+
+// getUpdates 回傳 offset 之後的 update，沒有新的 update 時最多等待 timeout 秒
+func (s *Server) getUpdates(w http.ResponseWriter, r *http.Request, offset, timeout int) {
+	s.mu.Lock()
+	if s.webhookURL != "" {
+		s.mu.Unlock()
+		writeError(w, http.StatusConflict, "Conflict: can't use getUpdates method while webhook is active; use deleteWebhook to delete the webhook first")
+		return
+	}
+	s.mu.Unlock()
+
+	deadline := time.After(time.Duration(timeout) * time.Second)
+	for {
+		s.mu.Lock()
+		// offset 之前的 update 視為已確認，不再回傳
+		var pending []json.RawMessage
+		for _, raw := range s.updates {
+			var u struct {
+				UpdateID int `json:"update_id"`
+			}
+			json.Unmarshal(raw, &u)
+			if u.UpdateID >= offset {
+				pending = append(pending, raw)
+			}
+		}
+		s.updates = pending
+		s.mu.Unlock()
+
+		if len(pending) > 0 || timeout <= 0 {
+			writeResult(w, pending)
+			return
+		}
+		select {
+		case <-s.notify:
+		case <-deadline:
+			writeResult(w, []json.RawMessage{})
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// find 找出 Bot 發送的訊息（呼叫前需持有鎖）
+func (s *Server) find(chatID int64, messageID int) *Message {
+	for _, m := range s.messages {
+		if m.ChatID == chatID && m.MessageID == messageID && !m.Deleted {
+			return m
+		}
+	}
+	return nil
+}
+
+func (s *Server) emit(m Message) {
+	if s.OnMessage != nil {
+		s.OnMessage(m)
+	}
+}
+
+func parseButtons(raw json.RawMessage) [][]Button {
+	if len(raw) == 0 {
+		return nil
+	}
+	var markup struct {
+		InlineKeyboard [][]Button `json:"inline_keyboard"`
+	}
+	json.Unmarshal(raw, &markup)
+	return markup.InlineKeyboard
+}
+
+func buttonsEqual(a, b [][]Button) bool {
+	x, _ := json.Marshal(a)
+	y, _ := json.Marshal(b)
+	return bytes.Equal(x, y)
+}
+
+func writeResult(w http.ResponseWriter, result interface{}) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{"ok": true, "result": result})
+}
+
+func writeError(w http.ResponseWriter, status int, description string) {
+	writeJSON(w, status, map[string]interface{}{"ok": false, "error_code": status, "description": description})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"accountbook/models"
+	"accountbook/repository"
+	"fmt"
+)
+
+// TransferCategoryName 轉帳紀錄使用的分類名稱
+const TransferCategoryName = "轉帳"
+
+// PostTransfer 建立轉帳：轉出帳戶新增一筆支出、轉入帳戶新增一筆收入，成功後寫回 ID
+// 原因：兩筆 records 讓轉帳能在日曆中顯示，並以 transfer_id 連結，之後可一併修改或刪除
+func (l *LedgerService) PostTransfer(t *models.Transfer) error {
+	if err := validateTransfer(t); err != nil {
+		return err
+	}
+
+	return l.store.WithTx(func(tx repository.Store) error {
+		out, in, err := transferLegs(tx, t)
+		if err != nil {
+			return err
+		}
+		if err := tx.Transfers().Insert(t); err != nil {
+			return err
+		}
+
+		out.TransferID = &t.ID
+		in.TransferID = &t.ID
+		if err := postRecord(tx, out); err != nil {
+			return err
+		}
+		return postRecord(tx, in)
+	})
+}
+
+// AmendTransfer 更新轉帳，兩筆紀錄與兩個帳戶的餘額在同一個 Transaction 中調整
+func (l *LedgerService) AmendTransfer(t *models.Transfer) error {
+	if err := validateTransfer(t); err != nil {
+		return err
+	}
+
+	return l.store.WithTx(func(tx repository.Store) error {
+		if _, err := tx.Transfers().Get(t.ID); err != nil {
+			return notFoundAs(err, ErrTransferNotFound)
+		}
+		legs, err := tx.Records().ListByTransfer(t.ID)
+		if err != nil {
+			return err
+		}
+		out, in, err := transferLegs(tx, t)
+		if err != nil {
+			return err
+		}
+		if err := tx.Transfers().Update(t); err != nil {
+			return notFoundAs(err, ErrTransferNotFound)
+		}
+
+		// 依舊紀錄的類型對應新的轉出、轉入內容，缺少的一筆（舊資料）則補上
+		for _, updated := range []*models.Record{out, in} {
+			updated.TransferID = &t.ID
+			old := findLeg(legs, updated.Type)
+			if old == nil {
+				if err := postRecord(tx, updated); err != nil {
+					return err
+				}
+				continue
+			}
+
+			updated.ID = old.ID
+			if err := adjustBalance(tx, old.AccountID, -balanceDelta(old.Type, old.Amount)); err != nil {
+				return err
+			}
+			if err := tx.Records().Update(updated); err != nil {
+				return err
+			}
+			if err := adjustBalance(tx, updated.AccountID, balanceDelta(updated.Type, updated.Amount)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// VoidTransfer 刪除轉帳與其兩筆紀錄，並回滾兩個帳戶的餘額
+func (l *LedgerService) VoidTransfer(id int) error {
+	return l.store.WithTx(func(tx repository.Store) error {
+		if _, err := tx.Transfers().Get(id); err != nil {
+			return notFoundAs(err, ErrTransferNotFound)
+		}
+		legs, err := tx.Records().ListByTransfer(id)
+		if err != nil {
+			return err
+		}
+
+		for _, old := range legs {
+			if err := tx.Records().Delete(old.ID); err != nil {
+				return err
+			}
+			if err := adjustBalance(tx, old.AccountID, -balanceDelta(old.Type, old.Amount)); err != nil {
+				return err
+			}
+		}
+		return notFoundAs(tx.Transfers().Delete(id), ErrTransferNotFound)
+	})
+}
+
+// validateTransfer 檢查轉帳欄位
+func validateTransfer(t *models.Transfer) error {
+	if t.FromAccountID == t.ToAccountID {
+		return ErrSameAccount
+	}
+	if t.ToAmount < 0 {
+		return ErrInvalidAmount
+	}
+	return validateAmount(t.Amount)
+}
+
+// transferLegs 依轉帳內容產生轉出（支出）與轉入（收入）兩筆紀錄
+// 跨幣別轉帳未指定轉入金額時，依轉帳日期的匯率換算並寫回 t.ToAmount
+func transferLegs(tx repository.Store, t *models.Transfer) (out, in *models.Record, err error) {
+	from, err := tx.Accounts().Get(t.FromAccountID)
+	if err != nil {
+		return nil, nil, notFoundAs(err, ErrAccountNotFound)
+	}
+	to, err := tx.Accounts().Get(t.ToAccountID)
+	if err != nil {
+		return nil, nil, notFoundAs(err, ErrAccountNotFound)
+	}
+	switch {
+	case from.Currency == to.Currency:
+		t.ToAmount = t.Amount
+	case t.ToAmount == 0:
+		t.ToAmount, err = convert(tx, t.Amount, from.Currency, to.Currency, t.Date)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	category, err := tx.Categories().FindOrCreate(TransferCategoryName, 999)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	out = &models.Record{
+		Date:       t.Date,
+		AccountID:  t.FromAccountID,
+		Type:       "支出",
+		Amount:     t.Amount,
+		Item:       fmt.Sprintf("轉帳至 %s", to.Name),
+		CategoryID: category.ID,
+		Note:       t.Note,
+	}
+	in = &models.Record{
+		Date:       t.Date,
+		AccountID:  t.ToAccountID,
+		Type:       "收入",
+		Amount:     t.ToAmount,
+		Item:       fmt.Sprintf("從 %s 轉入", from.Name),
+		CategoryID: category.ID,
+		Note:       t.Note,
+	}
+	return out, in, nil
+}
+
+// findLeg 從轉帳的紀錄中找出指定類型的一筆
+func findLeg(legs []models.RecordWithNames, recordType string) *models.RecordWithNames {
+	for i := range legs {
+		if legs[i].Type == recordType {
+			return &legs[i]
+		}
+	}
+	return nil
+}
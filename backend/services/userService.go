@@ -0,0 +1,82 @@
+package services
+
+import (
+	"accountbook/models"
+	"accountbook/repository"
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// 使用者相關錯誤
+var (
+	ErrUserNotFound    = errors.New("使用者不存在")
+	ErrInvalidUsername = errors.New("使用者名稱只能包含英文字母、數字、底線或連字號（1～32 字）")
+	ErrUsernameTaken   = errors.New("使用者名稱已存在")
+	ErrChatLinked      = errors.New("此聊天室已綁定其他使用者，請先解除綁定")
+)
+
+// usernamePattern 使用者名稱格式
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+
+// Users 全域使用者服務
+var Users *UserService
+
+// UserService 使用者服務
+// 原因：建立使用者時需一併建立個人帳本，聊天室也需綁定到使用者
+type UserService struct {
+	store repository.Store
+}
+
+// NewUserService 建立使用者服務
+func NewUserService(store repository.Store) *UserService {
+	return &UserService{store: store}
+}
+
+// CreateUser 建立使用者及其個人帳本（含預設帳戶與分類），成功後寫回 ID
+func (s *UserService) CreateUser(u *models.User) error {
+	u.Username = strings.TrimSpace(u.Username)
+	u.DisplayName = strings.TrimSpace(u.DisplayName)
+	if !usernamePattern.MatchString(u.Username) {
+		return ErrInvalidUsername
+	}
+	if u.DisplayName == "" {
+		u.DisplayName = u.Username
+	}
+
+	return s.store.WithTx(func(tx repository.Store) error {
+		if err := tx.Users().Create(u); err != nil {
+			if err == repository.ErrDuplicate {
+				return ErrUsernameTaken
+			}
+			return err
+		}
+
+		// 每位使用者都有一本自己擁有的個人帳本
+		_, err := createBook(tx, u.ID, u.DisplayName+"的帳本")
+		return err
+	})
+}
+
+// LinkChat 將 Telegram 聊天室綁定到使用者
+func (s *UserService) LinkChat(chatID int64, userID int) error {
+	return s.store.WithTx(func(tx repository.Store) error {
+		if _, err := tx.Users().Get(userID); err != nil {
+			return notFoundAs(err, ErrUserNotFound)
+		}
+		return linkChat(tx, chatID, userID)
+	})
+}
+
+// linkChat 在 Transaction 內綁定聊天室
+// 原因：已綁定其他使用者的聊天室不可直接改綁，避免取走他人的聊天室；需先由原使用者解除綁定
+func linkChat(tx repository.Store, chatID int64, userID int) error {
+	chat, err := tx.TelegramChats().Get(chatID)
+	if err != nil && err != repository.ErrNotFound {
+		return err
+	}
+	if err == nil && chat.UserID != userID {
+		return ErrChatLinked
+	}
+	return tx.TelegramChats().Link(chatID, userID)
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"accountbook/models"
+	"accountbook/repository"
+	"testing"
+)
+
+// newTestUsers 建立兩位使用者（admin 為 1、bob 為 2）的 MemoryStore
+func newTestUsers(t *testing.T) repository.Store {
+	t.Helper()
+	store := repository.NewMemoryStore()
+	users := NewUserService(store)
+	for _, name := range []string{"admin", "bob"} {
+		if err := users.CreateUser(&models.User{Username: name}); err != nil {
+			t.Fatalf("建立使用者 %s 失敗: %v", name, err)
+		}
+	}
+	return store
+}
+
+// expectChatOwner 確認聊天室綁定的使用者
+func expectChatOwner(t *testing.T, store repository.Store, chatID int64, userID int) {
+	t.Helper()
+	chat, err := store.TelegramChats().Get(chatID)
+	if err != nil {
+		t.Fatalf("查詢聊天室 %d 失敗: %v", chatID, err)
+	}
+	if chat.UserID != userID {
+		t.Errorf("聊天室 %d 綁定到使用者 %d，預期 %d", chatID, chat.UserID, userID)
+	}
+}
+
+func TestLinkChatRefusesOtherUsersChat(t *testing.T) {
+	store := newTestUsers(t)
+	users := NewUserService(store)
+
+	if err := users.LinkChat(1001, 1); err != nil {
+		t.Fatalf("LinkChat: %v", err)
+	}
+	// 重新綁定到同一位使用者不算改綁
+	if err := users.LinkChat(1001, 1); err != nil {
+		t.Errorf("重新綁定到同一使用者: %v", err)
+	}
+	if err := users.LinkChat(1001, 2); err != ErrChatLinked {
+		t.Errorf("綁定他人的聊天室 = %v，預期 %v", err, ErrChatLinked)
+	}
+	expectChatOwner(t, store, 1001, 1)
+
+	// 原使用者解除綁定後即可綁定到其他使用者
+	if err := store.TelegramChats().Unlink(1001); err != nil {
+		t.Fatalf("Unlink: %v", err)
+	}
+	if err := users.LinkChat(1001, 2); err != nil {
+		t.Errorf("解除綁定後綁定: %v", err)
+	}
+	expectChatOwner(t, store, 1001, 2)
+
+	if err := users.LinkChat(1002, 99); err != ErrUserNotFound {
+		t.Errorf("綁定到不存在的使用者 = %v，預期 %v", err, ErrUserNotFound)
+	}
+}
+
+func TestPairRefusesOtherUsersChat(t *testing.T) {
+	store := newTestUsers(t)
+	auth := NewTelegramAuthService(store, nil)
+	if err := NewUserService(store).LinkChat(1001, 1); err != nil {
+		t.Fatalf("LinkChat: %v", err)
+	}
+
+	code, _, err := auth.CreatePairCode(2)
+	if err != nil {
+		t.Fatalf("CreatePairCode: %v", err)
+	}
+	if _, err := auth.Pair(1001, code); err != ErrChatLinked {
+		t.Errorf("以配對碼取走他人的聊天室 = %v，預期 %v", err, ErrChatLinked)
+	}
+	expectChatOwner(t, store, 1001, 1)
+
+	code, _, err = auth.CreatePairCode(2)
+	if err != nil {
+		t.Fatalf("CreatePairCode: %v", err)
+	}
+	user, err := auth.Pair(2002, code)
+	if err != nil || user.ID != 2 {
+		t.Fatalf("配對未綁定的聊天室 = %v（%v），預期使用者 2", user, err)
+	}
+	expectChatOwner(t, store, 2002, 2)
+	if ok, err := auth.Authorized(2002); !ok || err != nil {
+		t.Errorf("配對後的聊天室應可使用 Bot（%v）", err)
+	}
+}
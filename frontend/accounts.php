@@ -18,7 +18,8 @@ include __DIR__ . '/components/header.php';
     <div class="modal-box">
         <h3 id="modal-title">新增帳戶</h3>
         <input type="text" id="modal-name" placeholder="帳戶名稱">
-        <input type="number" id="modal-balance" placeholder="初始餘額" step="1">
+        <input type="text" id="modal-currency" placeholder="幣別（如 TWD、USD、JPY，預設 TWD）" maxlength="3">
+        <input type="number" id="modal-balance" placeholder="初始餘額" step="any">
         <div class="modal-actions">
             <button class="btn-cancel" id="modal-cancel">取消</button>
             <button class="btn-confirm" id="modal-confirm">確定</button>
@@ -41,20 +42,28 @@ include __DIR__ . '/components/header.php';
                 return;
             }
 
-            let totalBalance = 0;
+            // 不同幣別分開加總（原因：不同幣別的金額不能直接相加）
+            const totals = {};
+            accounts.forEach(a => {
+                totals[a.currency] = (totals[a.currency] || 0) + a.balance;
+            });
+            const currencies = Object.keys(totals);
+            const formatMoney = (amount, currency) => currencies.length > 1
+                ? `${currency} ${Number(amount).toLocaleString()}`
+                : '$' + Number(amount).toLocaleString();
+
             listEl.innerHTML = accounts.map(a => {
-                totalBalance += a.balance;
                 const balanceClass = a.balance < 0 ? 'negative' : '';
                 return `
-                    <li class="account-item" data-id="${a.id}" data-name="${escapeAttr(a.name)}" data-balance="${a.balance}">
+                    <li class="account-item" data-id="${a.id}" data-name="${escapeAttr(a.name)}" data-balance="${a.balance}" data-currency="${escapeAttr(a.currency)}">
                         <span class="account-name">${escapeHtml(a.name)}</span>
-                        <span class="account-balance ${balanceClass}">$${Number(a.balance).toLocaleString()}</span>
+                        <span class="account-balance ${balanceClass}">${escapeHtml(formatMoney(a.balance, a.currency))}</span>
                     </li>
                 `;
             }).join('');
 
             document.getElementById('total-balance').textContent =
-                '$' + Number(totalBalance).toLocaleString();
+                currencies.map(c => formatMoney(totals[c], c)).join(' ＋ ');
 
             // 綁定點擊編輯
             listEl.querySelectorAll('.account-item').forEach(el => {
@@ -62,6 +71,7 @@ include __DIR__ . '/components/header.php';
                     editingId = el.dataset.id;
                     document.getElementById('modal-title').textContent = '編輯帳戶';
                     document.getElementById('modal-name').value = el.dataset.name;
+                    document.getElementById('modal-currency').value = el.dataset.currency;
                     document.getElementById('modal-balance').value = el.dataset.balance;
                     document.getElementById('modal-delete').style.display = 'block';
                     document.getElementById('modal').classList.add('active');
@@ -77,6 +87,7 @@ include __DIR__ . '/components/header.php';
         editingId = null;
         document.getElementById('modal-title').textContent = '新增帳戶';
         document.getElementById('modal-name').value = '';
+        document.getElementById('modal-currency').value = '';
         document.getElementById('modal-balance').value = '0';
         document.getElementById('modal-delete').style.display = 'none';
         document.getElementById('modal').classList.add('active');
@@ -90,6 +101,7 @@ include __DIR__ . '/components/header.php';
     // 彈窗確定
     document.getElementById('modal-confirm').addEventListener('click', async () => {
         const name = document.getElementById('modal-name').value.trim();
+        const currency = document.getElementById('modal-currency').value.trim().toUpperCase();
         const balance = parseFloat(document.getElementById('modal-balance').value) || 0;
 
         if (!name) {
@@ -99,10 +111,10 @@ include __DIR__ . '/components/header.php';
 
         try {
             if (editingId) {
-                await API.updateAccount(editingId, { name, balance });
+                await API.updateAccount(editingId, currency ? { name, currency, balance } : { name, balance });
                 showToast('更新成功');
             } else {
-                await API.createAccount({ name, balance });
+                await API.createAccount({ name, currency, balance });
                 showToast('新增成功');
             }
             document.getElementById('modal').classList.remove('active');
@@ -9,8 +9,8 @@ const API = {
     async request(endpoint, options = {}) {
         const url = this.baseURL + endpoint;
         const config = {
-            headers: { 'Content-Type': 'application/json' },
             ...options,
+            headers: { 'Content-Type': 'application/json', ...this.userHeaders(), ...options.headers },
         };
 
         if (config.body && typeof config.body === 'object') {
@@ -20,12 +20,61 @@ const API = {
         const response = await fetch(url, config);
         const data = await response.json();
 
+        // 未登入或登入已失效：清除 token 並導向登入頁
+        if (response.status === 401 && !endpoint.startsWith('/auth/login')) {
+            localStorage.removeItem('token');
+            location.href = '/login.php';
+        }
+
         if (!response.ok) {
             throw new Error(data.error || '請求失敗');
         }
         return data;
     },
 
+    // 登入 token 與目前帳本（設定頁切換，未選擇時由後端視為目前使用者的個人帳本）
+    // 後端關閉認證時沒有 token，改以 X-User-ID 指定使用者
+    userHeaders() {
+        const headers = {};
+        const token = localStorage.getItem('token');
+        const userId = localStorage.getItem('userId');
+        const bookId = localStorage.getItem('bookId');
+        if (token) {
+            headers['Authorization'] = `Bearer ${token}`;
+        } else if (userId) {
+            headers['X-User-ID'] = userId;
+        }
+        if (bookId) headers['X-Book-ID'] = bookId;
+        return headers;
+    },
+
+    // ========== 登入 ==========
+
+    getAuthConfig() {
+        return this.request('/auth/config');
+    },
+
+    async login(username, password) {
+        const result = await this.request('/auth/login', { method: 'POST', body: { username, password } });
+        localStorage.setItem('token', result.token);
+        // 換使用者登入時，原本的帳本不一定可存取
+        localStorage.removeItem('bookId');
+        return result;
+    },
+
+    async logout() {
+        try {
+            await this.request('/auth/logout', { method: 'POST' });
+        } finally {
+            localStorage.removeItem('token');
+            localStorage.removeItem('bookId');
+        }
+    },
+
+    getMe() {
+        return this.request('/users/me');
+    },
+
     // ========== 紀錄 ==========
 
     // 查詢指定日期的紀錄
@@ -58,6 +107,11 @@ const API = {
         return this.request(`/records/${id}`, { method: 'DELETE' });
     },
 
+    // 依項目名稱取得建議的分類與帳戶（沒有同名紀錄時 samples 為 0）
+    getSuggestion(item) {
+        return this.request(`/suggestions?item=${encodeURIComponent(item)}`);
+    },
+
     // ========== 帳戶 ==========
 
     getAccounts() {
@@ -104,6 +158,82 @@ const API = {
         return this.request('/transfer', { method: 'POST', body: data });
     },
 
+    getTransfer(id) {
+        return this.request(`/transfers/${id}`);
+    },
+
+    updateTransfer(id, data) {
+        return this.request(`/transfers/${id}`, { method: 'PUT', body: data });
+    },
+
+    deleteTransfer(id) {
+        return this.request(`/transfers/${id}`, { method: 'DELETE' });
+    },
+
+    // ========== 匯率 ==========
+
+    getExchangeRates({ from, to } = {}) {
+        const params = new URLSearchParams();
+        if (from) params.set('from', from);
+        if (to) params.set('to', to);
+        const query = params.toString();
+        return this.request('/exchange-rates' + (query ? `?${query}` : ''));
+    },
+
+    createExchangeRate(data) {
+        return this.request('/exchange-rates', { method: 'POST', body: data });
+    },
+
+    // 匯入 CSV（每列 date,from_currency,to_currency,rate）
+    importExchangeRates(csv) {
+        return this.request('/exchange-rates/import', {
+            method: 'POST',
+            headers: { 'Content-Type': 'text/csv' },
+            body: csv,
+        });
+    },
+
+    deleteExchangeRate(id) {
+        return this.request(`/exchange-rates/${id}`, { method: 'DELETE' });
+    },
+
+    // ========== 使用者 ==========
+
+    getUsers() {
+        return this.request('/users');
+    },
+
+    createUser(data) {
+        return this.request('/users', { method: 'POST', body: data });
+    },
+
+    // 產生 Telegram 配對碼，於聊天室輸入 /pair <配對碼> 綁定
+    createTelegramPairCode() {
+        return this.request('/telegram/pair-code', { method: 'POST' });
+    },
+
+    // ========== 帳本 ==========
+
+    getBooks() {
+        return this.request('/books');
+    },
+
+    createBook(data) {
+        return this.request('/books', { method: 'POST', body: data });
+    },
+
+    getBookMembers(bookId) {
+        return this.request(`/books/${bookId}/members`);
+    },
+
+    addBookMember(bookId, data) {
+        return this.request(`/books/${bookId}/members`, { method: 'POST', body: data });
+    },
+
+    removeBookMember(bookId, userId) {
+        return this.request(`/books/${bookId}/members/${userId}`, { method: 'DELETE' });
+    },
+
     // ========== 統計 ==========
 
     getStatistics(month, { accountId, categoryId } = {}) {
@@ -116,6 +246,19 @@ const API = {
     getSummary(month) {
         return this.request(`/statistics/summary?month=${month}`);
     },
+
+    // 後端產生的統計圖表（與 Bot 相同的圖片），回傳可用於 <img> 的 object URL
+    // 原因：<img src> 無法帶認證 header，改以 fetch 取得圖片
+    async getStatisticsChart(month, { chart = 'pie', accountId } = {}) {
+        let url = `${this.baseURL}/statistics/chart.png?month=${month}&chart=${chart}`;
+        if (accountId) url += `&account_id=${accountId}`;
+        const response = await fetch(url, { headers: this.userHeaders() });
+        if (!response.ok) {
+            const data = await response.json().catch(() => ({}));
+            throw new Error(data.error || '請求失敗');
+        }
+        return URL.createObjectURL(await response.blob());
+    },
 };
 
 // 提示訊息工具
@@ -1,3 +1,8 @@
+<?php if (!empty($hideNav)): ?>
+    </main>
+</div>
+<?php else: ?>
 <?php include __DIR__ . '/nav.php'; ?>
+<?php endif; ?>
 </body>
 </html>
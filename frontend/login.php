@@ -0,0 +1,39 @@
+<?php
+$pageTitle = '登入';
+$currentPage = 'login';
+$hideNav = true;
+include __DIR__ . '/components/header.php';
+?>
+
+<form id="login-form">
+    <div class="form-group">
+        <label>帳號</label>
+        <input type="text" id="login-username" required autocomplete="username" placeholder="使用者名稱">
+    </div>
+    <div class="form-group">
+        <label>密碼</label>
+        <input type="password" id="login-password" required autocomplete="current-password" placeholder="密碼">
+    </div>
+    <button type="submit" class="btn btn-primary">登入</button>
+</form>
+
+<script>
+    // 後端關閉認證時不需登入，直接回首頁
+    API.getAuthConfig().then(config => {
+        if (!config.enabled) location.href = '/';
+    }).catch(() => {});
+
+    document.getElementById('login-form').addEventListener('submit', async (e) => {
+        e.preventDefault();
+        const username = document.getElementById('login-username').value.trim();
+        const password = document.getElementById('login-password').value;
+        try {
+            await API.login(username, password);
+            location.href = '/';
+        } catch (err) {
+            showToast(err.message || '登入失敗');
+        }
+    });
+</script>
+
+<?php include __DIR__ . '/components/footer.php'; ?>
@@ -101,6 +101,28 @@ include __DIR__ . '/components/header.php';
         }
     }
 
+    // 使用者手動選過的欄位不再自動帶入建議
+    const touched = { account: false, category: false };
+    document.getElementById('record-account').addEventListener('change', () => touched.account = true);
+    document.getElementById('record-category').addEventListener('change', () => touched.category = true);
+
+    // 輸入項目後，依過去同名紀錄自動選擇分類與帳戶
+    document.getElementById('record-item').addEventListener('change', async (e) => {
+        const item = e.target.value.trim();
+        if (!item || (touched.account && touched.category)) return;
+        try {
+            const s = await API.getSuggestion(item);
+            if (s.category_id && !touched.category) {
+                document.getElementById('record-category').value = s.category_id;
+            }
+            if (s.account_id && !touched.account) {
+                document.getElementById('record-account').value = s.account_id;
+            }
+        } catch (e) {
+            // 建議只是輔助，失敗時不影響新增
+        }
+    });
+
     // 新增紀錄
     document.getElementById('btn-add').addEventListener('click', async () => {
         const data = {
@@ -48,6 +48,8 @@ include __DIR__ . '/components/header.php';
 <script>
     const urlParams = new URLSearchParams(window.location.search);
     const recordId = urlParams.get('id');
+    // 轉帳產生的紀錄需透過轉帳 API 修改，兩筆紀錄才會一起變動
+    let transfer = null;
 
     // 載入帳戶與分類選單，以及紀錄資料
     async function init() {
@@ -85,6 +87,10 @@ include __DIR__ . '/components/header.php';
             document.getElementById('record-item').value = record.item;
             categorySelect.value = record.category_id;
             document.getElementById('record-note').value = record.note || '';
+
+            if (record.transfer_id) {
+                transfer = await API.getTransfer(record.transfer_id);
+            }
         } catch (e) {
             showToast('載入紀錄失敗');
         }
@@ -103,7 +109,18 @@ include __DIR__ . '/components/header.php';
         };
 
         try {
-            await API.updateRecord(recordId, data);
+            if (transfer) {
+                // 轉帳只能修改日期、金額與備註，帳戶沿用原本的轉出、轉入帳戶
+                await API.updateTransfer(transfer.id, {
+                    date: data.date,
+                    from_account_id: transfer.from_account_id,
+                    to_account_id: transfer.to_account_id,
+                    amount: data.amount,
+                    note: data.note,
+                });
+            } else {
+                await API.updateRecord(recordId, data);
+            }
             showToast('更新成功');
             setTimeout(() => window.location.href = '/', 500);
         } catch (e) {
@@ -113,10 +130,17 @@ include __DIR__ . '/components/header.php';
 
     // 刪除
     document.getElementById('btn-delete').addEventListener('click', async () => {
-        if (!confirm('確定要刪除這筆紀錄嗎？')) return;
+        const message = transfer
+            ? '這是轉帳紀錄，轉出與轉入兩筆將一併刪除，確定嗎？'
+            : '確定要刪除這筆紀錄嗎？';
+        if (!confirm(message)) return;
 
         try {
-            await API.deleteRecord(recordId);
+            if (transfer) {
+                await API.deleteTransfer(transfer.id);
+            } else {
+                await API.deleteRecord(recordId);
+            }
             showToast('刪除成功');
             setTimeout(() => window.location.href = '/', 500);
         } catch (e) {
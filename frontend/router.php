@@ -12,7 +12,22 @@ if (strpos($path, '/api/') === 0) {
     $backendUrl = 'http://backend:8080' . $uri;
 
     $method = $_SERVER['REQUEST_METHOD'];
-    $headers = ['Content-Type: application/json'];
+    // 轉發請求的 Content-Type（未提供時視為 JSON）
+    $headers = ['Content-Type: ' . ($_SERVER['CONTENT_TYPE'] ?? 'application/json')];
+
+    // 轉發登入 token
+    if (!empty($_SERVER['HTTP_AUTHORIZATION'])) {
+        $headers[] = 'Authorization: ' . $_SERVER['HTTP_AUTHORIZATION'];
+    }
+
+    // 轉發目前使用者（後端關閉認證時，設定頁選擇的使用者）
+    if (!empty($_SERVER['HTTP_X_USER_ID'])) {
+        $headers[] = 'X-User-ID: ' . $_SERVER['HTTP_X_USER_ID'];
+    }
+    // 轉發目前帳本（設定頁選擇的帳本）
+    if (!empty($_SERVER['HTTP_X_BOOK_ID'])) {
+        $headers[] = 'X-Book-ID: ' . $_SERVER['HTTP_X_BOOK_ID'];
+    }
 
     $ch = curl_init($backendUrl);
     curl_setopt($ch, CURLOPT_RETURNTRANSFER, true);
@@ -27,10 +42,13 @@ if (strpos($path, '/api/') === 0) {
 
     $response = curl_exec($ch);
     $httpCode = curl_getinfo($ch, CURLINFO_HTTP_CODE);
+    $contentType = curl_getinfo($ch, CURLINFO_CONTENT_TYPE);
     curl_close($ch);
 
+    // 轉發後端回應的 Content-Type
+    // 原因：統計圖表（/api/statistics/chart.png）回傳 image/png，固定為 JSON 時瀏覽器無法顯示圖片
     http_response_code($httpCode);
-    header('Content-Type: application/json');
+    header('Content-Type: ' . ($contentType ?: 'application/json'));
     echo $response;
     return true;
 }
@@ -4,6 +4,35 @@ $currentPage = 'settings';
 include __DIR__ . '/components/header.php';
 ?>
 
+<div style="padding:12px 16px;font-size:13px;color:#999;background:#f9f9f9;border-bottom:1px solid #eee;">
+    使用者
+</div>
+
+<div style="padding:12px 16px;display:flex;gap:8px;">
+    <select id="user-select" style="flex:1;"></select>
+    <span id="current-user" style="flex:1;align-self:center;display:none;"></span>
+    <button class="btn-cancel" id="btn-add-user">新增</button>
+    <button class="btn-cancel" id="btn-logout" style="display:none;">登出</button>
+</div>
+
+<div style="padding:12px 16px;display:flex;gap:8px;align-items:center;">
+    <span id="pair-code" style="flex:1;color:#999;">Telegram 聊天室需配對後才能記帳</span>
+    <button class="btn-cancel" id="btn-pair-code">產生配對碼</button>
+</div>
+
+<div style="padding:12px 16px;font-size:13px;color:#999;background:#f9f9f9;border-bottom:1px solid #eee;">
+    帳本
+</div>
+
+<div style="padding:12px 16px;display:flex;gap:8px;">
+    <select id="book-select" style="flex:1;"></select>
+    <button class="btn-cancel" id="btn-add-book">新增</button>
+</div>
+
+<div id="member-list"></div>
+
+<button class="btn btn-primary" id="btn-add-member" style="display:none;">+ 邀請成員</button>
+
 <div style="padding:12px 16px;font-size:13px;color:#999;background:#f9f9f9;border-bottom:1px solid #eee;">
     分類管理
 </div>
@@ -12,6 +41,17 @@ include __DIR__ . '/components/header.php';
 
 <button class="btn btn-primary" id="btn-add-category">+ 新增分類</button>
 
+<div style="padding:12px 16px;font-size:13px;color:#999;background:#f9f9f9;border-bottom:1px solid #eee;">
+    匯率
+</div>
+
+<div id="rate-list"></div>
+
+<div style="padding:12px 16px;">
+    <textarea id="rate-csv" rows="4" style="width:100%;box-sizing:border-box;" placeholder="貼上 CSV，每列：日期,幣別,兌換幣別,匯率&#10;例如：2026-10-01,USD,TWD,32.1"></textarea>
+</div>
+<button class="btn btn-primary" id="btn-import-rates">匯入匯率</button>
+
 <!-- 新增/編輯分類彈窗 -->
 <div class="modal-overlay" id="modal">
     <div class="modal-box">
@@ -108,6 +148,208 @@ include __DIR__ . '/components/header.php';
         }
     });
 
+    // 載入最近的匯率
+    async function loadRates() {
+        try {
+            const rates = await API.getExchangeRates();
+            const listEl = document.getElementById('rate-list');
+
+            if (!rates || rates.length === 0) {
+                listEl.innerHTML = '<div class="empty-message">尚無匯率</div>';
+                return;
+            }
+
+            listEl.innerHTML = rates.slice(0, 20).map(r => `
+                <div class="setting-item" data-id="${r.id}">
+                    <span class="setting-name">${r.date.substring(0, 10)}｜1 ${escapeHtml(r.from_currency)} = ${r.rate} ${escapeHtml(r.to_currency)}</span>
+                    <div class="setting-actions">
+                        <button class="btn-del" onclick="deleteRate(${r.id})">刪除</button>
+                    </div>
+                </div>
+            `).join('');
+        } catch (e) {
+            showToast('載入匯率失敗');
+        }
+    }
+
+    async function deleteRate(id) {
+        if (!confirm('確定要刪除此匯率嗎？')) return;
+        try {
+            await API.deleteExchangeRate(id);
+            loadRates();
+        } catch (e) {
+            showToast(e.message || '刪除失敗');
+        }
+    }
+
+    // 匯入 CSV 匯率
+    document.getElementById('btn-import-rates').addEventListener('click', async () => {
+        const csv = document.getElementById('rate-csv').value.trim();
+        if (!csv) {
+            showToast('請貼上 CSV 內容');
+            return;
+        }
+
+        try {
+            const result = await API.importExchangeRates(csv);
+            showToast(`已匯入 ${result.imported} 筆匯率`);
+            document.getElementById('rate-csv').value = '';
+            loadRates();
+        } catch (e) {
+            showToast(e.message || '匯入失敗');
+        }
+    });
+
+    // 載入使用者列表，選擇後所有頁面改為顯示該使用者的資料
+    // 已登入時改為顯示目前使用者與登出按鈕（使用者由登入的帳號決定）
+    async function loadUsers() {
+        if (localStorage.getItem('token')) {
+            try {
+                const me = await API.getMe();
+                document.getElementById('user-select').style.display = 'none';
+                document.getElementById('current-user').style.display = '';
+                document.getElementById('current-user').textContent = me.display_name || me.username;
+                document.getElementById('btn-logout').style.display = '';
+                document.getElementById('btn-add-user').style.display = me.is_admin ? '' : 'none';
+            } catch (e) {
+                showToast('載入使用者失敗');
+            }
+            return;
+        }
+
+        try {
+            const users = await API.getUsers();
+            const current = localStorage.getItem('userId') || '1';
+            const selectEl = document.getElementById('user-select');
+            selectEl.innerHTML = users.map(u => `
+                <option value="${u.id}" ${String(u.id) === current ? 'selected' : ''}>${escapeHtml(u.display_name || u.username)}</option>
+            `).join('');
+        } catch (e) {
+            showToast('載入使用者失敗');
+        }
+    }
+
+    document.getElementById('user-select').addEventListener('change', (e) => {
+        localStorage.setItem('userId', e.target.value);
+        // 原本的帳本不一定屬於新的使用者，改回其個人帳本
+        localStorage.removeItem('bookId');
+        location.reload();
+    });
+
+    document.getElementById('btn-logout').addEventListener('click', async () => {
+        await API.logout();
+        location.href = '/login.php';
+    });
+
+    // 新增使用者（同時建立個人帳本；啟用認證時需設定密碼才能登入）
+    document.getElementById('btn-add-user').addEventListener('click', async () => {
+        const username = prompt('請輸入使用者名稱（英文字母、數字、底線或連字號）');
+        if (!username) return;
+        const password = localStorage.getItem('token') ? prompt('請輸入密碼（至少 8 個字元）') : '';
+        if (password === null) return;
+        try {
+            await API.createUser({ username: username.trim(), password });
+            showToast('新增成功');
+            loadUsers();
+        } catch (e) {
+            showToast(e.message || '新增失敗');
+        }
+    });
+
+    // 產生 Telegram 配對碼（10 分鐘內有效）
+    document.getElementById('btn-pair-code').addEventListener('click', async () => {
+        try {
+            const result = await API.createTelegramPairCode();
+            document.getElementById('pair-code').textContent = `在 Telegram 聊天室輸入：/pair ${result.code}（10 分鐘內有效）`;
+        } catch (e) {
+            showToast(e.message || '產生配對碼失敗');
+        }
+    });
+
+    const roleLabels = { owner: '擁有者', editor: '編輯者', viewer: '檢視者' };
+
+    // 載入目前使用者的帳本，選擇後所有頁面改為顯示該帳本的資料
+    async function loadBooks() {
+        try {
+            const books = await API.getBooks();
+            const selectEl = document.getElementById('book-select');
+            // 未選擇或已無權限時，以第一本自己擁有的帳本為目前帳本（與後端預設一致）
+            const current = books.find(b => String(b.id) === localStorage.getItem('bookId'))
+                || books.find(b => b.role === 'owner') || books[0];
+            selectEl.innerHTML = books.map(b => `
+                <option value="${b.id}" ${current && b.id === current.id ? 'selected' : ''}>${escapeHtml(b.name)}（${roleLabels[b.role]}）</option>
+            `).join('');
+            if (current) loadMembers(current);
+        } catch (e) {
+            showToast('載入帳本失敗');
+        }
+    }
+
+    // 載入帳本成員，擁有者可邀請與移除成員
+    async function loadMembers(book) {
+        const isOwner = book.role === 'owner';
+        document.getElementById('btn-add-member').style.display = isOwner ? '' : 'none';
+        document.getElementById('btn-add-member').dataset.bookId = book.id;
+        try {
+            const members = await API.getBookMembers(book.id);
+            document.getElementById('member-list').innerHTML = members.map(m => `
+                <div class="setting-item" data-id="${m.user_id}">
+                    <span class="setting-name">${escapeHtml(m.display_name || m.username)}（${roleLabels[m.role]}）</span>
+                    <div class="setting-actions">
+                        ${isOwner && m.role !== 'owner' ? `<button class="btn-del" onclick="removeMember(${book.id}, ${m.user_id})">移除</button>` : ''}
+                    </div>
+                </div>
+            `).join('');
+        } catch (e) {
+            showToast('載入成員失敗');
+        }
+    }
+
+    document.getElementById('book-select').addEventListener('change', (e) => {
+        localStorage.setItem('bookId', e.target.value);
+        location.reload();
+    });
+
+    // 新增帳本（同時建立預設帳戶與分類）
+    document.getElementById('btn-add-book').addEventListener('click', async () => {
+        const name = prompt('請輸入帳本名稱');
+        if (!name) return;
+        try {
+            await API.createBook({ name: name.trim() });
+            showToast('新增成功');
+            loadBooks();
+        } catch (e) {
+            showToast(e.message || '新增失敗');
+        }
+    });
+
+    // 邀請成員：輸入使用者名稱與角色
+    document.getElementById('btn-add-member').addEventListener('click', async (e) => {
+        const bookId = e.target.dataset.bookId;
+        const username = prompt('請輸入要邀請的使用者名稱');
+        if (!username) return;
+        const role = prompt('請輸入角色：editor（可記帳）、viewer（只能查看）或 owner（可管理成員）', 'editor');
+        if (!role) return;
+        try {
+            await API.addBookMember(bookId, { username: username.trim(), role: role.trim() });
+            showToast('已加入成員');
+            loadBooks();
+        } catch (err) {
+            showToast(err.message || '邀請失敗');
+        }
+    });
+
+    async function removeMember(bookId, userId) {
+        if (!confirm('確定要移除此成員？')) return;
+        try {
+            await API.removeBookMember(bookId, userId);
+            showToast('已移除成員');
+            loadBooks();
+        } catch (e) {
+            showToast(e.message || '移除失敗');
+        }
+    }
+
     function escapeHtml(text) {
         const div = document.createElement('div');
         div.textContent = text;
@@ -118,7 +360,10 @@ include __DIR__ . '/components/header.php';
         return text.replace(/'/g, "\\'").replace(/"/g, '\\"');
     }
 
+    loadUsers();
+    loadBooks();
     loadCategories();
+    loadRates();
 </script>
 
 <?php include __DIR__ . '/components/footer.php'; ?>
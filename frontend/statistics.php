@@ -46,6 +46,17 @@ include __DIR__ . '/components/header.php';
 
 <div id="category-list" class="category-list"></div>
 
+<div class="stat-filters">
+    <select id="chart-kind">
+        <option value="bar">每日支出</option>
+        <option value="line">資產變化</option>
+    </select>
+</div>
+
+<div class="chart-image">
+    <img id="stat-chart-image" alt="統計圖表">
+</div>
+
 <script>
     let currentYear = new Date().getFullYear();
     let currentMonth = new Date().getMonth() + 1;
@@ -98,6 +109,23 @@ include __DIR__ . '/components/header.php';
 
     document.getElementById('filter-account').addEventListener('change', loadStatistics);
     document.getElementById('filter-category').addEventListener('change', loadStatistics);
+    document.getElementById('chart-kind').addEventListener('change', loadChartImage);
+
+    // 載入後端產生的每日支出或資產變化圖（依帳戶篩選）
+    async function loadChartImage() {
+        const img = document.getElementById('stat-chart-image');
+        try {
+            const url = await API.getStatisticsChart(formatMonth(), {
+                chart: document.getElementById('chart-kind').value,
+                accountId: document.getElementById('filter-account').value,
+            });
+            if (img.src) URL.revokeObjectURL(img.src);
+            img.src = url;
+            img.hidden = false;
+        } catch (e) {
+            img.hidden = true;
+        }
+    }
 
     async function loadStatistics() {
         updateLabel();
@@ -119,6 +147,7 @@ include __DIR__ . '/components/header.php';
             const categories = data.expense_categories || [];
             renderPieChart('expense-chart', categories, '支出分類');
             renderCategoryList('category-list', categories);
+            loadChartImage();
         } catch (e) {
             document.getElementById('stat-income').textContent = '$0';
             document.getElementById('stat-expense').textContent = '$0';